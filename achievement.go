@@ -0,0 +1,23 @@
+package yahtzee
+
+// Achievement is a milestone a user unlocks by playing.
+type Achievement string
+
+// Available achievements
+const (
+	// FirstYahtzee is unlocked by scoring a Yahtzee in the Yahtzee box.
+	FirstYahtzee Achievement = "first-yahtzee"
+
+	// ThreeHundred is unlocked by finishing a game with at least 300 points,
+	// without the handicap.
+	ThreeHundred Achievement = "three-hundred"
+
+	// AllStraights is unlocked by scoring every straight box of a score
+	// sheet in one game.
+	AllStraights Achievement = "all-straights"
+)
+
+// Achievements returns every available achievement.
+func Achievements() []Achievement {
+	return []Achievement{FirstYahtzee, ThreeHundred, AllStraights}
+}
@@ -0,0 +1,30 @@
+package yahtzee
+
+// Activity is an entry of the log of a game, what the digests of the users
+// are made of.
+type Activity struct {
+	// At is the time of the entry in unix milliseconds
+	At int64
+
+	// Action is the type of the event logged, like "score" or "game-over"
+	Action string
+
+	// User is the user acting, it's empty for the events without one like
+	// the timeouts
+	User User
+
+	// Winners has the winners of the game in the game-over entries
+	Winners []User
+
+	// Dices has the dices of the turn in the score and the scratch entries
+	Dices []int
+
+	// Caption and Ruling are the texts of the annotation entries
+	Caption string
+	Ruling  string
+
+	// Totals has the totals of the players after the turn without the
+	// handicap in the score and the scratch entries, and the final totals of
+	// the game in the game-over entries
+	Totals map[User]int
+}
@@ -0,0 +1,134 @@
+// Package chaos wraps the store and the event emitter to fail or slow down
+// some of their calls, to see how the server and the clients behave under
+// partial failures. It's meant for staging, never for production.
+package chaos
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// ErrInjected is returned by the calls chosen to fail.
+var ErrInjected = errors.New("injected failure")
+
+// Config tells how often and how the calls are disturbed.
+type Config struct {
+	// ErrorRate is the chance of a call failing, between 0 and 1. A failed
+	// emit drops the event.
+	ErrorRate float64
+
+	// LatencyRate is the chance of a call being delayed, between 0 and 1
+	LatencyRate float64
+
+	// Latency is the delay of the delayed calls
+	Latency time.Duration
+
+	// Rand is the source of the chances, it has to return a number in
+	// [0,1). rand.Float64 is used when it's nil.
+	Rand func() float64
+
+	// Sleep waits for the delays. time.Sleep is used when it's nil.
+	Sleep func(time.Duration)
+}
+
+// enabled tells if any of the calls can be disturbed.
+func (c *Config) enabled() bool {
+	return c.ErrorRate > 0 || c.LatencyRate > 0 && c.Latency > 0
+}
+
+// disturb delays the call when it's chosen to, and tells if it has to fail.
+func (c *Config) disturb() bool {
+	random, sleep := c.Rand, c.Sleep
+	if random == nil {
+		random = rand.Float64
+	}
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	if random() < c.LatencyRate {
+		sleep(c.Latency)
+	}
+	return random() < c.ErrorRate
+}
+
+type chaosStore struct {
+	next   store.Store
+	config Config
+}
+
+// Store returns `s` with its calls disturbed by the config, or `s` itself
+// when the config never disturbs.
+func Store(s store.Store, c Config) store.Store {
+	if !c.enabled() {
+		return s
+	}
+	return &chaosStore{
+		next:   s,
+		config: c,
+	}
+}
+
+func (s *chaosStore) Load(id string) (yahtzee.Game, error) {
+	if s.config.disturb() {
+		return yahtzee.Game{}, ErrInjected
+	}
+	return s.next.Load(id)
+}
+
+func (s *chaosStore) Save(id string, g yahtzee.Game) error {
+	if s.config.disturb() {
+		return ErrInjected
+	}
+	return s.next.Save(id, g)
+}
+
+func (s *chaosStore) Lock(id string) (func(), error) {
+	if s.config.disturb() {
+		return nil, ErrInjected
+	}
+	return s.next.Lock(id)
+}
+
+func (s *chaosStore) List() ([]string, error) {
+	if s.config.disturb() {
+		return nil, ErrInjected
+	}
+	return s.next.List()
+}
+
+func (s *chaosStore) Delete(id string) error {
+	if s.config.disturb() {
+		return ErrInjected
+	}
+	return s.next.Delete(id)
+}
+
+type chaosEmitter struct {
+	next   event.Emitter
+	config Config
+}
+
+// Emitter returns `e` with its calls disturbed by the config, or `e` itself
+// when the config never disturbs.
+func Emitter(e event.Emitter, c Config) event.Emitter {
+	if !c.enabled() {
+		return e
+	}
+	return &chaosEmitter{
+		next:   e,
+		config: c,
+	}
+}
+
+func (e *chaosEmitter) Emit(gameID string, ev *event.Event) {
+	if e.config.disturb() {
+		return
+	}
+	e.next.Emit(gameID, ev)
+}
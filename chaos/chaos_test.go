@@ -0,0 +1,124 @@
+package chaos_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/chaos"
+	"github.com/akarasz/yahtzee/event"
+	"github.com/akarasz/yahtzee/store"
+)
+
+type memoryStore struct {
+	games map[string]yahtzee.Game
+}
+
+func (s *memoryStore) Load(id string) (yahtzee.Game, error) {
+	g, ok := s.games[id]
+	if !ok {
+		return g, store.ErrNotExists
+	}
+	return g, nil
+}
+
+func (s *memoryStore) Save(id string, g yahtzee.Game) error {
+	s.games[id] = g
+	return nil
+}
+
+func (s *memoryStore) Lock(id string) (func(), error) {
+	return func() {}, nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	delete(s.games, id)
+	return nil
+}
+
+func (s *memoryStore) List() ([]string, error) {
+	res := []string{}
+	for id := range s.games {
+		res = append(res, id)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+type recorder struct {
+	events []*event.Event
+}
+
+func (r *recorder) Emit(gameID string, e *event.Event) {
+	r.events = append(r.events, e)
+}
+
+// sequence returns the numbers one after the other.
+func sequence(numbers ...float64) func() float64 {
+	return func() float64 {
+		res := numbers[0]
+		numbers = numbers[1:]
+		return res
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	s := &memoryStore{games: map[string]yahtzee.Game{}}
+	assert.Same(t, s, chaos.Store(s, chaos.Config{}))
+
+	r := &recorder{}
+	assert.Same(t, r, chaos.Emitter(r, chaos.Config{LatencyRate: 1}))
+}
+
+func TestStore(t *testing.T) {
+	var slept []time.Duration
+	config := chaos.Config{
+		ErrorRate:   0.5,
+		LatencyRate: 0.2,
+		Latency:     time.Second,
+		Sleep:       func(d time.Duration) { slept = append(slept, d) },
+	}
+
+	// untouched
+	config.Rand = sequence(0.9, 0.9, 0.9, 0.9)
+	s := chaos.Store(&memoryStore{games: map[string]yahtzee.Game{}}, config)
+	require.NoError(t, s.Save("id", *yahtzee.NewGame()))
+	_, err := s.Load("id")
+	require.NoError(t, err)
+	assert.Empty(t, slept)
+
+	// delayed
+	config.Rand = sequence(0.1, 0.9)
+	s = chaos.Store(&memoryStore{games: map[string]yahtzee.Game{}}, config)
+	unlocker, err := s.Lock("id")
+	require.NoError(t, err)
+	unlocker()
+	assert.Exactly(t, []time.Duration{time.Second}, slept)
+
+	// failed
+	config.Rand = sequence(0.9, 0.1, 0.9, 0.1, 0.9, 0.1)
+	s = chaos.Store(&memoryStore{games: map[string]yahtzee.Game{}}, config)
+	assert.Exactly(t, chaos.ErrInjected, s.Save("id", *yahtzee.NewGame()))
+	_, err = s.Load("id")
+	assert.Exactly(t, chaos.ErrInjected, err)
+	_, err = s.Lock("id")
+	assert.Exactly(t, chaos.ErrInjected, err)
+}
+
+func TestEmitter(t *testing.T) {
+	r := &recorder{}
+	e := chaos.Emitter(r, chaos.Config{
+		ErrorRate: 0.5,
+		Rand:      sequence(0.9, 0.9, 0.9, 0.1),
+	})
+
+	e.Emit("id", &event.Event{Action: event.Roll})
+	e.Emit("id", &event.Event{Action: event.Score})
+
+	require.Len(t, r.events, 1)
+	assert.Exactly(t, event.Roll, r.events[0].Action)
+}
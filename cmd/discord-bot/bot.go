@@ -0,0 +1,316 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// Discord interaction and response types
+const (
+	interactionPing    = 1
+	interactionCommand = 2
+
+	responsePong           = 1
+	responseChannelMessage = 4
+)
+
+var diceEmoji = []string{"", "1️⃣", "2️⃣", "3️⃣", "4️⃣", "5️⃣", "6️⃣"}
+
+type interaction struct {
+	Type      int
+	ChannelID string `json:"channel_id"`
+	Member    *struct {
+		User discordUser
+	}
+	User *discordUser
+	Data struct {
+		Name    string
+		Options []struct {
+			Name  string
+			Value json.RawMessage
+		}
+	}
+}
+
+type discordUser struct {
+	ID string
+}
+
+type response struct {
+	Type int              `json:"type"`
+	Data *responseMessage `json:"data,omitempty"`
+}
+
+type responseMessage struct {
+	Content         string          `json:"content"`
+	AllowedMentions allowedMentions `json:"allowed_mentions"`
+}
+
+type allowedMentions struct {
+	Users []string `json:"users"`
+}
+
+// bot hosts one game in every Discord channel, served as an interactions
+// endpoint.
+type bot struct {
+	publicKey ed25519.PublicKey
+	store     store.Store
+}
+
+func (b *bot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	if !b.verify(r, body) {
+		http.Error(w, "", http.StatusUnauthorized)
+		return
+	}
+
+	var i interaction
+	if err := json.Unmarshal(body, &i); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	var res response
+	switch i.Type {
+	case interactionPing:
+		res = response{Type: responsePong}
+	case interactionCommand:
+		res = response{Type: responseChannelMessage, Data: b.command(&i)}
+	default:
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Printf("response json encode: %v", err)
+	}
+}
+
+func (b *bot) verify(r *http.Request, body []byte) bool {
+	sig, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+
+	msg := append([]byte(r.Header.Get("X-Signature-Timestamp")), body...)
+	return ed25519.Verify(b.publicKey, msg, sig)
+}
+
+func (b *bot) command(i *interaction) *responseMessage {
+	var u yahtzee.User
+	if i.Member != nil {
+		u = yahtzee.User(i.Member.User.ID)
+	} else if i.User != nil {
+		u = yahtzee.User(i.User.ID)
+	}
+
+	unlock, err := b.store.Lock(i.ChannelID)
+	if err != nil {
+		log.Printf("locking issue: %v", err)
+		return message("Something went wrong, try again.")
+	}
+	defer unlock()
+
+	if i.Data.Name == "new" {
+		return b.newGame(i.ChannelID, i.stringOption("features"), i.stringOption("timezone"))
+	}
+
+	g, err := b.store.Load(i.ChannelID)
+	if errors.Is(err, store.ErrNotExists) {
+		return message("There is no game in this channel, start one with `/new`.")
+	} else if err != nil {
+		log.Printf("load game: %v", err)
+		return message("Something went wrong, try again.")
+	}
+
+	var res *responseMessage
+	switch i.Data.Name {
+	case "join":
+		_, err = engine.AddPlayer(&g, u)
+		res = message("%s joined the game.", mention(u))
+	case "roll":
+		_, err = engine.Roll(&g, u, rand.Intn, time.Now())
+		res = message("%s rolled %s", mention(u), renderDices(g.Dices))
+	case "lock":
+		_, err = engine.Lock(&g, u, i.intOption("dice")-1)
+		res = message("%s", renderDices(g.Dices))
+	case "score":
+		category := yahtzee.Category(i.stringOption("category"))
+		_, err = engine.Score(&g, u, category, time.Now())
+		res = scored(&g, u, category)
+	case "show":
+		return show(&g)
+	default:
+		return message("Unknown command.")
+	}
+
+	if err != nil {
+		return message("%s: %v", mention(u), err)
+	}
+
+	if err := b.store.Save(i.ChannelID, g); err != nil {
+		log.Printf("save game: %v", err)
+		return message("Something went wrong, try again.")
+	}
+
+	return res
+}
+
+func (b *bot) newGame(channelID string, rawFeatures string, timeZone string) *responseMessage {
+	g, err := b.store.Load(channelID)
+	if err == nil && len(g.Players) > 0 && !engine.IsOver(&g) {
+		return message("A game is already running in this channel, finish it first.")
+	} else if err != nil && !errors.Is(err, store.ErrNotExists) {
+		log.Printf("load game: %v", err)
+		return message("Something went wrong, try again.")
+	}
+
+	var features []yahtzee.Feature
+	for _, f := range strings.Fields(strings.ReplaceAll(rawFeatures, ",", " ")) {
+		feature := yahtzee.Feature(f)
+
+		known := false
+		for _, available := range yahtzee.Features() {
+			known = known || feature == available
+		}
+		if !known {
+			return message("Unknown feature `%s`.", f)
+		}
+
+		features = append(features, feature)
+	}
+
+	g = *yahtzee.NewGame(features...)
+	if err := g.SetTimeZone(timeZone); err != nil {
+		return message("Unknown time zone `%s`.", timeZone)
+	}
+
+	if err := b.store.Save(channelID, g); err != nil {
+		log.Printf("save game: %v", err)
+		return message("Something went wrong, try again.")
+	}
+
+	return message("New game started, use `/join` to play!")
+}
+
+func scored(g *yahtzee.Game, u yahtzee.User, category yahtzee.Category) *responseMessage {
+	for i, p := range g.Players {
+		if p.User == u {
+			score := engine.SheetOwner(g, i).ScoreSheet[category]
+			res := message("%s scored %d for %s.", mention(u), score, category)
+			if engine.IsOver(g) {
+				res.Content += "\nThe game is over!\n" + totals(g)
+				return res
+			}
+
+			next := g.Players[g.CurrentPlayer].User
+			res.Content += fmt.Sprintf("\n%s, it's your turn!", mention(next))
+			if deadline, ok := engine.DeadlineTime(g); ok {
+				res.Content += fmt.Sprintf(" Play before %s.", localTime(g, deadline))
+			}
+			res.AllowedMentions.Users = []string{string(next)}
+			return res
+		}
+	}
+	return message("")
+}
+
+// localTime formats `t` in the time zone of the game.
+func localTime(g *yahtzee.Game, t time.Time) string {
+	loc, err := g.Location()
+	if err != nil {
+		log.Printf("game time zone: %v", err)
+		loc = time.UTC
+	}
+	return t.In(loc).Format("15:04:05 MST")
+}
+
+func show(g *yahtzee.Game) *responseMessage {
+	res := message("Round %d, rolls: %d\n%s\n%s", g.Round+1, g.RollCount, renderDices(g.Dices), totals(g))
+	if len(g.Players) > 0 {
+		res.Content += fmt.Sprintf("\nCurrent player: %s", mention(g.Players[g.CurrentPlayer].User))
+	}
+	return res
+}
+
+func totals(g *yahtzee.Game) string {
+	scorer := engine.GameScorer(g)
+
+	lines := make([]string, len(g.Players))
+	for i, p := range g.Players {
+		owner := engine.SheetOwner(g, i)
+		categories := make([]string, 0, len(owner.ScoreSheet))
+		for c, v := range owner.ScoreSheet {
+			categories = append(categories, fmt.Sprintf("%s: %d", c, v))
+		}
+		sort.Strings(categories)
+
+		lines[i] = fmt.Sprintf("%s **%d** (%s)", mention(p.User), scorer.Total(owner), strings.Join(categories, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func renderDices(dices []*yahtzee.Dice) string {
+	res := make([]string, len(dices))
+	for i, d := range dices {
+		res[i] = diceEmoji[d.Value]
+		if d.Locked {
+			res[i] += "🔒"
+		}
+	}
+	return strings.Join(res, " ")
+}
+
+func mention(u yahtzee.User) string {
+	return fmt.Sprintf("<@%s>", u)
+}
+
+// message creates a response that pings nobody.
+func message(format string, args ...interface{}) *responseMessage {
+	return &responseMessage{
+		Content:         fmt.Sprintf(format, args...),
+		AllowedMentions: allowedMentions{Users: []string{}},
+	}
+}
+
+func (i *interaction) stringOption(name string) string {
+	for _, o := range i.Data.Options {
+		if o.Name == name {
+			var res string
+			json.Unmarshal(o.Value, &res)
+			return res
+		}
+	}
+	return ""
+}
+
+func (i *interaction) intOption(name string) int {
+	for _, o := range i.Data.Options {
+		if o.Name == name {
+			var res int
+			json.Unmarshal(o.Value, &res)
+			return res
+		}
+	}
+	return 0
+}
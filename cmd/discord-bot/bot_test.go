@@ -0,0 +1,173 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+type memoryStore struct {
+	games map[string]yahtzee.Game
+}
+
+func (s *memoryStore) Load(id string) (yahtzee.Game, error) {
+	g, ok := s.games[id]
+	if !ok {
+		return g, store.ErrNotExists
+	}
+	return g, nil
+}
+
+func (s *memoryStore) Save(id string, g yahtzee.Game) error {
+	s.games[id] = g
+	return nil
+}
+
+func (s *memoryStore) Lock(id string) (func(), error) {
+	return func() {}, nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	delete(s.games, id)
+	return nil
+}
+
+func (s *memoryStore) List() ([]string, error) {
+	res := []string{}
+	for id := range s.games {
+		res = append(res, id)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+type testBot struct {
+	*bot
+	t          *testing.T
+	privateKey ed25519.PrivateKey
+}
+
+func newTestBot(t *testing.T) *testBot {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	return &testBot{
+		bot: &bot{
+			publicKey: publicKey,
+			store:     &memoryStore{games: map[string]yahtzee.Game{}},
+		},
+		t:          t,
+		privateKey: privateKey,
+	}
+}
+
+// send signs and serves the interaction.
+func (b *testBot) send(body string) *httptest.ResponseRecorder {
+	const timestamp = "1600000000"
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	sig := ed25519.Sign(b.privateKey, []byte(timestamp+body))
+	req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(sig))
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+
+	rr := httptest.NewRecorder()
+	b.ServeHTTP(rr, req)
+	return rr
+}
+
+// command sends the command of the user and returns the content of the
+// message.
+func (b *testBot) command(user string, name string, options string) string {
+	rr := b.send(fmt.Sprintf(
+		`{"type": 2, "channel_id": "channel", "member": {"user": {"id": %q}}, "data": {"name": %q, "options": [%s]}}`,
+		user, name, options))
+	require.Exactly(b.t, http.StatusOK, rr.Code)
+
+	var res response
+	require.NoError(b.t, json.Unmarshal(rr.Body.Bytes(), &res))
+	require.Exactly(b.t, responseChannelMessage, res.Type)
+	return res.Data.Content
+}
+
+func TestVerify(t *testing.T) {
+	b := newTestBot(t)
+
+	rr := b.send(`{"type": 1}`)
+	assert.Exactly(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"type": 1}`, rr.Body.String())
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"type": 1}`))
+	rr = httptest.NewRecorder()
+	b.ServeHTTP(rr, req)
+	assert.Exactly(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestGame(t *testing.T) {
+	b := newTestBot(t)
+
+	assert.Contains(t, b.command("alice", "roll", ""), "There is no game")
+	assert.Contains(t, b.command("alice", "new", `{"name": "features", "value": "wat"}`), "Unknown feature")
+	assert.Contains(t, b.command("alice", "new", `{"name": "features", "value": "triple"}`), "New game started")
+	assert.Contains(t, b.command("alice", "join", ""), "<@alice> joined")
+	assert.Contains(t, b.command("bob", "join", ""), "<@bob> joined")
+
+	// a running game is not replaced
+	assert.Contains(t, b.command("bob", "new", ""), "already running")
+
+	assert.Contains(t, b.command("bob", "roll", ""), "another players turn")
+	assert.Contains(t, b.command("alice", "roll", ""), "<@alice> rolled")
+	assert.Contains(t, b.command("alice", "lock", `{"name": "dice", "value": 1}`), "🔒")
+
+	g, err := b.store.Load("channel")
+	require.NoError(t, err)
+	chance := 0
+	for _, d := range g.Dices {
+		chance += d.Value
+	}
+
+	assert.Contains(t, b.command("alice", "score", `{"name": "category", "value": "chance"}`),
+		fmt.Sprintf("<@alice> scored %d for chance.\n<@bob>, it's your turn!", chance))
+	assert.Contains(t, b.command("alice", "show", ""),
+		fmt.Sprintf("<@alice> **%d** (chance: %d)", chance, chance))
+}
+
+func TestTimeZone(t *testing.T) {
+	b := newTestBot(t)
+
+	assert.Contains(t, b.command("alice", "new", `{"name": "timezone", "value": "Mars/Olympus"}`), "Unknown time zone")
+	assert.Contains(t, b.command("alice", "new",
+		`{"name": "features", "value": "blitz"}, {"name": "timezone", "value": "Asia/Tokyo"}`), "New game started")
+	b.command("alice", "join", "")
+	b.command("bob", "join", "")
+	b.command("alice", "roll", "")
+
+	// the shot clock of the next player is shown in the time zone of the game
+	assert.Regexp(t, `<@bob>, it's your turn! Play before \d\d:\d\d:\d\d JST\.`,
+		b.command("alice", "score", `{"name": "category", "value": "chance"}`))
+}
+
+func TestTotals(t *testing.T) {
+	g := yahtzee.NewGame(yahtzee.Triple)
+	alice := yahtzee.NewPlayer("alice")
+	alice.ScoreSheet[yahtzee.Chance] = 20
+	alice.ExtraSheets = []map[yahtzee.Category]int{
+		{yahtzee.Chance: 10},
+		{yahtzee.Chance: 5},
+	}
+	g.Players = append(g.Players, alice)
+
+	// the columns are multiplied
+	assert.Exactly(t, "<@alice> **55** (chance: 20)", totals(g))
+}
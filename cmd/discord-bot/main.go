@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"time"
+
+	store "github.com/akarasz/yahtzee/store/embedded"
+)
+
+// Discord application command option types
+const (
+	optionString  = 3
+	optionInteger = 4
+)
+
+type commandOption struct {
+	Type        int    `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+type command struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Options     []commandOption `json:"options,omitempty"`
+}
+
+var commands = []command{
+	{
+		Name:        "new",
+		Description: "Start a new game in this channel",
+		Options: []commandOption{
+			{Type: optionString, Name: "features", Description: "Comma separated list of features"},
+			{Type: optionString, Name: "timezone", Description: "Time zone of the game, like Europe/Budapest"},
+		},
+	},
+	{Name: "join", Description: "Join the game in this channel"},
+	{Name: "roll", Description: "Roll the unlocked dices"},
+	{
+		Name:        "lock",
+		Description: "Toggle the lock on a dice",
+		Options: []commandOption{
+			{Type: optionInteger, Name: "dice", Description: "Position of the dice, starting from 1", Required: true},
+		},
+	},
+	{
+		Name:        "score",
+		Description: "Score the dices in a category",
+		Options: []commandOption{
+			{Type: optionString, Name: "category", Description: "Category to score, eg. full-house", Required: true},
+		},
+	},
+	{Name: "show", Description: "Show the game in this channel"},
+}
+
+func main() {
+	rand.Seed(time.Now().UnixNano())
+
+	publicKey, err := hex.DecodeString(os.Getenv("DISCORD_PUBLIC_KEY"))
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		log.Fatal("DISCORD_PUBLIC_KEY has to be set to the hex encoded public key of the application")
+	}
+
+	if appID, token := os.Getenv("DISCORD_APP_ID"), os.Getenv("DISCORD_TOKEN"); appID != "" && token != "" {
+		if err := registerCommands(appID, token); err != nil {
+			log.Fatalf("register commands: %v", err)
+		}
+	}
+
+	port := "8000"
+	if envPort := os.Getenv("PORT"); envPort != "" {
+		port = envPort
+	}
+
+	b := &bot{
+		publicKey: publicKey,
+		store:     store.New(),
+	}
+
+	listenAddress := ":" + port
+	log.Fatal(http.ListenAndServe(listenAddress, b))
+}
+
+func registerCommands(appID, token string) error {
+	body, err := json.Marshal(commands)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(
+		"PUT",
+		fmt.Sprintf("https://discord.com/api/v10/applications/%s/commands", appID),
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return nil
+}
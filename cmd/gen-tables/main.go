@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+)
+
+func main() {
+	rawFeatures := flag.String("features", "", "comma separated features of the rule set")
+	rolls := flag.Int("rolls", 2, "the most rolls left to calculate")
+	dir := flag.String("out", ".", "directory of the table file")
+	flag.Parse()
+
+	var features []yahtzee.Feature
+	if *rawFeatures != "" {
+		for _, f := range strings.Split(*rawFeatures, ",") {
+			features = append(features, yahtzee.Feature(f))
+		}
+	}
+	for _, f := range features {
+		if !known(f) {
+			log.Fatalf("unknown feature %q", f)
+		}
+	}
+
+	t := engine.GenerateTable(features, yahtzee.NumberOfSides, *rolls)
+
+	path := filepath.Join(*dir, fileName(t))
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := engine.WriteTable(f, t); err != nil {
+		f.Close()
+		log.Fatalf("%s: %v", path, err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("%s: %v", path, err)
+	}
+
+	fmt.Printf("%s: %d entries\n", path, len(t.Entries))
+}
+
+// fileName returns the name of the table file with the rule set and the
+// version of the format, like `yatzy.v1.table.gz`.
+func fileName(t *engine.Table) string {
+	name := "standard"
+	if len(t.Features) > 0 {
+		parts := make([]string, len(t.Features))
+		for i, f := range t.Features {
+			parts[i] = string(f)
+		}
+		name = strings.Join(parts, "+")
+	}
+	return fmt.Sprintf("%s.v%d.table.gz", name, t.Version)
+}
+
+func known(f yahtzee.Feature) bool {
+	for _, available := range yahtzee.Features() {
+		if f == available {
+			return true
+		}
+	}
+	return false
+}
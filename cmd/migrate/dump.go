@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// dumpedGame is a line of a dump file.
+type dumpedGame struct {
+	ID   string
+	Game json.RawMessage
+}
+
+// dump is a store in a file with a JSON line for every game, to move the
+// games of a store without a file of its own, like the in-memory one. The
+// games are read when it's opened and written when it's closed.
+type dump struct {
+	path  string
+	games map[string]yahtzee.Game
+	mu    sync.Mutex
+}
+
+// openDump reads the dump file at `path`, it's empty when the file doesn't
+// exist.
+func openDump(path string) (*dump, error) {
+	res := &dump{
+		path:  path,
+		games: map[string]yahtzee.Game{},
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d := json.NewDecoder(f)
+	for d.More() {
+		var line dumpedGame
+		if err := d.Decode(&line); err != nil {
+			return nil, err
+		}
+		g, err := store.Unmarshal(line.Game)
+		if err != nil {
+			return nil, err
+		}
+		res.games[line.ID] = g
+	}
+	return res, nil
+}
+
+// Close writes the games to the file, replacing it at once.
+func (d *dump) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(d.path), filepath.Base(d.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	e := json.NewEncoder(w)
+	for _, id := range d.ids() {
+		g := d.games[id]
+		raw, err := store.Marshal(g)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := e.Encode(dumpedGame{ID: id, Game: raw}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), d.path)
+}
+
+func (d *dump) Load(id string) (yahtzee.Game, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	g, ok := d.games[id]
+	if !ok {
+		return yahtzee.Game{}, store.ErrNotExists
+	}
+	return g, nil
+}
+
+func (d *dump) Save(id string, g yahtzee.Game) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.games[id] = g
+	return nil
+}
+
+// Lock doesn't lock anything, the file is only used by the migration.
+func (d *dump) Lock(id string) (func(), error) {
+	return func() {}, nil
+}
+
+func (d *dump) Delete(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.games, id)
+	return nil
+}
+
+func (d *dump) List() ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.ids(), nil
+}
+
+func (d *dump) ids() []string {
+	res := []string{}
+	for id := range d.games {
+		res = append(res, id)
+	}
+	sort.Strings(res)
+	return res
+}
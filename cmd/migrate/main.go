@@ -0,0 +1,128 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	_ "github.com/lib/pq"
+
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/bolt"
+	"github.com/akarasz/yahtzee/store/dynamodb"
+	"github.com/akarasz/yahtzee/store/mongo"
+	"github.com/akarasz/yahtzee/store/postgres"
+	redis_store "github.com/akarasz/yahtzee/store/redis"
+	"github.com/akarasz/yahtzee/store/sqlite"
+)
+
+func main() {
+	from := flag.String("from", "", "store to copy the games from, like file:games.ndjson")
+	to := flag.String("to", "", "store to copy the games to, like postgres:postgres://...")
+	expiration := flag.Duration("expiration", 48*time.Hour, "expiration of the copied games")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s -from kind:address -to kind:address\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "kinds: file, redis, postgres, dynamodb, bolt, mongo, sqlite")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *from == "" || *to == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	source, closeSource, err := open(*from, *expiration)
+	if err != nil {
+		log.Fatalf("%s: %v", *from, err)
+	}
+	destination, closeDestination, err := open(*to, *expiration)
+	if err != nil {
+		log.Fatalf("%s: %v", *to, err)
+	}
+
+	r, err := migrate(source, destination)
+	if err := closeDestination(); err != nil {
+		log.Fatalf("%s: %v", *to, err)
+	}
+	closeSource()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d games copied, %d expired meanwhile\n", r.Copied, len(r.Expired))
+	if len(r.Failed) > 0 {
+		for _, id := range r.Failed {
+			fmt.Printf("%s: differs\n", id)
+		}
+		os.Exit(1)
+	}
+}
+
+// open returns the store of the `kind:address` spec, with the function
+// closing it.
+func open(spec string, expiration time.Duration) (store.Store, func() error, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, nil, errors.New("the store has to be kind:address")
+	}
+	kind, address := parts[0], parts[1]
+
+	switch kind {
+	case "file":
+		d, err := openDump(address)
+		if err != nil {
+			return nil, nil, err
+		}
+		return d, d.Close, nil
+	case "redis":
+		rdb := redis.NewClient(&redis.Options{Addr: address})
+		return redis_store.New(rdb, expiration), rdb.Close, nil
+	case "postgres":
+		db, err := sql.Open("postgres", address)
+		if err != nil {
+			return nil, nil, err
+		}
+		p := postgres.New(db, expiration)
+		if err := p.Migrate(); err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return p, db.Close, nil
+	case "dynamodb":
+		c, err := dynamodb.ConfigFromEnv(address)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.Endpoint = os.Getenv("DYNAMODB_ENDPOINT")
+		return dynamodb.New(c, expiration), func() error { return nil }, nil
+	case "bolt":
+		b, err := bolt.New(address, expiration)
+		if err != nil {
+			return nil, nil, err
+		}
+		return b, b.Close, nil
+	case "mongo":
+		m, err := mongo.New(address, expiration)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := m.Migrate(); err != nil {
+			m.Close()
+			return nil, nil, err
+		}
+		return m, m.Close, nil
+	case "sqlite":
+		l, err := sqlite.Open(address, expiration)
+		if err != nil {
+			return nil, nil, err
+		}
+		return l, l.Close, nil
+	}
+	return nil, nil, fmt.Errorf("unknown store %q", kind)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// report tells how a migration went.
+type report struct {
+	// Copied is the number of the games copied
+	Copied int
+
+	// Expired has the IDs of the games expired since they were listed
+	Expired []string
+
+	// Failed has the IDs of the games loaded differently from the destination
+	Failed []string
+}
+
+// migrate copies every game of `from` to `to`, and verifies the copies by
+// loading them back. The games are locked in the source while they're
+// copied, so the running servers don't change them meanwhile.
+func migrate(from store.Store, to store.Store) (*report, error) {
+	ids, err := from.List()
+	if err != nil {
+		return nil, fmt.Errorf("list games: %w", err)
+	}
+
+	res := &report{}
+	for _, id := range ids {
+		copied, err := copyGame(from, to, id)
+		if errors.Is(err, store.ErrNotExists) {
+			res.Expired = append(res.Expired, id)
+			continue
+		}
+		if err != nil {
+			return res, fmt.Errorf("%s: %w", id, err)
+		}
+
+		res.Copied++
+		if !copied {
+			res.Failed = append(res.Failed, id)
+		}
+	}
+
+	log.Printf("%d games migrated", res.Copied)
+	return res, nil
+}
+
+// copyGame copies the game, and tells if its copy is loaded the same.
+func copyGame(from store.Store, to store.Store, id string) (bool, error) {
+	unlock, err := from.Lock(id)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	g, err := from.Load(id)
+	if err != nil {
+		return false, err
+	}
+	if err := to.Save(id, g); err != nil {
+		return false, err
+	}
+
+	copied, err := to.Load(id)
+	if err != nil {
+		return false, err
+	}
+	return same(g, copied)
+}
+
+func same(a, b yahtzee.Game) (bool, error) {
+	rawA, err := store.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	rawB, err := store.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return string(rawA) == string(rawB), nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/embedded"
+	"github.com/akarasz/yahtzee/store/storetest"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "yahtzee")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	return dir
+}
+
+// seedlessStore loses the seeds of the games it saves.
+type seedlessStore struct {
+	store.Store
+}
+
+func (s *seedlessStore) Save(id string, g yahtzee.Game) error {
+	g.Seed = 0
+	return s.Store.Save(id, g)
+}
+
+func TestDump(t *testing.T) {
+	storetest.Run(t, func() store.Store {
+		d, err := openDump(filepath.Join(tempDir(t), "games.ndjson"))
+		require.NoError(t, err)
+		return d
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	source := embedded.New()
+	g := yahtzee.NewGame(yahtzee.Duplicate)
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	g.Players[0].ScoreSheet[yahtzee.Chance] = 20
+	g.Seed = 42
+	require.NoError(t, source.Save("aaaaa", *g))
+	require.NoError(t, source.Save("bbbbb", *yahtzee.NewGame()))
+
+	// to the file and back
+	path := filepath.Join(tempDir(t), "games.ndjson")
+	d, err := openDump(path)
+	require.NoError(t, err)
+	r, err := migrate(source, d)
+	require.NoError(t, err)
+	assert.Exactly(t, &report{Copied: 2}, r)
+	require.NoError(t, d.Close())
+
+	d, err = openDump(path)
+	require.NoError(t, err)
+	destination := embedded.New()
+	r, err = migrate(d, destination)
+	require.NoError(t, err)
+	assert.Exactly(t, &report{Copied: 2}, r)
+
+	if got, err := destination.Load("aaaaa"); assert.NoError(t, err) {
+		assert.Exactly(t, *g, got)
+	}
+
+	// the copies are verified
+	r, err = migrate(source, &seedlessStore{Store: embedded.New()})
+	require.NoError(t, err)
+	assert.Exactly(t, &report{Copied: 2, Failed: []string{"aaaaa"}}, r)
+}
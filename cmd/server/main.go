@@ -1,19 +1,46 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	_ "github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/streadway/amqp"
 
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/chaos"
+	"github.com/akarasz/yahtzee/engine"
 	event "github.com/akarasz/yahtzee/event/rabbit"
 	"github.com/akarasz/yahtzee/handler"
-	store "github.com/akarasz/yahtzee/store/redis"
+	"github.com/akarasz/yahtzee/id"
+	"github.com/akarasz/yahtzee/janitor"
+	"github.com/akarasz/yahtzee/metrics"
+	"github.com/akarasz/yahtzee/mqtt"
+	"github.com/akarasz/yahtzee/showcase"
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/archive"
+	"github.com/akarasz/yahtzee/store/batch"
+	"github.com/akarasz/yahtzee/store/bolt"
+	"github.com/akarasz/yahtzee/store/cache"
+	"github.com/akarasz/yahtzee/store/dynamodb"
+	"github.com/akarasz/yahtzee/store/eventlog"
+	"github.com/akarasz/yahtzee/store/instrumented"
+	"github.com/akarasz/yahtzee/store/mongo"
+	"github.com/akarasz/yahtzee/store/postgres"
+	redis_store "github.com/akarasz/yahtzee/store/redis"
+	"github.com/akarasz/yahtzee/store/sqlite"
+	"github.com/akarasz/yahtzee/webhook"
 )
 
 func main() {
@@ -24,7 +51,67 @@ func main() {
 		Addr: os.Getenv("REDIS"),
 	})
 	defer rdb.Close()
-	s := store.New(rdb, 48*time.Hour)
+	s := redis_store.New(rdb, 48*time.Hour)
+
+	// the games can be kept in postgres, dynamodb, mongo or a local file,
+	// everything else stays in redis
+	var games store.Store = s
+	switch os.Getenv("STORE") {
+	case "", "redis":
+	case "postgres":
+		db, err := sql.Open("postgres", os.Getenv("POSTGRES"))
+		if err != nil {
+			log.Fatalf("invalid POSTGRES: %v", err)
+		}
+		defer db.Close()
+		var opts []postgres.Option
+		if dsn := os.Getenv("POSTGRES_REPLICA"); dsn != "" {
+			replica, err := sql.Open("postgres", dsn)
+			if err != nil {
+				log.Fatalf("invalid POSTGRES_REPLICA: %v", err)
+			}
+			defer replica.Close()
+			opts = append(opts, postgres.WithReplica(replica))
+		}
+		p := postgres.New(db, 48*time.Hour, opts...)
+		if err := p.Migrate(); err != nil {
+			log.Fatalf("postgres: %v", err)
+		}
+		games = p
+	case "dynamodb":
+		c, err := dynamodb.ConfigFromEnv(os.Getenv("DYNAMODB_TABLE"))
+		if err != nil {
+			log.Fatalf("dynamodb: %v", err)
+		}
+		c.Endpoint = os.Getenv("DYNAMODB_ENDPOINT")
+		games = dynamodb.New(c, 48*time.Hour)
+	case "bolt":
+		b, err := bolt.New(os.Getenv("BOLT_PATH"), 48*time.Hour)
+		if err != nil {
+			log.Fatalf("bolt: %v", err)
+		}
+		defer b.Close()
+		games = b
+	case "mongo":
+		m, err := mongo.New(os.Getenv("MONGO"), 48*time.Hour)
+		if err != nil {
+			log.Fatalf("invalid MONGO: %v", err)
+		}
+		defer m.Close()
+		if err := m.Migrate(); err != nil {
+			log.Fatalf("mongo: %v", err)
+		}
+		games = m
+	case "sqlite":
+		l, err := sqlite.Open(os.Getenv("SQLITE_PATH"), 48*time.Hour)
+		if err != nil {
+			log.Fatalf("sqlite: %v", err)
+		}
+		defer l.Close()
+		games = l
+	default:
+		log.Fatalf("unknown STORE %q", os.Getenv("STORE"))
+	}
 
 	// rabbit
 	rabbitConn, err := amqp.Dial(os.Getenv("RABBIT"))
@@ -42,16 +129,341 @@ func main() {
 		panic(err)
 	}
 
+	// the stores without expiration of their own are swept
+	if expirer, ok := games.(store.Expirer); ok {
+		j := janitor.New(expirer, e, janitor.DefaultInterval)
+		defer j.Close()
+	}
+
+	// the readiness probe checks the backends, the wrappers hide their health
+	healthChecks := []handler.Option{handler.WithHealthCheck("redis", s)}
+	if h, ok := games.(store.Healther); ok && games != store.Store(s) {
+		healthChecks = append(healthChecks, handler.WithHealthCheck(os.Getenv("STORE"), h))
+	}
+
+	// the games can be kept as the logs of their changes
+	var logs *eventlog.Store
+	if os.Getenv("EVENT_LOG") != "" {
+		j, ok := games.(store.Journal)
+		if !ok {
+			log.Fatalf("STORE %q can't keep the logs of the games", os.Getenv("STORE"))
+		}
+		logs = eventlog.New(j, games, eventlog.DefaultSnapshotInterval)
+		games = logs
+	}
+
+	// the finished games are moved to a directory or an S3 bucket
+	switch os.Getenv("ARCHIVE") {
+	case "":
+	case "dir":
+		d, err := archive.NewDir(os.Getenv("ARCHIVE_PATH"))
+		if err != nil {
+			log.Fatalf("archive: %v", err)
+		}
+		games = archive.Store(games, d)
+	case "s3":
+		c, err := archive.S3ConfigFromEnv(os.Getenv("ARCHIVE_BUCKET"))
+		if err != nil {
+			log.Fatalf("archive: %v", err)
+		}
+		c.Endpoint = os.Getenv("ARCHIVE_ENDPOINT")
+		games = archive.Store(games, archive.NewS3(c))
+	default:
+		log.Fatalf("unknown ARCHIVE %q", os.Getenv("ARCHIVE"))
+	}
+
+	// the replicas of the server can share the locks of the games in redis
+	switch os.Getenv("LOCK") {
+	case "":
+	case "redis":
+		games = store.WithLocker(games, redis_store.NewLocker(rdb, redis_store.DefaultLease))
+	default:
+		log.Fatalf("unknown LOCK %q", os.Getenv("LOCK"))
+	}
+
+	backend := os.Getenv("STORE")
+	if backend == "" {
+		backend = "redis"
+	}
+	games = instrumented.Store(games, backend)
+
+	// the hot games are kept in memory when it's enabled
+	if raw := os.Getenv("CACHE_SIZE"); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil {
+			log.Fatalf("invalid CACHE_SIZE %q", raw)
+		}
+		// the replicas would change the stale games of their own caches
+		if os.Getenv("LOCK") == "redis" {
+			log.Fatal("CACHE_SIZE can't be used with LOCK=redis")
+		}
+		games = cache.New(games, size)
+	}
+
 	go func() {
 		http.Handle("/metrics", promhttp.Handler())
+		http.Handle("/load", metrics.DefaultLoad)
 		http.ListenAndServe(":2112", nil)
 	}()
 
+	if showcaseID := os.Getenv("SHOWCASE_ID"); showcaseID != "" {
+		pace := showcase.DefaultPace
+		if raw := os.Getenv("SHOWCASE_PACE"); raw != "" {
+			if pace, err = time.ParseDuration(raw); err != nil {
+				log.Fatalf("invalid SHOWCASE_PACE %q", raw)
+			}
+		}
+		go showcase.Run(context.Background(), games, e, showcaseID, pace)
+	}
+
+	var ids id.Generator
+	switch os.Getenv("ID_GENERATOR") {
+	case "", "random":
+		ids = id.Random(4)
+	case "words":
+		ids = id.WordPairs()
+	case "uuid":
+		ids = id.UUID()
+	default:
+		log.Fatalf("unknown ID_GENERATOR %q", os.Getenv("ID_GENERATOR"))
+	}
+	if prefix := os.Getenv("ID_PREFIX"); prefix != "" {
+		ids = id.Vanity(prefix, ids)
+	}
+
+	var inviteSecret []byte
+	if secret := os.Getenv("INVITE_SECRET"); secret != "" {
+		inviteSecret = []byte(secret)
+	}
+
+	// the logs of the games are written in batches when it's enabled
+	var activities store.Activities = s
+	if raw := os.Getenv("ACTIVITY_FLUSH_INTERVAL"); raw != "" {
+		interval, err := time.ParseDuration(raw)
+		if err != nil || interval <= 0 {
+			log.Fatalf("invalid ACTIVITY_FLUSH_INTERVAL %q", raw)
+		}
+		b := batch.New(s, interval, batch.DefaultSize)
+		defer b.Close()
+		activities = b
+	}
+
+	var dailySecret []byte
+	if secret := os.Getenv("DAILY_SECRET"); secret != "" {
+		dailySecret = []byte(secret)
+	}
+
+	opts := []handler.Option{
+		handler.WithIDGenerator(ids),
+		handler.WithBestScores(s),
+		handler.WithMatches(s),
+		handler.WithUserGames(s),
+		handler.WithNotes(s),
+		handler.WithAchievements(s),
+		handler.WithClocks(s),
+		handler.WithPublicURL(os.Getenv("PUBLIC_URL")),
+		handler.WithInviteSecret(inviteSecret),
+		handler.WithDailies(s),
+		handler.WithActivities(activities),
+		handler.WithDailySecret(dailySecret),
+	}
+	opts = append(opts, healthChecks...)
+	if raw := os.Getenv("ANNOTATION_TOKENS"); raw != "" {
+		opts = append(opts, handler.WithAnnotationTokens(strings.Split(raw, ",")...))
+	}
+	if token := os.Getenv("SMS_TOKEN"); token != "" {
+		opts = append(opts, handler.WithSMSToken(token))
+	}
+	if token := os.Getenv("ADMIN_TOKEN"); token != "" {
+		opts = append(opts, handler.WithAdminToken(token))
+	}
+	if logs != nil {
+		opts = append(opts, handler.WithReplayer(logs))
+	}
+	policy := handler.FeaturePolicy{
+		Defaults:  featuresEnv("DEFAULT_FEATURES"),
+		Required:  featuresEnv("REQUIRED_FEATURES"),
+		Forbidden: featuresEnv("FORBIDDEN_FEATURES"),
+	}
+	if err := policy.Validate(); err != nil {
+		log.Fatalf("invalid features: %v", err)
+	}
+	opts = append(opts, handler.WithFeaturePolicy(policy))
+	minimum, recommended := os.Getenv("CLIENT_MIN_VERSION"), os.Getenv("CLIENT_RECOMMENDED_VERSION")
+	if minimum != "" || recommended != "" {
+		opts = append(opts, handler.WithClientVersions(minimum, recommended))
+	}
+	// the tables are loaded by the warm-up when there is one, so the server
+	// is up while they load
+	if os.Getenv("WARM_UP") != "" {
+		warmUp := handler.WarmUp{
+			Tables: readTables,
+			Hints:  [][]yahtzee.Feature{nil},
+		}
+		if raw := os.Getenv("WARM_UP_HINTS"); raw != "" {
+			warmUp.Hints = nil
+			for _, set := range strings.Split(raw, ";") {
+				warmUp.Hints = append(warmUp.Hints, featureList(set))
+			}
+		}
+		if err := warmUp.Validate(); err != nil {
+			log.Fatalf("invalid WARM_UP_HINTS: %v", err)
+		}
+		opts = append(opts, handler.WithWarmUp(warmUp))
+	} else {
+		tables, err := readTables()
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, handler.WithProbabilityTables(tables...))
+	}
+	if raw := os.Getenv("SOLVER_CACHE_SIZE"); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil {
+			log.Fatalf("invalid SOLVER_CACHE_SIZE %q", raw)
+		}
+		opts = append(opts, handler.WithSolverCacheSize(size))
+	}
+	if raw := os.Getenv("SIMULATION_WORKERS"); raw != "" {
+		workers, err := strconv.Atoi(raw)
+		if err != nil {
+			log.Fatalf("invalid SIMULATION_WORKERS %q", raw)
+		}
+		queue := 4 * workers
+		if raw := os.Getenv("SIMULATION_QUEUE"); raw != "" {
+			if queue, err = strconv.Atoi(raw); err != nil {
+				log.Fatalf("invalid SIMULATION_QUEUE %q", raw)
+			}
+		}
+		opts = append(opts, handler.WithSimulationPool(workers, queue))
+	}
+	if raw := os.Getenv("SIMULATION_BUDGET"); raw != "" {
+		budget, err := time.ParseDuration(raw)
+		if err != nil {
+			log.Fatalf("invalid SIMULATION_BUDGET %q", raw)
+		}
+		opts = append(opts, handler.WithSimulationBudget(budget))
+	}
+	if rawGames, rawWebsockets := os.Getenv("MAX_GAMES"), os.Getenv("MAX_WEBSOCKETS"); rawGames != "" || rawWebsockets != "" {
+		var maxGames, maxWebsockets int
+		var err error
+		if rawGames != "" {
+			if maxGames, err = strconv.Atoi(rawGames); err != nil {
+				log.Fatalf("invalid MAX_GAMES %q", rawGames)
+			}
+		}
+		if rawWebsockets != "" {
+			if maxWebsockets, err = strconv.Atoi(rawWebsockets); err != nil {
+				log.Fatalf("invalid MAX_WEBSOCKETS %q", rawWebsockets)
+			}
+		}
+		opts = append(opts, handler.WithCapacity(maxGames, maxWebsockets))
+	}
+
 	port := "8000"
 	if envPort := os.Getenv("PORT"); envPort != "" {
 		port = envPort
 	}
 
+	chaosConfig := readChaosConfig()
+	games, emitter := chaos.Store(games, chaosConfig), chaos.Emitter(e, chaosConfig)
+
+	var registrations []webhook.Registration
+	if raw := os.Getenv("WEBHOOKS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &registrations); err != nil {
+			log.Fatalf("invalid WEBHOOKS: %v", err)
+		}
+	}
+	if emitter, err = webhook.Emitter(emitter, nil, registrations...); err != nil {
+		log.Fatalf("invalid WEBHOOKS: %v", err)
+	}
+	emitter = mqtt.Emitter(emitter, mqtt.Config{
+		Broker:   os.Getenv("MQTT_BROKER"),
+		ClientID: os.Getenv("MQTT_CLIENT_ID"),
+		Username: os.Getenv("MQTT_USERNAME"),
+		Password: os.Getenv("MQTT_PASSWORD"),
+		Prefix:   os.Getenv("MQTT_TOPIC_PREFIX"),
+	})
+
 	listenAddress := ":" + port
-	log.Fatal(http.ListenAndServe(listenAddress, handler.New(s, e, e)))
+	log.Fatal(http.ListenAndServe(listenAddress, handler.New(games, emitter, e, opts...)))
+}
+
+// readChaosConfig returns the settings of the chaos mode, which is off unless
+// the rates are set.
+func readChaosConfig() chaos.Config {
+	var res chaos.Config
+	for env, rate := range map[string]*float64{
+		"CHAOS_ERROR_RATE":   &res.ErrorRate,
+		"CHAOS_LATENCY_RATE": &res.LatencyRate,
+	} {
+		raw := os.Getenv(env)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 || v > 1 {
+			log.Fatalf("invalid %s %q", env, raw)
+		}
+		*rate = v
+	}
+
+	res.Latency = time.Second
+	if raw := os.Getenv("CHAOS_LATENCY"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			log.Fatalf("invalid CHAOS_LATENCY %q", raw)
+		}
+		res.Latency = d
+	}
+
+	if res.ErrorRate > 0 || res.LatencyRate > 0 {
+		log.Printf("chaos mode: %.2f error rate, %.2f latency rate of %v",
+			res.ErrorRate, res.LatencyRate, res.Latency)
+	}
+	return res
+}
+
+// readTables reads the comma separated table files of PROBABILITY_TABLES.
+func readTables() ([]*engine.Table, error) {
+	var res []*engine.Table
+	raw := os.Getenv("PROBABILITY_TABLES")
+	if raw == "" {
+		return res, nil
+	}
+	for _, path := range strings.Split(raw, ",") {
+		t, err := readTable(path)
+		if err != nil {
+			return nil, fmt.Errorf("probability table %s: %w", path, err)
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+func readTable(path string) (*engine.Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return engine.ReadTable(f)
+}
+
+// featuresEnv returns the comma separated features of the environment
+// variable.
+func featuresEnv(key string) []yahtzee.Feature {
+	return featureList(os.Getenv(key))
+}
+
+// featureList returns the comma separated features.
+func featureList(raw string) []yahtzee.Feature {
+	var res []yahtzee.Feature
+	for _, f := range strings.Split(raw, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			res = append(res, yahtzee.Feature(f))
+		}
+	}
+	return res
 }
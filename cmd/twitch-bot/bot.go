@@ -0,0 +1,227 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/vote"
+)
+
+// pollInterval is the time between checking whether the seat is on turn.
+const pollInterval = 2 * time.Second
+
+// bot plays the seat of a game with the choices of the chat.
+type bot struct {
+	chat   *chat
+	client *client
+	poll   *vote.Poll
+	window time.Duration
+
+	mu      sync.Mutex
+	open    bool
+	dices   int
+	canRoll bool
+}
+
+// run plays the seat until the game is over.
+func (b *bot) run() error {
+	if err := b.client.Join(); err != nil {
+		return err
+	}
+	go b.listen()
+
+	for {
+		g, err := b.client.Game()
+		if err != nil {
+			log.Printf("load game: %v", err)
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		if engine.IsOver(g) {
+			b.chat.Say("Game over! %s", totals(g))
+			return nil
+		}
+
+		if len(g.Players) == 0 || g.Players[g.CurrentPlayer].User != b.client.seat {
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		if g.RollCount == 0 {
+			if err := b.client.Roll(); err != nil {
+				log.Printf("roll: %v", err)
+				time.Sleep(pollInterval)
+			}
+			continue
+		}
+
+		b.vote(g)
+	}
+}
+
+// vote collects the votes of the chat during the voting window and submits
+// the winner as the action of the seat.
+func (b *bot) vote(g *yahtzee.Game) {
+	rolls := engine.RollsPerTurn(g)
+	canRoll := g.RollCount < rolls
+	if canRoll {
+		b.chat.Say("Chat rolled %s (roll %d/%d). Vote with !keep 1 2 or !score <category> in the next %s!",
+			renderDices(g.Dices), g.RollCount, rolls, b.window)
+	} else {
+		b.chat.Say("Chat rolled %s (last roll). Vote with !score <category> in the next %s!",
+			renderDices(g.Dices), b.window)
+	}
+
+	b.mu.Lock()
+	b.open, b.dices, b.canRoll = true, len(g.Dices), canRoll
+	b.mu.Unlock()
+
+	time.Sleep(b.window)
+
+	b.mu.Lock()
+	b.open = false
+	b.mu.Unlock()
+
+	res, err := b.poll.Close()
+	if err == vote.ErrNoVotes {
+		b.chat.Say("No votes, voting again.")
+		return
+	}
+
+	b.chat.Say("Chat decided: %s (%d of %d votes)", res.Winner, res.Votes, res.Total)
+	if err := b.submit(g, res.Winner); err != nil {
+		b.chat.Say("That did not work: %v", err)
+	}
+}
+
+// submit plays the option as the action of the seat.
+func (b *bot) submit(g *yahtzee.Game, option string) error {
+	fields := strings.Fields(option)
+
+	if fields[0] == "score" {
+		column := 0
+		if len(fields) > 2 {
+			column, _ = strconv.Atoi(fields[2])
+			column--
+		}
+		return b.client.Score(column, yahtzee.Category(fields[1]))
+	}
+
+	keep := map[int]bool{}
+	for _, f := range fields[1:] {
+		i, _ := strconv.Atoi(f)
+		keep[i-1] = true
+	}
+	for i, d := range g.Dices {
+		if d.Locked != keep[i] {
+			if err := b.client.Lock(i); err != nil {
+				return err
+			}
+		}
+	}
+	return b.client.Roll()
+}
+
+// listen records the votes from the chat while the voting is open.
+func (b *bot) listen() {
+	for m := range b.chat.Messages() {
+		b.mu.Lock()
+		open, dices, canRoll := b.open, b.dices, b.canRoll
+		b.mu.Unlock()
+
+		if !open {
+			continue
+		}
+
+		option, ok := parseVote(m.Text, dices, canRoll)
+		if !ok {
+			continue
+		}
+
+		if err := b.poll.Vote(m.User, option); err != nil {
+			log.Printf("vote from %s: %v", m.User, err)
+		}
+	}
+	log.Fatal("chat connection lost")
+}
+
+// parseVote turns a chat command into a normalized option, so the same
+// choices are counted together.
+func parseVote(text string, dices int, canRoll bool) (string, bool) {
+	fields := strings.Fields(strings.ToLower(text))
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	switch fields[0] {
+	case "!keep":
+		if !canRoll {
+			return "", false
+		}
+
+		var keep []int
+		seen := map[int]bool{}
+		for _, f := range fields[1:] {
+			i, err := strconv.Atoi(f)
+			if err != nil || i < 1 || i > dices {
+				return "", false
+			}
+			if !seen[i] {
+				seen[i] = true
+				keep = append(keep, i)
+			}
+		}
+		if len(keep) == dices {
+			return "", false
+		}
+		sort.Ints(keep)
+
+		option := "keep"
+		for _, i := range keep {
+			option += " " + strconv.Itoa(i)
+		}
+		return option, true
+	case "!score":
+		if len(fields) < 2 || len(fields) > 3 {
+			return "", false
+		}
+
+		option := "score " + fields[1]
+		if len(fields) == 3 {
+			column, err := strconv.Atoi(fields[2])
+			if err != nil || column < 1 {
+				return "", false
+			}
+			if column > 1 {
+				option += " " + fields[2]
+			}
+		}
+		return option, true
+	}
+	return "", false
+}
+
+func renderDices(dices []*yahtzee.Dice) string {
+	values := make([]string, len(dices))
+	for i, d := range dices {
+		values[i] = strconv.Itoa(d.Value)
+	}
+	return strings.Join(values, " ")
+}
+
+func totals(g *yahtzee.Game) string {
+	scorer := engine.GameScorer(g)
+
+	res := make([]string, len(g.Players))
+	for i, p := range g.Players {
+		res[i] = string(p.User) + ": " + strconv.Itoa(scorer.Total(engine.SheetOwner(g, i)))
+	}
+	return strings.Join(res, ", ")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// client calls the yahtzee server as the player of the seat.
+type client struct {
+	baseURL string
+	gameID  string
+	seat    yahtzee.User
+}
+
+func (c *client) Join() error {
+	err := c.do("POST", "/join", nil, nil)
+	if err != nil && strings.HasPrefix(err.Error(), "409") {
+		return nil
+	}
+	return err
+}
+
+func (c *client) Game() (*yahtzee.Game, error) {
+	var g yahtzee.Game
+	if err := c.do("GET", "", nil, &g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (c *client) Roll() error {
+	return c.do("POST", "/roll", nil, nil)
+}
+
+func (c *client) Lock(dice int) error {
+	return c.do("POST", fmt.Sprintf("/lock/%d", dice), nil, nil)
+}
+
+func (c *client) Score(column int, category yahtzee.Category) error {
+	body, err := json.Marshal(map[string]yahtzee.Category{"Category": category})
+	if err != nil {
+		return err
+	}
+	return c.do("POST", fmt.Sprintf("/score?column=%d", column), bytes.NewReader(body), nil)
+}
+
+func (c *client) do(method, path string, body io.Reader, res interface{}) error {
+	req, err := http.NewRequest(method, c.baseURL+"/"+c.gameID+path, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(string(c.seat), "")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%d %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if res == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(res)
+}
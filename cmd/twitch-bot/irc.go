@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// twitchAddress is the plain text IRC endpoint of the Twitch chat.
+const twitchAddress = "irc.chat.twitch.tv:6667"
+
+// sayInterval keeps the bot under the 20 messages per 30 seconds limit of
+// Twitch.
+const sayInterval = 1500 * time.Millisecond
+
+// chatMessage is a message sent to the channel by a viewer.
+type chatMessage struct {
+	User string
+	Text string
+}
+
+// chat is a connection to the chat of one Twitch channel.
+type chat struct {
+	conn    net.Conn
+	channel string
+
+	messages chan chatMessage
+	outgoing chan string
+}
+
+func dialChat(nick, token, channel string) (*chat, error) {
+	conn, err := net.Dial("tcp", twitchAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &chat{
+		conn:     conn,
+		channel:  "#" + strings.ToLower(strings.TrimPrefix(channel, "#")),
+		messages: make(chan chatMessage),
+		outgoing: make(chan string, 10),
+	}
+
+	if !strings.HasPrefix(token, "oauth:") {
+		token = "oauth:" + token
+	}
+	for _, line := range []string{
+		"PASS " + token,
+		"NICK " + strings.ToLower(nick),
+		"JOIN " + c.channel,
+	} {
+		if err := c.send(line); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	go c.read()
+	go c.write()
+
+	return c, nil
+}
+
+// Messages returns the messages of the channel. It is closed when the
+// connection is lost.
+func (c *chat) Messages() <-chan chatMessage {
+	return c.messages
+}
+
+// Say sends a message to the channel. Messages are dropped when the bot
+// talks faster than the rate limit allows.
+func (c *chat) Say(format string, args ...interface{}) {
+	select {
+	case c.outgoing <- fmt.Sprintf(format, args...):
+	default:
+	}
+}
+
+func (c *chat) send(line string) error {
+	_, err := fmt.Fprintf(c.conn, "%s\r\n", line)
+	return err
+}
+
+func (c *chat) read() {
+	defer close(c.messages)
+
+	scanner := bufio.NewScanner(c.conn)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "PING") {
+			c.send("PONG" + strings.TrimPrefix(line, "PING"))
+			continue
+		}
+
+		if m, ok := parsePrivmsg(line); ok {
+			c.messages <- m
+		}
+	}
+}
+
+func (c *chat) write() {
+	ticker := time.NewTicker(sayInterval)
+	defer ticker.Stop()
+
+	for text := range c.outgoing {
+		c.send("PRIVMSG " + c.channel + " :" + text)
+		<-ticker.C
+	}
+}
+
+// parsePrivmsg extracts the sender and the text from a line like
+// `:nick!nick@nick.tmi.twitch.tv PRIVMSG #channel :text`.
+func parsePrivmsg(line string) (chatMessage, bool) {
+	parts := strings.SplitN(line, " ", 4)
+	if len(parts) < 4 || parts[1] != "PRIVMSG" || !strings.HasPrefix(parts[0], ":") {
+		return chatMessage{}, false
+	}
+
+	user := strings.TrimPrefix(parts[0], ":")
+	if i := strings.Index(user, "!"); i >= 0 {
+		user = user[:i]
+	}
+
+	return chatMessage{
+		User: user,
+		Text: strings.TrimPrefix(parts[3], ":"),
+	}, true
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/vote"
+)
+
+func main() {
+	nick, token, channel := os.Getenv("TWITCH_NICK"), os.Getenv("TWITCH_TOKEN"), os.Getenv("TWITCH_CHANNEL")
+	if nick == "" || token == "" || channel == "" {
+		log.Fatal("TWITCH_NICK, TWITCH_TOKEN and TWITCH_CHANNEL have to be set")
+	}
+
+	gameID := os.Getenv("GAME_ID")
+	if gameID == "" {
+		log.Fatal("GAME_ID has to be set to the game the chat plays in")
+	}
+
+	baseURL := "http://localhost:8000"
+	if envURL := os.Getenv("YAHTZEE_URL"); envURL != "" {
+		baseURL = envURL
+	}
+
+	seat := yahtzee.User("chat")
+	if envSeat := os.Getenv("SEAT"); envSeat != "" {
+		seat = yahtzee.User(envSeat)
+	}
+
+	window := 30 * time.Second
+	if envWindow := os.Getenv("VOTE_WINDOW"); envWindow != "" {
+		var err error
+		if window, err = time.ParseDuration(envWindow); err != nil {
+			log.Fatalf("VOTE_WINDOW: %v", err)
+		}
+	}
+
+	c, err := dialChat(nick, token, channel)
+	if err != nil {
+		log.Fatalf("connect to chat: %v", err)
+	}
+
+	b := &bot{
+		chat: c,
+		client: &client{
+			baseURL: baseURL,
+			gameID:  gameID,
+			seat:    seat,
+		},
+		poll:   vote.NewPoll(window / 3),
+		window: window,
+	}
+
+	if err := b.run(); err != nil {
+		log.Fatal(err)
+	}
+}
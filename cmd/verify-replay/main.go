@@ -0,0 +1,271 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/bolt"
+	"github.com/akarasz/yahtzee/store/eventlog"
+)
+
+// entry is a state of a game in its log.
+type entry struct {
+	Game yahtzee.Game
+
+	// At is the time the state was saved
+	At time.Time
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s BOLT_PATH [gameID...]\n", os.Args[0])
+		os.Exit(2)
+	}
+
+	// the store would create a missing file
+	if _, err := os.Stat(os.Args[1]); err != nil {
+		log.Fatal(err)
+	}
+	b, err := bolt.New(os.Args[1], 0)
+	if err != nil {
+		log.Fatalf("%s: %v", os.Args[1], err)
+	}
+	defer b.Close()
+	logs := eventlog.New(b, b, eventlog.DefaultSnapshotInterval)
+
+	// every logged game is replayed without the IDs
+	ids := os.Args[2:]
+	if len(ids) == 0 {
+		if ids, err = logs.List(); err != nil {
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
+	}
+
+	failed := false
+	for _, id := range ids {
+		entries, err := readLog(logs, id)
+		if err != nil {
+			log.Fatalf("%s: %v", id, err)
+		}
+
+		divergences, err := verify(entries)
+		if err != nil {
+			log.Fatalf("%s: %v", id, err)
+		}
+
+		if len(divergences) == 0 {
+			fmt.Printf("%s: OK\n", id)
+			continue
+		}
+
+		failed = true
+		for _, d := range divergences {
+			fmt.Printf("%s: %s\n", id, d)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// readLog returns every state of the game saved in its log, in order.
+func readLog(logs *eventlog.Store, id string) ([]entry, error) {
+	history, err := logs.History(id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]entry, len(history))
+	for i, c := range history {
+		g, err := logs.At(id, c.Seq)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = entry{
+			Game: g,
+			At:   time.Unix(0, c.At*int64(time.Millisecond)),
+		}
+	}
+	return res, nil
+}
+
+// verify replays the turns of the log on its first state, so the replay has
+// the features, the rules, the rounds and the seed of the game. A turn is
+// played with the dices recorded before its box was filled, at the time it
+// was saved; a zero is replayed as a scratch when the dices score more. The
+// tiebreaks are not replayed.
+func verify(entries []entry) ([]string, error) {
+	if len(entries) == 0 {
+		return nil, errors.New("empty log")
+	}
+
+	// the first state is copied, the replay changes its dices
+	raw, err := store.Marshal(entries[0].Game)
+	if err != nil {
+		return nil, err
+	}
+	g, err := store.Unmarshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < len(entries); i++ {
+		prev, next, now := &entries[i-1].Game, &entries[i].Game, entries[i].At
+
+		for j := len(g.Players); j < len(next.Players); j++ {
+			p := next.Players[j]
+			if err := addPlayer(&g, p); err != nil {
+				return []string{fmt.Sprintf("save %d: adding %s: %v", i, p.User, err)}, nil
+			}
+		}
+
+		column, category, score, ok := filledBox(prev, next)
+		if !ok {
+			continue
+		}
+		if len(prev.Dices) != len(g.Dices) {
+			return nil, fmt.Errorf("save %d: wrong number of dices", i)
+		}
+
+		u := prev.Players[prev.CurrentPlayer].User
+		if err := playTurn(&g, prev, column, category, score, now); err != nil {
+			return []string{fmt.Sprintf("save %d: %s scoring %q: %v", i, u, category, err)}, nil
+		}
+	}
+
+	return compare(entries[len(entries)-1].Game, g), nil
+}
+
+func addPlayer(g *yahtzee.Game, p *yahtzee.Player) error {
+	if p.Handicap != 0 {
+		_, err := engine.AddHandicappedPlayer(g, p.User, p.Handicap)
+		return err
+	}
+	_, err := engine.AddPlayer(g, p.User)
+	return err
+}
+
+// filledBox returns the box of the score sheets filled between the two states
+// with its score.
+func filledBox(prev, next *yahtzee.Game) (int, yahtzee.Category, int, bool) {
+	scorer := engine.GameScorer(next)
+	for i, p := range prev.Players {
+		if i >= len(next.Players) {
+			break
+		}
+		for column := range scorer.Columns {
+			before, after := sheet(p, column), sheet(next.Players[i], column)
+			for c, score := range after {
+				if _, ok := before[c]; ok {
+					continue
+				}
+				// the bonuses are filled with the boxes, they are not boxes
+				if _, ok := scorer.ScoreActions[c]; ok {
+					return column, c, score, true
+				}
+			}
+		}
+	}
+	return 0, "", 0, false
+}
+
+// playTurn ends the turn of the current player of `g` with the dices and the
+// state of the turn in `recorded`, filling the box of the column.
+func playTurn(g *yahtzee.Game, recorded *yahtzee.Game, column int, category yahtzee.Category, score int, now time.Time) error {
+	for i, d := range recorded.Dices {
+		g.Dices[i].Value = d.Value
+		g.Dices[i].Color = d.Color
+	}
+	g.RollCount = recorded.RollCount
+	g.Announcement = recorded.Announcement
+	g.ExtraRolls = recorded.ExtraRolls
+	g.Deadline = recorded.Deadline
+	u := g.Players[g.CurrentPlayer].User
+
+	// the box of a missed shot clock is filled by the timeout
+	if deadline, ok := engine.DeadlineTime(g); ok && g.HasFeature(yahtzee.Blitz) && !now.Before(deadline) {
+		_, err := engine.Timeout(g, now)
+		return err
+	}
+
+	dices := make([]int, len(g.Dices))
+	colors := make([]yahtzee.Color, len(g.Dices))
+	for i, d := range g.Dices {
+		dices[i], colors[i] = d.Value, d.Color
+	}
+	if score == 0 {
+		if value, err := engine.GameScorer(g).EvaluateColors(category, dices, colors); err == nil && value > 0 {
+			_, err := engine.ScratchColumn(g, u, column, category, now)
+			return err
+		}
+	}
+
+	_, err := engine.ScoreColumn(g, u, column, category, now)
+	return err
+}
+
+func compare(recorded, replayed yahtzee.Game) []string {
+	var res []string
+	for i, p := range recorded.Players {
+		if i >= len(replayed.Players) {
+			res = append(res, fmt.Sprintf("%s: recorded, not replayed", p.User))
+			continue
+		}
+
+		for column := 0; column <= len(p.ExtraSheets); column++ {
+			recordedSheet, got := sheet(p, column), sheet(replayed.Players[i], column)
+
+			categories := map[yahtzee.Category]bool{}
+			for c := range recordedSheet {
+				categories[c] = true
+			}
+			for c := range got {
+				categories[c] = true
+			}
+
+			sorted := make([]string, 0, len(categories))
+			for c := range categories {
+				sorted = append(sorted, string(c))
+			}
+			sort.Strings(sorted)
+
+			name := string(p.User)
+			if column > 0 {
+				name = fmt.Sprintf("%s column %d", p.User, column)
+			}
+			for _, c := range sorted {
+				want, wantOK := recordedSheet[yahtzee.Category(c)]
+				have, haveOK := got[yahtzee.Category(c)]
+				if want != have || wantOK != haveOK {
+					res = append(res, fmt.Sprintf("%s %q: recorded %s, replayed %s",
+						name, c, value(want, wantOK), value(have, haveOK)))
+				}
+			}
+		}
+	}
+	return res
+}
+
+// sheet returns the score sheet of the column, nil when the player has no
+// such column.
+func sheet(p *yahtzee.Player, column int) map[yahtzee.Category]int {
+	if column > len(p.ExtraSheets) {
+		return nil
+	}
+	return p.Sheet(column)
+}
+
+func value(v int, ok bool) string {
+	if !ok {
+		return "nothing"
+	}
+	return fmt.Sprint(v)
+}
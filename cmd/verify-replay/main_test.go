@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/store/embedded"
+	"github.com/akarasz/yahtzee/store/eventlog"
+)
+
+// openBox returns the first box of the current player not filled yet.
+func openBox(g *yahtzee.Game) yahtzee.Category {
+	var boxes []string
+	for c := range engine.GameScorer(g).ScoreActions {
+		boxes = append(boxes, string(c))
+	}
+	sort.Strings(boxes)
+
+	sheet := engine.SheetOwner(g, g.CurrentPlayer).ScoreSheet
+	for _, c := range boxes {
+		if _, ok := sheet[yahtzee.Category(c)]; !ok {
+			return yahtzee.Category(c)
+		}
+	}
+	return ""
+}
+
+func TestVerify(t *testing.T) {
+	j := embedded.New()
+	logs := eventlog.New(j, j, 5)
+
+	// the game is saved after every change like on the server
+	g := yahtzee.NewGame(yahtzee.Maxi)
+	g.Seed = 42
+	save := func() {
+		require.NoError(t, logs.Save("aaaaa", *g))
+	}
+	save()
+	for _, u := range []yahtzee.User{"Alice", "Bob"} {
+		_, err := engine.AddPlayer(g, u)
+		require.NoError(t, err)
+		save()
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for turn := 0; !engine.IsOver(g); turn++ {
+		u := g.Players[g.CurrentPlayer].User
+		_, err := engine.Roll(g, u, r.Intn, time.Now())
+		require.NoError(t, err)
+		save()
+
+		if turn%7 == 0 {
+			_, err = engine.Scratch(g, u, openBox(g), time.Now())
+		} else {
+			_, err = engine.Score(g, u, openBox(g), time.Now())
+		}
+		require.NoError(t, err)
+		save()
+	}
+
+	entries, err := readLog(logs, "aaaaa")
+	require.NoError(t, err)
+	divergences, err := verify(entries)
+	require.NoError(t, err)
+	assert.Empty(t, divergences)
+
+	// a box scored differently is reported
+	sheet := entries[len(entries)-1].Game.Players[0].ScoreSheet
+	scored := sheet[yahtzee.Chance]
+	sheet[yahtzee.Chance]++
+	divergences, err = verify(entries)
+	require.NoError(t, err)
+	assert.Exactly(t, []string{
+		fmt.Sprintf("Alice %q: recorded %d, replayed %d", yahtzee.Chance, scored+1, scored),
+	}, divergences)
+}
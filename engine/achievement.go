@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// threeHundred is the total of a game unlocking the ThreeHundred achievement.
+const threeHundred = 300
+
+// straights are the categories needed for the AllStraights achievement, the
+// ones of the scorer.
+var straights = []yahtzee.Category{
+	yahtzee.SmallStraight,
+	yahtzee.LargeStraight,
+	yahtzee.FullStraight,
+}
+
+// AchievementResult has the achievement earned by a player.
+type AchievementResult struct {
+	User        yahtzee.User
+	Achievement yahtzee.Achievement
+}
+
+// trackAchievements adds the actions earning the achievements. They run after
+// every other action, so the Joker scores count.
+func (s *Scorer) trackAchievements() {
+	s.PostScoreActions = append(s.PostScoreActions, firstYahtzee, s.allStraights)
+	s.PostGameActions = append(s.PostGameActions, s.threeHundred)
+}
+
+func firstYahtzee(g *yahtzee.Game, sheet map[yahtzee.Category]int, category yahtzee.Category, _ []int) {
+	if category == yahtzee.Yahtzee && sheet[category] > 0 {
+		achieve(g.Players[g.CurrentPlayer], yahtzee.FirstYahtzee)
+	}
+}
+
+func (s *Scorer) allStraights(g *yahtzee.Game, sheet map[yahtzee.Category]int, category yahtzee.Category, _ []int) {
+	if !inSection(category, straights) {
+		return
+	}
+	for _, c := range straights {
+		if _, ok := s.ScoreActions[c]; ok && sheet[c] == 0 {
+			return
+		}
+	}
+	achieve(g.Players[g.CurrentPlayer], yahtzee.AllStraights)
+}
+
+func (s *Scorer) threeHundred(g *yahtzee.Game) {
+	for i, p := range g.Players {
+		if s.RawTotal(SheetOwner(g, i)) >= threeHundred {
+			achieve(p, yahtzee.ThreeHundred)
+		}
+	}
+}
+
+// achieve gives the achievement to the player unless it's already earned in
+// the game.
+func achieve(p *yahtzee.Player, a yahtzee.Achievement) {
+	for _, earned := range p.Achievements {
+		if earned == a {
+			return
+		}
+	}
+	p.Achievements = append(p.Achievements, a)
+}
+
+// achievementCounts returns the number of achievements of every player.
+func achievementCounts(g *yahtzee.Game) []int {
+	res := make([]int, len(g.Players))
+	for i, p := range g.Players {
+		res[i] = len(p.Achievements)
+	}
+	return res
+}
+
+// achieved returns the events of the achievements earned since the players
+// had the `counts` of achievements.
+func achieved(g *yahtzee.Game, counts []int) []*Event {
+	var res []*Event
+	for i, p := range g.Players {
+		for _, a := range p.Achievements[counts[i]:] {
+			res = append(res, &Event{
+				Action: event.Achievement,
+				Data: &AchievementResult{
+					User:        p.User,
+					Achievement: a,
+				},
+			})
+		}
+	}
+	return res
+}
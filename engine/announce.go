@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// Errors returned by the announce rules.
+var (
+	ErrNotAnnounceGame      = errors.New("announce feature is not enabled")
+	ErrAlreadyAnnounced     = errors.New("category is already announced")
+	ErrAnnounceFirst        = errors.New("announce a category first")
+	ErrNotAnnouncedCategory = errors.New("only the announced category can be scored")
+)
+
+// AnnounceResult has the changes of an announcement.
+type AnnounceResult struct {
+	Announcement yahtzee.Category
+}
+
+// Announce records the category `u` has to score in this round. It's only
+// possible right after the first roll.
+func Announce(g *yahtzee.Game, u yahtzee.User, category yahtzee.Category) ([]*Event, error) {
+	if !g.HasFeature(yahtzee.Announce) {
+		return nil, ErrNotAnnounceGame
+	}
+	if err := checkTurn(g, u); err != nil {
+		return nil, err
+	}
+	if g.RollCount == 0 {
+		return nil, ErrRollFirst
+	}
+	if g.Announcement != "" || g.RollCount > 1 {
+		return nil, ErrAlreadyAnnounced
+	}
+
+	scorer := GameScorer(g)
+	if _, ok := scorer.ScoreActions[category]; !ok {
+		return nil, ErrInvalidCategory
+	}
+
+	p := SheetOwner(g, g.CurrentPlayer)
+	open := false
+	for column := range scorer.Columns {
+		if _, ok := p.Sheet(column)[category]; !ok {
+			open = true
+		}
+	}
+	if !open {
+		return nil, ErrCategoryUsed
+	}
+
+	g.Announcement = category
+
+	return []*Event{{
+		Action: event.Announce,
+		Data: &AnnounceResult{
+			Announcement: g.Announcement,
+		},
+	}}, nil
+}
+
+func announce(s *Scorer) {
+	s.PreScoreActions = append(s.PreScoreActions, announced)
+}
+
+// announced only lets the announced category to be scored.
+func announced(g *yahtzee.Game, _ map[yahtzee.Category]int, category yahtzee.Category, _ []int) error {
+	if g.Announcement == "" {
+		return ErrAnnounceFirst
+	}
+	if g.Announcement != category {
+		return ErrNotAnnouncedCategory
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package engine
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// Action is a move of a player that can be applied on a game.
+type Action interface {
+	apply(g *yahtzee.Game) ([]*Event, error)
+}
+
+// AddPlayerAction adds the user to the players of the game.
+type AddPlayerAction struct {
+	User yahtzee.User
+
+	// Handicap is the points of the user with the handicap feature
+	Handicap int
+}
+
+func (a AddPlayerAction) apply(g *yahtzee.Game) ([]*Event, error) {
+	if a.Handicap != 0 {
+		return AddHandicappedPlayer(g, a.User, a.Handicap)
+	}
+	return AddPlayer(g, a.User)
+}
+
+// RollAction rolls the unlocked dices.
+type RollAction struct {
+	User yahtzee.User
+
+	// Rand is the source of the new face values, it has to return a number in
+	// [0,n). rand.Intn is used when it's nil.
+	Rand func(n int) int
+
+	// Now is the time of the roll, the current time is used when it's zero
+	Now time.Time
+}
+
+func (a RollAction) apply(g *yahtzee.Game) ([]*Event, error) {
+	intn := a.Rand
+	if intn == nil {
+		intn = rand.Intn
+	}
+	return Roll(g, a.User, intn, nowOr(a.Now))
+}
+
+// LockAction toggles the lock on a dice.
+type LockAction struct {
+	User yahtzee.User
+	Dice int
+}
+
+func (a LockAction) apply(g *yahtzee.Game) ([]*Event, error) {
+	return Lock(g, a.User, a.Dice)
+}
+
+// ScoreCategoryAction records the value of the dices in a category.
+type ScoreCategoryAction struct {
+	User     yahtzee.User
+	Category yahtzee.Category
+
+	// Column is the index of the score column, the first one when omitted
+	Column int
+
+	// Now is the time of the scoring, the current time is used when it's
+	// zero
+	Now time.Time
+}
+
+func (a ScoreCategoryAction) apply(g *yahtzee.Game) ([]*Event, error) {
+	return ScoreColumn(g, a.User, a.Column, a.Category, nowOr(a.Now))
+}
+
+// ScratchAction records zero in a category.
+type ScratchAction struct {
+	User     yahtzee.User
+	Category yahtzee.Category
+
+	// Column is the index of the score column, the first one when omitted
+	Column int
+
+	// Now is the time of the scratch, the current time is used when it's
+	// zero
+	Now time.Time
+}
+
+func (a ScratchAction) apply(g *yahtzee.Game) ([]*Event, error) {
+	return ScratchColumn(g, a.User, a.Column, a.Category, nowOr(a.Now))
+}
+
+// AnnounceAction announces the category to be scored in the round.
+type AnnounceAction struct {
+	User     yahtzee.User
+	Category yahtzee.Category
+}
+
+func (a AnnounceAction) apply(g *yahtzee.Game) ([]*Event, error) {
+	return Announce(g, a.User, a.Category)
+}
+
+// ExtraRollAction spends an extra roll token for the turn.
+type ExtraRollAction struct {
+	User yahtzee.User
+}
+
+func (a ExtraRollAction) apply(g *yahtzee.Game) ([]*Event, error) {
+	return ExtraRoll(g, a.User)
+}
+
+// HintAction spends a hint on the advice of the coach.
+type HintAction struct {
+	User yahtzee.User
+}
+
+func (a HintAction) apply(g *yahtzee.Game) ([]*Event, error) {
+	return SpendHint(g, a.User)
+}
+
+// nowOr returns `t`, or the current time when it's zero.
+func nowOr(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Now()
+	}
+	return t
+}
+
+// Apply returns the state of the game after the action and the events caused
+// by it. The original state is left untouched, even when the action fails.
+func Apply(state yahtzee.Game, a Action) (yahtzee.Game, []*Event, error) {
+	g := clone(state)
+
+	events, err := a.apply(&g)
+	if err != nil {
+		return state, nil, err
+	}
+
+	return g, events, nil
+}
+
+// clone returns a deep copy of the game. The structs are copied as a whole,
+// so only their maps, slices and pointers have to be copied here.
+func clone(g yahtzee.Game) yahtzee.Game {
+	res := g
+
+	res.Players = make([]*yahtzee.Player, len(g.Players))
+	for i, p := range g.Players {
+		player := *p
+		player.ScoreSheet = copySheet(p.ScoreSheet)
+		player.ExtraSheets = nil
+		for _, es := range p.ExtraSheets {
+			player.ExtraSheets = append(player.ExtraSheets, copySheet(es))
+		}
+		player.Achievements = append([]yahtzee.Achievement(nil), p.Achievements...)
+		res.Players[i] = &player
+	}
+
+	res.Dices = make([]*yahtzee.Dice, len(g.Dices))
+	for i, d := range g.Dices {
+		dice := *d
+		res.Dices[i] = &dice
+	}
+
+	if g.Features != nil {
+		res.Features = append([]yahtzee.Feature{}, g.Features...)
+	}
+
+	if g.Rules != nil {
+		rules := *g.Rules
+		if g.Rules.Payouts != nil {
+			rules.Payouts = copySheet(g.Rules.Payouts)
+		}
+		res.Rules = &rules
+	}
+
+	if g.Tables != nil {
+		res.Tables = append([]string{}, g.Tables...)
+	}
+
+	if g.Tiebreak != nil {
+		scores := make(map[yahtzee.User]int, len(g.Tiebreak.Scores))
+		for k, v := range g.Tiebreak.Scores {
+			scores[k] = v
+		}
+		res.Tiebreak = &yahtzee.Tiebreak{
+			Players: append([]yahtzee.User{}, g.Tiebreak.Players...),
+			Scores:  scores,
+		}
+	}
+
+	return res
+}
+
+// copySheet returns a copy of the scores by category.
+func copySheet(sheet map[yahtzee.Category]int) map[yahtzee.Category]int {
+	res := make(map[yahtzee.Category]int, len(sheet))
+	for k, v := range sheet {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"errors"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// Errors returned by the away rules.
+var (
+	ErrNotJoined      = errors.New("not joined")
+	ErrAwayNotAllowed = errors.New("being away is not allowed")
+	ErrAwayTooLong    = errors.New("away for too long")
+)
+
+// AwayResult has the away window of a player and the deadline of the
+// current decision.
+type AwayResult struct {
+	User yahtzee.User
+
+	// Away is the end of the away window in unix milliseconds, zero when the
+	// player is back
+	Away int64
+
+	// Deadline is the end of the shot clock of the current decision in unix
+	// milliseconds
+	Deadline int64
+}
+
+// MaxAway returns the longest time a player can be away for in the game.
+func MaxAway(g *yahtzee.Game) time.Duration {
+	if g.Rules == nil {
+		return 0
+	}
+	return time.Duration(g.Rules.MaxAway) * time.Second
+}
+
+// Away pauses the shot clock of `u` from `now` until `until`, a zero time or
+// one in the past ends the away window. The clock of the turns of the player
+// starts when the window ends.
+func Away(g *yahtzee.Game, u yahtzee.User, until time.Time, now time.Time) ([]*Event, error) {
+	if !g.HasFeature(yahtzee.Blitz) {
+		return nil, ErrNotBlitzGame
+	}
+	if IsOver(g) {
+		return nil, ErrGameOver
+	}
+
+	var p *yahtzee.Player
+	for _, player := range g.Players {
+		if player.User == u {
+			p = player
+		}
+	}
+	if p == nil {
+		return nil, ErrNotJoined
+	}
+
+	limit := MaxAway(g)
+	if limit == 0 {
+		return nil, ErrAwayNotAllowed
+	}
+	if until.After(now.Add(limit)) {
+		return nil, ErrAwayTooLong
+	}
+
+	p.Away = 0
+	if until.After(now) {
+		p.Away = unixMillis(until)
+	}
+
+	if g.Players[g.CurrentPlayer] == p && g.Deadline != 0 {
+		startShotClock(g, now)
+	}
+
+	return []*Event{{
+		Action: event.Away,
+		Data: &AwayResult{
+			User:     u,
+			Away:     p.Away,
+			Deadline: g.Deadline,
+		},
+	}}, nil
+}
+
+// clockStart returns when the shot clock of the current player starts, which
+// is the end of the away window of the player or `now`.
+func clockStart(g *yahtzee.Game, now time.Time) time.Time {
+	if len(g.Players) == 0 {
+		return now
+	}
+
+	away := g.Players[g.CurrentPlayer].Away
+	if away == 0 {
+		return now
+	}
+	if until := time.Unix(0, away*int64(time.Millisecond)); until.After(now) {
+		return until
+	}
+	return now
+}
+
+func unixMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
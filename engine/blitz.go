@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"errors"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// defaultShotClock is the time of a decision in blitz games without house
+// rules.
+const defaultShotClock = 15 * time.Second
+
+// blitzBonusPeriod is the unused time of the shot clock worth a bonus point.
+const blitzBonusPeriod = 5 * time.Second
+
+// Errors returned by the blitz rules.
+var (
+	ErrNotBlitzGame = errors.New("blitz feature is not enabled")
+	ErrClockRunning = errors.New("shot clock is still running")
+	ErrClockStopped = errors.New("shot clock is not running")
+	ErrClockExpired = errors.New("shot clock ran out")
+)
+
+// TurnChangedResult has the player on turn, when the shot clock of the turn
+// runs out and the projected final totals.
+type TurnChangedResult struct {
+	User  yahtzee.User
+	Round int
+
+	// Deadline is the end of the shot clock in unix milliseconds, zero
+	// without the clock
+	Deadline int64
+
+	// Projections has the projected final totals of the players in games
+	// with the projection feature, filled by the caller with Projections
+	// as the expectations take a while to calculate
+	Projections map[yahtzee.User]float64
+}
+
+// CountdownResult has the time left of the current decision.
+type CountdownResult struct {
+	User yahtzee.User
+
+	// Deadline is the end of the shot clock in unix milliseconds
+	Deadline int64
+
+	// Remaining is the time left in seconds, rounded up
+	Remaining int
+}
+
+// ShotClock returns the time a player has for a decision in the game.
+func ShotClock(g *yahtzee.Game) time.Duration {
+	if g.Rules != nil && g.Rules.ShotClock > 0 {
+		return time.Duration(g.Rules.ShotClock) * time.Second
+	}
+	return defaultShotClock
+}
+
+// DeadlineTime returns when the shot clock of the current decision runs out,
+// and false when it's not running.
+func DeadlineTime(g *yahtzee.Game) (time.Time, bool) {
+	if g.Deadline == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, g.Deadline*int64(time.Millisecond)), true
+}
+
+// Timeout ends the turn of the current player when the shot clock ran out by
+// `now`, scratching the announced or the first open box.
+func Timeout(g *yahtzee.Game, now time.Time) ([]*Event, error) {
+	if !g.HasFeature(yahtzee.Blitz) {
+		return nil, ErrNotBlitzGame
+	}
+	if len(g.Players) == 0 {
+		return nil, ErrNoPlayers
+	}
+	if IsOver(g) {
+		return nil, ErrGameOver
+	}
+	deadline, ok := DeadlineTime(g)
+	if !ok || now.Before(deadline) {
+		return nil, ErrClockRunning
+	}
+
+	scorer := GameScorer(g)
+	p := SheetOwner(g, g.CurrentPlayer)
+	counts := achievementCounts(g)
+	if InTiebreak(g) {
+		if _, err := scoreTiebreak(g, yahtzee.TiebreakBox, true); err != nil {
+			return nil, err
+		}
+	} else {
+		column, category := openBox(scorer, p, g.Announcement)
+		sheet := p.Sheet(column)
+		sheet[category] = 0
+		scorer.upperBonus(g, sheet, category, nil)
+	}
+
+	round := g.Round
+	nextTurn(g, now)
+
+	events := []*Event{{
+		Action: event.Timeout,
+		Data:   g,
+	}}
+	events = append(events, achieved(g, counts)...)
+	events = append(events, tiebreakStarted(g, round)...)
+	events = append(events, turnChanged(g)...)
+	return append(events, gameOver(g)...), nil
+}
+
+// Countdown tells the players how much time the current player has left for
+// the decision at `now`.
+func Countdown(g *yahtzee.Game, now time.Time) ([]*Event, error) {
+	if !g.HasFeature(yahtzee.Blitz) {
+		return nil, ErrNotBlitzGame
+	}
+	deadline, ok := DeadlineTime(g)
+	if !ok || IsOver(g) {
+		return nil, ErrClockStopped
+	}
+	left := deadline.Sub(now)
+	if left <= 0 {
+		return nil, ErrClockStopped
+	}
+
+	return []*Event{{
+		Action: event.Countdown,
+		Data: &CountdownResult{
+			User:      g.Players[g.CurrentPlayer].User,
+			Deadline:  g.Deadline,
+			Remaining: int((left + time.Second - 1) / time.Second),
+		},
+	}}, nil
+}
+
+// turnChanged returns the event of passing the turn when the shot clock of
+// the next player is running, or when the game goes on with projections.
+func turnChanged(g *yahtzee.Game) []*Event {
+	projected := g.HasFeature(yahtzee.Projection) && !IsOver(g)
+	if g.Deadline == 0 && !projected {
+		return nil
+	}
+
+	return []*Event{{
+		Action: event.TurnChanged,
+		Data: &TurnChangedResult{
+			User:     g.Players[g.CurrentPlayer].User,
+			Round:    g.Round,
+			Deadline: g.Deadline,
+		},
+	}}
+}
+
+// openBox returns the first open box of the player, preferring the
+// `announced` category.
+func openBox(s *Scorer, p *yahtzee.Player, announced yahtzee.Category) (int, yahtzee.Category) {
+	categories := s.Categories()
+	if announced != "" {
+		categories = append([]yahtzee.Category{announced}, categories...)
+	}
+
+	for _, c := range categories {
+		for column := range s.Columns {
+			if _, ok := p.Sheet(column)[c]; !ok {
+				return column, c
+			}
+		}
+	}
+	return 0, categories[0]
+}
+
+// checkClock returns an error when the shot clock of the current decision ran
+// out by `now`. The turn is ended by Timeout then.
+func checkClock(g *yahtzee.Game, now time.Time) error {
+	deadline, ok := DeadlineTime(g)
+	if ok && !now.Before(deadline) {
+		return ErrClockExpired
+	}
+	return nil
+}
+
+// startShotClock starts the countdown of the next decision in blitz games at
+// `now`.
+func startShotClock(g *yahtzee.Game, now time.Time) {
+	if !g.HasFeature(yahtzee.Blitz) || IsOver(g) {
+		g.Deadline = 0
+		return
+	}
+	g.Deadline = unixMillis(clockStart(g, now).Add(ShotClock(g)))
+}
+
+// blitzBonus gives a point for every full five seconds left on the shot clock
+// when scoring at `now`, or takes it when the lowest total wins. The time of
+// an away window doesn't count.
+func (s *Scorer) blitzBonus(g *yahtzee.Game, sheet map[yahtzee.Category]int, now time.Time) {
+	deadline, ok := DeadlineTime(g)
+	if !g.HasFeature(yahtzee.Blitz) || !ok {
+		return
+	}
+
+	left := deadline.Sub(now)
+	if left <= 0 {
+		return
+	}
+	if clock := ShotClock(g); left > clock {
+		left = clock
+	}
+	points := int(left / blitzBonusPeriod)
+	if points == 0 {
+		return
+	}
+	if s.LowestWins {
+		points = -points
+	}
+	sheet[yahtzee.BlitzBonus] += points
+}
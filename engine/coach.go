@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// Errors returned when asking the coach.
+var (
+	// ErrNotCoachGame is returned when asking for advice in a game without
+	// the coach feature.
+	ErrNotCoachGame = errors.New("coach feature is not enabled")
+
+	// ErrCoachDices is returned when the dices of the game have too many
+	// outcomes for the coach to go through.
+	ErrCoachDices = errors.New("too many dice outcomes for the coach")
+)
+
+// maxCoachOutcomes is the most different rolls of every dice the coach goes
+// through, enough for six six-sided dices.
+const maxCoachOutcomes = 500
+
+// Reasons of the advice of the coach.
+const (
+	// ReasonBest is the box with the best expected score.
+	ReasonBest = "coach-best"
+
+	// ReasonScoresNow is a box the dices already score in.
+	ReasonScoresNow = "coach-scores-now"
+
+	// ReasonBonus is an upper box reaching the upper section bonus with the
+	// dices.
+	ReasonBonus = "coach-bonus"
+
+	// ReasonRisky is a box scoring only with luck on the rolls left.
+	ReasonRisky = "coach-risky"
+
+	// ReasonSacrifice is a box that can't score with the dices, a place to
+	// give up the turn.
+	ReasonSacrifice = "coach-sacrifice"
+)
+
+// Advice is an open box recommended by the coach for the dices of the
+// current player.
+type Advice struct {
+	Category yahtzee.Category
+
+	// Score is the score of the box with the dices now
+	Score int
+
+	// Expected is the expected score of the box keeping the best dices for
+	// it with the rolls left
+	Expected float64
+
+	// Chance is the chance of scoring in the box with the rolls left
+	Chance float64
+
+	// Reasons has the codes of the considerations behind the advice
+	Reasons []string
+}
+
+// Coach returns the open boxes of the first column of `u` from the best to
+// the worst by the expected score with the rolls left, with the reasons for
+// them. In the games with hints the player needs a hint left, spent with
+// SpendHint.
+func Coach(g *yahtzee.Game, u yahtzee.User) ([]*Advice, error) {
+	if !g.HasFeature(yahtzee.Coach) && !g.HasFeature(yahtzee.Hints) {
+		return nil, ErrNotCoachGame
+	}
+	if err := checkTurn(g, u); err != nil {
+		return nil, err
+	}
+	if g.HasFeature(yahtzee.Hints) && g.Players[g.CurrentPlayer].Hints <= 0 {
+		return nil, ErrNoHints
+	}
+	if g.RollCount == 0 {
+		return nil, ErrRollFirst
+	}
+
+	sides := g.Sides
+	if sides == 0 {
+		sides = yahtzee.NumberOfSides
+	}
+	if outcomeCount(len(g.Dices), sides) > maxCoachOutcomes {
+		return nil, ErrCoachDices
+	}
+	dices := make([]int, len(g.Dices))
+	for i, d := range g.Dices {
+		dices[i] = d.Value
+	}
+	rolls := RollsPerTurn(g) - g.RollCount
+
+	scorer := GameScorer(g)
+	expected := scorer.Expectations(dices, sides, rolls)
+	chances := scorer.Probabilities(dices, sides, rolls)
+	sheet := SheetOwner(g, g.CurrentPlayer).ScoreSheet
+
+	res := []*Advice{}
+	for _, c := range scorer.Categories() {
+		if _, ok := sheet[c]; ok {
+			continue
+		}
+		score, err := scorer.Evaluate(c, dices)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &Advice{
+			Category: c,
+			Score:    score,
+			Expected: expected[c],
+			Chance:   chances[c],
+		})
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if scorer.LowestWins {
+			return res[i].Expected < res[j].Expected
+		}
+		return res[i].Expected > res[j].Expected
+	})
+
+	for i, a := range res {
+		if i == 0 {
+			a.Reasons = append(a.Reasons, ReasonBest)
+		}
+		switch {
+		case scorer.ScoreActions[a.Category](dices) > 0:
+			a.Reasons = append(a.Reasons, ReasonScoresNow)
+		case a.Chance > 0:
+			a.Reasons = append(a.Reasons, ReasonRisky)
+		default:
+			a.Reasons = append(a.Reasons, ReasonSacrifice)
+		}
+		if scorer.reachesBonus(sheet, a.Category, a.Score) {
+			a.Reasons = append(a.Reasons, ReasonBonus)
+		}
+	}
+
+	return res, nil
+}
+
+// reachesBonus tells if scoring `score` in the category earns the upper
+// section bonus of the sheet.
+func (s *Scorer) reachesBonus(sheet map[yahtzee.Category]int, category yahtzee.Category, score int) bool {
+	if _, ok := sheet[yahtzee.Bonus]; ok || !inSection(category, s.UpperSection) {
+		return false
+	}
+
+	total := score
+	for k, v := range sheet {
+		if inSection(k, s.UpperSection) {
+			total += v
+		}
+	}
+	return total >= s.UpperBonusThreshold
+}
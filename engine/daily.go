@@ -0,0 +1,16 @@
+package engine
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// DailySeed returns the seed of the rolls of the daily challenge on the `day`
+// ("2006-01-02"), the same for every player. The `secret` keeps the rolls of
+// the days to come from being calculated in advance.
+func DailySeed(day string, secret []byte) int64 {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(day))
+	return int64(binary.BigEndian.Uint64(mac.Sum(nil)))
+}
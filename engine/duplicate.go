@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// Errors returned when comparing the tables of a duplicate game.
+var (
+	ErrNotDuplicateGame = errors.New("not a duplicate game")
+	ErrDifferentRolls   = errors.New("tables are not playing the same rolls")
+	ErrUnknownTable     = errors.New("no game for the table")
+)
+
+// Standing is the place of a player among every player of the tables playing
+// the same rolls.
+type Standing struct {
+	// Rank is the place of the player starting from 1, the players with the
+	// same total share it
+	Rank int
+
+	// Game is the ID of the table of the player
+	Game string
+
+	User yahtzee.User
+
+	// Total is the total of the player, the total of the team in partnership
+	// games
+	Total int
+
+	// Over tells if the game of the table is over, the total may still change
+	// when it's not
+	Over bool
+}
+
+// CompareTables ranks the players of the `tables` by their IDs. Every ID needs
+// a game in `tables` and the tables have to play the same rolls, the
+// standings are in the order of the ranks, then the order of `ids` and the
+// players.
+func CompareTables(ids []string, tables map[string]*yahtzee.Game) ([]*Standing, error) {
+	if len(ids) == 0 {
+		return []*Standing{}, nil
+	}
+	for _, id := range ids {
+		if tables[id] == nil {
+			return nil, ErrUnknownTable
+		}
+	}
+
+	first := tables[ids[0]]
+	for _, id := range ids {
+		g := tables[id]
+		if !g.HasFeature(yahtzee.Duplicate) {
+			return nil, ErrNotDuplicateGame
+		}
+		if g.Seed != first.Seed || !sameFeatures(g, first) {
+			return nil, ErrDifferentRolls
+		}
+	}
+
+	scorer := GameScorer(first)
+	res := []*Standing{}
+	for _, id := range ids {
+		g := tables[id]
+		for i, p := range g.Players {
+			res = append(res, &Standing{
+				Game:  id,
+				User:  p.User,
+				Total: scorer.Total(SheetOwner(g, i)),
+				Over:  IsOver(g),
+			})
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return scorer.Beats(res[i].Total, res[j].Total)
+	})
+	for i, s := range res {
+		s.Rank = i + 1
+		if i > 0 && s.Total == res[i-1].Total {
+			s.Rank = res[i-1].Rank
+		}
+	}
+
+	return res, nil
+}
+
+// sameFeatures tells if the games are played with the same features in the
+// same order.
+func sameFeatures(a, b *yahtzee.Game) bool {
+	if len(a.Features) != len(b.Features) {
+		return false
+	}
+	for i := range a.Features {
+		if a.Features[i] != b.Features[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// duplicateFaces returns the faces of every dice for the current roll. They
+// are derived from the seed of the game, the round and the roll count, so
+// every player gets the same faces on the same roll of the round.
+func duplicateFaces(g *yahtzee.Game, sides int) []int {
+	r := rand.New(rand.NewSource(g.Seed ^ int64(g.Round)<<8 ^ int64(g.RollCount)))
+
+	res := make([]int, len(g.Dices))
+	for i := range res {
+		res[i] = r.Intn(sides) + 1
+	}
+	return res
+}
@@ -0,0 +1,364 @@
+// Package engine contains the rules of the game. It applies the actions of
+// the players on a game and returns the changes as events, without any
+// knowledge about how the game is stored or served.
+package engine
+
+import (
+	"errors"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// defaultRollsPerTurn is the number of rolls in a turn without house rules.
+const defaultRollsPerTurn = 3
+
+// Errors returned when an action is against the rules.
+var (
+	ErrAlreadyStarted  = errors.New("game already started")
+	ErrAlreadyJoined   = errors.New("already joined")
+	ErrNoPlayers       = errors.New("no players joined")
+	ErrAnotherPlayer   = errors.New("another players turn")
+	ErrGameOver        = errors.New("game is over")
+	ErrNoMoreRolls     = errors.New("no more rolls")
+	ErrRollFirst       = errors.New("roll first")
+	ErrCategoryUsed    = errors.New("category is already used")
+	ErrInvalidCategory = errors.New("invalid category")
+	ErrInvalidDice     = errors.New("invalid dice")
+	ErrInvalidColumn   = errors.New("invalid column")
+	ErrSoloGame        = errors.New("solo game has only one player")
+	ErrMatchGame       = errors.New("players of a match game are fixed")
+	ErrSandboxGame     = errors.New("players of a sandbox game are fixed")
+)
+
+// Event is a change on the game caused by an action.
+type Event struct {
+	Action event.Type
+	Data   interface{}
+}
+
+// AddPlayerResult has the changes of adding a player.
+type AddPlayerResult struct {
+	Players []*yahtzee.Player
+}
+
+// RollResult has the changes of a roll.
+type RollResult struct {
+	Dices     []*yahtzee.Dice
+	RollCount int
+}
+
+// BonusResult has the upper section bonus earned by a player.
+type BonusResult struct {
+	User   yahtzee.User
+	Column int
+	Bonus  int
+}
+
+// LockResult has the changes of toggling the lock on a dice.
+type LockResult struct {
+	Dices []*yahtzee.Dice
+}
+
+// AddPlayer adds `u` to the players of the game with a score sheet for every
+// column.
+func AddPlayer(g *yahtzee.Game, u yahtzee.User) ([]*Event, error) {
+	if g.CurrentPlayer > 0 || g.Round > 0 {
+		return nil, ErrAlreadyStarted
+	}
+	for _, p := range g.Players {
+		if p.User == u {
+			return nil, ErrAlreadyJoined
+		}
+	}
+	if g.HasFeature(yahtzee.Solo) && len(g.Players) > 0 {
+		return nil, ErrSoloGame
+	}
+	if g.Match != "" {
+		return nil, ErrMatchGame
+	}
+	if g.Sandbox {
+		return nil, ErrSandboxGame
+	}
+	if err := checkTeams(g); err != nil {
+		return nil, err
+	}
+
+	p := yahtzee.NewPlayer(u)
+	if g.HasFeature(yahtzee.ExtraRoll) {
+		p.ExtraRolls = extraRollTokens(g)
+	}
+	if g.HasFeature(yahtzee.Hints) {
+		p.Hints = hintQuota(g)
+	}
+	for i := 1; i < len(GameScorer(g).Columns); i++ {
+		p.ExtraSheets = append(p.ExtraSheets, map[yahtzee.Category]int{})
+	}
+	g.Players = append(g.Players, p)
+
+	return []*Event{{
+		Action: event.AddPlayer,
+		Data: &AddPlayerResult{
+			Players: g.Players,
+		},
+	}}, nil
+}
+
+// Roll rolls the unlocked dices for `u` at `now`. The new face values are
+// taken from `intn`, which has to return a number in [0,n) like rand.Intn
+// does.
+func Roll(g *yahtzee.Game, u yahtzee.User, intn func(n int) int, now time.Time) ([]*Event, error) {
+	if err := checkTurn(g, u); err != nil {
+		return nil, err
+	}
+	if err := checkClock(g, now); err != nil {
+		return nil, err
+	}
+	if g.RollCount >= RollsPerTurn(g) {
+		return nil, ErrNoMoreRolls
+	}
+	if g.HasFeature(yahtzee.Announce) && g.RollCount > 0 && g.Announcement == "" && !InTiebreak(g) {
+		return nil, ErrAnnounceFirst
+	}
+
+	sides := g.Sides
+	if sides == 0 {
+		sides = yahtzee.NumberOfSides
+	}
+
+	var (
+		faces  []int
+		colors []yahtzee.Color
+	)
+	if g.HasFeature(yahtzee.Duplicate) {
+		faces = duplicateFaces(g, sides)
+		colors = duplicateColors(g)
+	}
+	rainbow := g.HasFeature(yahtzee.Rainbow)
+	palette := yahtzee.Colors()
+
+	for i, d := range g.Dices {
+		if d.Locked {
+			continue
+		}
+
+		if faces != nil {
+			d.Value = faces[i]
+			if rainbow {
+				d.Color = colors[i]
+			}
+			continue
+		}
+		d.Value = intn(sides) + 1
+		if rainbow {
+			d.Color = palette[intn(len(palette))]
+		}
+	}
+
+	g.RollCount++
+	startShotClock(g, now)
+
+	return []*Event{{
+		Action: event.Roll,
+		Data: &RollResult{
+			Dices:     g.Dices,
+			RollCount: g.RollCount,
+		},
+	}}, nil
+}
+
+// Lock toggles the lock on the dice with the `dice` index for `u`.
+func Lock(g *yahtzee.Game, u yahtzee.User, dice int) ([]*Event, error) {
+	if err := checkTurn(g, u); err != nil {
+		return nil, err
+	}
+	if g.RollCount == 0 {
+		return nil, ErrRollFirst
+	}
+	if g.RollCount >= RollsPerTurn(g) {
+		return nil, ErrNoMoreRolls
+	}
+	if dice < 0 || dice >= len(g.Dices) {
+		return nil, ErrInvalidDice
+	}
+
+	g.Dices[dice].Locked = !g.Dices[dice].Locked
+
+	return []*Event{{
+		Action: event.Lock,
+		Data: &LockResult{
+			Dices: g.Dices,
+		},
+	}}, nil
+}
+
+// Score records the value of the dices in `category` for `u` at `now` and
+// passes the turn to the next player.
+func Score(g *yahtzee.Game, u yahtzee.User, category yahtzee.Category, now time.Time) ([]*Event, error) {
+	return ScoreColumn(g, u, 0, category, now)
+}
+
+// ScoreColumn records the value of the dices in `category` of the `column`
+// for `u` at `now` and passes the turn to the next player.
+func ScoreColumn(g *yahtzee.Game, u yahtzee.User, column int, category yahtzee.Category, now time.Time) ([]*Event, error) {
+	return endTurn(g, u, column, category, false, now)
+}
+
+// Scratch records zero in `category` for `u` at `now` and passes the turn to
+// the next player.
+func Scratch(g *yahtzee.Game, u yahtzee.User, category yahtzee.Category, now time.Time) ([]*Event, error) {
+	return ScratchColumn(g, u, 0, category, now)
+}
+
+// ScratchColumn records zero in `category` of the `column` for `u` at `now`
+// and passes the turn to the next player.
+func ScratchColumn(g *yahtzee.Game, u yahtzee.User, column int, category yahtzee.Category, now time.Time) ([]*Event, error) {
+	return endTurn(g, u, column, category, true, now)
+}
+
+func endTurn(g *yahtzee.Game, u yahtzee.User, column int, category yahtzee.Category, scratch bool, now time.Time) ([]*Event, error) {
+	if err := checkTurn(g, u); err != nil {
+		return nil, err
+	}
+	if err := checkClock(g, now); err != nil {
+		return nil, err
+	}
+	if g.RollCount == 0 {
+		return nil, ErrRollFirst
+	}
+
+	scorer := GameScorer(g)
+	if scratch && scorer.LowestWins {
+		return nil, ErrLowballScratch
+	}
+	if InTiebreak(g) {
+		events, err := scoreTiebreak(g, category, scratch)
+		if err != nil {
+			return nil, err
+		}
+		round := g.Round
+		nextTurn(g, now)
+
+		events = append(events, tiebreakStarted(g, round)...)
+		events = append(events, turnChanged(g)...)
+		return append(events, gameOver(g)...), nil
+	}
+	if column < 0 || column >= len(scorer.Columns) {
+		return nil, ErrInvalidColumn
+	}
+	if _, ok := SheetOwner(g, g.CurrentPlayer).Sheet(column)[category]; ok {
+		return nil, ErrCategoryUsed
+	}
+
+	dices := make([]int, len(g.Dices))
+	for i, d := range g.Dices {
+		dices[i] = d.Value
+	}
+
+	sheet := SheetOwner(g, g.CurrentPlayer).Sheet(column)
+	_, hadBonus := sheet[yahtzee.Bonus]
+
+	counts := achievementCounts(g)
+	record, action := scorer.Score, event.Score
+	if scratch {
+		record, action = scorer.Scratch, event.Scratch
+	}
+	if err := record(g, column, category, dices); err != nil {
+		return nil, err
+	}
+	scorer.blitzBonus(g, sheet, now)
+
+	events := []*Event{{
+		Action: action,
+		Data:   g,
+	}}
+	if bonus := sheet[yahtzee.Bonus]; !hadBonus && bonus > 0 {
+		events = append(events, &Event{
+			Action: event.Bonus,
+			Data: &BonusResult{
+				User:   u,
+				Column: column,
+				Bonus:  bonus,
+			},
+		})
+	}
+
+	round := g.Round
+	nextTurn(g, now)
+
+	events = append(events, achieved(g, counts)...)
+	events = append(events, tiebreakStarted(g, round)...)
+	events = append(events, turnChanged(g)...)
+	return append(events, gameOver(g)...), nil
+}
+
+// nextTurn passes the turn to the next player at `now`.
+func nextTurn(g *yahtzee.Game, now time.Time) {
+	for _, d := range g.Dices {
+		d.Locked = false
+	}
+
+	g.RollCount = 0
+	g.Announcement = ""
+	g.ExtraRolls = 0
+	if InTiebreak(g) {
+		nextTiebreakTurn(g)
+		startShotClock(g, now)
+		return
+	}
+
+	g.CurrentPlayer = (g.CurrentPlayer + 1) % len(g.Players)
+	if team(g, g.CurrentPlayer) == 0 {
+		g.Round++
+	}
+
+	if IsOver(g) {
+		for _, action := range GameScorer(g).PostGameActions {
+			action(g)
+		}
+		suddenDeath(g)
+	}
+
+	startShotClock(g, now)
+}
+
+func checkTurn(g *yahtzee.Game, u yahtzee.User) error {
+	if len(g.Players) == 0 {
+		return ErrNoPlayers
+	}
+	if g.HasFeature(yahtzee.Partners) && len(g.Players) != partnersPlayers {
+		return ErrTeamsIncomplete
+	}
+	if u != g.Players[g.CurrentPlayer].User {
+		return ErrAnotherPlayer
+	}
+	if IsOver(g) {
+		return ErrGameOver
+	}
+	return nil
+}
+
+// RollsPerTurn returns the number of rolls the current player has in this
+// turn, including the spent extra rolls.
+func RollsPerTurn(g *yahtzee.Game) int {
+	if g.Rules != nil && g.Rules.RollsPerTurn > 0 {
+		return g.Rules.RollsPerTurn + g.ExtraRolls
+	}
+	return defaultRollsPerTurn + g.ExtraRolls
+}
+
+// Rounds returns the number of rounds of the game, which is one for every
+// box of the score sheet unless the game is shortened.
+func Rounds(g *yahtzee.Game) int {
+	all := GameScorer(g).Rounds()
+	if g.Rounds > 0 && g.Rounds < all {
+		return g.Rounds
+	}
+	return all
+}
+
+// IsOver tells if every round of the game is played.
+func IsOver(g *yahtzee.Game) bool {
+	return g.Round >= Rounds(g) && !InTiebreak(g)
+}
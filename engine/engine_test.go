@@ -0,0 +1,2075 @@
+package engine_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/event"
+)
+
+func sequence(values ...int) func(int) int {
+	next := 0
+	return func(int) int {
+		v := values[next] - 1
+		next++
+		return v
+	}
+}
+
+func TestAddPlayer(t *testing.T) {
+	g := yahtzee.NewGame()
+
+	events, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	assert.Exactly(t, []*engine.Event{{
+		Action: event.AddPlayer,
+		Data: &engine.AddPlayerResult{
+			Players: []*yahtzee.Player{yahtzee.NewPlayer("Alice")},
+		},
+	}}, events)
+
+	_, err = engine.AddPlayer(g, "Alice")
+	assert.Exactly(t, engine.ErrAlreadyJoined, err)
+
+	g.Round = 1
+	_, err = engine.AddPlayer(g, "Bob")
+	assert.Exactly(t, engine.ErrAlreadyStarted, err)
+}
+
+func TestRoll(t *testing.T) {
+	g := yahtzee.NewGame()
+	_, err := engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), time.Now())
+	assert.Exactly(t, engine.ErrNoPlayers, err)
+
+	_, err = engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	_, err = engine.Roll(g, "Bob", sequence(1, 2, 3, 4, 5), time.Now())
+	assert.Exactly(t, engine.ErrAnotherPlayer, err)
+
+	events, err := engine.Roll(g, "Alice", sequence(6, 2, 6, 4, 5), time.Now())
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Exactly(t, event.Roll, events[0].Action)
+	assert.Exactly(t, 1, g.RollCount)
+	assert.Exactly(t, []*yahtzee.Dice{
+		{Value: 6}, {Value: 2}, {Value: 6}, {Value: 4}, {Value: 5},
+	}, g.Dices)
+
+	_, err = engine.Lock(g, "Alice", 0)
+	require.NoError(t, err)
+	_, err = engine.Lock(g, "Alice", 5)
+	assert.Exactly(t, engine.ErrInvalidDice, err)
+
+	_, err = engine.Roll(g, "Alice", sequence(1, 1, 1, 1), time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, []*yahtzee.Dice{
+		{Value: 6, Locked: true}, {Value: 1}, {Value: 1}, {Value: 1}, {Value: 1},
+	}, g.Dices)
+
+	_, err = engine.Roll(g, "Alice", sequence(1, 1, 1, 1), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Roll(g, "Alice", sequence(1, 1, 1, 1), time.Now())
+	assert.Exactly(t, engine.ErrNoMoreRolls, err)
+}
+
+func TestScore(t *testing.T) {
+	g := yahtzee.NewGame()
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	_, err = engine.AddPlayer(g, "Bob")
+	require.NoError(t, err)
+
+	_, err = engine.Score(g, "Alice", yahtzee.Chance, time.Now())
+	assert.Exactly(t, engine.ErrRollFirst, err)
+
+	_, err = engine.Roll(g, "Alice", sequence(3, 3, 3, 2, 2), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Score(g, "Alice", "wat", time.Now())
+	assert.Exactly(t, engine.ErrInvalidCategory, err)
+
+	events, err := engine.Score(g, "Alice", yahtzee.FullHouse, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, []*engine.Event{{Action: event.Score, Data: g}}, events)
+	assert.Exactly(t, 25, g.Players[0].ScoreSheet[yahtzee.FullHouse])
+	assert.Exactly(t, 1, g.CurrentPlayer)
+	assert.Exactly(t, 0, g.RollCount)
+}
+
+func TestEvaluate(t *testing.T) {
+	cases := []struct {
+		dices    []int
+		category yahtzee.Category
+		value    int
+	}{
+		{[]int{1, 2, 3, 1, 1}, yahtzee.Ones, 3},
+		{[]int{5, 2, 5, 5, 5}, yahtzee.ThreeOfAKind, 15},
+		{[]int{5, 5, 2, 5, 2}, yahtzee.FullHouse, 25},
+		{[]int{1, 6, 3, 5, 4}, yahtzee.SmallStraight, 30},
+		{[]int{5, 2, 6, 3, 4}, yahtzee.LargeStraight, 40},
+		{[]int{3, 3, 3, 3, 3}, yahtzee.Yahtzee, 50},
+		{[]int{2, 3, 4, 2, 3}, yahtzee.Chance, 14},
+
+		// misses
+		{[]int{5, 2, 5, 1, 5}, yahtzee.FourOfAKind, 0},
+		{[]int{5, 5, 5, 5, 2}, yahtzee.FullHouse, 0},
+		{[]int{1, 1, 2, 2, 3}, yahtzee.FullHouse, 0},
+		{[]int{3, 3, 3, 3, 3}, yahtzee.FullHouse, 0},
+		{[]int{1, 2, 3, 3, 5}, yahtzee.SmallStraight, 0},
+		{[]int{1, 2, 3, 3, 4}, yahtzee.LargeStraight, 0},
+
+		// faces of big dices
+		{[]int{30, 30, 2, 30, 1}, yahtzee.ThreeOfAKind, 90},
+		{[]int{30, 2, 30, 2, 30}, yahtzee.FullHouse, 25},
+		{[]int{25, 21, 23, 22, 24}, yahtzee.LargeStraight, 40},
+	}
+
+	for _, tc := range cases {
+		got, err := engine.Evaluate(tc.category, tc.dices)
+		if assert.NoError(t, err) {
+			assert.Exactly(t, tc.value, got, "for %q on %v", tc.category, tc.dices)
+		}
+	}
+
+	_, err := engine.Evaluate("wat", []int{1, 2, 3, 4, 5})
+	assert.Exactly(t, engine.ErrInvalidCategory, err)
+}
+
+func TestYahtzeeBonus(t *testing.T) {
+	newGame := func(sheet map[yahtzee.Category]int) *yahtzee.Game {
+		g := yahtzee.NewGame(yahtzee.YahtzeeBonus)
+		g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+		for k, v := range sheet {
+			g.Players[0].ScoreSheet[k] = v
+		}
+		_, err := engine.Roll(g, "Alice", sequence(4, 4, 4, 4, 4), time.Now())
+		require.NoError(t, err)
+		return g
+	}
+
+	// first yahtzee gives no bonus
+	g := newGame(nil)
+	_, err := engine.Score(g, "Alice", yahtzee.Yahtzee, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, map[yahtzee.Category]int{yahtzee.Yahtzee: 50}, g.Players[0].ScoreSheet)
+
+	// forced into the upper box
+	g = newGame(map[yahtzee.Category]int{yahtzee.Yahtzee: 50})
+	_, err = engine.Score(g, "Alice", yahtzee.FullHouse, time.Now())
+	assert.Exactly(t, engine.ErrJokerUpperBox, err)
+	_, err = engine.Score(g, "Alice", yahtzee.Fours, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 20, g.Players[0].ScoreSheet[yahtzee.Fours])
+	assert.Exactly(t, 100, g.Players[0].ScoreSheet[yahtzee.YahtzeeBonuses])
+
+	// joker in the lower section
+	g = newGame(map[yahtzee.Category]int{yahtzee.Yahtzee: 50, yahtzee.Fours: 12, yahtzee.YahtzeeBonuses: 100})
+	_, err = engine.Score(g, "Alice", yahtzee.Ones, time.Now())
+	assert.Exactly(t, engine.ErrJokerLowerBox, err)
+	_, err = engine.Score(g, "Alice", yahtzee.LargeStraight, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 40, g.Players[0].ScoreSheet[yahtzee.LargeStraight])
+	assert.Exactly(t, 200, g.Players[0].ScoreSheet[yahtzee.YahtzeeBonuses])
+
+	// scratched yahtzee gives no bonus
+	g = newGame(map[yahtzee.Category]int{yahtzee.Yahtzee: 0, yahtzee.Fours: 12})
+	_, err = engine.Score(g, "Alice", yahtzee.FullHouse, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 25, g.Players[0].ScoreSheet[yahtzee.FullHouse])
+	assert.NotContains(t, g.Players[0].ScoreSheet, yahtzee.Category(yahtzee.YahtzeeBonuses))
+
+	// faces without an upper box go to the lower section
+	g = yahtzee.NewGame(yahtzee.YahtzeeBonus)
+	g.Sides = 8
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	g.Players[0].ScoreSheet[yahtzee.Yahtzee] = 50
+	_, err = engine.Roll(g, "Alice", sequence(7, 7, 7, 7, 7), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Score(g, "Alice", yahtzee.Sixes, time.Now())
+	assert.Exactly(t, engine.ErrJokerLowerBox, err)
+	_, err = engine.Score(g, "Alice", yahtzee.Chance, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 35, g.Players[0].ScoreSheet[yahtzee.Chance])
+	assert.Exactly(t, 100, g.Players[0].ScoreSheet[yahtzee.YahtzeeBonuses])
+}
+
+func TestYatzy(t *testing.T) {
+	s := engine.NewScorer(yahtzee.Yatzy)
+	assert.Exactly(t, 15, s.Rounds())
+
+	cases := []struct {
+		dices    []int
+		category yahtzee.Category
+		value    int
+	}{
+		{[]int{3, 3, 5, 5, 1}, yahtzee.OnePair, 10},
+		{[]int{1, 2, 3, 4, 6}, yahtzee.OnePair, 0},
+		{[]int{3, 3, 5, 5, 1}, yahtzee.TwoPairs, 16},
+		{[]int{3, 3, 3, 3, 1}, yahtzee.TwoPairs, 0},
+		{[]int{2, 2, 6, 6, 6}, yahtzee.FullHouse, 22},
+		{[]int{6, 6, 6, 6, 6}, yahtzee.FullHouse, 0},
+		{[]int{5, 4, 3, 2, 1}, yahtzee.SmallStraight, 15},
+		{[]int{6, 4, 3, 2, 1}, yahtzee.SmallStraight, 0},
+		{[]int{5, 4, 3, 2, 6}, yahtzee.LargeStraight, 20},
+		{[]int{5, 4, 3, 2, 1}, yahtzee.LargeStraight, 0},
+		{[]int{4, 4, 4, 4, 4}, yahtzee.Yahtzee, 50},
+	}
+
+	for _, tc := range cases {
+		got, err := s.Evaluate(tc.category, tc.dices)
+		if assert.NoError(t, err) {
+			assert.Exactly(t, tc.value, got, "for %q on %v", tc.category, tc.dices)
+		}
+	}
+
+	g := yahtzee.NewGame(yahtzee.Yatzy)
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	g.Players[0].ScoreSheet[yahtzee.Ones] = 3
+	g.Players[0].ScoreSheet[yahtzee.Twos] = 6
+	g.Players[0].ScoreSheet[yahtzee.Threes] = 9
+	g.Players[0].ScoreSheet[yahtzee.Fours] = 12
+	g.Players[0].ScoreSheet[yahtzee.Fives] = 15
+	_, err := engine.Roll(g, "Alice", sequence(6, 6, 6, 1, 1), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Score(g, "Alice", yahtzee.Sixes, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 50, g.Players[0].ScoreSheet[yahtzee.Bonus])
+}
+
+func TestKniffel(t *testing.T) {
+	s := engine.NewScorer(yahtzee.Kniffel)
+	assert.Exactly(t, 13, s.Rounds())
+
+	cases := []struct {
+		dices    []int
+		category yahtzee.Category
+		value    int
+	}{
+		{[]int{3, 3, 3, 5, 1}, yahtzee.ThreeOfAKind, 15},
+		{[]int{3, 3, 2, 5, 1}, yahtzee.ThreeOfAKind, 0},
+		{[]int{6, 6, 6, 6, 1}, yahtzee.FourOfAKind, 25},
+		{[]int{6, 6, 6, 2, 1}, yahtzee.FourOfAKind, 0},
+		{[]int{2, 3, 4, 5, 5}, yahtzee.SmallStraight, 30},
+		{[]int{2, 3, 4, 5, 6}, yahtzee.LargeStraight, 40},
+	}
+
+	for _, tc := range cases {
+		got, err := s.Evaluate(tc.category, tc.dices)
+		if assert.NoError(t, err) {
+			assert.Exactly(t, tc.value, got, "for %q on %v", tc.category, tc.dices)
+		}
+	}
+
+	g := yahtzee.NewGame(yahtzee.Kniffel)
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	g.Players[0].ScoreSheet[yahtzee.Yahtzee] = 50
+
+	_, err := engine.Roll(g, "Alice", sequence(5, 5, 5, 5, 5), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Score(g, "Alice", yahtzee.LargeStraight, time.Now())
+	assert.Exactly(t, engine.ErrJokerUpperBox, err)
+	_, err = engine.Score(g, "Alice", yahtzee.Fives, time.Now())
+	require.NoError(t, err)
+
+	g.CurrentPlayer = 0
+	_, err = engine.Roll(g, "Alice", sequence(5, 5, 5, 5, 5), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Score(g, "Alice", yahtzee.LargeStraight, time.Now())
+	require.NoError(t, err)
+
+	assert.Exactly(t, 25, g.Players[0].ScoreSheet[yahtzee.Fives])
+	assert.Exactly(t, 40, g.Players[0].ScoreSheet[yahtzee.LargeStraight])
+	assert.NotContains(t, g.Players[0].ScoreSheet, yahtzee.Category(yahtzee.YahtzeeBonuses))
+}
+
+func TestConflicts(t *testing.T) {
+	assert.Empty(t, engine.Conflicts())
+	assert.Empty(t, engine.Conflicts(yahtzee.Kniffel, yahtzee.Triple, yahtzee.Blitz, yahtzee.Lowball))
+	assert.Exactly(t,
+		[][]yahtzee.Feature{{yahtzee.Yatzy, yahtzee.Kniffel}, {yahtzee.Partners, yahtzee.SuddenDeath}},
+		engine.Conflicts(yahtzee.SuddenDeath, yahtzee.Kniffel, yahtzee.Partners, yahtzee.Yatzy))
+	assert.Exactly(t,
+		[][]yahtzee.Feature{{yahtzee.Triple, yahtzee.DoubleSheet, yahtzee.TripleSheet}},
+		engine.Conflicts(yahtzee.TripleSheet, yahtzee.DoubleSheet, yahtzee.Triple))
+}
+
+func TestRainbow(t *testing.T) {
+	s := engine.NewScorer(yahtzee.Rainbow)
+	assert.Exactly(t, 15, s.Rounds())
+	assert.Exactly(t, [][]yahtzee.Feature{{yahtzee.Coach, yahtzee.Rainbow}}, engine.Conflicts(yahtzee.Rainbow, yahtzee.Coach))
+
+	red := []yahtzee.Color{yahtzee.Red, yahtzee.Red, yahtzee.Red, yahtzee.Red, yahtzee.Red}
+	rainbow := []yahtzee.Color{yahtzee.Red, yahtzee.Orange, yahtzee.Yellow, yahtzee.Green, yahtzee.Blue}
+	cases := []struct {
+		dices    []int
+		colors   []yahtzee.Color
+		category yahtzee.Category
+		value    int
+	}{
+		{[]int{1, 3, 4, 6, 6}, red, yahtzee.Flush, 20},
+		{[]int{1, 3, 4, 6, 6}, rainbow, yahtzee.Flush, 0},
+		{[]int{2, 3, 4, 5, 6}, rainbow, yahtzee.RainbowStraight, 60},
+		{[]int{2, 3, 4, 5, 6}, red, yahtzee.RainbowStraight, 0},
+		{[]int{1, 2, 3, 4, 6}, rainbow, yahtzee.RainbowStraight, 0},
+		{[]int{2, 3, 4, 5, 6}, nil, yahtzee.RainbowStraight, 0},
+		{[]int{2, 3, 4, 5, 6}, red, yahtzee.LargeStraight, 40},
+	}
+	for _, tc := range cases {
+		got, err := s.EvaluateColors(tc.category, tc.dices, tc.colors)
+		require.NoError(t, err)
+		assert.Exactly(t, tc.value, got, "%v %v in %s", tc.dices, tc.colors, tc.category)
+	}
+
+	g := yahtzee.NewGame(yahtzee.Rainbow)
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+
+	// every dice rolls its face and its color
+	_, err = engine.Roll(g, "Alice", sequence(2, 1, 3, 2, 4, 3, 5, 4, 6, 5), time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, rainbow, []yahtzee.Color{g.Dices[0].Color, g.Dices[1].Color, g.Dices[2].Color, g.Dices[3].Color, g.Dices[4].Color})
+
+	_, err = engine.Score(g, "Alice", yahtzee.RainbowStraight, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 60, g.Players[0].ScoreSheet[yahtzee.RainbowStraight])
+}
+
+func TestMaxi(t *testing.T) {
+	s := engine.NewScorer(yahtzee.Maxi)
+	assert.Exactly(t, 20, s.Rounds())
+	assert.Len(t, yahtzee.NewGame(yahtzee.Maxi).Dices, 6)
+
+	cases := []struct {
+		dices    []int
+		category yahtzee.Category
+		value    int
+	}{
+		{[]int{3, 3, 5, 5, 1, 1}, yahtzee.ThreePairs, 18},
+		{[]int{3, 3, 5, 5, 1, 2}, yahtzee.ThreePairs, 0},
+		{[]int{2, 2, 2, 6, 6, 6}, yahtzee.ThreeOfAKind, 18},
+		{[]int{4, 4, 4, 4, 4, 1}, yahtzee.FiveOfAKind, 20},
+		{[]int{1, 2, 3, 4, 5, 6}, yahtzee.FullStraight, 21},
+		{[]int{1, 2, 3, 4, 5, 5}, yahtzee.FullStraight, 0},
+		{[]int{2, 2, 2, 6, 6, 6}, yahtzee.FullHouse, 22},
+		{[]int{2, 2, 2, 6, 6, 6}, yahtzee.Castle, 24},
+		{[]int{2, 2, 2, 2, 6, 6}, yahtzee.Tower, 20},
+		{[]int{2, 2, 2, 6, 6, 6}, yahtzee.Tower, 0},
+		{[]int{5, 5, 5, 5, 5, 5}, yahtzee.Yahtzee, 100},
+		{[]int{5, 5, 5, 5, 5, 1}, yahtzee.Yahtzee, 0},
+		{[]int{5, 5, 5, 5, 5, 1}, yahtzee.Chance, 26},
+	}
+
+	for _, tc := range cases {
+		got, err := s.Evaluate(tc.category, tc.dices)
+		if assert.NoError(t, err) {
+			assert.Exactly(t, tc.value, got, "for %q on %v", tc.category, tc.dices)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	initial := *yahtzee.NewGame()
+
+	joined, events, err := engine.Apply(initial, engine.AddPlayerAction{User: "Alice"})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Empty(t, initial.Players)
+	assert.Len(t, joined.Players, 1)
+
+	rolled, events, err := engine.Apply(joined, engine.RollAction{User: "Alice", Rand: sequence(2, 2, 3, 3, 3)})
+	require.NoError(t, err)
+	assert.Exactly(t, event.Roll, events[0].Action)
+	assert.Exactly(t, 0, joined.RollCount)
+	assert.Exactly(t, 1, joined.Dices[0].Value)
+	assert.Exactly(t, 2, rolled.Dices[0].Value)
+
+	locked, _, err := engine.Apply(rolled, engine.LockAction{User: "Alice", Dice: 1})
+	require.NoError(t, err)
+	assert.False(t, rolled.Dices[1].Locked)
+	assert.True(t, locked.Dices[1].Locked)
+
+	scored, _, err := engine.Apply(locked, engine.ScoreCategoryAction{User: "Alice", Category: yahtzee.FullHouse})
+	require.NoError(t, err)
+	assert.Empty(t, locked.Players[0].ScoreSheet)
+	assert.Exactly(t, 25, scored.Players[0].ScoreSheet[yahtzee.FullHouse])
+
+	same, events, err := engine.Apply(scored, engine.LockAction{User: "Bob", Dice: 1})
+	assert.Exactly(t, engine.ErrAnotherPlayer, err)
+	assert.Nil(t, events)
+	assert.Exactly(t, scored, same)
+}
+
+func TestTriple(t *testing.T) {
+	s := engine.NewScorer(yahtzee.Triple)
+	assert.Exactly(t, 39, s.Rounds())
+
+	g := yahtzee.NewGame(yahtzee.Triple)
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	require.Len(t, g.Players[0].ExtraSheets, 2)
+
+	_, err = engine.Roll(g, "Alice", sequence(6, 6, 6, 6, 6), time.Now())
+	require.NoError(t, err)
+	_, err = engine.ScoreColumn(g, "Alice", 3, yahtzee.Yahtzee, time.Now())
+	assert.Exactly(t, engine.ErrInvalidColumn, err)
+	_, err = engine.ScoreColumn(g, "Alice", 2, yahtzee.Yahtzee, time.Now())
+	require.NoError(t, err)
+
+	_, err = engine.Roll(g, "Alice", sequence(6, 6, 6, 6, 6), time.Now())
+	require.NoError(t, err)
+	_, err = engine.ScoreColumn(g, "Alice", 2, yahtzee.Yahtzee, time.Now())
+	assert.Exactly(t, engine.ErrCategoryUsed, err)
+	_, err = engine.ScoreColumn(g, "Alice", 0, yahtzee.Yahtzee, time.Now())
+	require.NoError(t, err)
+
+	assert.Exactly(t, map[yahtzee.Category]int{yahtzee.Yahtzee: 50}, g.Players[0].ScoreSheet)
+	assert.Exactly(t, map[yahtzee.Category]int{yahtzee.Yahtzee: 50}, g.Players[0].ExtraSheets[1])
+	assert.Exactly(t, 200, s.Total(g.Players[0]))
+}
+
+func TestParallelSheets(t *testing.T) {
+	assert.Exactly(t, 26, engine.NewScorer(yahtzee.DoubleSheet).Rounds())
+	assert.Exactly(t, 39, engine.NewScorer(yahtzee.TripleSheet).Rounds())
+
+	g := yahtzee.NewGame(yahtzee.Kniffel, yahtzee.TripleSheet)
+	s := engine.GameScorer(g)
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	require.Len(t, g.Players[0].ExtraSheets, 2)
+
+	// any column in any turn
+	for _, column := range []int{2, 0, 1} {
+		_, err = engine.Roll(g, "Alice", sequence(2, 2, 2, 5, 5), time.Now())
+		require.NoError(t, err)
+		_, err = engine.ScoreColumn(g, "Alice", column, yahtzee.FullHouse, time.Now())
+		require.NoError(t, err)
+	}
+	_, err = engine.Roll(g, "Alice", sequence(2, 2, 2, 5, 5), time.Now())
+	require.NoError(t, err)
+	_, err = engine.ScoreColumn(g, "Alice", 1, yahtzee.FullHouse, time.Now())
+	assert.Exactly(t, engine.ErrCategoryUsed, err)
+	_, err = engine.ScoreColumn(g, "Alice", 3, yahtzee.Chance, time.Now())
+	assert.Exactly(t, engine.ErrInvalidColumn, err)
+
+	assert.Exactly(t, 75, s.Total(g.Players[0]))
+}
+
+func TestImportSheets(t *testing.T) {
+	sheet := func(u yahtzee.User, boxes map[yahtzee.Category]int) *yahtzee.Player {
+		p := yahtzee.NewPlayer(u)
+		p.ScoreSheet = boxes
+		return p
+	}
+
+	g := yahtzee.NewGame()
+	err := engine.ImportSheets(g, []*yahtzee.Player{
+		sheet("Alice", map[yahtzee.Category]int{yahtzee.Sixes: 30, yahtzee.Fives: 25, yahtzee.Fours: 12, yahtzee.FullHouse: 25}),
+		sheet("Bob", map[yahtzee.Category]int{yahtzee.Yahtzee: 50, yahtzee.Chance: 0, yahtzee.Ones: 3}),
+	})
+	require.NoError(t, err)
+	assert.Exactly(t, 3, g.Round)
+	assert.Exactly(t, 1, g.CurrentPlayer)
+	assert.Exactly(t, 0, g.RollCount)
+	assert.Exactly(t, map[yahtzee.Category]int{yahtzee.Yahtzee: 50, yahtzee.Chance: 0, yahtzee.Ones: 3}, g.Players[1].ScoreSheet)
+
+	// the upper section bonus is earned on the way
+	assert.Exactly(t, 35, g.Players[0].ScoreSheet[yahtzee.Bonus])
+	assert.Exactly(t, 127, engine.GameScorer(g).Total(g.Players[0]))
+
+	cases := []struct {
+		description string
+		players     []*yahtzee.Player
+		err         error
+	}{
+		{"no players", nil, engine.ErrNoPlayers},
+		{"impossible score", []*yahtzee.Player{
+			sheet("Alice", map[yahtzee.Category]int{yahtzee.FullHouse: 20}),
+		}, engine.ErrImpossibleScore},
+		{"unknown box", []*yahtzee.Player{
+			sheet("Alice", map[yahtzee.Category]int{yahtzee.Bonus: 35}),
+		}, engine.ErrInvalidCategory},
+		{"second player ahead", []*yahtzee.Player{
+			sheet("Alice", map[yahtzee.Category]int{yahtzee.Chance: 20}),
+			sheet("Bob", map[yahtzee.Category]int{yahtzee.Chance: 20, yahtzee.Ones: 2}),
+		}, engine.ErrSheetOrder},
+		{"first player too far ahead", []*yahtzee.Player{
+			sheet("Alice", map[yahtzee.Category]int{yahtzee.Chance: 20, yahtzee.Ones: 2}),
+			sheet("Bob", nil),
+		}, engine.ErrSheetOrder},
+		{"same player twice", []*yahtzee.Player{sheet("Alice", nil), sheet("Alice", nil)}, engine.ErrAlreadyJoined},
+	}
+	for _, tc := range cases {
+		err := engine.ImportSheets(yahtzee.NewGame(), tc.players)
+		assert.Exactly(t, tc.err, err, "when %s", tc.description)
+	}
+
+	// scratching a box is not possible in lowball
+	err = engine.ImportSheets(yahtzee.NewGame(yahtzee.Lowball), []*yahtzee.Player{
+		sheet("Alice", map[yahtzee.Category]int{yahtzee.Chance: 0}),
+	})
+	assert.Exactly(t, engine.ErrLowballScratch, err)
+
+	// the replay has no shot clock, blitz bonus or achievement
+	g = yahtzee.NewGame(yahtzee.YahtzeeBonus, yahtzee.Blitz)
+	require.NoError(t, engine.ImportSheets(g, []*yahtzee.Player{
+		sheet("Alice", map[yahtzee.Category]int{yahtzee.Yahtzee: 50, yahtzee.Chance: 6}),
+	}))
+	assert.Exactly(t, map[yahtzee.Category]int{yahtzee.Yahtzee: 50, yahtzee.Chance: 6}, g.Players[0].ScoreSheet)
+	assert.Empty(t, g.Players[0].Achievements)
+	assert.Zero(t, g.Deadline)
+
+	// too many rolls to search
+	g = yahtzee.NewGame()
+	g.SetDices(10, 20)
+	err = engine.ImportSheets(g, []*yahtzee.Player{sheet("Alice", nil)})
+	assert.Exactly(t, engine.ErrImportDices, err)
+}
+
+func TestSuddenDeath(t *testing.T) {
+	g := yahtzee.NewGame(yahtzee.SuddenDeath)
+	g.Rounds = 1
+	for _, u := range []yahtzee.User{"Alice", "Bob", "Carol"} {
+		_, err := engine.AddPlayer(g, u)
+		require.NoError(t, err)
+	}
+
+	play := func(u yahtzee.User, dices []int, category yahtzee.Category) []*engine.Event {
+		_, err := engine.Roll(g, u, sequence(dices...), time.Now())
+		require.NoError(t, err)
+		events, err := engine.Score(g, u, category, time.Now())
+		require.NoError(t, err)
+		return events
+	}
+
+	play("Alice", []int{6, 6, 6, 5, 5}, yahtzee.Chance)
+	play("Bob", []int{1, 1, 1, 1, 2}, yahtzee.Chance)
+	events := play("Carol", []int{5, 5, 6, 6, 6}, yahtzee.Chance)
+
+	// tied at the end
+	assert.False(t, engine.IsOver(g))
+	assert.Exactly(t, 1, g.Round)
+	assert.Exactly(t, 0, g.CurrentPlayer)
+	assert.Exactly(t, []*engine.Event{
+		{Action: event.Score, Data: g},
+		{Action: event.Tiebreak, Data: &engine.TiebreakResult{Players: []yahtzee.User{"Alice", "Carol"}, Round: 1}},
+	}, events)
+
+	_, err := engine.Roll(g, "Alice", sequence(3, 3, 3, 3, 3), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Score(g, "Alice", yahtzee.Ones, time.Now())
+	assert.Exactly(t, engine.ErrTiebreakBox, err)
+	_, err = engine.Score(g, "Alice", yahtzee.TiebreakBox, time.Now())
+	require.NoError(t, err)
+
+	// only the tied players play
+	_, err = engine.Roll(g, "Bob", sequence(6, 6, 6, 6, 6), time.Now())
+	assert.Exactly(t, engine.ErrAnotherPlayer, err)
+
+	// tied again
+	events = play("Carol", []int{1, 2, 3, 4, 5}, yahtzee.TiebreakBox)
+	assert.Exactly(t, &engine.TiebreakResult{Players: []yahtzee.User{"Alice", "Carol"}, Round: 2}, events[1].Data)
+
+	play("Alice", []int{2, 2, 2, 2, 2}, yahtzee.TiebreakBox)
+	events = play("Carol", []int{6, 6, 6, 6, 1}, yahtzee.TiebreakBox)
+	assert.True(t, engine.IsOver(g))
+	assert.Exactly(t, 3, g.Round)
+	assert.Exactly(t, []yahtzee.User{"Carol"}, engine.Winners(g))
+	assert.Exactly(t, event.GameOver, events[len(events)-1].Action)
+	assert.Exactly(t, map[yahtzee.User]int{"Alice": 10, "Carol": 25}, g.Tiebreak.Scores)
+
+	// a tie is final without the feature
+	g = yahtzee.NewGame()
+	g.Rounds = 1
+	for _, u := range []yahtzee.User{"Alice", "Bob"} {
+		_, err := engine.AddPlayer(g, u)
+		require.NoError(t, err)
+	}
+	play("Alice", []int{6, 6, 6, 5, 5}, yahtzee.Chance)
+	play("Bob", []int{5, 5, 6, 6, 6}, yahtzee.Chance)
+	assert.True(t, engine.IsOver(g))
+	assert.Exactly(t, []yahtzee.User{"Alice", "Bob"}, engine.Winners(g))
+}
+
+func TestRollSides(t *testing.T) {
+	g := yahtzee.NewGame()
+	g.SetDices(3, 8)
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+
+	var sides []int
+	_, err = engine.Roll(g, "Alice", func(n int) int {
+		sides = append(sides, n)
+		return n - 1
+	}, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, []int{8, 8, 8}, sides)
+	assert.Exactly(t, []*yahtzee.Dice{{Value: 8}, {Value: 8}, {Value: 8}}, g.Dices)
+
+	_, err = engine.Lock(g, "Alice", 3)
+	assert.Exactly(t, engine.ErrInvalidDice, err)
+
+	_, err = engine.Score(g, "Alice", yahtzee.Yahtzee, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 50, g.Players[0].ScoreSheet[yahtzee.Yahtzee])
+}
+
+func TestAnnounce(t *testing.T) {
+	g := yahtzee.NewGame(yahtzee.Announce)
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+
+	_, err = engine.Announce(g, "Alice", yahtzee.Chance)
+	assert.Exactly(t, engine.ErrRollFirst, err)
+
+	_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), time.Now())
+	require.NoError(t, err)
+
+	_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), time.Now())
+	assert.Exactly(t, engine.ErrAnnounceFirst, err)
+	_, err = engine.Score(g, "Alice", yahtzee.LargeStraight, time.Now())
+	assert.Exactly(t, engine.ErrAnnounceFirst, err)
+	_, err = engine.Announce(g, "Alice", "wat")
+	assert.Exactly(t, engine.ErrInvalidCategory, err)
+
+	events, err := engine.Announce(g, "Alice", yahtzee.Yahtzee)
+	require.NoError(t, err)
+	assert.Exactly(t, event.Announce, events[0].Action)
+	assert.Exactly(t, yahtzee.Category(yahtzee.Yahtzee), g.Announcement)
+
+	_, err = engine.Announce(g, "Alice", yahtzee.Chance)
+	assert.Exactly(t, engine.ErrAlreadyAnnounced, err)
+
+	_, err = engine.Roll(g, "Alice", sequence(6, 6, 6, 6, 6), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Score(g, "Alice", yahtzee.Chance, time.Now())
+	assert.Exactly(t, engine.ErrNotAnnouncedCategory, err)
+	_, err = engine.Score(g, "Alice", yahtzee.Yahtzee, time.Now())
+	require.NoError(t, err)
+
+	assert.Exactly(t, 50, g.Players[0].ScoreSheet[yahtzee.Yahtzee])
+	assert.Exactly(t, yahtzee.Category(""), g.Announcement)
+
+	_, err = engine.Announce(yahtzee.NewGame(), "Alice", yahtzee.Chance)
+	assert.Exactly(t, engine.ErrNotAnnounceGame, err)
+}
+
+func TestSolo(t *testing.T) {
+	g := yahtzee.NewGame(yahtzee.Solo)
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+
+	_, err = engine.AddPlayer(g, "Bob")
+	assert.Exactly(t, engine.ErrSoloGame, err)
+	assert.Len(t, g.Players, 1)
+}
+
+func TestDuplicate(t *testing.T) {
+	g := yahtzee.NewGame(yahtzee.Duplicate)
+	g.Seed = 42
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	_, err = engine.AddPlayer(g, "Bob")
+	require.NoError(t, err)
+
+	rolls := map[yahtzee.User][][]int{}
+	for _, u := range []yahtzee.User{"Alice", "Bob"} {
+		for i := 0; i < 3; i++ {
+			_, err = engine.Roll(g, u, sequence(), time.Now())
+			require.NoError(t, err)
+
+			var faces []int
+			for _, d := range g.Dices {
+				faces = append(faces, d.Value)
+			}
+			rolls[u] = append(rolls[u], faces)
+		}
+
+		_, err = engine.Score(g, u, yahtzee.Chance, time.Now())
+		require.NoError(t, err)
+	}
+
+	assert.Exactly(t, rolls["Alice"], rolls["Bob"])
+	assert.NotEqual(t, rolls["Alice"][0], rolls["Alice"][1])
+	assert.Exactly(t, g.Players[0].ScoreSheet, g.Players[1].ScoreSheet)
+}
+
+func TestCompareTables(t *testing.T) {
+	table := func(sheets map[yahtzee.User]int) *yahtzee.Game {
+		g := yahtzee.NewGame(yahtzee.Duplicate)
+		g.Seed = 42
+		for _, u := range []yahtzee.User{"Alice", "Bob"} {
+			p := yahtzee.NewPlayer(u)
+			p.ScoreSheet[yahtzee.Chance] = sheets[u]
+			g.Players = append(g.Players, p)
+		}
+		return g
+	}
+	tables := map[string]*yahtzee.Game{
+		"first":  table(map[yahtzee.User]int{"Alice": 20, "Bob": 25}),
+		"second": table(map[yahtzee.User]int{"Alice": 25, "Bob": 18}),
+	}
+
+	got, err := engine.CompareTables([]string{"first", "second"}, tables)
+	require.NoError(t, err)
+	assert.Exactly(t, []*engine.Standing{
+		{Rank: 1, Game: "first", User: "Bob", Total: 25},
+		{Rank: 1, Game: "second", User: "Alice", Total: 25},
+		{Rank: 3, Game: "first", User: "Alice", Total: 20},
+		{Rank: 4, Game: "second", User: "Bob", Total: 18},
+	}, got)
+
+	// other rolls
+	tables["second"].Seed = 7
+	_, err = engine.CompareTables([]string{"first", "second"}, tables)
+	assert.Exactly(t, engine.ErrDifferentRolls, err)
+
+	tables["second"] = yahtzee.NewGame()
+	_, err = engine.CompareTables([]string{"first", "second"}, tables)
+	assert.Exactly(t, engine.ErrNotDuplicateGame, err)
+
+	// every table needs its game
+	_, err = engine.CompareTables([]string{"missing", "first"}, tables)
+	assert.Exactly(t, engine.ErrUnknownTable, err)
+}
+
+func TestDailySeed(t *testing.T) {
+	secret := []byte("secret")
+
+	assert.Exactly(t, engine.DailySeed("2021-03-14", secret), engine.DailySeed("2021-03-14", secret))
+	assert.NotEqual(t, engine.DailySeed("2021-03-14", secret), engine.DailySeed("2021-03-15", secret))
+	assert.NotEqual(t, engine.DailySeed("2021-03-14", secret), engine.DailySeed("2021-03-14", []byte("other")))
+}
+
+func TestRules(t *testing.T) {
+	g := yahtzee.NewGame()
+	g.Rules = &yahtzee.Rules{
+		UpperBonusThreshold: 10,
+		UpperBonus:          20,
+		YahtzeeScore:        75,
+		RollsPerTurn:        1,
+	}
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+
+	_, err = engine.Roll(g, "Alice", sequence(4, 4, 4, 4, 4), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Roll(g, "Alice", sequence(4, 4, 4, 4, 4), time.Now())
+	assert.Exactly(t, engine.ErrNoMoreRolls, err)
+	_, err = engine.Score(g, "Alice", yahtzee.Yahtzee, time.Now())
+	require.NoError(t, err)
+
+	_, err = engine.Roll(g, "Alice", sequence(4, 4, 4, 4, 4), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Score(g, "Alice", yahtzee.Fours, time.Now())
+	require.NoError(t, err)
+
+	assert.Exactly(t, 75, g.Players[0].ScoreSheet[yahtzee.Yahtzee])
+	assert.Exactly(t, 20, g.Players[0].ScoreSheet[yahtzee.Bonus])
+}
+
+func TestPayouts(t *testing.T) {
+	g := yahtzee.NewGame()
+	g.Rules = &yahtzee.Rules{
+		YahtzeeScore: 75,
+		Payouts: map[yahtzee.Category]int{
+			yahtzee.Yahtzee:   100,
+			yahtzee.FullHouse: 30,
+			yahtzee.Castle:    50,
+		},
+	}
+	s := engine.GameScorer(g)
+
+	cases := []struct {
+		dices    []int
+		category yahtzee.Category
+		value    int
+	}{
+		{[]int{2, 2, 3, 3, 3}, yahtzee.FullHouse, 30},
+		{[]int{2, 2, 3, 3, 4}, yahtzee.FullHouse, 0},
+		{[]int{4, 4, 4, 4, 4}, yahtzee.Yahtzee, 100},
+		{[]int{2, 3, 4, 5, 6}, yahtzee.LargeStraight, 40},
+	}
+	for _, tc := range cases {
+		got, err := s.Evaluate(tc.category, tc.dices)
+		require.NoError(t, err)
+		assert.Exactly(t, tc.value, got, "%v in %s", tc.dices, tc.category)
+	}
+
+	// the categories not on the sheet are left out
+	_, err := s.Evaluate(yahtzee.Castle, []int{1, 1, 1, 2, 2})
+	assert.Exactly(t, engine.ErrInvalidCategory, err)
+}
+
+func TestRollsPerTurn(t *testing.T) {
+	for _, rolls := range []int{2, 4} {
+		g := yahtzee.NewGame()
+		g.Rules = &yahtzee.Rules{RollsPerTurn: rolls}
+		_, err := engine.AddPlayer(g, "Alice")
+		require.NoError(t, err)
+		assert.Exactly(t, rolls, engine.RollsPerTurn(g))
+
+		for i := 0; i < rolls; i++ {
+			_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), time.Now())
+			require.NoError(t, err, "with %d rolls", rolls)
+		}
+		_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), time.Now())
+		assert.Exactly(t, engine.ErrNoMoreRolls, err, "with %d rolls", rolls)
+		_, err = engine.Lock(g, "Alice", 0)
+		assert.Exactly(t, engine.ErrNoMoreRolls, err, "with %d rolls", rolls)
+		_, err = engine.Score(g, "Alice", yahtzee.LargeStraight, time.Now())
+		require.NoError(t, err, "with %d rolls", rolls)
+	}
+}
+
+func TestScratch(t *testing.T) {
+	g := yahtzee.NewGame()
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+
+	_, err = engine.Scratch(g, "Alice", yahtzee.Chance, time.Now())
+	assert.Exactly(t, engine.ErrRollFirst, err)
+
+	_, err = engine.Roll(g, "Alice", sequence(2, 3, 4, 5, 6), time.Now())
+	require.NoError(t, err)
+
+	events, err := engine.Scratch(g, "Alice", yahtzee.LargeStraight, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, event.Scratch, events[0].Action)
+	assert.Exactly(t, map[yahtzee.Category]int{yahtzee.LargeStraight: 0}, g.Players[0].ScoreSheet)
+	assert.Exactly(t, 0, g.RollCount)
+	assert.Exactly(t, 1, g.Round)
+
+	_, err = engine.Roll(g, "Alice", sequence(2, 3, 4, 5, 6), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Scratch(g, "Alice", yahtzee.LargeStraight, time.Now())
+	assert.Exactly(t, engine.ErrCategoryUsed, err)
+}
+
+func TestPlaySheet(t *testing.T) {
+	turns := []engine.Turn{
+		{Dices: []int{1, 1, 1, 2, 3}, Category: yahtzee.Ones},
+		{Dices: []int{2, 2, 2, 2, 5}, Category: yahtzee.Twos},
+		{Dices: []int{3, 3, 3, 1, 1}, Category: yahtzee.Threes},
+		{Dices: []int{4, 4, 4, 5, 6}, Category: yahtzee.Fours},
+		{Dices: []int{5, 5, 5, 1, 2}, Category: yahtzee.Fives},
+		{Dices: []int{6, 6, 6, 1, 1}, Category: yahtzee.Sixes},
+		{Dices: []int{6, 6, 6, 5, 5}, Category: yahtzee.ThreeOfAKind},
+		{Dices: []int{5, 5, 5, 5, 1}, Category: yahtzee.FourOfAKind},
+		{Dices: []int{2, 2, 3, 3, 3}, Category: yahtzee.FullHouse},
+		{Dices: []int{1, 2, 3, 4, 6}, Category: yahtzee.SmallStraight},
+		{Dices: []int{2, 3, 4, 5, 6}, Category: yahtzee.LargeStraight},
+		{Dices: []int{4, 4, 4, 4, 4}, Category: yahtzee.Yahtzee},
+		{Dices: []int{1, 1, 1, 1, 1}, Category: yahtzee.Chance, Scratch: true},
+	}
+
+	newGame := func() *yahtzee.Game {
+		g := yahtzee.NewGame()
+		_, err := engine.AddPlayer(g, "Alice")
+		require.NoError(t, err)
+		return g
+	}
+
+	g := newGame()
+	require.NoError(t, engine.PlaySheet(g, turns))
+	assert.True(t, engine.IsOver(g))
+	assert.Exactly(t, 35, g.Players[0].ScoreSheet[yahtzee.Bonus])
+	assert.Exactly(t, 0, g.Players[0].ScoreSheet[yahtzee.Chance])
+	assert.Exactly(t, 283, engine.GameScorer(g).Total(g.Players[0]))
+
+	var turnErr *engine.TurnError
+
+	err := engine.PlaySheet(newGame(), turns[:12])
+	require.True(t, errors.As(err, &turnErr))
+	assert.Exactly(t, 12, turnErr.Turn)
+	assert.Exactly(t, engine.ErrIncompleteSheet, turnErr.Err)
+
+	err = engine.PlaySheet(newGame(), append([]engine.Turn{turns[0]}, turns...))
+	require.True(t, errors.As(err, &turnErr))
+	assert.Exactly(t, 1, turnErr.Turn)
+	assert.True(t, errors.Is(err, engine.ErrCategoryUsed))
+
+	err = engine.PlaySheet(newGame(), []engine.Turn{{Dices: []int{1, 2, 3, 4, 7}, Category: yahtzee.Chance}})
+	require.True(t, errors.As(err, &turnErr))
+	assert.Exactly(t, 0, turnErr.Turn)
+	assert.Exactly(t, engine.ErrInvalidDice, turnErr.Err)
+
+	err = engine.PlaySheet(newGame(), []engine.Turn{{Dices: []int{1, 2, 3}, Category: yahtzee.Chance}})
+	require.True(t, errors.As(err, &turnErr))
+	assert.Exactly(t, engine.ErrInvalidDice, turnErr.Err)
+}
+
+func TestPlayTurn(t *testing.T) {
+	g := yahtzee.NewGame()
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	_, err = engine.AddPlayer(g, "Bob")
+	require.NoError(t, err)
+
+	turn := engine.ScriptedTurn{
+		Keep:     [][]int{{6, 6}, {6, 6, 6}},
+		Category: yahtzee.Sixes,
+	}
+	_, err = engine.PlayTurn(g, "Bob", turn, sequence(6, 2, 6, 4, 5), time.Now())
+	assert.Exactly(t, engine.ErrAnotherPlayer, err)
+
+	events, err := engine.PlayTurn(g, "Alice", turn, sequence(6, 2, 6, 4, 5, 6, 1, 1, 3, 6), time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 24, g.Players[0].ScoreSheet[yahtzee.Sixes])
+	assert.Exactly(t, 1, g.CurrentPlayer)
+
+	var actions []event.Type
+	for _, e := range events {
+		actions = append(actions, e.Action)
+	}
+	assert.Exactly(t, []event.Type{
+		event.Roll, event.Lock, event.Roll, event.Lock, event.Roll, event.Score,
+	}, actions)
+	// the events show the dices of their time
+	assert.Exactly(t, []*yahtzee.Dice{
+		{Value: 6, Locked: true}, {Value: 2}, {Value: 6, Locked: true}, {Value: 4}, {Value: 5},
+	}, events[1].Data.(*engine.LockResult).Dices)
+
+	// the turn is checked before the dices are rolled
+	_, err = engine.PlayTurn(g, "Bob", engine.ScriptedTurn{
+		Keep:     [][]int{{1}, {1}, {1}},
+		Category: yahtzee.Ones,
+	}, sequence(), time.Now())
+	assert.Exactly(t, engine.ErrNoMoreRolls, err)
+	_, err = engine.PlayTurn(g, "Bob", engine.ScriptedTurn{
+		Keep:     [][]int{{7}},
+		Category: yahtzee.Ones,
+	}, sequence(), time.Now())
+	assert.Exactly(t, engine.ErrInvalidDice, err)
+	_, err = engine.PlayTurn(g, "Bob", engine.ScriptedTurn{Category: "nothing"}, sequence(), time.Now())
+	assert.Exactly(t, engine.ErrInvalidCategory, err)
+	assert.Exactly(t, 0, g.RollCount)
+
+	_, err = engine.Roll(g, "Bob", sequence(1, 2, 3, 4, 5), time.Now())
+	require.NoError(t, err)
+	_, err = engine.PlayTurn(g, "Bob", engine.ScriptedTurn{Category: yahtzee.Ones}, sequence(), time.Now())
+	assert.Exactly(t, engine.ErrTurnStarted, err)
+}
+
+func TestBonusEvent(t *testing.T) {
+	g := yahtzee.NewGame()
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	g.Players[0].ScoreSheet = map[yahtzee.Category]int{
+		yahtzee.Ones:   3,
+		yahtzee.Twos:   6,
+		yahtzee.Threes: 9,
+		yahtzee.Fours:  12,
+		yahtzee.Fives:  15,
+	}
+
+	_, err = engine.Roll(g, "Alice", sequence(6, 6, 6, 1, 2), time.Now())
+	require.NoError(t, err)
+
+	events, err := engine.Score(g, "Alice", yahtzee.Sixes, time.Now())
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Exactly(t, event.Score, events[0].Action)
+	assert.Exactly(t, event.Bonus, events[1].Action)
+	assert.Exactly(t, &engine.BonusResult{User: "Alice", Column: 0, Bonus: 35}, events[1].Data)
+	assert.Exactly(t, 35, g.Players[0].ScoreSheet[yahtzee.Bonus])
+
+	_, err = engine.Roll(g, "Alice", sequence(6, 6, 6, 1, 2), time.Now())
+	require.NoError(t, err)
+
+	events, err = engine.Score(g, "Alice", yahtzee.Chance, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestExtraRoll(t *testing.T) {
+	g := yahtzee.NewGame()
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+
+	_, err = engine.ExtraRoll(g, "Alice")
+	assert.Exactly(t, engine.ErrNotExtraRollGame, err)
+
+	g = yahtzee.NewGame(yahtzee.ExtraRoll)
+	g.Rules = &yahtzee.Rules{ExtraRolls: 1}
+	_, err = engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	assert.Exactly(t, 1, g.Players[0].ExtraRolls)
+
+	for i := 0; i < 3; i++ {
+		_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), time.Now())
+		require.NoError(t, err)
+	}
+	_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), time.Now())
+	assert.Exactly(t, engine.ErrNoMoreRolls, err)
+
+	events, err := engine.ExtraRoll(g, "Alice")
+	require.NoError(t, err)
+	assert.Exactly(t, event.ExtraRoll, events[0].Action)
+	assert.Exactly(t, &engine.ExtraRollResult{ExtraRolls: 0, RollsPerTurn: 4}, events[0].Data)
+
+	_, err = engine.ExtraRoll(g, "Alice")
+	assert.Exactly(t, engine.ErrNoExtraRolls, err)
+
+	_, err = engine.Lock(g, "Alice", 0)
+	require.NoError(t, err)
+	_, err = engine.Roll(g, "Alice", sequence(2, 3, 4, 5), time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 4, g.RollCount)
+
+	_, err = engine.Score(g, "Alice", yahtzee.LargeStraight, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 0, g.ExtraRolls)
+	assert.Exactly(t, 3, engine.RollsPerTurn(g))
+}
+
+func TestProbabilities(t *testing.T) {
+	got := engine.NewScorer().Probabilities([]int{1, 2, 3, 4, 5}, 6, 0)
+	assert.Exactly(t, 1.0, got[yahtzee.LargeStraight])
+	assert.Exactly(t, 0.0, got[yahtzee.Sixes])
+	assert.Exactly(t, 0.0, got[yahtzee.Yahtzee])
+
+	got = engine.NewScorer().Probabilities([]int{1, 3, 3, 5, 6}, 6, 2)
+	assert.Len(t, got, 13)
+	assert.Exactly(t, 1.0, got[yahtzee.Chance])
+	assert.InDelta(t, 1-math.Pow(5.0/6, 10), got[yahtzee.Fours], 1e-9)
+	assert.InDelta(t, 0.0291, got[yahtzee.Yahtzee], 1e-4)
+
+	// one roll left for the last die
+	got = engine.NewScorer().Probabilities([]int{6, 6, 6, 6, 1}, 6, 1)
+	assert.InDelta(t, 1.0/6, got[yahtzee.Yahtzee], 1e-9)
+}
+
+func TestExpectations(t *testing.T) {
+	got := engine.NewScorer().Expectations([]int{1, 1, 1, 1, 1}, 6, 0)
+	assert.Exactly(t, 5.0, got[yahtzee.Ones])
+	assert.Exactly(t, 50.0, got[yahtzee.Yahtzee])
+
+	got = engine.NewScorer().Expectations([]int{6, 6, 6, 6, 1}, 6, 1)
+	assert.InDelta(t, 50.0/6, got[yahtzee.Yahtzee], 1e-9)
+	assert.InDelta(t, 24+3.5, got[yahtzee.Chance], 1e-9)
+
+	// the lowest is the best in lowball
+	got = engine.NewScorer(yahtzee.Lowball).Expectations([]int{6, 6, 6, 6, 6}, 6, 1)
+	assert.InDelta(t, 17.5, got[yahtzee.Chance], 1e-9)
+}
+
+func TestMaxScore(t *testing.T) {
+	cases := []struct {
+		features []yahtzee.Feature
+		category yahtzee.Category
+		max      int
+	}{
+		{nil, yahtzee.Sixes, 30},
+		{nil, yahtzee.LargeStraight, 40},
+		{[]yahtzee.Feature{yahtzee.Yatzy}, yahtzee.LargeStraight, 20},
+		{[]yahtzee.Feature{yahtzee.Maxi}, yahtzee.Yahtzee, 100},
+		{[]yahtzee.Feature{yahtzee.Rainbow}, yahtzee.Flush, 30},
+		{[]yahtzee.Feature{yahtzee.Rainbow}, yahtzee.RainbowStraight, 60},
+	}
+	for _, tc := range cases {
+		got, err := engine.NewScorer(tc.features...).MaxScore(tc.category, yahtzee.DiceCount(tc.features...))
+		require.NoError(t, err)
+		assert.Exactly(t, tc.max, got, "%s with %v", tc.category, tc.features)
+	}
+
+	_, err := engine.NewScorer().MaxScore(yahtzee.Castle, 5)
+	assert.Exactly(t, engine.ErrInvalidCategory, err)
+}
+
+func TestCoach(t *testing.T) {
+	g := yahtzee.NewGame(yahtzee.Coach)
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	_, err = engine.AddPlayer(g, "Bob")
+	require.NoError(t, err)
+
+	_, err = engine.Coach(g, "Alice")
+	assert.Exactly(t, engine.ErrRollFirst, err)
+
+	_, err = engine.Roll(g, "Alice", sequence(6, 6, 6, 6, 6), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Coach(g, "Bob")
+	assert.Exactly(t, engine.ErrAnotherPlayer, err)
+
+	got, err := engine.Coach(g, "Alice")
+	require.NoError(t, err)
+	assert.Len(t, got, 13)
+	assert.Exactly(t, yahtzee.Category(yahtzee.Yahtzee), got[0].Category)
+	assert.Exactly(t, 50, got[0].Score)
+	assert.Exactly(t, []string{engine.ReasonBest, engine.ReasonScoresNow}, got[0].Reasons)
+
+	for _, a := range got {
+		if a.Category == yahtzee.Ones {
+			assert.Exactly(t, 0, a.Score)
+			assert.Exactly(t, []string{engine.ReasonRisky}, a.Reasons)
+		}
+	}
+
+	// the last roll for the bonus
+	for _, c := range []yahtzee.Category{yahtzee.Ones, yahtzee.Twos, yahtzee.Threes, yahtzee.Fours, yahtzee.Fives} {
+		g.Players[0].ScoreSheet[c] = 12
+	}
+	g.Rules = &yahtzee.Rules{RollsPerTurn: 1}
+	got, err = engine.Coach(g, "Alice")
+	require.NoError(t, err)
+	assert.Len(t, got, 8)
+	for _, a := range got {
+		switch a.Category {
+		case yahtzee.Sixes:
+			assert.Exactly(t, []string{engine.ReasonScoresNow, engine.ReasonBonus}, a.Reasons)
+		case yahtzee.SmallStraight:
+			assert.Exactly(t, []string{engine.ReasonSacrifice}, a.Reasons)
+		}
+	}
+
+	_, err = engine.Coach(yahtzee.NewGame(), "Alice")
+	assert.Exactly(t, engine.ErrNotCoachGame, err)
+
+	// too many rolls to go through
+	g.SetDices(10, 20)
+	g.RollCount = 1
+	_, err = engine.Coach(g, "Alice")
+	assert.Exactly(t, engine.ErrCoachDices, err)
+}
+
+func TestHints(t *testing.T) {
+	g := yahtzee.NewGame(yahtzee.Hints)
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	assert.Exactly(t, 3, g.Players[0].Hints)
+
+	g = yahtzee.NewGame(yahtzee.Hints)
+	g.Rules = &yahtzee.Rules{Hints: 1}
+	_, err = engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	_, err = engine.AddPlayer(g, "Bob")
+	require.NoError(t, err)
+	assert.Exactly(t, 1, g.Players[0].Hints)
+
+	_, err = engine.Roll(g, "Alice", sequence(6, 6, 6, 6, 6), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Coach(g, "Alice")
+	require.NoError(t, err)
+
+	_, err = engine.SpendHint(g, "Bob")
+	assert.Exactly(t, engine.ErrAnotherPlayer, err)
+
+	events, err := engine.SpendHint(g, "Alice")
+	require.NoError(t, err)
+	assert.Exactly(t, event.Hint, events[0].Action)
+	assert.Exactly(t, &engine.HintResult{Hints: 0}, events[0].Data)
+
+	_, err = engine.Coach(g, "Alice")
+	assert.Exactly(t, engine.ErrNoHints, err)
+	_, err = engine.SpendHint(g, "Alice")
+	assert.Exactly(t, engine.ErrNoHints, err)
+
+	_, err = engine.SpendHint(yahtzee.NewGame(yahtzee.Coach), "Alice")
+	assert.Exactly(t, engine.ErrNotCoachGame, err)
+	assert.Exactly(t,
+		[][]yahtzee.Feature{{yahtzee.Coach, yahtzee.Hints}},
+		engine.Conflicts(yahtzee.Hints, yahtzee.Coach))
+}
+
+func TestProjections(t *testing.T) {
+	g := yahtzee.NewGame(yahtzee.Projection)
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+
+	expected, err := engine.ProjectionExpectations(g)
+	require.NoError(t, err)
+	assert.InDelta(t, 70.0/3, expected[yahtzee.Chance], 1e-9)
+	full := 0.0
+	for _, e := range expected {
+		full += e
+	}
+
+	got := engine.Projections(g, expected)
+	assert.InDelta(t, full, got["Alice"], 1e-9)
+	assert.InDelta(t, full, got["Bob"], 1e-9)
+
+	_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), time.Now())
+	require.NoError(t, err)
+	events, err := engine.Score(g, "Alice", yahtzee.Chance, time.Now())
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Exactly(t, &engine.Event{
+		Action: event.TurnChanged,
+		Data: &engine.TurnChangedResult{
+			User:  "Bob",
+			Round: 0,
+		},
+	}, events[1])
+
+	got = engine.Projections(g, expected)
+	assert.InDelta(t, full-expected[yahtzee.Chance]+15, got["Alice"], 1e-9)
+	assert.InDelta(t, full, got["Bob"], 1e-9)
+
+	// only the best box is left for the last turn
+	g = yahtzee.NewGame(yahtzee.Projection)
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	g.Rounds = 1
+	_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Score(g, "Alice", yahtzee.Chance, time.Now())
+	require.NoError(t, err)
+	got = engine.Projections(g, expected)
+	assert.InDelta(t, 15, got["Alice"], 1e-9)
+	assert.InDelta(t, expected[yahtzee.Chance], got["Bob"], 1e-9)
+
+	// no turn changes after the last turn
+	_, err = engine.Roll(g, "Bob", sequence(1, 2, 3, 4, 5), time.Now())
+	require.NoError(t, err)
+	events, err = engine.Score(g, "Bob", yahtzee.Chance, time.Now())
+	require.NoError(t, err)
+	for _, e := range events {
+		assert.NotEqual(t, event.TurnChanged, e.Action)
+	}
+
+	g = yahtzee.NewGame(yahtzee.Projection)
+	for i := 0; i < 5; i++ {
+		g.Dices = append(g.Dices, &yahtzee.Dice{Value: 1})
+	}
+	_, err = engine.ProjectionExpectations(g)
+	assert.Exactly(t, engine.ErrCoachDices, err)
+
+	assert.Exactly(t,
+		[][]yahtzee.Feature{{yahtzee.Projection, yahtzee.Rainbow}},
+		engine.Conflicts(yahtzee.Projection, yahtzee.Rainbow))
+}
+
+func TestWinProbabilities(t *testing.T) {
+	intn := rand.New(rand.NewSource(1)).Intn
+
+	_, err := engine.WinProbabilities(yahtzee.NewGame(), nil, 10, intn)
+	assert.Exactly(t, engine.ErrNoPlayers, err)
+
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	expected, err := engine.ProjectionExpectations(g)
+	require.NoError(t, err)
+
+	got, err := engine.WinProbabilities(g, expected, 50, intn)
+	require.NoError(t, err)
+	assert.InDelta(t, 1, got["Alice"]+got["Bob"], 1e-9)
+
+	// bob can't catch up in the last turn
+	for _, p := range g.Players {
+		for _, c := range yahtzee.Categories()[1:] {
+			p.ScoreSheet[c] = 0
+		}
+	}
+	g.Players[0].ScoreSheet[yahtzee.Ones] = 0
+	g.Players[0].ScoreSheet[yahtzee.Chance] = 100
+	g.Round = 12
+	g.CurrentPlayer = 1
+
+	got, err = engine.WinProbabilities(g, expected, 50, intn)
+	require.NoError(t, err)
+	assert.Exactly(t, map[yahtzee.User]float64{"Alice": 1, "Bob": 0}, got)
+
+	// the game is left as it was
+	assert.Exactly(t, 12, g.Round)
+	assert.NotContains(t, g.Players[1].ScoreSheet, yahtzee.Ones)
+
+	// a tie splits the win
+	g.Players[0].ScoreSheet[yahtzee.Chance] = 0
+	g.Players[1].ScoreSheet[yahtzee.Ones] = 0
+	g.Round = 13
+	g.CurrentPlayer = 0
+
+	got, err = engine.WinProbabilities(g, nil, 10, intn)
+	require.NoError(t, err)
+	assert.Exactly(t, map[yahtzee.User]float64{"Alice": 0.5, "Bob": 0.5}, got)
+}
+
+func TestSandbox(t *testing.T) {
+	_, err := engine.Sandbox(yahtzee.NewGame(), "Carol")
+	assert.Exactly(t, engine.ErrNoPlayers, err)
+
+	g := yahtzee.NewGame(yahtzee.Duplicate, yahtzee.Blitz)
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	g.Seed = 42
+	g.Tables = []string{"other"}
+	g.Match = "match"
+	g.Deadline = 1000
+	g.Creator = "Alice"
+
+	sandbox, err := engine.Sandbox(g, "Carol")
+	require.NoError(t, err)
+	assert.True(t, sandbox.Sandbox)
+	assert.Exactly(t, yahtzee.User("Carol"), sandbox.Creator)
+	assert.Empty(t, sandbox.Features)
+	assert.Zero(t, sandbox.Seed)
+	assert.Empty(t, sandbox.Tables)
+	assert.Empty(t, sandbox.Match)
+	assert.Zero(t, sandbox.Deadline)
+
+	// the game is left as it was
+	assert.False(t, g.Sandbox)
+	assert.Exactly(t, []yahtzee.Feature{yahtzee.Duplicate, yahtzee.Blitz}, g.Features)
+
+	// the owner plays every turn
+	assert.Exactly(t, yahtzee.User("Alice"), engine.Actor(&sandbox, "Carol"))
+	assert.Exactly(t, yahtzee.User("Bob"), engine.Actor(&sandbox, "Bob"))
+	assert.Exactly(t, yahtzee.User("Carol"), engine.Actor(g, "Carol"))
+
+	_, err = engine.AddPlayer(&sandbox, "Dave")
+	assert.Exactly(t, engine.ErrSandboxGame, err)
+}
+
+func TestSandboxCopiesDeep(t *testing.T) {
+	g := yahtzee.NewGame(yahtzee.Rainbow)
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	g.Players[0].ScoreSheet[yahtzee.Ones] = 3
+	g.Players[0].ExtraSheets = []map[yahtzee.Category]int{{yahtzee.Twos: 4}}
+	g.Players[0].Achievements = []yahtzee.Achievement{yahtzee.FirstYahtzee}
+	g.Dices[0].Color = yahtzee.Red
+	g.Rules = &yahtzee.Rules{UpperBonus: 35, Payouts: map[yahtzee.Category]int{yahtzee.Yahtzee: 100}}
+	g.Tiebreak = &yahtzee.Tiebreak{
+		Players: []yahtzee.User{"Alice", "Bob"},
+		Scores:  map[yahtzee.User]int{"Alice": 10},
+	}
+	before, err := json.Marshal(g)
+	require.NoError(t, err)
+
+	sandbox, err := engine.Sandbox(g, "Carol")
+	require.NoError(t, err)
+	sandbox.Players[0].ScoreSheet[yahtzee.Ones] = 5
+	sandbox.Players[0].ExtraSheets[0][yahtzee.Twos] = 6
+	sandbox.Players[0].Achievements[0] = "changed"
+	sandbox.Players[0].Hints = 2
+	sandbox.Dices[0].Value = 6
+	sandbox.Dices[0].Color = yahtzee.Blue
+	sandbox.Rules.UpperBonus = 0
+	sandbox.Rules.Payouts[yahtzee.Yahtzee] = 0
+	sandbox.Tiebreak.Players[0] = "Carol"
+	sandbox.Tiebreak.Scores["Alice"] = 0
+
+	after, err := json.Marshal(g)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(before), string(after))
+}
+
+func TestHighlights(t *testing.T) {
+	g := yahtzee.NewGame()
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	_, err = engine.AddPlayer(g, "Bob")
+	require.NoError(t, err)
+
+	log := []yahtzee.Activity{
+		{At: 1, Action: "score", User: "Alice", Dices: []int{1, 2, 3, 4, 6}, Totals: map[yahtzee.User]int{"Alice": 16, "Bob": 0}},
+		{At: 2, Action: "score", User: "Bob", Dices: []int{4, 4, 4, 4, 4}, Totals: map[yahtzee.User]int{"Alice": 16, "Bob": 50}},
+		{At: 3, Action: "timeout"},
+		{At: 4, Action: "scratch", User: "Alice", Dices: []int{2, 2, 2, 2, 2}, Totals: map[yahtzee.User]int{"Alice": 16, "Bob": 50}},
+		{At: 5, Action: "score", User: "Alice", Dices: []int{5, 5, 5, 6, 6}, Totals: map[yahtzee.User]int{"Alice": 50, "Bob": 50}},
+		{At: 6, Action: "score", User: "Bob", Dices: []int{1, 1, 1, 1, 2}, Totals: map[yahtzee.User]int{"Alice": 50, "Bob": 54}},
+		{At: 7, Action: "game-over", Totals: map[yahtzee.User]int{"Alice": 50, "Bob": 54}},
+	}
+
+	_, err = engine.Highlights(g, log)
+	assert.Exactly(t, engine.ErrGameNotOver, err)
+
+	g.Round = engine.Rounds(g)
+	got, err := engine.Highlights(g, log)
+	require.NoError(t, err)
+	assert.Exactly(t, &engine.HighlightsResult{
+		Yahtzees: []*engine.Highlight{
+			{At: 2, User: "Bob", Dices: []int{4, 4, 4, 4, 4}, Points: 50},
+			{At: 4, User: "Alice", Dices: []int{2, 2, 2, 2, 2}, Points: 0},
+		},
+		BiggestTurn: &engine.Highlight{At: 2, User: "Bob", Dices: []int{4, 4, 4, 4, 4}, Points: 50},
+		LeadChanges: []*engine.LeadChange{
+			// the tie at 5 is not a change
+			{At: 2, Leaders: []yahtzee.User{"Bob"}, Previous: []yahtzee.User{"Alice"}},
+		},
+	}, got)
+}
+
+func TestRegisteredCategory(t *testing.T) {
+	yahtzee.RegisterCategory("sevens", func(dices []int) int {
+		s := 0
+		for _, d := range dices {
+			if d == 1 || d == 6 {
+				s += 7
+			}
+		}
+		return s
+	}, yahtzee.Upper)
+
+	plain := engine.NewScorer()
+	_, ok := plain.ScoreActions["sevens"]
+	assert.False(t, ok)
+	assert.Exactly(t, 13, plain.Rounds())
+
+	s := engine.NewScorer("sevens")
+	assert.Exactly(t, 14, s.Rounds())
+	assert.Exactly(t, yahtzee.Upper, s.Section("sevens"))
+	assert.Exactly(t, yahtzee.Lower, s.Section(yahtzee.Chance))
+	assert.Exactly(t, yahtzee.Category("sevens"), s.Categories()[13])
+
+	score, err := s.Evaluate("sevens", []int{1, 6, 6, 2, 3})
+	require.NoError(t, err)
+	assert.Exactly(t, 21, score)
+
+	// the registered upper category counts for the bonus
+	g := yahtzee.NewGame("sevens")
+	_, err = engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	g.Players[0].ScoreSheet = map[yahtzee.Category]int{
+		yahtzee.Ones:   3,
+		yahtzee.Twos:   6,
+		yahtzee.Threes: 9,
+		yahtzee.Fours:  12,
+		yahtzee.Fives:  15,
+		yahtzee.Sixes:  12,
+	}
+
+	_, err = engine.Roll(g, "Alice", sequence(1, 6, 2, 3, 4), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Score(g, "Alice", "sevens", time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 35, g.Players[0].ScoreSheet[yahtzee.Bonus])
+}
+
+func TestJokerRules(t *testing.T) {
+	newGame := func(features ...yahtzee.Feature) *yahtzee.Game {
+		g := yahtzee.NewGame(features...)
+		g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+		g.Players[0].ScoreSheet[yahtzee.Yahtzee] = 50
+		_, err := engine.Roll(g, "Alice", sequence(4, 4, 4, 4, 4), time.Now())
+		require.NoError(t, err)
+		return g
+	}
+
+	// forced joker without the bonus points
+	g := newGame(yahtzee.ForcedJoker)
+	_, err := engine.Score(g, "Alice", yahtzee.FullHouse, time.Now())
+	assert.Exactly(t, engine.ErrJokerUpperBox, err)
+	_, err = engine.Score(g, "Alice", yahtzee.Fours, time.Now())
+	require.NoError(t, err)
+	assert.NotContains(t, g.Players[0].ScoreSheet, yahtzee.Category(yahtzee.YahtzeeBonuses))
+
+	// free joker while the upper box is open
+	g = newGame(yahtzee.FreeJoker)
+	_, err = engine.Score(g, "Alice", yahtzee.SmallStraight, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 30, g.Players[0].ScoreSheet[yahtzee.SmallStraight])
+
+	// free joker overrides the forced one of the bonus
+	g = newGame(yahtzee.YahtzeeBonus, yahtzee.FreeJoker)
+	_, err = engine.Score(g, "Alice", yahtzee.FullHouse, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 25, g.Players[0].ScoreSheet[yahtzee.FullHouse])
+	assert.Exactly(t, 100, g.Players[0].ScoreSheet[yahtzee.YahtzeeBonuses])
+
+	// free joker in the upper section while the lower one is open
+	g = newGame(yahtzee.Kniffel, yahtzee.FreeJoker)
+	g.Players[0].ScoreSheet[yahtzee.Fours] = 12
+	_, err = engine.Score(g, "Alice", yahtzee.Ones, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 0, g.Players[0].ScoreSheet[yahtzee.Ones])
+
+	// the joker straights are worth their yatzy value
+	g = newGame(yahtzee.Yatzy, yahtzee.FreeJoker)
+	_, err = engine.Score(g, "Alice", yahtzee.SmallStraight, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 15, g.Players[0].ScoreSheet[yahtzee.SmallStraight])
+	g.CurrentPlayer, g.RollCount = 0, 1
+	_, err = engine.Score(g, "Alice", yahtzee.LargeStraight, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 20, g.Players[0].ScoreSheet[yahtzee.LargeStraight])
+
+	// no joker at all
+	g = newGame()
+	_, err = engine.Score(g, "Alice", yahtzee.LargeStraight, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, 0, g.Players[0].ScoreSheet[yahtzee.LargeStraight])
+}
+
+func TestBlitz(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	g := yahtzee.NewGame(yahtzee.Blitz)
+	g.Rules = &yahtzee.Rules{ShotClock: 20}
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	_, err = engine.AddPlayer(g, "Bob")
+	require.NoError(t, err)
+	assert.Exactly(t, int64(0), g.Deadline)
+
+	_, err = engine.Timeout(g, now)
+	assert.Exactly(t, engine.ErrClockRunning, err)
+
+	// the clock starts with the roll
+	_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), now)
+	require.NoError(t, err)
+	assert.Exactly(t, int64(1020000), g.Deadline)
+
+	// unused time is worth a point for every five seconds
+	now = now.Add(9 * time.Second)
+	_, err = engine.Score(g, "Alice", yahtzee.LargeStraight, now)
+	require.NoError(t, err)
+	assert.Exactly(t, 2, g.Players[0].ScoreSheet[yahtzee.BlitzBonus])
+	assert.Exactly(t, int64(1029000), g.Deadline)
+
+	_, err = engine.Timeout(g, now)
+	assert.Exactly(t, engine.ErrClockRunning, err)
+
+	// the first open box is scratched when the time runs out
+	now = now.Add(20 * time.Second)
+	events, err := engine.Timeout(g, now)
+	require.NoError(t, err)
+	assert.Exactly(t, event.Timeout, events[0].Action)
+	assert.Exactly(t, map[yahtzee.Category]int{yahtzee.Ones: 0}, g.Players[1].ScoreSheet)
+	assert.Exactly(t, 0, g.CurrentPlayer)
+	assert.Exactly(t, 1, g.Round)
+	assert.Exactly(t, int64(1049000), g.Deadline)
+
+	// no bonus in the last seconds
+	_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), now)
+	require.NoError(t, err)
+	now = now.Add(17 * time.Second)
+	_, err = engine.Score(g, "Alice", yahtzee.SmallStraight, now)
+	require.NoError(t, err)
+	assert.Exactly(t, 2, g.Players[0].ScoreSheet[yahtzee.BlitzBonus])
+
+	// the moves are refused once the time ran out
+	now = now.Add(time.Minute)
+	_, err = engine.Roll(g, "Bob", sequence(1, 2, 3, 4, 5), now)
+	assert.Exactly(t, engine.ErrClockExpired, err)
+	g.RollCount = 1
+	_, err = engine.Score(g, "Bob", yahtzee.Chance, now)
+	assert.Exactly(t, engine.ErrClockExpired, err)
+	assert.NotContains(t, g.Players[1].ScoreSheet, yahtzee.Chance)
+	_, err = engine.Timeout(g, now)
+	require.NoError(t, err)
+
+	_, err = engine.Timeout(yahtzee.NewGame(), now)
+	assert.Exactly(t, engine.ErrNotBlitzGame, err)
+}
+
+func TestTurnDeadline(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	_, err := engine.Countdown(yahtzee.NewGame(), now)
+	assert.Exactly(t, engine.ErrNotBlitzGame, err)
+
+	g := yahtzee.NewGame(yahtzee.Blitz)
+	g.Rules = &yahtzee.Rules{ShotClock: 40}
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+
+	_, err = engine.Countdown(g, now)
+	assert.Exactly(t, engine.ErrClockStopped, err)
+
+	_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), now)
+	require.NoError(t, err)
+
+	// the remaining time is rounded up
+	now = now.Add(9500 * time.Millisecond)
+	events, err := engine.Countdown(g, now)
+	require.NoError(t, err)
+	assert.Exactly(t, []*engine.Event{{
+		Action: event.Countdown,
+		Data: &engine.CountdownResult{
+			User:      "Alice",
+			Deadline:  1040000,
+			Remaining: 31,
+		},
+	}}, events)
+
+	// the turn changed event has the deadline of the next player
+	events, err = engine.Score(g, "Alice", yahtzee.Chance, now)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Exactly(t, &engine.Event{
+		Action: event.TurnChanged,
+		Data: &engine.TurnChangedResult{
+			User:     "Bob",
+			Round:    0,
+			Deadline: 1049500,
+		},
+	}, events[1])
+
+	now = now.Add(time.Minute)
+	events, err = engine.Timeout(g, now)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Exactly(t, event.TurnChanged, events[1].Action)
+	assert.Exactly(t, 1, events[1].Data.(*engine.TurnChangedResult).Round)
+
+	// no turn changes without the clock
+	g = yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), now)
+	require.NoError(t, err)
+	events, err = engine.Score(g, "Alice", yahtzee.Chance, now)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestAway(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	_, err := engine.Away(yahtzee.NewGame(), "Alice", now, now)
+	assert.Exactly(t, engine.ErrNotBlitzGame, err)
+
+	g := yahtzee.NewGame(yahtzee.Blitz)
+	g.Rules = &yahtzee.Rules{ShotClock: 20}
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+
+	_, err = engine.Away(g, "Carol", now, now)
+	assert.Exactly(t, engine.ErrNotJoined, err)
+	_, err = engine.Away(g, "Bob", now.Add(time.Minute), now)
+	assert.Exactly(t, engine.ErrAwayNotAllowed, err)
+
+	g.Rules.MaxAway = 3600
+	_, err = engine.Away(g, "Bob", now.Add(2*time.Hour), now)
+	assert.Exactly(t, engine.ErrAwayTooLong, err)
+
+	_, err = engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), now)
+	require.NoError(t, err)
+
+	// the clock of the current player is paused until the window ends
+	events, err := engine.Away(g, "Alice", now.Add(time.Hour), now)
+	require.NoError(t, err)
+	assert.Exactly(t, []*engine.Event{{
+		Action: event.Away,
+		Data: &engine.AwayResult{
+			User:     "Alice",
+			Away:     4600000,
+			Deadline: 4620000,
+		},
+	}}, events)
+
+	// the time away is not worth a bonus
+	_, err = engine.Score(g, "Alice", yahtzee.Chance, now)
+	require.NoError(t, err)
+	assert.Exactly(t, 4, g.Players[0].ScoreSheet[yahtzee.BlitzBonus])
+
+	// the next turn of an away player starts when the window ends
+	_, err = engine.Away(g, "Bob", now.Add(time.Minute), now)
+	require.NoError(t, err)
+	assert.Exactly(t, int64(1080000), g.Deadline)
+
+	// coming back early starts the clock
+	now = now.Add(10 * time.Second)
+	events, err = engine.Away(g, "Bob", time.Time{}, now)
+	require.NoError(t, err)
+	assert.Exactly(t, int64(0), g.Players[1].Away)
+	assert.Exactly(t, int64(1030000), events[0].Data.(*engine.AwayResult).Deadline)
+}
+
+func TestTable(t *testing.T) {
+	table := engine.GenerateTable([]yahtzee.Feature{yahtzee.Yatzy}, 6, 1)
+	assert.Len(t, table.Entries, 2*252)
+	assert.True(t, table.Matches([]yahtzee.Feature{yahtzee.Yatzy}))
+	assert.False(t, table.Matches(nil))
+
+	got, ok := table.Probabilities([]int{1, 6, 6, 6, 6}, 1)
+	require.True(t, ok)
+	assert.Exactly(t, engine.NewScorer(yahtzee.Yatzy).Probabilities([]int{6, 6, 6, 6, 1}, 6, 1), got)
+
+	_, ok = table.Probabilities([]int{1, 6, 6, 6, 6}, 2)
+	assert.False(t, ok)
+
+	var buf bytes.Buffer
+	require.NoError(t, engine.WriteTable(&buf, table))
+	read, err := engine.ReadTable(&buf)
+	require.NoError(t, err)
+	assert.Exactly(t, table.Features, read.Features)
+	assert.Len(t, read.Entries, len(table.Entries))
+
+	table.Version = engine.TableVersion + 1
+	buf.Reset()
+	require.NoError(t, engine.WriteTable(&buf, table))
+	_, err = engine.ReadTable(&buf)
+	assert.True(t, errors.Is(err, engine.ErrTableVersion))
+}
+
+func TestRecordGame(t *testing.T) {
+	finished := func(scores ...int) *yahtzee.Game {
+		g := yahtzee.NewGame()
+		for i, u := range []yahtzee.User{"Alice", "Bob"} {
+			p := yahtzee.NewPlayer(u)
+			p.ScoreSheet[yahtzee.Chance] = scores[i]
+			g.Players = append(g.Players, p)
+		}
+		g.Round = engine.GameScorer(g).Rounds()
+		return g
+	}
+
+	m := yahtzee.NewMatch(3, []yahtzee.User{"Alice", "Bob"})
+	m.Games = append(m.Games, "first")
+
+	_, err := engine.RecordGame(m, "other", finished(20, 10))
+	assert.Exactly(t, engine.ErrNotCurrentGame, err)
+
+	unfinished := finished(20, 10)
+	unfinished.Round = 3
+	_, err = engine.RecordGame(m, "first", unfinished)
+	assert.Exactly(t, engine.ErrGameNotOver, err)
+
+	events, err := engine.RecordGame(m, "first", finished(20, 10))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Exactly(t, event.MatchGame, events[0].Action)
+	assert.Exactly(t, []yahtzee.User{"Alice"}, events[0].Data.(*engine.MatchGameResult).Winners)
+	assert.Exactly(t, map[yahtzee.User]int{"Alice": 1, "Bob": 0}, m.Wins)
+	assert.Exactly(t, map[yahtzee.User]int{"Alice": 20, "Bob": 10}, m.Points)
+	assert.False(t, engine.IsMatchOver(m))
+
+	// a tie is a win for both
+	m.Games = append(m.Games, "second")
+	_, err = engine.RecordGame(m, "second", finished(15, 15))
+	require.NoError(t, err)
+	assert.Exactly(t, map[yahtzee.User]int{"Alice": 2, "Bob": 1}, m.Wins)
+
+	// the majority decides
+	assert.True(t, engine.IsMatchOver(m))
+	assert.Exactly(t, yahtzee.User("Alice"), m.Winner)
+
+	_, err = engine.RecordGame(m, "second", finished(15, 15))
+	assert.Exactly(t, engine.ErrMatchOver, err)
+
+	// a shared lead goes on after the last game
+	m = yahtzee.NewMatch(1, []yahtzee.User{"Alice", "Bob"})
+	m.Games = append(m.Games, "first")
+	_, err = engine.RecordGame(m, "first", finished(15, 15))
+	require.NoError(t, err)
+	assert.False(t, engine.IsMatchOver(m))
+
+	m.Games = append(m.Games, "second")
+	events, err = engine.RecordGame(m, "second", finished(10, 30))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Exactly(t, event.MatchOver, events[1].Action)
+	assert.Exactly(t, yahtzee.User("Bob"), m.Winner)
+}
+
+func TestHandicap(t *testing.T) {
+	_, err := engine.AddHandicappedPlayer(yahtzee.NewGame(), "Alice", 25)
+	assert.Exactly(t, engine.ErrNotHandicapGame, err)
+
+	g := yahtzee.NewGame(yahtzee.Handicap)
+	_, err = engine.AddHandicappedPlayer(g, "Alice", 101)
+	assert.Exactly(t, engine.ErrInvalidHandicap, err)
+
+	state, _, err := engine.Apply(*g, engine.AddPlayerAction{User: "Alice", Handicap: 25})
+	require.NoError(t, err)
+	g = &state
+	_, err = engine.AddPlayer(g, "Bob")
+	require.NoError(t, err)
+	assert.Exactly(t, 25, g.Players[0].Handicap)
+
+	for _, p := range g.Players {
+		for _, c := range yahtzee.Categories()[1:] {
+			p.ScoreSheet[c] = 0
+		}
+	}
+	g.Round = 12
+
+	// the handicap counts before it's given
+	s := engine.GameScorer(g)
+	assert.Exactly(t, 0, s.RawTotal(g.Players[0]))
+	assert.Exactly(t, 25, s.AdjustedTotal(g, 0))
+
+	for _, u := range []yahtzee.User{"Alice", "Bob"} {
+		_, err = engine.Roll(g, u, sequence(1, 1, 2, 3, 4), time.Now())
+		require.NoError(t, err)
+		_, err = engine.Score(g, u, yahtzee.Ones, time.Now())
+		require.NoError(t, err)
+	}
+
+	// given at the end of the game
+	require.True(t, engine.IsOver(g))
+	assert.Exactly(t, 25, g.Players[0].ScoreSheet[yahtzee.HandicapPoints])
+	assert.NotContains(t, g.Players[1].ScoreSheet, yahtzee.Category(yahtzee.HandicapPoints))
+	assert.Exactly(t, 27, s.Total(g.Players[0]))
+	assert.Exactly(t, 2, s.RawTotal(g.Players[0]))
+	assert.Exactly(t, 27, s.AdjustedTotal(g, 0))
+	assert.Exactly(t, 2, s.AdjustedTotal(g, 1))
+
+	// the handicaps of the partners go to the sheet of the team
+	g = yahtzee.NewGame(yahtzee.Handicap, yahtzee.Partners)
+	for i, u := range []yahtzee.User{"Alice", "Bob", "Carol", "Dave"} {
+		_, err = engine.AddHandicappedPlayer(g, u, 10*(i+1))
+		require.NoError(t, err)
+	}
+	for _, p := range g.Players[:2] {
+		for _, c := range yahtzee.Categories()[1:] {
+			p.ScoreSheet[c] = 0
+		}
+	}
+	g.Round = 12
+
+	s = engine.GameScorer(g)
+	assert.Exactly(t, 40, s.AdjustedTotal(g, 0))
+	assert.Exactly(t, 40, s.AdjustedTotal(g, 2))
+	assert.Exactly(t, 60, s.AdjustedTotal(g, 3))
+
+	for _, u := range []yahtzee.User{"Alice", "Bob"} {
+		_, err = engine.Roll(g, u, sequence(1, 1, 2, 3, 4), time.Now())
+		require.NoError(t, err)
+		_, err = engine.Score(g, u, yahtzee.Ones, time.Now())
+		require.NoError(t, err)
+	}
+
+	require.True(t, engine.IsOver(g))
+	assert.Exactly(t, 40, g.Players[0].ScoreSheet[yahtzee.HandicapPoints])
+	assert.Exactly(t, 60, g.Players[1].ScoreSheet[yahtzee.HandicapPoints])
+	assert.Empty(t, g.Players[2].ScoreSheet)
+	assert.Empty(t, g.Players[3].ScoreSheet)
+	assert.Exactly(t, 42, s.AdjustedTotal(g, 2))
+}
+
+func TestPartners(t *testing.T) {
+	g := yahtzee.NewGame(yahtzee.Partners)
+	for _, u := range []yahtzee.User{"Alice", "Bob", "Carol"} {
+		_, err := engine.AddPlayer(g, u)
+		require.NoError(t, err)
+	}
+
+	_, err := engine.Roll(g, "Alice", sequence(1, 2, 3, 4, 5), time.Now())
+	assert.Exactly(t, engine.ErrTeamsIncomplete, err)
+
+	_, err = engine.AddPlayer(g, "Dave")
+	require.NoError(t, err)
+	_, err = engine.AddPlayer(g, "Eve")
+	assert.Exactly(t, engine.ErrTeamsFull, err)
+
+	play := func(u yahtzee.User, category yahtzee.Category) error {
+		if _, err := engine.Roll(g, u, sequence(2, 2, 2, 3, 3), time.Now()); err != nil {
+			return err
+		}
+		_, err := engine.Score(g, u, category, time.Now())
+		return err
+	}
+
+	// the teams take turns alternately
+	require.NoError(t, play("Alice", yahtzee.FullHouse))
+	assert.Exactly(t, 1, g.CurrentPlayer)
+	require.NoError(t, play("Bob", yahtzee.Chance))
+	assert.Exactly(t, 2, g.CurrentPlayer)
+	assert.Exactly(t, 1, g.Round)
+
+	// Carol scores on the sheet of her partner
+	assert.Exactly(t, engine.ErrCategoryUsed, play("Carol", yahtzee.FullHouse))
+	_, err = engine.Score(g, "Carol", yahtzee.Twos, time.Now())
+	require.NoError(t, err)
+	assert.Exactly(t, map[yahtzee.Category]int{yahtzee.FullHouse: 25, yahtzee.Twos: 6}, g.Players[0].ScoreSheet)
+	assert.Empty(t, g.Players[2].ScoreSheet)
+
+	require.NoError(t, play("Dave", yahtzee.Threes))
+	assert.Exactly(t, map[yahtzee.Category]int{yahtzee.Chance: 12, yahtzee.Threes: 6}, g.Players[1].ScoreSheet)
+	assert.Exactly(t, 0, g.CurrentPlayer)
+	assert.Exactly(t, 2, g.Round)
+
+	// a round for every category of the team
+	assert.Exactly(t, 13, engine.GameScorer(g).Rounds())
+}
+
+func TestRounds(t *testing.T) {
+	g := yahtzee.NewGame()
+	assert.Exactly(t, 13, engine.Rounds(g))
+
+	// a quick game
+	g.Rounds = 2
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	assert.Exactly(t, 2, engine.Rounds(g))
+	for _, c := range []yahtzee.Category{yahtzee.Chance, yahtzee.Sixes} {
+		require.False(t, engine.IsOver(g))
+		_, err := engine.Roll(g, "Alice", sequence(6, 6, 6, 2, 3), time.Now())
+		require.NoError(t, err)
+		_, err = engine.Score(g, "Alice", c, time.Now())
+		require.NoError(t, err)
+	}
+	assert.True(t, engine.IsOver(g))
+
+	// no more rounds than boxes
+	g.Rounds = 40
+	assert.Exactly(t, 13, engine.Rounds(g))
+	g.Features = []yahtzee.Feature{yahtzee.Triple}
+	assert.Exactly(t, 39, engine.Rounds(g))
+}
+
+func TestLowball(t *testing.T) {
+	g := yahtzee.NewGame(yahtzee.Lowball, yahtzee.Handicap)
+	_, err := engine.AddHandicappedPlayer(g, "Alice", 10)
+	require.NoError(t, err)
+	_, err = engine.AddPlayer(g, "Bob")
+	require.NoError(t, err)
+
+	for _, p := range g.Players {
+		for _, c := range yahtzee.Categories()[1:] {
+			p.ScoreSheet[c] = 0
+		}
+	}
+	g.Round = 12
+
+	// no zero for free
+	_, err = engine.Roll(g, "Alice", sequence(1, 1, 2, 3, 4), time.Now())
+	require.NoError(t, err)
+	_, err = engine.Scratch(g, "Alice", yahtzee.Ones, time.Now())
+	assert.Exactly(t, engine.ErrLowballScratch, err)
+
+	// the handicap helps by taking points
+	s := engine.GameScorer(g)
+	assert.Exactly(t, -10, s.AdjustedTotal(g, 0))
+
+	// missing the box costs the highest total of the dices
+	score, err := s.Evaluate(yahtzee.Yahtzee, []int{1, 1, 2, 3, 4})
+	require.NoError(t, err)
+	assert.Exactly(t, 30, score)
+
+	_, err = engine.Score(g, "Alice", yahtzee.Ones, time.Now())
+	require.NoError(t, err)
+	_, err = engine.Roll(g, "Bob", sequence(1, 2, 3, 4, 5), time.Now())
+	require.NoError(t, err)
+	events, err := engine.Score(g, "Bob", yahtzee.Ones, time.Now())
+	require.NoError(t, err)
+
+	// the lowest total wins
+	require.True(t, engine.IsOver(g))
+	assert.Exactly(t, -10, g.Players[0].ScoreSheet[yahtzee.HandicapPoints])
+	assert.Exactly(t, &engine.Event{
+		Action: event.GameOver,
+		Data: &engine.GameOverResult{
+			Winners: []yahtzee.User{"Alice"},
+			Totals:  map[yahtzee.User]int{"Alice": -8, "Bob": 1},
+		},
+	}, events[len(events)-1])
+
+	// the lowest scores are the best hints
+	scores := map[yahtzee.Category]int{yahtzee.Ones: 1, yahtzee.Twos: 0, yahtzee.Chance: 15, yahtzee.Sixes: 0}
+	assert.Exactly(t,
+		[]yahtzee.Category{yahtzee.Twos, yahtzee.Sixes, yahtzee.Ones, yahtzee.Chance},
+		s.Rank(scores))
+	assert.Exactly(t,
+		[]yahtzee.Category{yahtzee.Chance, yahtzee.Ones, yahtzee.Twos, yahtzee.Sixes},
+		engine.NewScorer().Rank(scores))
+}
+
+func TestAchievements(t *testing.T) {
+	g := yahtzee.NewGame()
+	_, err := engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+
+	play := func(dices []int, category yahtzee.Category) []*engine.Event {
+		_, err := engine.Roll(g, "Alice", sequence(dices...), time.Now())
+		require.NoError(t, err)
+		events, err := engine.Score(g, "Alice", category, time.Now())
+		require.NoError(t, err)
+		return events
+	}
+	achievement := func(a yahtzee.Achievement) *engine.Event {
+		return &engine.Event{
+			Action: event.Achievement,
+			Data:   &engine.AchievementResult{User: "Alice", Achievement: a},
+		}
+	}
+
+	events := play([]int{6, 6, 6, 6, 6}, yahtzee.Yahtzee)
+	assert.Exactly(t, []*engine.Event{{Action: event.Score, Data: g}, achievement(yahtzee.FirstYahtzee)}, events)
+
+	// every straight of the sheet
+	events = play([]int{1, 2, 3, 4, 6}, yahtzee.SmallStraight)
+	assert.Len(t, events, 1)
+	events = play([]int{1, 2, 3, 4, 5}, yahtzee.LargeStraight)
+	assert.Exactly(t, achievement(yahtzee.AllStraights), events[1])
+
+	// once in a game
+	events = play([]int{2, 3, 4, 5, 6}, yahtzee.Chance)
+	assert.Len(t, events, 1)
+
+	// a total of 300 at the end
+	for _, c := range yahtzee.Categories() {
+		if _, ok := g.Players[0].ScoreSheet[c]; !ok && c != yahtzee.Sixes {
+			g.Players[0].ScoreSheet[c] = 25
+		}
+	}
+	g.Round = 12
+	events = play([]int{6, 6, 6, 6, 1}, yahtzee.Sixes)
+	assert.Exactly(t, achievement(yahtzee.ThreeHundred), events[2])
+	assert.Exactly(t,
+		[]yahtzee.Achievement{yahtzee.FirstYahtzee, yahtzee.AllStraights, yahtzee.ThreeHundred},
+		g.Players[0].Achievements)
+
+	// maxi has the full straight too
+	g = yahtzee.NewGame(yahtzee.Maxi)
+	_, err = engine.AddPlayer(g, "Alice")
+	require.NoError(t, err)
+	play([]int{1, 2, 3, 4, 5, 1}, yahtzee.SmallStraight)
+	play([]int{2, 3, 4, 5, 6, 1}, yahtzee.LargeStraight)
+	assert.Empty(t, g.Players[0].Achievements)
+	events = play([]int{1, 2, 3, 4, 5, 6}, yahtzee.FullStraight)
+	assert.Exactly(t, achievement(yahtzee.AllStraights), events[1])
+}
+
+// benchmarkHands are rolls hitting and missing the categories.
+var benchmarkHands = [][]int{
+	{1, 2, 3, 4, 5},
+	{2, 3, 4, 5, 6},
+	{3, 3, 3, 5, 5},
+	{6, 6, 6, 6, 2},
+	{4, 4, 4, 4, 4},
+	{1, 3, 3, 5, 6},
+}
+
+func BenchmarkScoreActions(b *testing.B) {
+	rulesets := []struct {
+		name     string
+		features []yahtzee.Feature
+	}{
+		{"standard", nil},
+		{"yatzy", []yahtzee.Feature{yahtzee.Yatzy}},
+		{"kniffel", []yahtzee.Feature{yahtzee.Kniffel}},
+		{"maxi", []yahtzee.Feature{yahtzee.Maxi}},
+	}
+
+	for _, rs := range rulesets {
+		s := engine.NewScorer(rs.features...)
+
+		hands := make([][]int, len(benchmarkHands))
+		for i, h := range benchmarkHands {
+			hands[i] = append([]int{}, h...)
+			for len(hands[i]) < yahtzee.DiceCount(rs.features...) {
+				hands[i] = append(hands[i], h[i%len(h)])
+			}
+		}
+
+		for _, c := range s.Categories() {
+			action := s.ScoreActions[c]
+			b.Run(fmt.Sprintf("%s/%s", rs.name, c), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					for _, h := range hands {
+						action(h)
+					}
+				}
+			})
+		}
+
+		// every category is scored for the hints
+		b.Run(rs.name+"/all", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, h := range hands {
+					for _, action := range s.ScoreActions {
+						action(h)
+					}
+				}
+			}
+		})
+	}
+}
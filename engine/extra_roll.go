@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// defaultExtraRolls is the number of extra roll tokens of a player without
+// house rules.
+const defaultExtraRolls = 2
+
+// Errors returned by the extra roll rules.
+var (
+	ErrNotExtraRollGame = errors.New("extra roll feature is not enabled")
+	ErrNoExtraRolls     = errors.New("no extra rolls left")
+)
+
+// ExtraRollResult has the changes of spending an extra roll token.
+type ExtraRollResult struct {
+	// ExtraRolls is the number of tokens the player has left
+	ExtraRolls int
+
+	// RollsPerTurn is the number of rolls the player has in this turn
+	RollsPerTurn int
+}
+
+// ExtraRoll spends an extra roll token of `u`, giving one more roll in this
+// turn.
+func ExtraRoll(g *yahtzee.Game, u yahtzee.User) ([]*Event, error) {
+	if !g.HasFeature(yahtzee.ExtraRoll) {
+		return nil, ErrNotExtraRollGame
+	}
+	if err := checkTurn(g, u); err != nil {
+		return nil, err
+	}
+
+	p := g.Players[g.CurrentPlayer]
+	if p.ExtraRolls <= 0 {
+		return nil, ErrNoExtraRolls
+	}
+
+	p.ExtraRolls--
+	g.ExtraRolls++
+
+	return []*Event{{
+		Action: event.ExtraRoll,
+		Data: &ExtraRollResult{
+			ExtraRolls:   p.ExtraRolls,
+			RollsPerTurn: RollsPerTurn(g),
+		},
+	}}, nil
+}
+
+// extraRollTokens returns the number of extra roll tokens a player gets in
+// the game.
+func extraRollTokens(g *yahtzee.Game) int {
+	if g.Rules != nil && g.Rules.ExtraRolls > 0 {
+		return g.Rules.ExtraRolls
+	}
+	return defaultExtraRolls
+}
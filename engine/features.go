@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/akarasz/yahtzee"
+)
+
+var (
+	// ErrJokerUpperBox is returned when the Joker rules force the player to
+	// score in the upper section box of the rolled Yahtzee.
+	ErrJokerUpperBox = errors.New("joker must be scored in the upper section")
+
+	// ErrJokerLowerBox is returned when the Joker rules force the player to
+	// score in an open lower section box.
+	ErrJokerLowerBox = errors.New("joker must be scored in the lower section")
+
+	// ErrIncompatibleFeatures is returned when a game has features that
+	// can't be played together.
+	ErrIncompatibleFeatures = errors.New("features are incompatible")
+)
+
+// incompatibleFeatures has the groups of features where only one can be
+// enabled: the ones swapping in a scoring table or the score columns, the
+// ones with rules of the players not playing well together, and the coach,
+// limited by the hints or not, and the projection not knowing the odds of the
+// colors.
+var incompatibleFeatures = [][]yahtzee.Feature{
+	{yahtzee.Yatzy, yahtzee.Maxi, yahtzee.Kniffel},
+	{yahtzee.Triple, yahtzee.DoubleSheet, yahtzee.TripleSheet},
+	{yahtzee.Solo, yahtzee.Partners},
+	{yahtzee.Partners, yahtzee.SuddenDeath},
+	{yahtzee.Coach, yahtzee.Hints, yahtzee.Rainbow},
+	{yahtzee.Projection, yahtzee.Rainbow},
+}
+
+// Conflicts returns the enabled features of every group of incompatible
+// features with more than one of them enabled, empty when the features can
+// be played together.
+func Conflicts(features ...yahtzee.Feature) [][]yahtzee.Feature {
+	enabled := map[yahtzee.Feature]bool{}
+	for _, f := range features {
+		enabled[f] = true
+	}
+
+	res := [][]yahtzee.Feature{}
+	for _, group := range incompatibleFeatures {
+		var conflict []yahtzee.Feature
+		for _, f := range group {
+			if enabled[f] {
+				conflict = append(conflict, f)
+			}
+		}
+		if len(conflict) > 1 {
+			res = append(res, conflict)
+		}
+	}
+	return res
+}
+
+var featureScorers = map[yahtzee.Feature]func(*Scorer){
+	yahtzee.YahtzeeBonus: yahtzeeBonus,
+	yahtzee.Yatzy:        yatzy,
+	yahtzee.Maxi:         maxi,
+	yahtzee.Triple:       triple,
+	yahtzee.Announce:     announce,
+	yahtzee.Kniffel:      kniffel,
+	yahtzee.ForcedJoker:  func(s *Scorer) { s.useJoker(forcedJokerRule) },
+	yahtzee.FreeJoker:    func(s *Scorer) { s.useJoker(freeJokerRule) },
+	yahtzee.Handicap:     handicap,
+	yahtzee.Lowball:      lowball,
+	yahtzee.DoubleSheet:  func(s *Scorer) { s.Columns = []int{1, 1} },
+	yahtzee.TripleSheet:  func(s *Scorer) { s.Columns = []int{1, 1, 1} },
+	yahtzee.Rainbow:      rainbow,
+}
+
+// jokerRule tells where a Yahtzee can be scored when the Yahtzee box is
+// already filled. The later rules are the more permissive ones.
+type jokerRule int
+
+const (
+	noJoker jokerRule = iota
+	forcedJokerRule
+	freeJokerRule
+)
+
+var (
+	upperSection = []yahtzee.Category{
+		yahtzee.Ones,
+		yahtzee.Twos,
+		yahtzee.Threes,
+		yahtzee.Fours,
+		yahtzee.Fives,
+		yahtzee.Sixes,
+	}
+
+	lowerSection = []yahtzee.Category{
+		yahtzee.ThreeOfAKind,
+		yahtzee.FourOfAKind,
+		yahtzee.FullHouse,
+		yahtzee.SmallStraight,
+		yahtzee.LargeStraight,
+		yahtzee.Yahtzee,
+		yahtzee.Chance,
+	}
+
+	// jokerHands has the hands of the boxes a Joker scores as, the Joker is
+	// worth the best of them in the box
+	jokerHands = map[yahtzee.Category]func(count, sides int) [][]int{
+		yahtzee.FullHouse:     fullHouseHands,
+		yahtzee.SmallStraight: straightHands,
+		yahtzee.LargeStraight: straightHands,
+	}
+)
+
+func yahtzeeBonus(s *Scorer) {
+	s.useJoker(forcedJokerRule)
+	s.PostScoreActions = append(s.PostScoreActions, extraYahtzee)
+}
+
+// useJoker enables the Joker with the `rule`, unless a more permissive one is
+// already enabled.
+func (s *Scorer) useJoker(rule jokerRule) {
+	if rule > s.joker {
+		s.joker = rule
+	}
+}
+
+// applyJoker adds the score actions of the enabled Joker rule. The forced
+// Joker has to be scored in the upper box of its face while it's open, then
+// in the lower section; the free Joker can be scored in any open box. Both
+// are worth the full value in the lower section.
+func (s *Scorer) applyJoker() {
+	if s.joker == noJoker {
+		return
+	}
+	if s.joker == forcedJokerRule {
+		s.PreScoreActions = append(s.PreScoreActions, forcedJoker)
+	}
+	s.PostScoreActions = append(s.PostScoreActions, s.jokerScore)
+}
+
+// isJoker tells if the dices are a Yahtzee while the Yahtzee box is already
+// filled.
+func isJoker(sheet map[yahtzee.Category]int, category yahtzee.Category, dices []int) bool {
+	if category == yahtzee.Yahtzee || !isYahtzee(dices) {
+		return false
+	}
+	_, ok := sheet[yahtzee.Yahtzee]
+	return ok
+}
+
+func forcedJoker(_ *yahtzee.Game, sheet map[yahtzee.Category]int, category yahtzee.Category, dices []int) error {
+	if !isJoker(sheet, category, dices) {
+		return nil
+	}
+
+	// the faces above six have no upper box, they go to the lower section
+	if face := dices[0]; face <= len(upperSection) {
+		box := upperSection[face-1]
+		if _, ok := sheet[box]; !ok {
+			if category != box {
+				return ErrJokerUpperBox
+			}
+			return nil
+		}
+	}
+
+	if !inSection(category, upperSection) {
+		return nil
+	}
+	for _, c := range lowerSection {
+		if _, ok := sheet[c]; !ok {
+			return ErrJokerLowerBox
+		}
+	}
+	return nil
+}
+
+// jokerScore gives the Joker the best score of the box with the rules of the
+// scorer, like 30 and 40 for the straights or 15 and 20 in yatzy.
+func (s *Scorer) jokerScore(_ *yahtzee.Game, sheet map[yahtzee.Category]int, category yahtzee.Category, dices []int) {
+	if !isJoker(sheet, category, dices) {
+		return
+	}
+
+	hands, ok := jokerHands[category]
+	if !ok {
+		return
+	}
+	best := 0
+	for _, hand := range hands(len(dices), s.sides) {
+		if v := s.ScoreActions[category](hand); v > best {
+			best = v
+		}
+	}
+	if best > 0 {
+		sheet[category] = best
+	}
+}
+
+// straightHands returns the runs of `count` dices starting from every face,
+// the ones running over the highest face padded with it.
+func straightHands(count, sides int) [][]int {
+	res := [][]int{}
+	for from := 1; from <= sides; from++ {
+		hand := make([]int, count)
+		for i := range hand {
+			hand[i] = from + i
+			if hand[i] > sides {
+				hand[i] = sides
+			}
+		}
+		res = append(res, hand)
+	}
+	return res
+}
+
+// fullHouseHands returns the hands with three dices of a face and the rest of
+// another one.
+func fullHouseHands(count, sides int) [][]int {
+	res := [][]int{}
+	for three := 1; three <= sides; three++ {
+		for rest := 1; rest <= sides; rest++ {
+			if three == rest {
+				continue
+			}
+			hand := make([]int, count)
+			for i := range hand {
+				hand[i] = rest
+				if i < 3 {
+					hand[i] = three
+				}
+			}
+			res = append(res, hand)
+		}
+	}
+	return res
+}
+
+func extraYahtzee(_ *yahtzee.Game, sheet map[yahtzee.Category]int, category yahtzee.Category, dices []int) {
+	if !isJoker(sheet, category, dices) || sheet[yahtzee.Yahtzee] == 0 {
+		return
+	}
+
+	sheet[yahtzee.YahtzeeBonuses] += 100
+}
+
+// triple gives three columns worth one, two and three times their value.
+func triple(s *Scorer) {
+	s.Columns = []int{1, 2, 3}
+}
+
+func inSection(category yahtzee.Category, section []yahtzee.Category) bool {
+	for _, c := range section {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
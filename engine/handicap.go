@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// maxHandicap is the most points a player can get or give with the handicap
+// feature.
+const maxHandicap = 100
+
+// Errors returned by the handicap rules.
+var (
+	ErrNotHandicapGame = errors.New("handicap feature is not enabled")
+	ErrInvalidHandicap = errors.New("invalid handicap")
+)
+
+// AddHandicappedPlayer adds `u` to the players of the game with `handicap`
+// points given at the end of the game. A negative handicap takes points.
+func AddHandicappedPlayer(g *yahtzee.Game, u yahtzee.User, handicap int) ([]*Event, error) {
+	if !g.HasFeature(yahtzee.Handicap) {
+		return nil, ErrNotHandicapGame
+	}
+	if handicap < -maxHandicap || handicap > maxHandicap {
+		return nil, ErrInvalidHandicap
+	}
+
+	events, err := AddPlayer(g, u)
+	if err != nil {
+		return nil, err
+	}
+	g.Players[len(g.Players)-1].Handicap = handicap
+
+	return events, nil
+}
+
+// RawTotal returns the total score of the sheets of the player without the
+// handicap.
+func (s *Scorer) RawTotal(p *yahtzee.Player) int {
+	return s.Total(p) - p.ScoreSheet[yahtzee.HandicapPoints]
+}
+
+// AdjustedTotal returns the total score of the player at index `i` with the
+// handicap, even before it's given at the end of the game. In partnership
+// games it's the total of the team with the handicaps of both partners.
+func (s *Scorer) AdjustedTotal(g *yahtzee.Game, i int) int {
+	return s.RawTotal(SheetOwner(g, i)) + s.teamHandicap(g, i)
+}
+
+// teamHandicap returns the points the handicaps of the team of the player at
+// index `i` are worth.
+func (s *Scorer) teamHandicap(g *yahtzee.Game, i int) int {
+	res := 0
+	for j, p := range g.Players {
+		if team(g, j) == team(g, i) {
+			res += s.handicapPoints(p)
+		}
+	}
+	return res
+}
+
+// handicapPoints returns the points the handicap of the player is worth. A
+// handicap helping the player is taken from the total when the lowest total
+// wins.
+func (s *Scorer) handicapPoints(p *yahtzee.Player) int {
+	if s.LowestWins {
+		return -p.Handicap
+	}
+	return p.Handicap
+}
+
+// giveHandicap records the handicap of the teams on the score sheets of
+// their sheet owners.
+func (s *Scorer) giveHandicap(g *yahtzee.Game) {
+	for i, p := range g.Players {
+		if team(g, i) != i {
+			continue
+		}
+		if points := s.teamHandicap(g, i); points != 0 {
+			p.ScoreSheet[yahtzee.HandicapPoints] = points
+		}
+	}
+}
+
+func handicap(s *Scorer) {
+	s.PostGameActions = append(s.PostGameActions, s.giveHandicap)
+}
@@ -0,0 +1,137 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// Highlight is a turn worth remembering after the game.
+type Highlight struct {
+	// At is the time of the turn in unix milliseconds
+	At int64
+
+	User  yahtzee.User
+	Dices []int
+
+	// Points is what the turn added to the total of the player
+	Points int
+}
+
+// LeadChange is a turn taking the lead from the players leading before.
+type LeadChange struct {
+	// At is the time of the turn in unix milliseconds
+	At int64
+
+	// Leaders has the players taking the lead, more on a tie and both
+	// players of a team in partnership games
+	Leaders []yahtzee.User
+
+	// Previous has the players leading before
+	Previous []yahtzee.User
+}
+
+// HighlightsResult has the highlights of a finished game.
+type HighlightsResult struct {
+	// Yahtzees has the turns ending with a Yahtzee, scored or not
+	Yahtzees []*Highlight
+
+	// BiggestTurn is the turn adding the most to a total, nil when no turn
+	// did
+	BiggestTurn *Highlight
+
+	LeadChanges []*LeadChange
+}
+
+// RunningTotals returns the totals of the players after the last turn,
+// without the handicap given at the end of the game.
+func RunningTotals(g *yahtzee.Game) map[yahtzee.User]int {
+	scorer := GameScorer(g)
+	res := map[yahtzee.User]int{}
+	for i, p := range g.Players {
+		owner := SheetOwner(g, i)
+		res[p.User] = scorer.Total(owner) - owner.ScoreSheet[yahtzee.HandicapPoints]
+	}
+	return res
+}
+
+// Highlights returns the Yahtzees, the biggest turn and the lead changes of
+// the finished game from its log. The turns are known by the dices and the
+// totals of the score and the scratch entries.
+func Highlights(g *yahtzee.Game, log []yahtzee.Activity) (*HighlightsResult, error) {
+	if !IsOver(g) {
+		return nil, ErrGameNotOver
+	}
+
+	scorer := GameScorer(g)
+	res := &HighlightsResult{
+		Yahtzees:    []*Highlight{},
+		LeadChanges: []*LeadChange{},
+	}
+	previous := map[yahtzee.User]int{}
+	var leaders []yahtzee.User
+	for _, a := range log {
+		switch event.Type(a.Action) {
+		case event.Score, event.Scratch:
+		default:
+			continue
+		}
+		if a.Totals == nil {
+			continue
+		}
+
+		h := &Highlight{
+			At:     a.At,
+			User:   a.User,
+			Dices:  a.Dices,
+			Points: a.Totals[a.User] - previous[a.User],
+		}
+		if len(a.Dices) > 0 && isYahtzee(a.Dices) {
+			res.Yahtzees = append(res.Yahtzees, h)
+		}
+		if h.Points > 0 && (res.BiggestTurn == nil || h.Points > res.BiggestTurn.Points) {
+			res.BiggestTurn = h
+		}
+
+		current := leading(scorer, a.Totals)
+		if leaders != nil && !overlap(leaders, current) {
+			res.LeadChanges = append(res.LeadChanges, &LeadChange{
+				At:       a.At,
+				Leaders:  current,
+				Previous: leaders,
+			})
+		}
+		leaders = current
+		previous = a.Totals
+	}
+
+	return res, nil
+}
+
+// leading returns the players with the best total in alphabetical order.
+func leading(s *Scorer, totals map[yahtzee.User]int) []yahtzee.User {
+	res := []yahtzee.User{}
+	for u, total := range totals {
+		if len(res) > 0 && s.Beats(totals[res[0]], total) {
+			continue
+		}
+		if len(res) > 0 && s.Beats(total, totals[res[0]]) {
+			res = res[:0]
+		}
+		res = append(res, u)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+func overlap(a, b []yahtzee.User) bool {
+	for _, u := range a {
+		for _, v := range b {
+			if u == v {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// defaultHints is the number of hints of a player without house rules.
+const defaultHints = 3
+
+// ErrNoHints is returned when asking the coach without hints left.
+var ErrNoHints = errors.New("no hints left")
+
+// HintResult has the changes of spending a hint.
+type HintResult struct {
+	// Hints is the number of hints the player has left
+	Hints int
+}
+
+// SpendHint spends a hint of `u` on the advice of the coach.
+func SpendHint(g *yahtzee.Game, u yahtzee.User) ([]*Event, error) {
+	if !g.HasFeature(yahtzee.Hints) {
+		return nil, ErrNotCoachGame
+	}
+	if err := checkTurn(g, u); err != nil {
+		return nil, err
+	}
+
+	p := g.Players[g.CurrentPlayer]
+	if p.Hints <= 0 {
+		return nil, ErrNoHints
+	}
+
+	p.Hints--
+
+	return []*Event{{
+		Action: event.Hint,
+		Data: &HintResult{
+			Hints: p.Hints,
+		},
+	}}, nil
+}
+
+// hintQuota returns the number of hints a player gets in the game.
+func hintQuota(g *yahtzee.Game) int {
+	if g.Rules != nil && g.Rules.Hints > 0 {
+		return g.Rules.Hints
+	}
+	return defaultHints
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// Errors returned when importing the score sheets of a game played on paper.
+var (
+	ErrImpossibleScore = errors.New("score is not possible in the box")
+	ErrSheetOrder      = errors.New("sheets don't fit the order of the turns")
+	ErrImportDices     = errors.New("too many dice outcomes to check the sheets")
+)
+
+// maxImportOutcomes is the most different rolls of every dice searched for
+// the dices of an imported score.
+const maxImportOutcomes = 100000
+
+// ImportSheets joins the `players` to a new game in their order with the
+// boxes of their score sheets filled, so a game played on paper can go on
+// from where it was. The boxes are played turn by turn with dices giving
+// their score, the round and the current player follow from the number of
+// boxes filled. Every box of a sheet has to be possible by the rules of the
+// game, and the players after the current one can have one box less than the
+// ones before.
+func ImportSheets(g *yahtzee.Game, players []*yahtzee.Player) error {
+	if len(players) == 0 {
+		return ErrNoPlayers
+	}
+	for _, p := range players {
+		if _, err := AddPlayer(g, p.User); err != nil {
+			return err
+		}
+	}
+
+	scorer := GameScorer(g)
+	sides := g.Sides
+	if sides == 0 {
+		sides = yahtzee.NumberOfSides
+	}
+	if outcomeCount(len(g.Dices), sides) > maxImportOutcomes {
+		return ErrImportDices
+	}
+	outcomes := rollOutcomes(len(g.Dices), sides)
+
+	turns := map[int][]Turn{}
+	for i, sheet := range players {
+		if len(sheet.ExtraSheets) >= len(scorer.Columns) {
+			return ErrInvalidColumn
+		}
+		if i != team(g, i) && len(sheet.ScoreSheet) > 0 {
+			return ErrSheetOrder
+		}
+
+		for column := 0; column <= len(sheet.ExtraSheets); column++ {
+			boxes := sheet.Sheet(column)
+			for c := range boxes {
+				if _, ok := scorer.ScoreActions[c]; !ok {
+					return ErrInvalidCategory
+				}
+			}
+			for _, c := range scorer.Categories() {
+				score, ok := boxes[c]
+				if !ok {
+					continue
+				}
+				t, err := importedTurn(scorer, c, score, outcomes)
+				if err != nil {
+					return err
+				}
+				t.Column = column
+				turns[i] = append(turns[i], t)
+			}
+		}
+	}
+
+	for left := countTurns(turns); left > 0; left-- {
+		owner := team(g, g.CurrentPlayer)
+		if len(turns[owner]) == 0 {
+			return ErrSheetOrder
+		}
+		t := turns[owner][0]
+		turns[owner] = turns[owner][1:]
+
+		if err := playTurn(g, g.Players[g.CurrentPlayer].User, t); err != nil {
+			return err
+		}
+		if g.Players[owner].Sheet(t.Column)[t.Category] != players[owner].Sheet(t.Column)[t.Category] {
+			return ErrImpossibleScore
+		}
+	}
+
+	for _, d := range g.Dices {
+		d.Value = 1
+	}
+	return nil
+}
+
+// importedTurn returns a turn scoring `score` in the category, a scratch when
+// no dices score zero there. A box missed in a lowball game has the penalty.
+func importedTurn(s *Scorer, category yahtzee.Category, score int, outcomes []outcome) (Turn, error) {
+	count := len(outcomes[0].dices)
+	if dices, ok := scoringDices(s.ScoreActions[category], score, outcomes); ok {
+		return Turn{Dices: dices, Category: category}, nil
+	}
+	if s.LowestWins && score == s.penalize(0, count) {
+		if dices, ok := scoringDices(s.ScoreActions[category], 0, outcomes); ok {
+			return Turn{Dices: dices, Category: category}, nil
+		}
+	}
+	if score == 0 {
+		dices := make([]int, count)
+		for i := range dices {
+			dices[i] = 1
+		}
+		return Turn{Dices: dices, Category: category, Scratch: true}, nil
+	}
+	return Turn{}, ErrImpossibleScore
+}
+
+// scoringDices returns the first dices of the `outcomes` scoring `score` with
+// the action. Dices other than a Yahtzee are preferred, so the replayed turn
+// doesn't play a Joker the sheet never had.
+func scoringDices(action ScoreAction, score int, outcomes []outcome) ([]int, bool) {
+	var yahtzeeDices []int
+	for _, o := range outcomes {
+		if action(o.dices) != score {
+			continue
+		}
+		if !isYahtzee(o.dices) {
+			return o.dices, true
+		}
+		if yahtzeeDices == nil {
+			yahtzeeDices = o.dices
+		}
+	}
+	return yahtzeeDices, yahtzeeDices != nil
+}
+
+func countTurns(turns map[int][]Turn) int {
+	res := 0
+	for _, t := range turns {
+		res += len(t)
+	}
+	return res
+}
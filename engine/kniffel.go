@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"github.com/akarasz/yahtzee"
+)
+
+// kniffel swaps in the German scoring table, where the of a kind categories
+// are worth the sum of the dices and every further Kniffel is a Joker
+// without bonus points.
+func kniffel(s *Scorer) {
+	s.ScoreActions[yahtzee.ThreeOfAKind] = sumOfAKind(3)
+	s.ScoreActions[yahtzee.FourOfAKind] = sumOfAKind(4)
+
+	s.useJoker(forcedJokerRule)
+}
+
+// sumOfAKind returns the score action worth the sum of the dices when at least
+// `n` of them are the same.
+func sumOfAKind(n int) ScoreAction {
+	return func(dices []int) int {
+		var buf faceCounts
+		for _, c := range counts(dices, &buf) {
+			if c >= n {
+				return chance(dices)
+			}
+		}
+		return 0
+	}
+}
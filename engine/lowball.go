@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// ErrLowballScratch is returned when scratching a box in a lowball game,
+// where the zero would be the best score.
+var ErrLowballScratch = errors.New("boxes can't be scratched in lowball games")
+
+// GameOverResult has the winners and the final totals of a finished game.
+type GameOverResult struct {
+	// Winners has the players with the best total, more than one on a tie
+	// and both players of the winning team in partnership games
+	Winners []yahtzee.User
+
+	// Totals has the final total of every player, the total of the team in
+	// partnership games
+	Totals map[yahtzee.User]int
+}
+
+// Beats tells if the total `a` ranks ahead of the total `b`.
+func (s *Scorer) Beats(a, b int) bool {
+	if s.LowestWins {
+		return a < b
+	}
+	return a > b
+}
+
+// Rank returns the categories of the `scores` from the best to the worst,
+// the ones with the same score in the order of the score sheet.
+func (s *Scorer) Rank(scores map[yahtzee.Category]int) []yahtzee.Category {
+	res := []yahtzee.Category{}
+	for _, c := range s.Categories() {
+		if _, ok := scores[c]; ok {
+			res = append(res, c)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return s.Beats(scores[res[i]], scores[res[j]])
+	})
+	return res
+}
+
+// Winners returns the players with the best total of the game, or the ones
+// still in the tiebreak or the winner of it after a tie.
+func Winners(g *yahtzee.Game) []yahtzee.User {
+	if g.Tiebreak != nil {
+		return append([]yahtzee.User{}, g.Tiebreak.Players...)
+	}
+
+	scorer := GameScorer(g)
+
+	best := 0
+	var res []yahtzee.User
+	for i, p := range g.Players {
+		total := scorer.Total(SheetOwner(g, i))
+		switch {
+		case len(res) == 0 || scorer.Beats(total, best):
+			best = total
+			res = []yahtzee.User{p.User}
+		case total == best:
+			res = append(res, p.User)
+		}
+	}
+	return res
+}
+
+// gameOver returns the event of the winners when the last turn of the game
+// was played.
+func gameOver(g *yahtzee.Game) []*Event {
+	if !IsOver(g) {
+		return nil
+	}
+
+	scorer := GameScorer(g)
+	totals := map[yahtzee.User]int{}
+	for i, p := range g.Players {
+		totals[p.User] = scorer.Total(SheetOwner(g, i))
+	}
+
+	return []*Event{{
+		Action: event.GameOver,
+		Data: &GameOverResult{
+			Winners: Winners(g),
+			Totals:  totals,
+		},
+	}}
+}
+
+func lowball(s *Scorer) {
+	s.LowestWins = true
+}
+
+// penalize returns the `score` of `count` dices, or the penalty for missing
+// the box in lowball games: the highest total the dices can show, so a zero
+// is never the best score.
+func (s *Scorer) penalize(score int, count int) int {
+	if !s.LowestWins || score > 0 {
+		return score
+	}
+	return count * s.sides
+}
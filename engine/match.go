@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// Errors returned when recording the games of a match.
+var (
+	ErrGameNotOver    = errors.New("game is not over")
+	ErrMatchOver      = errors.New("match is over")
+	ErrNotCurrentGame = errors.New("not the current game of the match")
+)
+
+// MatchGameResult is the outcome of a finished game of a match.
+type MatchGameResult struct {
+	Game    string
+	Winners []yahtzee.User
+	Match   *yahtzee.Match
+}
+
+// RecordGame rolls the scores of the finished game `gameID` into the
+// standings of the match. Every winner of the game gets a win. The match is
+// won by the only leader after the majority of the games, or after all of
+// them when the lead is shared the match goes on until it's broken.
+func RecordGame(m *yahtzee.Match, gameID string, g *yahtzee.Game) ([]*Event, error) {
+	if m.Winner != "" {
+		return nil, ErrMatchOver
+	}
+	if len(m.Games) == 0 || m.Games[len(m.Games)-1] != gameID {
+		return nil, ErrNotCurrentGame
+	}
+	if !IsOver(g) {
+		return nil, ErrGameNotOver
+	}
+
+	scorer := GameScorer(g)
+	for i, p := range g.Players {
+		m.Points[p.User] += scorer.Total(SheetOwner(g, i))
+	}
+	winners := Winners(g)
+	for _, u := range winners {
+		m.Wins[u]++
+	}
+
+	if leader, ok := matchLeader(m); ok &&
+		(2*m.Wins[leader] > m.BestOf || len(m.Games) >= m.BestOf) {
+		m.Winner = leader
+	}
+
+	res := []*Event{{
+		Action: event.MatchGame,
+		Data: &MatchGameResult{
+			Game:    gameID,
+			Winners: winners,
+			Match:   m,
+		},
+	}}
+	if m.Winner != "" {
+		res = append(res, &Event{
+			Action: event.MatchOver,
+			Data:   m,
+		})
+	}
+
+	return res, nil
+}
+
+// IsMatchOver tells if the match has a winner.
+func IsMatchOver(m *yahtzee.Match) bool {
+	return m.Winner != ""
+}
+
+// matchLeader returns the player with the most wins, and false when the lead
+// is shared.
+func matchLeader(m *yahtzee.Match) (yahtzee.User, bool) {
+	var leader yahtzee.User
+	shared := false
+	for _, u := range m.Players {
+		switch {
+		case leader == "" || m.Wins[u] > m.Wins[leader]:
+			leader = u
+			shared = false
+		case m.Wins[u] == m.Wins[leader]:
+			shared = true
+		}
+	}
+	return leader, leader != "" && !shared
+}
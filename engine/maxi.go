@@ -0,0 +1,119 @@
+package engine
+
+import (
+	"github.com/akarasz/yahtzee"
+)
+
+// maxi swaps in the extended Scandinavian scoring table for six dices.
+func maxi(s *Scorer) {
+	s.ScoreActions[yahtzee.OnePair] = onePair
+	s.ScoreActions[yahtzee.TwoPairs] = twoPairs
+	s.ScoreActions[yahtzee.ThreePairs] = threePairs
+	s.ScoreActions[yahtzee.FiveOfAKind] = ofAKind(5)
+	s.ScoreActions[yahtzee.SmallStraight] = yatzyStraight(1, 15)
+	s.ScoreActions[yahtzee.LargeStraight] = yatzyStraight(2, 20)
+	s.ScoreActions[yahtzee.FullStraight] = fullStraight
+	s.ScoreActions[yahtzee.FullHouse] = maxiFullHouse
+	s.ScoreActions[yahtzee.Castle] = castle
+	s.ScoreActions[yahtzee.Tower] = tower
+	s.ScoreActions[yahtzee.Yahtzee] = maxiYahtzee
+
+	s.UpperBonusThreshold = 84
+	s.UpperBonus = 50
+}
+
+func threePairs(dices []int) int {
+	var buf faceCounts
+	o := counts(dices, &buf)
+
+	s, pairs := 0, 0
+	for face := len(o) - 1; face > 0; face-- {
+		if o[face] >= 2 {
+			s += 2 * face
+			pairs++
+		}
+	}
+
+	if pairs < 3 {
+		return 0
+	}
+	return s
+}
+
+func fullStraight(dices []int) int {
+	var buf faceCounts
+	o := counts(dices, &buf)
+	if len(o) < 7 {
+		return 0
+	}
+	for face := 1; face <= 6; face++ {
+		if o[face] == 0 {
+			return 0
+		}
+	}
+	return 21
+}
+
+// maxiFullHouse scores three and two dices of different faces.
+func maxiFullHouse(dices []int) int {
+	var buf faceCounts
+	o := counts(dices, &buf)
+	for three := len(o) - 1; three > 0; three-- {
+		if o[three] < 3 {
+			continue
+		}
+		for two := len(o) - 1; two > 0; two-- {
+			if two != three && o[two] >= 2 {
+				return 3*three + 2*two
+			}
+		}
+	}
+	return 0
+}
+
+// castle scores two times three dices of different faces.
+func castle(dices []int) int {
+	var buf faceCounts
+	o := counts(dices, &buf)
+
+	s, triples := 0, 0
+	for face := len(o) - 1; face > 0; face-- {
+		if o[face] == 3 {
+			s += 3 * face
+			triples++
+		}
+	}
+
+	if triples < 2 {
+		return 0
+	}
+	return s
+}
+
+// tower scores four and two dices of different faces.
+func tower(dices []int) int {
+	var buf faceCounts
+	o := counts(dices, &buf)
+
+	four, two := 0, 0
+	for face := len(o) - 1; face > 0; face-- {
+		switch o[face] {
+		case 4:
+			four = face
+		case 2:
+			two = face
+		}
+	}
+
+	if four == 0 || two == 0 {
+		return 0
+	}
+	return 4*four + 2*two
+}
+
+func maxiYahtzee(dices []int) int {
+	if isYahtzee(dices) {
+		return 100
+	}
+	return 0
+}
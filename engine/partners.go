@@ -0,0 +1,48 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/akarasz/yahtzee"
+)
+
+const (
+	// partnersTeams is the number of teams in partnership games.
+	partnersTeams = 2
+
+	// partnersPlayers is the number of players in partnership games.
+	partnersPlayers = 2 * partnersTeams
+)
+
+// Errors returned by the partnership rules.
+var (
+	ErrTeamsFull       = errors.New("teams are full")
+	ErrTeamsIncomplete = errors.New("teams are incomplete")
+)
+
+// team returns the team of the player at index `i`, which is the index of the
+// player keeping the score sheets of the team. The teams of partnership games
+// are the first and the third, and the second and the fourth player, so the
+// teams take turns alternately and so do the players of a team. In the other
+// games every player is a team.
+func team(g *yahtzee.Game, i int) int {
+	if g.HasFeature(yahtzee.Partners) {
+		return i % partnersTeams
+	}
+	return i
+}
+
+// SheetOwner returns the player keeping the score sheets of the player at
+// index `i`, which is the teammate keeping the sheets of the team in
+// partnership games and the player itself otherwise.
+func SheetOwner(g *yahtzee.Game, i int) *yahtzee.Player {
+	return g.Players[team(g, i)]
+}
+
+// checkTeams tells if another player can join the partnership game.
+func checkTeams(g *yahtzee.Game) error {
+	if g.HasFeature(yahtzee.Partners) && len(g.Players) >= partnersPlayers {
+		return ErrTeamsFull
+	}
+	return nil
+}
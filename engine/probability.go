@@ -0,0 +1,267 @@
+package engine
+
+import (
+	"math"
+	"sort"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// Probabilities returns the chance of scoring in every category of the
+// scorer, starting from the `dices` with `rolls` rolls left and keeping the
+// dices that give the best chance for the category before every roll.
+func (s *Scorer) Probabilities(dices []int, sides int, rolls int) map[yahtzee.Category]float64 {
+	start := append([]int{}, dices...)
+	sort.Ints(start)
+
+	outcomes := map[int][]outcome{}
+	for n := 0; n <= len(start); n++ {
+		outcomes[n] = rollOutcomes(n, sides)
+	}
+
+	res := map[yahtzee.Category]float64{}
+	for c, action := range s.ScoreActions {
+		action := action
+		o := &odds{
+			value: func(hand []int) float64 {
+				if action(hand) > 0 {
+					return 1
+				}
+				return 0
+			},
+			ceiling:  1,
+			size:     len(start),
+			outcomes: outcomes,
+			best:     map[handKey]float64{},
+			kept:     map[handKey]float64{},
+		}
+		res[c] = o.bestValue(start, rolls)
+	}
+	return res
+}
+
+// Expectations returns the expected score of every category of the scorer,
+// starting from the `dices` with `rolls` rolls left and keeping the dices
+// that give the best expected score for the category before every roll. The
+// lowest score is the best in lowball games, where missing a box costs the
+// penalty.
+func (s *Scorer) Expectations(dices []int, sides int, rolls int) map[yahtzee.Category]float64 {
+	start := append([]int{}, dices...)
+	sort.Ints(start)
+
+	all, sign := s.expectationOdds(len(start), sides)
+	res := map[yahtzee.Category]float64{}
+	for c, o := range all {
+		res[c] = sign * o.bestValue(start, rolls)
+	}
+	return res
+}
+
+// TurnExpectations returns the expected score of every category of the
+// scorer for a turn of `n` dices with `rolls` rolls, before its first roll.
+func (s *Scorer) TurnExpectations(n int, sides int, rolls int) map[yahtzee.Category]float64 {
+	all, sign := s.expectationOdds(n, sides)
+	res := map[yahtzee.Category]float64{}
+	for c, o := range all {
+		res[c] = sign * o.keptValue(nil, rolls)
+	}
+	return res
+}
+
+// expectationOdds returns the odds of the score of every category with `n`
+// dices, and the sign of their values: the odds look for the highest value,
+// so the scores are negated when the lowest wins.
+func (s *Scorer) expectationOdds(n int, sides int) (map[yahtzee.Category]*odds, float64) {
+	outcomes := map[int][]outcome{}
+	for i := 0; i <= n; i++ {
+		outcomes[i] = rollOutcomes(i, sides)
+	}
+
+	sign, ceiling := 1.0, math.Inf(1)
+	if s.LowestWins {
+		sign, ceiling = -1, 0
+	}
+
+	res := map[yahtzee.Category]*odds{}
+	for c, action := range s.ScoreActions {
+		action := action
+		res[c] = &odds{
+			value: func(hand []int) float64 {
+				return sign * float64(s.penalize(action(hand), len(hand)))
+			},
+			ceiling:  ceiling,
+			size:     n,
+			outcomes: outcomes,
+			best:     map[handKey]float64{},
+			kept:     map[handKey]float64{},
+		}
+	}
+	return res, sign
+}
+
+// outcome is a roll of some dices with the chance of getting it.
+type outcome struct {
+	dices  []int
+	chance float64
+}
+
+type handKey struct {
+	dices string
+	rolls int
+}
+
+func newHandKey(dices []int, rolls int) handKey {
+	b := make([]byte, len(dices))
+	for i, d := range dices {
+		b[i] = byte(d)
+	}
+	return handKey{dices: string(b), rolls: rolls}
+}
+
+// odds calculates the best expected value of a hand, like the chance of
+// scoring with a score action. The dices are kept in sorted order, so the
+// same hands share the memoized results.
+type odds struct {
+	value func(hand []int) float64
+
+	// ceiling is the highest value, no roll can do better than a hand
+	// having it
+	ceiling float64
+
+	size     int
+	outcomes map[int][]outcome
+
+	best map[handKey]float64
+	kept map[handKey]float64
+}
+
+// bestValue returns the expected value from the sorted `hand` with `rolls`
+// rolls left, stopping when rolling again is not worth it.
+func (o *odds) bestValue(hand []int, rolls int) float64 {
+	res := o.value(hand)
+	if res >= o.ceiling || rolls <= 0 {
+		return res
+	}
+
+	key := newHandKey(hand, rolls)
+	if v, ok := o.best[key]; ok {
+		return v
+	}
+
+	seen := map[string]bool{}
+	for mask := 0; mask < 1<<len(hand); mask++ {
+		var keep []int
+		for i, d := range hand {
+			if mask&(1<<i) != 0 {
+				keep = append(keep, d)
+			}
+		}
+
+		k := newHandKey(keep, rolls)
+		if seen[k.dices] {
+			continue
+		}
+		seen[k.dices] = true
+
+		if v := o.keptValue(keep, rolls); v > res {
+			res = v
+		}
+	}
+
+	o.best[key] = res
+	return res
+}
+
+// keptValue returns the expected value when rolling the dices beside the
+// sorted `keep` with `rolls` rolls left.
+func (o *odds) keptValue(keep []int, rolls int) float64 {
+	key := newHandKey(keep, rolls)
+	if v, ok := o.kept[key]; ok {
+		return v
+	}
+
+	res := 0.0
+	for _, out := range o.outcomes[o.size-len(keep)] {
+		res += out.chance * o.bestValue(merge(keep, out.dices), rolls-1)
+	}
+
+	o.kept[key] = res
+	return res
+}
+
+// rollOutcomes returns every distinct result of rolling `n` dices with
+// `sides` sides, in sorted order.
+func rollOutcomes(n int, sides int) []outcome {
+	total := 1.0
+	for i := 0; i < n; i++ {
+		total *= float64(sides)
+	}
+
+	var res []outcome
+	var collect func(dices []int, from int)
+	collect = func(dices []int, from int) {
+		if len(dices) == n {
+			res = append(res, outcome{
+				dices:  append([]int{}, dices...),
+				chance: float64(permutations(dices)) / total,
+			})
+			return
+		}
+		for face := from; face <= sides; face++ {
+			collect(append(dices, face), face)
+		}
+	}
+	collect(make([]int, 0, n), 1)
+
+	return res
+}
+
+// permutations returns the number of orders the sorted `dices` can be rolled
+// in.
+func permutations(dices []int) int {
+	res := factorial(len(dices))
+	for i := 0; i < len(dices); {
+		j := i
+		for j < len(dices) && dices[j] == dices[i] {
+			j++
+		}
+		res /= factorial(j - i)
+		i = j
+	}
+	return res
+}
+
+// outcomeCount returns the number of different rolls of `n` dices with
+// `sides` sides in sorted order, the length of rollOutcomes.
+func outcomeCount(n int, sides int) int {
+	res := 1
+	for i := 1; i <= n; i++ {
+		res = res * (sides - 1 + i) / i
+	}
+	return res
+}
+
+func factorial(n int) int {
+	res := 1
+	for i := 2; i <= n; i++ {
+		res *= i
+	}
+	return res
+}
+
+// merge returns the sorted union of two sorted lists of dices.
+func merge(a, b []int) []int {
+	res := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] <= b[j] {
+			res = append(res, a[i])
+			i++
+		} else {
+			res = append(res, b[j])
+			j++
+		}
+	}
+	res = append(res, a[i:]...)
+	return append(res, b[j:]...)
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// ProjectionExpectations returns the expected score of every category of the
+// game for a fresh turn, the base of its projections. ErrCoachDices is
+// returned when the dices have too many outcomes to go through.
+func ProjectionExpectations(g *yahtzee.Game) (map[yahtzee.Category]float64, error) {
+	sides := g.Sides
+	if sides == 0 {
+		sides = yahtzee.NumberOfSides
+	}
+	if outcomeCount(len(g.Dices), sides) > maxCoachOutcomes {
+		return nil, ErrCoachDices
+	}
+
+	rolls := RollsPerTurn(g) - g.ExtraRolls
+	return GameScorer(g).TurnExpectations(len(g.Dices), sides, rolls), nil
+}
+
+// Projections returns the projected final totals of the players: their total
+// so far with the handicap, and the `expected` score of the open boxes they
+// have turns left for, as returned by ProjectionExpectations. The best boxes
+// are played when not every box can be. The bonuses still to come are not
+// projected.
+func Projections(g *yahtzee.Game, expected map[yahtzee.Category]float64) map[yahtzee.User]float64 {
+	scorer := GameScorer(g)
+	res := map[yahtzee.User]float64{}
+	for i, p := range g.Players {
+		open := openExpectations(scorer, SheetOwner(g, i), expected)
+		if turns := turnsLeft(g, team(g, i)); turns < len(open) {
+			open = open[:turns]
+		}
+
+		projection := float64(scorer.AdjustedTotal(g, i))
+		for _, e := range open {
+			projection += e
+		}
+		res[p.User] = projection
+	}
+	return res
+}
+
+// openExpectations returns the expected scores of the open boxes of the
+// player, from the best to the worst.
+func openExpectations(s *Scorer, p *yahtzee.Player, expected map[yahtzee.Category]float64) []float64 {
+	var res []float64
+	for column, multiplier := range s.Columns {
+		if column > len(p.ExtraSheets) {
+			break
+		}
+		sheet := p.Sheet(column)
+		for _, c := range s.Categories() {
+			if _, ok := sheet[c]; !ok {
+				res = append(res, float64(multiplier)*expected[c])
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if s.LowestWins {
+			return res[i] < res[j]
+		}
+		return res[i] > res[j]
+	})
+	return res
+}
+
+// turnsLeft returns the number of turns the team `t` has left in the game,
+// the teams before the current one already had their turn in this round.
+func turnsLeft(g *yahtzee.Game, t int) int {
+	res := Rounds(g) - g.Round
+	if t < team(g, g.CurrentPlayer) {
+		res--
+	}
+	if res < 0 {
+		return 0
+	}
+	return res
+}
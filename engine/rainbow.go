@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"math/rand"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// ColorAction returns the score of the dices in a category, where the colors
+// they show count too.
+type ColorAction func(dices []int, colors []yahtzee.Color) int
+
+// rainbowStraightScore is the value of the rainbow straight.
+const rainbowStraightScore = 60
+
+// rainbow adds the categories scored by the colors of the dices.
+func rainbow(s *Scorer) {
+	s.colorCategory(yahtzee.Flush, flush)
+	s.colorCategory(yahtzee.RainbowStraight, rainbowStraight)
+}
+
+// colorCategory adds the category scored by the colors too. Its score action
+// doesn't know the colors, so it scores zero.
+func (s *Scorer) colorCategory(category yahtzee.Category, action ColorAction) {
+	s.ColorActions[category] = action
+	s.ScoreActions[category] = func(dices []int) int {
+		return action(dices, nil)
+	}
+}
+
+// flush scores every dice showing the same color, worth the sum of the
+// dices.
+func flush(dices []int, colors []yahtzee.Color) int {
+	if len(colors) != len(dices) || len(colors) == 0 {
+		return 0
+	}
+	for _, c := range colors {
+		if c != colors[0] {
+			return 0
+		}
+	}
+	return chance(dices)
+}
+
+// rainbowStraight scores five faces in a row with every dice showing a
+// different color.
+func rainbowStraight(dices []int, colors []yahtzee.Color) int {
+	if len(colors) != len(dices) || longestRun(dices) < 5 {
+		return 0
+	}
+	seen := map[yahtzee.Color]bool{}
+	for _, c := range colors {
+		if seen[c] {
+			return 0
+		}
+		seen[c] = true
+	}
+	return rainbowStraightScore
+}
+
+// colorPatterns returns the colors of `count` dices worth the most in the
+// color categories: every dice showing the same color, and every dice
+// showing a different one when there are enough colors.
+func colorPatterns(count int) [][]yahtzee.Color {
+	palette := yahtzee.Colors()
+
+	same := make([]yahtzee.Color, count)
+	for i := range same {
+		same[i] = palette[0]
+	}
+	if count > len(palette) {
+		return [][]yahtzee.Color{same}
+	}
+	return [][]yahtzee.Color{same, palette[:count]}
+}
+
+// diceColors returns the colors the dices of the game show.
+func diceColors(g *yahtzee.Game) []yahtzee.Color {
+	res := make([]yahtzee.Color, len(g.Dices))
+	for i, d := range g.Dices {
+		res[i] = d.Color
+	}
+	return res
+}
+
+// duplicateColors returns the colors of every dice for the current roll of a
+// duplicate game, the same for every player like the faces.
+func duplicateColors(g *yahtzee.Game) []yahtzee.Color {
+	palette := yahtzee.Colors()
+	r := rand.New(rand.NewSource(^(g.Seed ^ int64(g.Round)<<8 ^ int64(g.RollCount))))
+
+	res := make([]yahtzee.Color, len(g.Dices))
+	for i := range res {
+		res[i] = palette[r.Intn(len(palette))]
+	}
+	return res
+}
@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"github.com/akarasz/yahtzee"
+)
+
+// Sandbox returns a copy of the game for `owner` to explore alternate lines of
+// it, the owner plays every turn of the copy as told by Actor. The copy is not
+// part of a match, a daily challenge or a duplicate game, and it has no shot
+// clock. The seed of the duplicate rolls is not copied, so the copy can't tell
+// the rolls to come.
+func Sandbox(g *yahtzee.Game, owner yahtzee.User) (yahtzee.Game, error) {
+	if len(g.Players) == 0 {
+		return yahtzee.Game{}, ErrNoPlayers
+	}
+
+	res := clone(*g)
+	res.Sandbox = true
+	res.Creator = owner
+	res.Match = ""
+	res.Daily = ""
+	res.Source = ""
+	res.Tables = nil
+	res.Seed = 0
+	res.Deadline = 0
+
+	features := res.Features[:0]
+	for _, f := range res.Features {
+		if f != yahtzee.Duplicate && f != yahtzee.Blitz {
+			features = append(features, f)
+		}
+	}
+	res.Features = features
+
+	return res, nil
+}
+
+// Actor returns the user acting for `u` in the game, the current player when
+// `u` owns the sandbox game, `u` otherwise.
+func Actor(g *yahtzee.Game, u yahtzee.User) yahtzee.User {
+	if g.Sandbox && u == g.Creator && len(g.Players) > 0 {
+		return g.Players[g.CurrentPlayer].User
+	}
+	return u
+}
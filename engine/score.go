@@ -0,0 +1,513 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// ScoreAction returns the score of the dices in a category.
+type ScoreAction func(dices []int) int
+
+// PreScoreAction validates scoring `category` on the `sheet` of the current
+// player before it happens.
+type PreScoreAction func(g *yahtzee.Game, sheet map[yahtzee.Category]int, category yahtzee.Category, dices []int) error
+
+// PostScoreAction updates the game after the current player scored
+// `category` on the `sheet`.
+type PostScoreAction func(g *yahtzee.Game, sheet map[yahtzee.Category]int, category yahtzee.Category, dices []int)
+
+// PostGameAction updates the game after the last turn.
+type PostGameAction func(g *yahtzee.Game)
+
+// Scorer has the scoring rules of a game.
+type Scorer struct {
+	// ScoreActions has the score calculation for every category
+	ScoreActions map[yahtzee.Category]ScoreAction
+
+	// ColorActions has the score calculation of the categories where the
+	// colors of the dices count too, it replaces their score actions when
+	// the colors are known
+	ColorActions map[yahtzee.Category]ColorAction
+
+	// PreScoreActions are called in order before scoring
+	PreScoreActions []PreScoreAction
+
+	// PostScoreActions are called in order after scoring
+	PostScoreActions []PostScoreAction
+
+	// PostGameActions are called in order after the last turn
+	PostGameActions []PostGameAction
+
+	// UpperBonusThreshold is the total of the upper section needed for the
+	// bonus
+	UpperBonusThreshold int
+
+	// UpperBonus is the value of the upper section bonus
+	UpperBonus int
+
+	// UpperSection has the categories counting for the upper section bonus
+	UpperSection []yahtzee.Category
+
+	// Columns has the multiplier of every score column
+	Columns []int
+
+	// LowestWins ranks the lower totals ahead of the higher ones
+	LowestWins bool
+
+	joker jokerRule
+
+	// sides is the number of sides of the dices
+	sides int
+}
+
+// NewScorer returns the scorer with the default rules modified by the
+// features.
+func NewScorer(features ...yahtzee.Feature) *Scorer {
+	s := &Scorer{
+		ScoreActions: map[yahtzee.Category]ScoreAction{
+			yahtzee.Ones:          upper(1),
+			yahtzee.Twos:          upper(2),
+			yahtzee.Threes:        upper(3),
+			yahtzee.Fours:         upper(4),
+			yahtzee.Fives:         upper(5),
+			yahtzee.Sixes:         upper(6),
+			yahtzee.ThreeOfAKind:  ofAKind(3),
+			yahtzee.FourOfAKind:   ofAKind(4),
+			yahtzee.FullHouse:     fullHouse,
+			yahtzee.SmallStraight: smallStraight,
+			yahtzee.LargeStraight: largeStraight,
+			yahtzee.Yahtzee:       yahtzeeScore,
+			yahtzee.Chance:        chance,
+		},
+		ColorActions:        map[yahtzee.Category]ColorAction{},
+		UpperBonusThreshold: 63,
+		UpperBonus:          35,
+		UpperSection:        append([]yahtzee.Category{}, upperSection...),
+		Columns:             []int{1},
+		sides:               yahtzee.NumberOfSides,
+	}
+	s.PostScoreActions = []PostScoreAction{
+		s.upperBonus,
+	}
+
+	registered := yahtzee.RegisteredCategories()
+	for _, f := range features {
+		if configure, ok := featureScorers[f]; ok {
+			configure(s)
+		}
+
+		for _, c := range registered {
+			if yahtzee.Feature(c.Name) != f {
+				continue
+			}
+			s.ScoreActions[c.Name] = ScoreAction(c.Score)
+			if c.Section == yahtzee.Upper {
+				s.UpperSection = append(s.UpperSection, c.Name)
+			}
+		}
+	}
+	s.applyJoker()
+	s.trackAchievements()
+
+	return s
+}
+
+// sheetOrder is the order of the built-in categories on the score sheet.
+var sheetOrder = []yahtzee.Category{
+	yahtzee.Ones,
+	yahtzee.Twos,
+	yahtzee.Threes,
+	yahtzee.Fours,
+	yahtzee.Fives,
+	yahtzee.Sixes,
+	yahtzee.OnePair,
+	yahtzee.TwoPairs,
+	yahtzee.ThreePairs,
+	yahtzee.ThreeOfAKind,
+	yahtzee.FourOfAKind,
+	yahtzee.FiveOfAKind,
+	yahtzee.FullHouse,
+	yahtzee.Castle,
+	yahtzee.Tower,
+	yahtzee.SmallStraight,
+	yahtzee.LargeStraight,
+	yahtzee.FullStraight,
+	yahtzee.Flush,
+	yahtzee.RainbowStraight,
+	yahtzee.Yahtzee,
+	yahtzee.Chance,
+}
+
+// Categories returns the categories of the scorer in the order of the score
+// sheet: the built-in ones first, then the registered ones in the order of
+// the registration.
+func (s *Scorer) Categories() []yahtzee.Category {
+	var res []yahtzee.Category
+	listed := map[yahtzee.Category]bool{}
+	add := func(c yahtzee.Category) {
+		if _, ok := s.ScoreActions[c]; ok && !listed[c] {
+			res = append(res, c)
+			listed[c] = true
+		}
+	}
+
+	for _, c := range sheetOrder {
+		add(c)
+	}
+	for _, c := range yahtzee.RegisteredCategories() {
+		add(c.Name)
+	}
+
+	var rest []yahtzee.Category
+	for c := range s.ScoreActions {
+		if !listed[c] {
+			rest = append(rest, c)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool { return rest[i] < rest[j] })
+
+	return append(res, rest...)
+}
+
+// Section returns the section of the score sheet the category is in.
+func (s *Scorer) Section(category yahtzee.Category) yahtzee.Section {
+	if inSection(category, s.UpperSection) {
+		return yahtzee.Upper
+	}
+	return yahtzee.Lower
+}
+
+// MaxScore returns the highest score `count` dices can get in the category.
+func (s *Scorer) MaxScore(category yahtzee.Category, count int) (int, error) {
+	patterns := [][]yahtzee.Color{nil}
+	if _, ok := s.ColorActions[category]; ok {
+		patterns = colorPatterns(count)
+	}
+
+	best := 0
+	for _, o := range rollOutcomes(count, s.sides) {
+		for _, colors := range patterns {
+			score, err := s.EvaluateColors(category, o.dices, colors)
+			if err != nil {
+				return 0, err
+			}
+			if score > best {
+				best = score
+			}
+		}
+	}
+	return best, nil
+}
+
+// GameScorer returns the scorer of the game with its features and house rules.
+func GameScorer(g *yahtzee.Game) *Scorer {
+	s := NewScorer(g.Features...)
+	if g.Sides > 0 {
+		s.sides = g.Sides
+	}
+
+	r := g.Rules
+	if r == nil {
+		return s
+	}
+	if r.UpperBonusThreshold > 0 {
+		s.UpperBonusThreshold = r.UpperBonusThreshold
+	}
+	if r.UpperBonus > 0 {
+		s.UpperBonus = r.UpperBonus
+	}
+	if r.YahtzeeScore > 0 {
+		s.ScoreActions[yahtzee.Yahtzee] = fixedYahtzee(r.YahtzeeScore)
+	}
+	for category, payout := range r.Payouts {
+		s.pay(category, payout)
+	}
+
+	return s
+}
+
+// pay makes the category worth `payout` whenever the dices make it. The
+// categories not on the sheet are left out.
+func (s *Scorer) pay(category yahtzee.Category, payout int) {
+	action, ok := s.ScoreActions[category]
+	if !ok {
+		return
+	}
+	s.ScoreActions[category] = func(dices []int) int {
+		if action(dices) > 0 {
+			return payout
+		}
+		return 0
+	}
+
+	if colorAction, ok := s.ColorActions[category]; ok {
+		s.ColorActions[category] = func(dices []int, colors []yahtzee.Color) int {
+			if colorAction(dices, colors) > 0 {
+				return payout
+			}
+			return 0
+		}
+	}
+}
+
+// Evaluate returns the score of the `dices` in `category`.
+func (s *Scorer) Evaluate(category yahtzee.Category, dices []int) (int, error) {
+	return s.EvaluateColors(category, dices, nil)
+}
+
+// EvaluateColors returns the score of the `dices` showing the `colors` in
+// `category`. The colors only count in the categories of the rainbow
+// feature, which score zero without them.
+func (s *Scorer) EvaluateColors(category yahtzee.Category, dices []int, colors []yahtzee.Color) (int, error) {
+	action, ok := s.ScoreActions[category]
+	if !ok {
+		return 0, ErrInvalidCategory
+	}
+
+	score := action(dices)
+	if colorAction, ok := s.ColorActions[category]; ok && colors != nil {
+		score = colorAction(dices, colors)
+	}
+	return s.penalize(score, len(dices)), nil
+}
+
+// Score records the score of the `dices` in `category` of the `column` for
+// the current player of the game.
+func (s *Scorer) Score(g *yahtzee.Game, column int, category yahtzee.Category, dices []int) error {
+	return s.record(g, column, category, dices, false)
+}
+
+// Scratch records zero in `category` of the `column` for the current player
+// of the game, whatever the `dices` are.
+func (s *Scorer) Scratch(g *yahtzee.Game, column int, category yahtzee.Category, dices []int) error {
+	return s.record(g, column, category, dices, true)
+}
+
+func (s *Scorer) record(g *yahtzee.Game, column int, category yahtzee.Category, dices []int, scratch bool) error {
+	if column < 0 || column >= len(s.Columns) {
+		return ErrInvalidColumn
+	}
+
+	score, err := s.EvaluateColors(category, dices, diceColors(g))
+	if err != nil {
+		return err
+	}
+	if scratch {
+		score = 0
+	}
+
+	sheet := SheetOwner(g, g.CurrentPlayer).Sheet(column)
+	for _, action := range s.PreScoreActions {
+		if err := action(g, sheet, category, dices); err != nil {
+			return err
+		}
+	}
+
+	sheet[category] = score
+
+	for _, action := range s.PostScoreActions {
+		action(g, sheet, category, dices)
+	}
+
+	// post actions like the Joker can't give points to a scratched box
+	if scratch {
+		sheet[category] = 0
+	}
+
+	return nil
+}
+
+// Total returns the total score of the player, with the values of every
+// column multiplied.
+func (s *Scorer) Total(p *yahtzee.Player) int {
+	total := 0
+	for column, multiplier := range s.Columns {
+		if column > len(p.ExtraSheets) {
+			break
+		}
+		for _, v := range p.Sheet(column) {
+			total += multiplier * v
+		}
+	}
+	return total
+}
+
+// Evaluate returns the score of the `dices` in `category` using the default
+// rules.
+func Evaluate(category yahtzee.Category, dices []int) (int, error) {
+	return NewScorer().Evaluate(category, dices)
+}
+
+// Rounds returns the number of rounds in a game, one for every category in
+// every column.
+func (s *Scorer) Rounds() int {
+	return len(s.ScoreActions) * len(s.Columns)
+}
+
+func (s *Scorer) upperBonus(_ *yahtzee.Game, sheet map[yahtzee.Category]int, _ yahtzee.Category, _ []int) {
+	if _, ok := sheet[yahtzee.Bonus]; ok {
+		return
+	}
+
+	var total, types int
+	for k, v := range sheet {
+		if inSection(k, s.UpperSection) {
+			types++
+			total += v
+		}
+	}
+
+	if total >= s.UpperBonusThreshold {
+		sheet[yahtzee.Bonus] = s.UpperBonus
+	} else if types == len(s.UpperSection) {
+		sheet[yahtzee.Bonus] = 0
+	}
+}
+
+func upper(face int) ScoreAction {
+	return func(dices []int) int {
+		s := 0
+		for _, d := range dices {
+			if d == face {
+				s += face
+			}
+		}
+		return s
+	}
+}
+
+// ofAKind returns the score action for `n` dices with the same face, worth
+// the sum of those dices.
+func ofAKind(n int) ScoreAction {
+	return func(dices []int) int {
+		var buf faceCounts
+		o := counts(dices, &buf)
+		for face := len(o) - 1; face > 0; face-- {
+			if o[face] >= n {
+				return n * face
+			}
+		}
+		return 0
+	}
+}
+
+// fullHouse scores dices of two different faces, two or three of each.
+func fullHouse(dices []int) int {
+	var buf faceCounts
+	o := counts(dices, &buf)
+
+	first := o[dices[0]]
+	if first != 2 && first != 3 {
+		return 0
+	}
+	for _, d := range dices {
+		if d != dices[0] && o[d] != len(dices)-first {
+			return 0
+		}
+	}
+	return 25
+}
+
+func smallStraight(dices []int) int {
+	if longestRun(dices) >= 4 {
+		return 30
+	}
+	return 0
+}
+
+func largeStraight(dices []int) int {
+	if longestRun(dices) >= 5 {
+		return 40
+	}
+	return 0
+}
+
+func yahtzeeScore(dices []int) int {
+	if isYahtzee(dices) {
+		return 50
+	}
+	return 0
+}
+
+// fixedYahtzee returns the score action of a Yahtzee worth `value`.
+func fixedYahtzee(value int) ScoreAction {
+	return func(dices []int) int {
+		if isYahtzee(dices) {
+			return value
+		}
+		return 0
+	}
+}
+
+func chance(dices []int) int {
+	s := 0
+	for _, d := range dices {
+		s += d
+	}
+	return s
+}
+
+// faceCounts is the backing array of counting the faces. It fits the dices of
+// up to 20 sides, so the scoring of the usual games doesn't allocate.
+type faceCounts [21]int
+
+// counts returns how many times the faces occur in the dices, indexed by the
+// face. The result is stored in `buf` when the faces fit in it.
+func counts(dices []int, buf *faceCounts) []int {
+	max := 0
+	for _, d := range dices {
+		if d >= len(buf) {
+			return countsOf(dices)
+		}
+		buf[d]++
+		if d > max {
+			max = d
+		}
+	}
+	return buf[:max+1]
+}
+
+// countsOf is counts for the faces not fitting in the buffer.
+func countsOf(dices []int) []int {
+	max := 0
+	for _, d := range dices {
+		if d > max {
+			max = d
+		}
+	}
+
+	res := make([]int, max+1)
+	for _, d := range dices {
+		res[d]++
+	}
+	return res
+}
+
+// longestRun returns the length of the longest sequence of consecutive faces
+// in the dices.
+func longestRun(dices []int) int {
+	var buf faceCounts
+	longest, run := 0, 0
+	for _, c := range counts(dices, &buf)[1:] {
+		if c == 0 {
+			run = 0
+			continue
+		}
+
+		run++
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+func isYahtzee(dices []int) bool {
+	for i := 0; i < len(dices)-1; i++ {
+		if dices[i] != dices[i+1] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// ErrIncompleteSheet is returned when a sheet has fewer turns than the
+// rounds of the game.
+var ErrIncompleteSheet = errors.New("sheet is incomplete")
+
+// Turn is a turn of a game played offline: the final dices and the box they
+// were scored in.
+type Turn struct {
+	Dices    []int
+	Category yahtzee.Category
+
+	// Column is the index of the score column, the first one when omitted
+	Column int
+
+	// Scratch tells if the box was scratched instead of scored
+	Scratch bool
+}
+
+// TurnError tells which turn of a sheet is against the rules.
+type TurnError struct {
+	// Turn is the index of the turn, it's the number of turns when the sheet
+	// is incomplete
+	Turn int
+
+	Err error
+}
+
+func (e *TurnError) Error() string {
+	return fmt.Sprintf("turn %d: %v", e.Turn, e.Err)
+}
+
+func (e *TurnError) Unwrap() error {
+	return e.Err
+}
+
+// PlaySheet plays the `turns` of the current player on a game with a single
+// player, filling the score sheet as if the dices were rolled on the server.
+// It returns a *TurnError with the first turn against the rules, or when the
+// turns don't cover every round of the game.
+func PlaySheet(g *yahtzee.Game, turns []Turn) error {
+	if len(g.Players) == 0 {
+		return ErrNoPlayers
+	}
+	u := g.Players[g.CurrentPlayer].User
+
+	for i, t := range turns {
+		if err := playTurn(g, u, t); err != nil {
+			return &TurnError{Turn: i, Err: err}
+		}
+	}
+
+	if !IsOver(g) {
+		return &TurnError{Turn: len(turns), Err: ErrIncompleteSheet}
+	}
+	return nil
+}
+
+// playTurn scores the dices of the turn for `u` as if they were rolled on
+// the server. The turn is replayed outside of time: there is no shot clock
+// or blitz bonus, and no achievement is earned by it.
+func playTurn(g *yahtzee.Game, u yahtzee.User, t Turn) error {
+	if err := checkTurn(g, u); err != nil {
+		return err
+	}
+
+	sides := g.Sides
+	if sides == 0 {
+		sides = yahtzee.NumberOfSides
+	}
+	if len(t.Dices) != len(g.Dices) {
+		return ErrInvalidDice
+	}
+	for j, v := range t.Dices {
+		if v < 1 || v > sides {
+			return ErrInvalidDice
+		}
+		g.Dices[j].Value = v
+	}
+
+	g.RollCount = 1
+	if g.HasFeature(yahtzee.Announce) {
+		g.Announcement = t.Category
+	}
+
+	achievements := make([][]yahtzee.Achievement, len(g.Players))
+	for i, p := range g.Players {
+		achievements[i] = p.Achievements
+	}
+
+	g.Deadline = 0
+	_, err := endTurn(g, u, t.Column, t.Category, t.Scratch, time.Time{})
+	g.Deadline = 0
+
+	for i, p := range g.Players {
+		p.Achievements = achievements[i]
+	}
+	return err
+}
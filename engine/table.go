@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// TableVersion is the version of the table format. Tables of other versions
+// can't be read.
+const TableVersion = 1
+
+// ErrTableVersion is returned when reading a table of another version.
+var ErrTableVersion = errors.New("unsupported table version")
+
+// Table has the precalculated probabilities of every dice state of a rule
+// set, so solving is a lookup.
+type Table struct {
+	// Version is the version of the format the table was written with
+	Version int
+
+	// Features are the rule set of the table
+	Features []yahtzee.Feature
+
+	// Sides is the number of sides of the dices
+	Sides int
+
+	// Rolls is the most rolls left the table has entries for
+	Rolls int
+
+	// Entries has the probabilities by the sorted dices and the rolls left
+	Entries map[string]map[yahtzee.Category]float64
+}
+
+// GenerateTable calculates the probabilities of every dice state of the
+// features with at most `rolls` rolls left.
+func GenerateTable(features []yahtzee.Feature, sides int, rolls int) *Table {
+	t := &Table{
+		Version:  TableVersion,
+		Features: sortedFeatures(features),
+		Sides:    sides,
+		Rolls:    rolls,
+		Entries:  map[string]map[yahtzee.Category]float64{},
+	}
+
+	s := NewScorer(features...)
+	for _, hand := range rollOutcomes(yahtzee.DiceCount(features...), sides) {
+		for r := 0; r <= rolls; r++ {
+			t.Entries[tableKey(hand.dices, r)] = s.Probabilities(hand.dices, sides, r)
+		}
+	}
+	return t
+}
+
+// Matches tells if the table was generated for the features, in any order.
+func (t *Table) Matches(features []yahtzee.Feature) bool {
+	sorted := sortedFeatures(features)
+	if len(sorted) != len(t.Features) {
+		return false
+	}
+	for i, f := range sorted {
+		if t.Features[i] != f {
+			return false
+		}
+	}
+	return true
+}
+
+// Probabilities returns the probabilities of the dices with `rolls` rolls
+// left, and false when the table has no entry for them.
+func (t *Table) Probabilities(dices []int, rolls int) (map[yahtzee.Category]float64, bool) {
+	sorted := append([]int{}, dices...)
+	sort.Ints(sorted)
+
+	res, ok := t.Entries[tableKey(sorted, rolls)]
+	return res, ok
+}
+
+// WriteTable writes the table gzipped JSON encoded.
+func WriteTable(w io.Writer, t *Table) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(t); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadTable reads a table written by WriteTable.
+func ReadTable(r io.Reader) (*Table, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	t := &Table{}
+	if err := json.NewDecoder(zr).Decode(t); err != nil {
+		return nil, err
+	}
+	if t.Version != TableVersion {
+		return nil, fmt.Errorf("%w: %d", ErrTableVersion, t.Version)
+	}
+	return t, nil
+}
+
+func tableKey(sortedDices []int, rolls int) string {
+	parts := make([]string, len(sortedDices))
+	for i, d := range sortedDices {
+		parts[i] = strconv.Itoa(d)
+	}
+	return strings.Join(parts, ",") + "|" + strconv.Itoa(rolls)
+}
+
+func sortedFeatures(features []yahtzee.Feature) []yahtzee.Feature {
+	res := append([]yahtzee.Feature{}, features...)
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"errors"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// ErrTiebreakBox is returned when scoring a tiebreak turn anywhere else than
+// in the tiebreak box.
+var ErrTiebreakBox = errors.New("tiebreak turns are scored in the tiebreak box")
+
+// TiebreakResult has the players of a new tiebreak round.
+type TiebreakResult struct {
+	Players []yahtzee.User
+	Round   int
+}
+
+// InTiebreak tells if the tied players are playing a tiebreak round.
+func InTiebreak(g *yahtzee.Game) bool {
+	return g.Tiebreak != nil && len(g.Tiebreak.Players) > 1
+}
+
+// suddenDeath starts the tiebreak when more players have the best total at
+// the end of the game.
+func suddenDeath(g *yahtzee.Game) {
+	if !g.HasFeature(yahtzee.SuddenDeath) || g.HasFeature(yahtzee.Partners) {
+		return
+	}
+	if winners := Winners(g); len(winners) > 1 {
+		g.Tiebreak = &yahtzee.Tiebreak{
+			Players: winners,
+			Scores:  map[yahtzee.User]int{},
+		}
+		g.CurrentPlayer = nextTied(g, -1)
+	}
+}
+
+// scoreTiebreak records the tiebreak score of the dices for the current
+// player, the sum of the faces. A scratch is the worst score.
+func scoreTiebreak(g *yahtzee.Game, category yahtzee.Category, scratch bool) ([]*Event, error) {
+	if category != yahtzee.TiebreakBox {
+		return nil, ErrTiebreakBox
+	}
+
+	score := 0
+	switch {
+	case !scratch:
+		for _, d := range g.Dices {
+			score += d.Value
+		}
+	case GameScorer(g).LowestWins:
+		sides := g.Sides
+		if sides == 0 {
+			sides = yahtzee.NumberOfSides
+		}
+		score = len(g.Dices) * sides
+	}
+	g.Tiebreak.Scores[g.Players[g.CurrentPlayer].User] = score
+
+	action := event.Score
+	if scratch {
+		action = event.Scratch
+	}
+	return []*Event{{
+		Action: action,
+		Data:   g,
+	}}, nil
+}
+
+// nextTiebreakTurn passes the turn to the next tied player, or starts the
+// next tiebreak round with the players having the best tiebreak score.
+func nextTiebreakTurn(g *yahtzee.Game) {
+	if next := nextTied(g, g.CurrentPlayer); next >= 0 {
+		g.CurrentPlayer = next
+		return
+	}
+
+	scorer := GameScorer(g)
+	var best []yahtzee.User
+	for _, u := range g.Tiebreak.Players {
+		score := g.Tiebreak.Scores[u]
+		switch {
+		case len(best) == 0 || scorer.Beats(score, g.Tiebreak.Scores[best[0]]):
+			best = []yahtzee.User{u}
+		case score == g.Tiebreak.Scores[best[0]]:
+			best = append(best, u)
+		}
+	}
+
+	g.Round++
+	g.Tiebreak.Players = best
+	if len(best) > 1 {
+		g.Tiebreak.Scores = map[yahtzee.User]int{}
+		g.CurrentPlayer = nextTied(g, -1)
+	}
+}
+
+// nextTied returns the index of the first tied player after the index
+// `from`, or -1 when there is none.
+func nextTied(g *yahtzee.Game, from int) int {
+	for i := from + 1; i < len(g.Players); i++ {
+		for _, u := range g.Tiebreak.Players {
+			if g.Players[i].User == u {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// tiebreakStarted returns the event of a new tiebreak round when the round
+// changed from `round`.
+func tiebreakStarted(g *yahtzee.Game, round int) []*Event {
+	if !InTiebreak(g) || g.Round == round {
+		return nil
+	}
+	return []*Event{{
+		Action: event.Tiebreak,
+		Data: &TiebreakResult{
+			Players: append([]yahtzee.User{}, g.Tiebreak.Players...),
+			Round:   g.Round,
+		},
+	}}
+}
@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"errors"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// ErrTurnStarted is returned when a whole turn is played after the dices
+// were rolled in the turn.
+var ErrTurnStarted = errors.New("turn already started")
+
+// ScriptedTurn is a whole turn sent at once: the faces kept for the rolls
+// after the first one, and the box the dices are scored in at the end.
+type ScriptedTurn struct {
+	// Keep has the faces of the dices kept for every roll after the first.
+	// The faces the dices don't show are ignored.
+	Keep [][]int
+
+	Category yahtzee.Category
+
+	// Column is the index of the score column, the first one when omitted
+	Column int
+
+	// Scratch tells if the box is scratched instead of scored
+	Scratch bool
+}
+
+// PlayTurn plays the whole turn of `u` at `now`: it rolls the dices, keeps
+// the faces of the script before every roll after the first, and scores the
+// dices in the box of the script. The category is announced after the first
+// roll with the announce feature. The new face values are taken from `intn`
+// like in Roll.
+//
+// The turn is checked before the first roll, so a turn against the rules
+// fails without rolling the dices.
+func PlayTurn(
+	g *yahtzee.Game,
+	u yahtzee.User,
+	t ScriptedTurn,
+	intn func(n int) int,
+	now time.Time) ([]*Event, error) {
+	if err := checkScript(g, u, t); err != nil {
+		return nil, err
+	}
+
+	var res []*Event
+	roll := func() error {
+		events, err := Roll(g, u, intn, now)
+		if err != nil {
+			return err
+		}
+		res = append(res, &Event{
+			Action: event.Roll,
+			Data: &RollResult{
+				Dices:     copyDices(g.Dices),
+				RollCount: events[0].Data.(*RollResult).RollCount,
+			},
+		})
+		return nil
+	}
+
+	if err := roll(); err != nil {
+		return nil, err
+	}
+	if g.HasFeature(yahtzee.Announce) && !InTiebreak(g) {
+		events, err := Announce(g, u, t.Category)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, events...)
+	}
+	for _, faces := range t.Keep {
+		keep(g, faces)
+		res = append(res, &Event{
+			Action: event.Lock,
+			Data: &LockResult{
+				Dices: copyDices(g.Dices),
+			},
+		})
+		if err := roll(); err != nil {
+			return nil, err
+		}
+	}
+
+	events, err := endTurn(g, u, t.Column, t.Category, t.Scratch, now)
+	if err != nil {
+		return nil, err
+	}
+	return append(res, events...), nil
+}
+
+// checkScript checks the turn of the script against the rules before it's
+// played.
+func checkScript(g *yahtzee.Game, u yahtzee.User, t ScriptedTurn) error {
+	if err := checkTurn(g, u); err != nil {
+		return err
+	}
+	if g.RollCount > 0 {
+		return ErrTurnStarted
+	}
+	if len(t.Keep) >= RollsPerTurn(g) {
+		return ErrNoMoreRolls
+	}
+
+	sides := g.Sides
+	if sides == 0 {
+		sides = yahtzee.NumberOfSides
+	}
+	for _, faces := range t.Keep {
+		if len(faces) > len(g.Dices) {
+			return ErrInvalidDice
+		}
+		for _, v := range faces {
+			if v < 1 || v > sides {
+				return ErrInvalidDice
+			}
+		}
+	}
+
+	if InTiebreak(g) {
+		return nil
+	}
+	scorer := GameScorer(g)
+	if t.Scratch && scorer.LowestWins {
+		return ErrLowballScratch
+	}
+	if _, ok := scorer.ScoreActions[t.Category]; !ok {
+		return ErrInvalidCategory
+	}
+	if t.Column < 0 || t.Column >= len(scorer.Columns) {
+		return ErrInvalidColumn
+	}
+	if _, ok := SheetOwner(g, g.CurrentPlayer).Sheet(t.Column)[t.Category]; ok {
+		return ErrCategoryUsed
+	}
+	return nil
+}
+
+// keep locks a dice for every face in `faces` showing it, and unlocks the
+// rest.
+func keep(g *yahtzee.Game, faces []int) {
+	for _, d := range g.Dices {
+		d.Locked = false
+	}
+	for _, v := range faces {
+		for _, d := range g.Dices {
+			if !d.Locked && d.Value == v {
+				d.Locked = true
+				break
+			}
+		}
+	}
+}
+
+// copyDices returns a copy of the dices, so the events of the turn show the
+// dices as they were at the time.
+func copyDices(dices []*yahtzee.Dice) []*yahtzee.Dice {
+	res := make([]*yahtzee.Dice, len(dices))
+	for i, d := range dices {
+		dice := *d
+		res[i] = &dice
+	}
+	return res
+}
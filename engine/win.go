@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// WinProbabilities estimates the chance of every player to win the game by
+// playing the rest of it `simulations` times from the start of the current
+// turn. The rolls are taken from `intn` like in Roll. Every simulated player
+// goes for the most common face of the dices, and scores where the dices beat
+// the `expected` score of a fresh turn the most, as returned by
+// ProjectionExpectations; the dices are scored where they are worth the most
+// when it's nil. A tie splits the win between the teams.
+func WinProbabilities(
+	g *yahtzee.Game,
+	expected map[yahtzee.Category]float64,
+	simulations int,
+	intn func(n int) int) (map[yahtzee.User]float64, error) {
+	if len(g.Players) == 0 {
+		return nil, ErrNoPlayers
+	}
+
+	res := map[yahtzee.User]float64{}
+	for _, p := range g.Players {
+		res[p.User] = 0
+	}
+
+	start := simulationStart(g)
+	for i := 0; i < simulations; i++ {
+		sim := clone(start)
+		if err := simulate(&sim, expected, intn); err != nil {
+			return nil, err
+		}
+
+		teams := map[int]bool{}
+		winners := Winners(&sim)
+		for _, u := range winners {
+			teams[team(&sim, playerIndex(&sim, u))] = true
+		}
+		for _, u := range winners {
+			res[u] += 1 / float64(len(teams))
+		}
+	}
+
+	for u := range res {
+		res[u] /= float64(simulations)
+	}
+	return res, nil
+}
+
+// simulationStart returns the game at the start of the current turn, with the
+// dices of the duplicate games rolled at random so the simulations don't know
+// the rolls to come. The shot clock is stopped.
+func simulationStart(g *yahtzee.Game) yahtzee.Game {
+	res := clone(*g)
+
+	for _, d := range res.Dices {
+		d.Locked = false
+	}
+	res.RollCount = 0
+	res.Announcement = ""
+	res.ExtraRolls = 0
+	res.Deadline = 0
+
+	features := res.Features[:0]
+	for _, f := range res.Features {
+		if f != yahtzee.Duplicate {
+			features = append(features, f)
+		}
+	}
+	res.Features = features
+
+	return res
+}
+
+// simulate plays the turns of the game until it's over.
+func simulate(g *yahtzee.Game, expected map[yahtzee.Category]float64, intn func(n int) int) error {
+	now := time.Now()
+	scorer := GameScorer(g)
+
+	for !IsOver(g) {
+		u := g.Players[g.CurrentPlayer].User
+		if _, err := Roll(g, u, intn, now); err != nil {
+			return err
+		}
+
+		if g.HasFeature(yahtzee.Announce) && !InTiebreak(g) {
+			// the best box of the first roll is announced
+			if boxes := simulatedBoxes(g, scorer, expected); len(boxes) > 0 {
+				if _, err := Announce(g, u, boxes[0].category); err != nil {
+					return err
+				}
+			}
+		}
+		for g.RollCount < RollsPerTurn(g) {
+			keepMostCommon(g)
+			if _, err := Roll(g, u, intn, now); err != nil {
+				return err
+			}
+		}
+
+		if err := scoreSimulated(g, u, simulatedBoxes(g, scorer, expected), now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// simulatedBox is an open box of the current player, with the gain of the
+// dices over the expected score of the box.
+type simulatedBox struct {
+	column   int
+	category yahtzee.Category
+	gain     float64
+}
+
+// simulatedBoxes returns the open boxes of the current player from the best
+// to the worst for the dices, only the tiebreak box in the tiebreak.
+func simulatedBoxes(g *yahtzee.Game, s *Scorer, expected map[yahtzee.Category]float64) []*simulatedBox {
+	if InTiebreak(g) {
+		return []*simulatedBox{{category: yahtzee.TiebreakBox}}
+	}
+
+	dices := make([]int, len(g.Dices))
+	for i, d := range g.Dices {
+		dices[i] = d.Value
+	}
+
+	sign := 1.0
+	if s.LowestWins {
+		sign = -1
+	}
+
+	p := SheetOwner(g, g.CurrentPlayer)
+	var res []*simulatedBox
+	for column, multiplier := range s.Columns {
+		if column > len(p.ExtraSheets) {
+			break
+		}
+		sheet := p.Sheet(column)
+		for _, c := range s.Categories() {
+			if _, ok := sheet[c]; ok {
+				continue
+			}
+			score, err := s.Evaluate(c, dices)
+			if err != nil {
+				continue
+			}
+			res = append(res, &simulatedBox{
+				column:   column,
+				category: c,
+				gain:     sign * float64(multiplier) * (float64(score) - expected[c]),
+			})
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].gain > res[j].gain
+	})
+	return res
+}
+
+// scoreSimulated scores the dices in the first of the boxes the rules allow,
+// or scratches the first one they allow when none.
+func scoreSimulated(g *yahtzee.Game, u yahtzee.User, boxes []*simulatedBox, now time.Time) error {
+	var err error
+	for _, b := range boxes {
+		if _, err = ScoreColumn(g, u, b.column, b.category, now); err == nil {
+			return nil
+		}
+	}
+	for _, b := range boxes {
+		if _, err = ScratchColumn(g, u, b.column, b.category, now); err == nil {
+			return nil
+		}
+	}
+	if err == nil {
+		err = ErrCategoryUsed
+	}
+	return err
+}
+
+// keepMostCommon locks the dices of the most common face, the highest of
+// the most common ones, or the dices above the middle in the tiebreak.
+func keepMostCommon(g *yahtzee.Game) {
+	sides := g.Sides
+	if sides == 0 {
+		sides = yahtzee.NumberOfSides
+	}
+
+	if InTiebreak(g) {
+		for _, d := range g.Dices {
+			d.Locked = 2*d.Value > sides+1
+		}
+		return
+	}
+
+	counts := map[int]int{}
+	best := 0
+	for _, d := range g.Dices {
+		counts[d.Value]++
+		if counts[d.Value] > counts[best] || counts[d.Value] == counts[best] && d.Value > best {
+			best = d.Value
+		}
+	}
+	for _, d := range g.Dices {
+		d.Locked = d.Value == best
+	}
+}
+
+func playerIndex(g *yahtzee.Game, u yahtzee.User) int {
+	for i, p := range g.Players {
+		if p.User == u {
+			return i
+		}
+	}
+	return -1
+}
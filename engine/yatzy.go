@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"github.com/akarasz/yahtzee"
+)
+
+// yatzy swaps in the Scandinavian scoring table.
+func yatzy(s *Scorer) {
+	s.ScoreActions[yahtzee.OnePair] = onePair
+	s.ScoreActions[yahtzee.TwoPairs] = twoPairs
+	s.ScoreActions[yahtzee.FullHouse] = yatzyFullHouse
+	s.ScoreActions[yahtzee.SmallStraight] = yatzyStraight(1, 15)
+	s.ScoreActions[yahtzee.LargeStraight] = yatzyStraight(2, 20)
+
+	s.UpperBonus = 50
+}
+
+func onePair(dices []int) int {
+	var buf faceCounts
+	o := counts(dices, &buf)
+	for face := len(o) - 1; face > 0; face-- {
+		if o[face] >= 2 {
+			return 2 * face
+		}
+	}
+	return 0
+}
+
+func twoPairs(dices []int) int {
+	var buf faceCounts
+	o := counts(dices, &buf)
+
+	s, pairs := 0, 0
+	for face := len(o) - 1; face > 0 && pairs < 2; face-- {
+		if o[face] >= 2 {
+			s += 2 * face
+			pairs++
+		}
+	}
+
+	if pairs < 2 {
+		return 0
+	}
+	return s
+}
+
+func yatzyFullHouse(dices []int) int {
+	if fullHouse(dices) == 0 {
+		return 0
+	}
+	return chance(dices)
+}
+
+// yatzyStraight returns the score action of the straight made of five
+// consecutive faces starting from `from`.
+func yatzyStraight(from, value int) ScoreAction {
+	return func(dices []int) int {
+		var buf faceCounts
+		o := counts(dices, &buf)
+		if len(o) < from+5 {
+			return 0
+		}
+
+		for face := from; face < from+5; face++ {
+			if o[face] == 0 {
+				return 0
+			}
+		}
+		return value
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
-	"github.com/akarasz/yahtzee"
 	"github.com/akarasz/yahtzee/event"
 )
 
@@ -27,37 +26,43 @@ type InApp struct {
 	games map[string]*game
 }
 
+// metricsOnce registers the metrics of the first backend created, more
+// backends would panic on the duplicate registration.
+var metricsOnce sync.Once
+
 func New() *InApp {
 	res := InApp{
 		games: map[string]*game{},
 	}
 
-	promauto.NewGaugeFunc(
-		prometheus.GaugeOpts{
-			Name: "yahtzee_websocket_games_total",
-			Help: "The total number of games with websocket channels",
-		},
-		func() float64 {
-			res.RLock()
-			total := len(res.games)
-			res.RUnlock()
-			return float64(total)
-		})
-
-	promauto.NewGaugeFunc(
-		prometheus.GaugeOpts{
-			Name: "yahtzee_websocket_clients_total",
-			Help: "The total number of clients with websocket channels",
-		},
-		func() float64 {
-			total := 0
-			res.RLock()
-			for _, g := range res.games {
-				total += len(g.clients)
-			}
-			res.RUnlock()
-			return float64(total)
-		})
+	metricsOnce.Do(func() {
+		promauto.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "yahtzee_websocket_games_total",
+				Help: "The total number of games with websocket channels",
+			},
+			func() float64 {
+				res.RLock()
+				total := len(res.games)
+				res.RUnlock()
+				return float64(total)
+			})
+
+		promauto.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "yahtzee_websocket_clients_total",
+				Help: "The total number of clients with websocket channels",
+			},
+			func() float64 {
+				total := 0
+				res.RLock()
+				for _, g := range res.games {
+					total += len(g.clients)
+				}
+				res.RUnlock()
+				return float64(total)
+			})
+	})
 
 	return &res
 }
@@ -107,7 +112,27 @@ func (b *InApp) Unsubscribe(gameID string, clientID interface{}) error {
 	return nil
 }
 
-func (b *InApp) Emit(gameID string, u *yahtzee.User, t event.Type, body interface{}) {
+func (b *InApp) CloseGame(gameID string) error {
+	b.Lock()
+	defer b.Unlock()
+
+	g, ok := b.games[gameID]
+	if !ok {
+		return nil
+	}
+
+	g.Lock()
+	for clientID, c := range g.clients {
+		close(c)
+		delete(g.clients, clientID)
+	}
+	delete(b.games, gameID)
+	g.Unlock()
+
+	return nil
+}
+
+func (b *InApp) Emit(gameID string, e *event.Event) {
 	b.RLock()
 	g, ok := b.games[gameID]
 	b.RUnlock()
@@ -119,10 +144,6 @@ func (b *InApp) Emit(gameID string, u *yahtzee.User, t event.Type, body interfac
 	defer g.Unlock()
 
 	for _, s := range g.clients {
-		s <- &event.Event{
-			User:   u,
-			Action: t,
-			Data:   body,
-		}
+		s <- e
 	}
 }
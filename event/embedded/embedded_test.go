@@ -3,15 +3,39 @@ package embedded_test
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/akarasz/yahtzee/event"
 	"github.com/akarasz/yahtzee/event/embedded"
-	"github.com/stretchr/testify/suite"
+	"github.com/akarasz/yahtzee/event/eventtest"
 )
 
 func TestSuite(t *testing.T) {
+	eventtest.Run(t, newInApp)
+}
+
+// emitAllocBudget is the number of allocations an emit can make. The fan-out
+// only sends the pointer of the event, it doesn't need any.
+const emitAllocBudget = 0
+
+func newInApp() (event.Subscriber, event.Emitter) {
 	subject := embedded.New()
-	suite.Run(t, &event.TestSuite{
-		S: subject,
-		E: subject,
-	})
+	return subject, subject
+}
+
+func TestEmitAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("skipping allocation budgets with the race detector")
+	}
+
+	for _, f := range eventtest.FanOuts {
+		t.Run(f.String(), func(t *testing.T) {
+			allocs := eventtest.AllocsPerEmit(t, newInApp, f)
+			assert.LessOrEqual(t, allocs, float64(emitAllocBudget))
+		})
+	}
+}
+
+func BenchmarkEmit(b *testing.B) {
+	eventtest.Benchmark(b, newInApp)
 }
@@ -0,0 +1,6 @@
+//go:build !race
+// +build !race
+
+package embedded_test
+
+const raceEnabled = false
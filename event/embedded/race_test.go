@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package embedded_test
+
+// raceEnabled skips the allocation budgets, the race detector allocates on
+// its own.
+const raceEnabled = true
@@ -1,12 +1,6 @@
 package event
 
 import (
-	"fmt"
-	"sync"
-	"time"
-
-	"github.com/stretchr/testify/suite"
-
 	"github.com/akarasz/yahtzee"
 )
 
@@ -15,10 +9,25 @@ type Type string
 
 // Available types
 const (
-	AddPlayer Type = "add-player"
-	Roll      Type = "roll"
-	Lock      Type = "lock"
-	Score     Type = "score"
+	AddPlayer   Type = "add-player"
+	Roll        Type = "roll"
+	Lock        Type = "lock"
+	Score       Type = "score"
+	Announce    Type = "announce"
+	Scratch     Type = "scratch"
+	Bonus       Type = "bonus"
+	ExtraRoll   Type = "extra-roll"
+	Hint        Type = "hint"
+	Timeout     Type = "timeout"
+	TurnChanged Type = "turn-changed"
+	Countdown   Type = "countdown"
+	Away        Type = "away"
+	GameOver    Type = "game-over"
+	Tiebreak    Type = "tiebreak"
+	Achievement Type = "achievement"
+	MatchGame   Type = "match-game"
+	MatchOver   Type = "match-over"
+	Annotation  Type = "annotation"
 )
 
 // Subscriber for subscribe events
@@ -28,115 +37,50 @@ type Subscriber interface {
 	Unsubscribe(gameID string, clientID interface{}) error
 }
 
-// Emitter used by the event producer side to fire events
-type Emitter interface {
-	// Emit notifies the consumers of `gameID` that `u` user triggered `t` event
-	// that caused changes described in `body`
-	Emit(gameID string, u *yahtzee.User, t Type, body interface{})
+// Closer can drop every subscription of a game at once.
+type Closer interface {
+	// CloseGame unsubscribes every client of `gameID`, closing their
+	// channels.
+	CloseGame(gameID string) error
 }
 
-type Event struct {
-	User   *yahtzee.User
-	Action Type
-	Data   interface{}
+// SpectatorChannel is where the events of the game are sent to its
+// spectators.
+func SpectatorChannel(gameID string) string {
+	return "spectate:" + gameID
 }
 
-type TestSuite struct {
-	suite.Suite
-
-	S Subscriber
-	E Emitter
-}
-
-func (ts *TestSuite) TestSubscribe() {
-	s := ts.S
-	e := ts.E
-
-	c, err := s.Subscribe("subscribeID", "subscribeWSID")
-	ts.NoError(err)
-
-	got := ts.receiveWithTimeout(c)
-	e.Emit("subscribeID", yahtzee.NewUser("Alice"), AddPlayer, nil)
-	ts.NotNil(<-got)
-}
-
-func (ts *TestSuite) TestUnsubscribe() {
-	s := ts.S
-	e := ts.E
-
-	c, err := s.Subscribe("unsubscribeID", "unsubscribeWSID")
-	ts.Require().NoError(err)
-
-	ts.NoError(s.Unsubscribe("unsubscribeID", "unsubscribeWSID"))
-
-	got := ts.receiveWithTimeout(c)
-	e.Emit("unsubscribeID", yahtzee.NewUser("Alice"), AddPlayer, nil)
-	ts.Nil(<-got)
-}
-
-func (ts *TestSuite) TestEmit() {
-	s := ts.S
-	e := ts.E
-
-	c1, err := s.Subscribe("emitID", "emit1WSID")
-	ts.Require().NoError(err)
-	c2, err := s.Subscribe("emitID", "emit2WSID")
-	ts.Require().NoError(err)
-	c3, err := s.Subscribe("notEmitID", "emit3WSID")
-	ts.Require().NoError(err)
-
-	got1 := ts.receiveWithTimeout(c1)
-	got2 := ts.receiveWithTimeout(c2)
-	got3 := ts.receiveWithTimeout(c3)
-	e.Emit("emitID", yahtzee.NewUser("Alice"), AddPlayer, nil)
-	ts.NotNil(<-got1)
-	ts.NotNil(<-got2)
-	ts.Nil(<-got3)
+// Close unsubscribes every client of the game and of its spectators, when
+// `s` is a Closer. It's a no-op otherwise.
+func Close(s Subscriber, gameID string) error {
+	closer, ok := s.(Closer)
+	if !ok {
+		return nil
+	}
+	for _, channel := range []string{gameID, SpectatorChannel(gameID)} {
+		if err := closer.CloseGame(channel); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (ts *TestSuite) TestRace() {
-	s := ts.S
-	e := ts.E
-	wg := &sync.WaitGroup{}
-
-	for i := 0; i < 3; i++ {
-		wg.Add(1)
-		go func(i int) {
-			id := fmt.Sprintf("raceID%d", i)
-			c, err := s.Subscribe(id, id+"WS")
-			ts.Require().NoError(err)
-
-			go func(c chan *Event) {
-				for {
-					<-c
-				}
-			}(c)
-
-			for j := 0; j < 3; j++ {
-				e.Emit(id, yahtzee.NewUser("Alice"), AddPlayer, nil)
-			}
-
-			ts.Require().NoError(s.Unsubscribe(id, id+"WS"))
-			wg.Done()
-		}(i)
-	}
-	wg.Wait()
+// Emitter used by the event producer side to fire events
+type Emitter interface {
+	// Emit notifies the consumers of `gameID` about `e`
+	Emit(gameID string, e *Event)
 }
 
-func (ts *TestSuite) receiveWithTimeout(c <-chan *Event) chan interface{} {
-	res := make(chan interface{}, 1)
+type Event struct {
+	User   *yahtzee.User
+	Action Type
+	Data   interface{}
 
-	go func() {
-		for {
-			select {
-			case got := <-c:
-				res <- got
-			case <-time.After(100 * time.Millisecond):
-				res <- nil
-				return
-			}
-		}
-	}()
+	// Hash is the hash of the game state after the event. Clients can compare
+	// it with the hash of their local state to detect divergence.
+	Hash string
 
-	return res
+	// AppliedActionID is the ID the client sent with the request that caused
+	// the event.
+	AppliedActionID string
 }
@@ -0,0 +1,100 @@
+package eventtest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// FanOut is a load on the backend: every event emitted to one of the Games
+// is delivered to its Subscribers.
+type FanOut struct {
+	Games       int
+	Subscribers int
+}
+
+func (f FanOut) String() string {
+	return fmt.Sprintf("%dgames-%dsubscribers", f.Games, f.Subscribers)
+}
+
+// FanOuts are the loads benchmarked, from a lonely player to a tournament
+// final streamed to the crowd.
+var FanOuts = []FanOut{
+	{Games: 1, Subscribers: 1},
+	{Games: 1, Subscribers: 100},
+	{Games: 100, Subscribers: 4},
+	{Games: 1000, Subscribers: 1},
+}
+
+// Benchmark emits events round robin to the games of every fan-out on the
+// backends created by `newBackend`. Besides the time and the allocations of
+// an emit it reports the deliveries per second. Run it with -cpuprofile or
+// -memprofile to profile the hub.
+func Benchmark(b *testing.B, newBackend func() (event.Subscriber, event.Emitter)) {
+	for _, f := range FanOuts {
+		b.Run(f.String(), func(b *testing.B) {
+			s, e := newBackend()
+			ids := subscribe(b, s, f)
+			ev := &event.Event{User: yahtzee.NewUser("Alice"), Action: event.Roll}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				e.Emit(ids[i%len(ids)], ev)
+			}
+			elapsed := time.Since(start)
+			b.StopTimer()
+
+			b.ReportMetric(float64(b.N*f.Subscribers)/elapsed.Seconds(), "deliveries/s")
+		})
+	}
+}
+
+// AllocsPerEmit returns the average number of allocations of emitting an
+// event with the fan-out on the backend created by `newBackend`. The tests of
+// the backends compare it with their allocation budget.
+func AllocsPerEmit(t testing.TB, newBackend func() (event.Subscriber, event.Emitter), f FanOut) float64 {
+	s, e := newBackend()
+	ids := subscribe(t, s, f)
+	ev := &event.Event{User: yahtzee.NewUser("Alice"), Action: event.Roll}
+
+	i := 0
+	return testing.AllocsPerRun(100, func() {
+		e.Emit(ids[i%len(ids)], ev)
+		i++
+	})
+}
+
+// subscribe subscribes the clients of the fan-out draining their channels,
+// unsubscribes them at the end of the test, and returns the IDs of the
+// games. The drainers don't select on anything else: waking up from a select
+// allocates in the runtime, and it would count in the budgets.
+func subscribe(t testing.TB, s event.Subscriber, f FanOut) []string {
+	ids := make([]string, f.Games)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("fanOutID%d", i)
+		for j := 0; j < f.Subscribers; j++ {
+			clientID := fmt.Sprintf("fanOut%dWSID%d", i, j)
+			c, err := s.Subscribe(ids[i], clientID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			go func(c chan *event.Event) {
+				for range c {
+				}
+			}(c)
+
+			gameID := ids[i]
+			t.Cleanup(func() {
+				s.Unsubscribe(gameID, clientID)
+			})
+		}
+	}
+
+	return ids
+}
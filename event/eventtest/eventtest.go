@@ -0,0 +1,126 @@
+// Package eventtest has the conformance tests every event implementation has
+// to pass.
+package eventtest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// Run runs the conformance tests on the backends created by `newBackend`. A
+// new backend is created for every test.
+func Run(t *testing.T, newBackend func() (event.Subscriber, event.Emitter)) {
+	suite.Run(t, &eventSuite{newBackend: newBackend})
+}
+
+type eventSuite struct {
+	suite.Suite
+
+	newBackend func() (event.Subscriber, event.Emitter)
+	subscriber event.Subscriber
+	emitter    event.Emitter
+}
+
+func (ts *eventSuite) SetupTest() {
+	ts.subscriber, ts.emitter = ts.newBackend()
+}
+
+func (ts *eventSuite) TestSubscribe() {
+	s := ts.subscriber
+	e := ts.emitter
+
+	c, err := s.Subscribe("subscribeID", "subscribeWSID")
+	ts.NoError(err)
+
+	got := ts.receiveWithTimeout(c)
+	e.Emit("subscribeID", &event.Event{User: yahtzee.NewUser("Alice"), Action: event.AddPlayer})
+	ts.NotNil(<-got)
+}
+
+func (ts *eventSuite) TestUnsubscribe() {
+	s := ts.subscriber
+	e := ts.emitter
+
+	c, err := s.Subscribe("unsubscribeID", "unsubscribeWSID")
+	ts.Require().NoError(err)
+
+	ts.NoError(s.Unsubscribe("unsubscribeID", "unsubscribeWSID"))
+
+	got := ts.receiveWithTimeout(c)
+	e.Emit("unsubscribeID", &event.Event{User: yahtzee.NewUser("Alice"), Action: event.AddPlayer})
+	ts.Nil(<-got)
+}
+
+func (ts *eventSuite) TestEmit() {
+	s := ts.subscriber
+	e := ts.emitter
+
+	c1, err := s.Subscribe("emitID", "emit1WSID")
+	ts.Require().NoError(err)
+	c2, err := s.Subscribe("emitID", "emit2WSID")
+	ts.Require().NoError(err)
+	c3, err := s.Subscribe("notEmitID", "emit3WSID")
+	ts.Require().NoError(err)
+
+	got1 := ts.receiveWithTimeout(c1)
+	got2 := ts.receiveWithTimeout(c2)
+	got3 := ts.receiveWithTimeout(c3)
+	e.Emit("emitID", &event.Event{User: yahtzee.NewUser("Alice"), Action: event.AddPlayer})
+	ts.NotNil(<-got1)
+	ts.NotNil(<-got2)
+	ts.Nil(<-got3)
+}
+
+func (ts *eventSuite) TestRace() {
+	s := ts.subscriber
+	e := ts.emitter
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			id := fmt.Sprintf("raceID%d", i)
+			c, err := s.Subscribe(id, id+"WS")
+			ts.Require().NoError(err)
+
+			go func(c chan *event.Event) {
+				for {
+					<-c
+				}
+			}(c)
+
+			for j := 0; j < 3; j++ {
+				e.Emit(id, &event.Event{User: yahtzee.NewUser("Alice"), Action: event.AddPlayer})
+			}
+
+			ts.Require().NoError(s.Unsubscribe(id, id+"WS"))
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (ts *eventSuite) receiveWithTimeout(c <-chan *event.Event) chan interface{} {
+	res := make(chan interface{}, 1)
+
+	go func() {
+		for {
+			select {
+			case got := <-c:
+				res <- got
+			case <-time.After(100 * time.Millisecond):
+				res <- nil
+				return
+			}
+		}
+	}()
+
+	return res
+}
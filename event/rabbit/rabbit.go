@@ -7,7 +7,6 @@ import (
 
 	"github.com/streadway/amqp"
 
-	"github.com/akarasz/yahtzee"
 	"github.com/akarasz/yahtzee/event"
 )
 
@@ -25,16 +24,12 @@ func New(ch *amqp.Channel) (*Rabbit, error) {
 	}, nil
 }
 
-func (r *Rabbit) Emit(gameID string, u *yahtzee.User, t event.Type, body interface{}) {
+func (r *Rabbit) Emit(gameID string, e *event.Event) {
 	if err := r.exchangeDeclare(gameID); err != nil {
 		return
 	}
 
-	jsonBody, err := json.Marshal(event.Event{
-		User:   u,
-		Action: t,
-		Data:   body,
-	})
+	jsonBody, err := json.Marshal(e)
 	if err != nil {
 		return
 	}
@@ -7,11 +7,11 @@ import (
 
 	"github.com/streadway/amqp"
 	"github.com/stretchr/testify/require"
-	"github.com/stretchr/testify/suite"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 
 	"github.com/akarasz/yahtzee/event"
+	"github.com/akarasz/yahtzee/event/eventtest"
 	"github.com/akarasz/yahtzee/event/rabbit"
 )
 
@@ -48,11 +48,9 @@ func TestSuite(t *testing.T) {
 	}
 	defer ch.Close()
 
-	subject, err := rabbit.New(ch)
-	require.NoError(t, err)
-
-	suite.Run(t, &event.TestSuite{
-		S: subject,
-		E: subject,
+	eventtest.Run(t, func() (event.Subscriber, event.Emitter) {
+		subject, err := rabbit.New(ch)
+		require.NoError(t, err)
+		return subject, subject
 	})
 }
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/event"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// WithAchievements sets where the achievements of the users are kept. Without
+// it the achievements are not tracked across games.
+func WithAchievements(a store.Achievements) Option {
+	return func(h *handler) {
+		h.achievements = a
+	}
+}
+
+// AchievementsResponse has the achievements unlocked by a user.
+type AchievementsResponse struct {
+	User         yahtzee.User
+	Achievements []yahtzee.Achievement
+}
+
+func (h *handler) Achievements(w http.ResponseWriter, r *http.Request) {
+	user := yahtzee.User(mux.Vars(r)["user"])
+
+	if h.achievements == nil {
+		writeError(w, r, nil, "achievements are not tracked", http.StatusNotFound)
+		return
+	}
+
+	unlocked, err := h.achievements.Achievements(user)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	res := &AchievementsResponse{User: user, Achievements: unlocked}
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("achievements returned")
+}
+
+// unlock records the achievements earned in the game for the users, and
+// returns the events without the ones already unlocked in earlier games. The
+// achievements of the sandbox games are not recorded.
+func (h *handler) unlock(g *yahtzee.Game, events []*engine.Event) []*engine.Event {
+	if h.achievements == nil || g.Sandbox {
+		return events
+	}
+
+	res := make([]*engine.Event, 0, len(events))
+	for _, e := range events {
+		if e.Action != event.Achievement {
+			res = append(res, e)
+			continue
+		}
+
+		a := e.Data.(*engine.AchievementResult)
+		unlocked, err := h.achievements.Unlock(a.User, a.Achievement)
+		if err != nil {
+			log.Printf("unlock achievement: %v", err)
+			continue
+		}
+		if unlocked {
+			res = append(res, e)
+		}
+	}
+	return res
+}
@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+var errInvalidBackup = errors.New("invalid backup")
+
+// WithAdminToken enables the admin endpoints for the requests with the token
+// in their Admin-Token header. Without it the admin endpoints are not served.
+func WithAdminToken(token string) Option {
+	return func(h *handler) {
+		h.adminToken = token
+	}
+}
+
+// WithReplayer enables the admin endpoint returning the games as they were
+// saved with a change of their logs, like the eventlog store does.
+func WithReplayer(r store.Replayer) Option {
+	return func(h *handler) {
+		h.replayer = r
+	}
+}
+
+// BackupLine is a line of a backup, a game with its ID. The lines are the
+// same as the ones of the dump files of cmd/migrate, so a backup can be
+// copied into any store with it too.
+type BackupLine struct {
+	ID string
+
+	// Game is the stored form of the game, with its seed and the
+	// store.SchemaVersion it was saved with
+	Game json.RawMessage
+}
+
+// RestoreResponse tells what a restore did.
+type RestoreResponse struct {
+	Restored int
+
+	// Skipped are the games kept, as the store already had them
+	Skipped int
+}
+
+// checkAdmin tells if the request has the admin token, and writes the error
+// when it doesn't.
+func (h *handler) checkAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if h.adminToken == "" {
+		writeError(w, r, nil, "admin is not enabled", http.StatusNotFound)
+		return false
+	}
+	got := []byte(r.Header.Get("Admin-Token"))
+	if subtle.ConstantTimeCompare(got, []byte(h.adminToken)) != 1 {
+		writeError(w, r, nil, "invalid admin token", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// Backup streams every game of the store as newline-delimited JSON, a
+// BackupLine for every game. The games are read one at a time, so the
+// backup of a busy server is not a snapshot: a game changed while the backup
+// runs is in it before or after the change. A failing game cuts the backup
+// short, as the status is sent by then.
+func (h *handler) Backup(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	ids, err := h.store.List()
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition",
+		`attachment; filename="yahtzee-`+h.now().UTC().Format("20060102-150405")+`.ndjson"`)
+
+	buf := bufio.NewWriter(w)
+	e := json.NewEncoder(buf)
+	count := 0
+	for _, id := range ids {
+		g, err := h.store.Load(id)
+		if errors.Is(err, store.ErrNotExists) {
+			// expired since the listing
+			continue
+		}
+		if err != nil {
+			log.Printf("backup %s: %v", id, err)
+			return
+		}
+
+		raw, err := store.Marshal(g)
+		if err != nil {
+			log.Printf("backup %s: %v", id, err)
+			return
+		}
+		if err := e.Encode(BackupLine{ID: id, Game: raw}); err != nil {
+			log.Printf("backup: %v", err)
+			return
+		}
+		count++
+	}
+	if err := buf.Flush(); err != nil {
+		log.Printf("backup: %v", err)
+		return
+	}
+
+	log.Printf("backed up %d games", count)
+}
+
+// DeleteGame deletes the game with everything the server keeps for it, and
+// disconnects its clients and spectators.
+func (h *handler) DeleteGame(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.deleteGame(gameID); err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.Print("game deleted")
+}
+
+// StateAt returns the stored form of the game as it was saved with the change
+// of the `seq` query parameter, the first change being 1, for looking into
+// a moment of a game reported broken.
+func (h *handler) StateAt(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+	if h.replayer == nil {
+		writeError(w, r, nil, "event log is not enabled", http.StatusNotFound)
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+	seq, err := strconv.Atoi(r.URL.Query().Get("seq"))
+	if err != nil || seq < 1 {
+		writeError(w, r, err, "invalid seq", http.StatusBadRequest)
+		return
+	}
+
+	g, err := h.replayer.At(gameID, seq)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	raw, err := store.Marshal(g)
+	if err != nil {
+		writeError(w, r, err, "marshal issue", http.StatusInternalServerError)
+		return
+	}
+
+	if ok := writeJSON(w, r, json.RawMessage(raw)); !ok {
+		return
+	}
+
+	log.Print("state returned")
+}
+
+// Restore saves the games of a backup in the body into the store, locking
+// them one at a time. The games the store has are kept, unless the
+// `overwrite` query parameter is true. The games are saved while the body is
+// read, so the ones before an invalid line are restored.
+func (h *handler) Restore(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+	overwrite := false
+	if raw := r.URL.Query().Get("overwrite"); raw != "" {
+		var err error
+		if overwrite, err = strconv.ParseBool(raw); err != nil {
+			writeError(w, r, err, "invalid overwrite", http.StatusBadRequest)
+			return
+		}
+	}
+
+	res := &RestoreResponse{}
+	d := json.NewDecoder(r.Body)
+	for line := 1; d.More(); line++ {
+		var l BackupLine
+		if err := d.Decode(&l); err != nil {
+			writeError(w, r, err, "invalid backup line "+strconv.Itoa(line), http.StatusBadRequest)
+			return
+		}
+		g, err := restoredGame(&l)
+		if err != nil {
+			writeError(w, r, err, "invalid backup line "+strconv.Itoa(line), http.StatusBadRequest)
+			return
+		}
+
+		restored, err := h.restore(l.ID, g, overwrite)
+		if err != nil {
+			writeError(w, r, err, "restore "+l.ID, http.StatusInternalServerError)
+			return
+		}
+		if restored {
+			res.Restored++
+		} else {
+			res.Skipped++
+		}
+	}
+
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Printf("restored %d games, skipped %d", res.Restored, res.Skipped)
+}
+
+// restoredGame returns the game of the line, upgraded to the current form.
+func restoredGame(l *BackupLine) (*yahtzee.Game, error) {
+	if l.ID == "" || len(l.Game) == 0 {
+		return nil, errInvalidBackup
+	}
+	g, err := store.Unmarshal(l.Game)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// restore saves the game unless the store has it and it's not overwritten,
+// and tells if it was saved.
+func (h *handler) restore(id string, g *yahtzee.Game, overwrite bool) (bool, error) {
+	unlock, err := h.store.Lock(id)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	if !overwrite {
+		_, err := h.store.Load(id)
+		if err == nil {
+			return false, nil
+		}
+		if !errors.Is(err, store.ErrNotExists) {
+			return false, err
+		}
+	}
+
+	if err := h.store.Save(id, *g); err != nil {
+		return false, err
+	}
+	return true, nil
+}
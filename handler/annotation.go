@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// maxAnnotationLength is the most characters a caption or a ruling can have.
+const maxAnnotationLength = 500
+
+var errEmptyAnnotation = errors.New("empty annotation")
+
+// WithAnnotationTokens sets the tokens of the trusted clients, like the UI of
+// a tournament director, which can annotate the games. Without them the games
+// can't be annotated.
+func WithAnnotationTokens(tokens ...string) Option {
+	return func(h *handler) {
+		h.annotationTokens = tokens
+	}
+}
+
+// Annotation is a note of a trusted client on a game, like a caption for the
+// broadcast or a ruling of the director.
+type Annotation struct {
+	Caption string
+	Ruling  string
+
+	// At is the time the annotation is about in unix milliseconds, the time
+	// of the request when it's zero
+	At int64
+}
+
+// Annotate appends the annotation of the body to the events of the game, and
+// to its log when the logs are kept, so the replays show it. The client sends
+// its token in the Annotation-Token header, the annotation is signed by the
+// user of the request when there is one.
+func (h *handler) Annotate(w http.ResponseWriter, r *http.Request) {
+	if len(h.annotationTokens) == 0 {
+		writeError(w, r, nil, "annotations are not enabled", http.StatusNotFound)
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+	if !h.validAnnotationToken(r) {
+		writeError(w, r, nil, "invalid annotation token", http.StatusForbidden)
+		return
+	}
+	a := &Annotation{}
+	if ok := readBody(w, r, a); !ok {
+		return
+	}
+	if a.Caption == "" && a.Ruling == "" {
+		writeError(w, r, errEmptyAnnotation, "empty annotation", http.StatusBadRequest)
+		return
+	}
+	if len([]rune(a.Caption)) > maxAnnotationLength || len([]rune(a.Ruling)) > maxAnnotationLength {
+		writeError(w, r, nil, "annotation too long", http.StatusBadRequest)
+		return
+	}
+	if a.At == 0 {
+		a.At = unixMillis(h.now())
+	}
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	var user *yahtzee.User
+	if name, _, ok := r.BasicAuth(); ok && name != "" {
+		u := yahtzee.User(name)
+		user = &u
+	}
+
+	actionID := readActionID(r)
+	h.emit(gameID, user, &g, actionID, []*engine.Event{{Action: event.Annotation, Data: a}})
+
+	if actionID != "" {
+		w.Header().Set("Applied-Action-ID", actionID)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if ok := writeJSON(w, r, a); !ok {
+		return
+	}
+
+	log.Print("annotated")
+}
+
+func (h *handler) validAnnotationToken(r *http.Request) bool {
+	got := []byte(r.Header.Get("Annotation-Token"))
+	for _, token := range h.annotationTokens {
+		if token != "" && subtle.ConstantTimeCompare(got, []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// maxAway is the longest away window in seconds the house rules can allow.
+const maxAway = 14 * 24 * 60 * 60
+
+// WithUserGames sets where the games of the users are kept. Without it the
+// players can't be away.
+func WithUserGames(u store.UserGames) Option {
+	return func(h *handler) {
+		h.userGames = u
+	}
+}
+
+// AwayRequest is the body of the away request.
+type AwayRequest struct {
+	// Until is the end of the away window in unix milliseconds, zero ends it
+	Until int64
+}
+
+// AwayResponse has the games the away window was set in.
+type AwayResponse struct {
+	Games []string
+}
+
+func (h *handler) Away(w http.ResponseWriter, r *http.Request) {
+	if h.userGames == nil {
+		writeError(w, r, nil, "away is not enabled", http.StatusNotFound)
+		return
+	}
+	user, ok := readUser(w, r)
+	if !ok {
+		return
+	}
+	if user != yahtzee.User(mux.Vars(r)["user"]) {
+		writeError(w, r, nil, "another user", http.StatusForbidden)
+		return
+	}
+	req := &AwayRequest{}
+	if ok := readBody(w, r, req); !ok {
+		return
+	}
+	var until time.Time
+	if req.Until != 0 {
+		until = time.Unix(0, req.Until*int64(time.Millisecond))
+	}
+
+	gameIDs, err := h.userGames.Games(user)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	res := &AwayResponse{Games: []string{}}
+	for _, gameID := range gameIDs {
+		ok, err := h.away(gameID, user, until)
+		if err != nil {
+			writeError(w, r, err, "away", http.StatusInternalServerError)
+			return
+		}
+		if ok {
+			res.Games = append(res.Games, gameID)
+		}
+	}
+
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("away set")
+}
+
+// away sets the away window of the user in the game. It tells false without
+// an error when the engine refuses the window, like when the game is over or
+// not a blitz game.
+func (h *handler) away(gameID string, u yahtzee.User, until time.Time) (bool, error) {
+	unlocker, err := h.store.Lock(gameID)
+	if err != nil {
+		return false, err
+	}
+	defer unlocker()
+
+	g, err := h.store.Load(gameID)
+	if errors.Is(err, store.ErrNotExists) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	events, err := engine.Away(&g, u, until, h.now())
+	if err != nil {
+		return false, nil
+	}
+
+	if err := h.store.Save(gameID, g); err != nil {
+		return false, err
+	}
+	h.schedule(gameID, &g)
+
+	h.emit(gameID, &u, &g, "", events)
+
+	return true, nil
+}
+
+// joined records that the user plays in the game.
+func (h *handler) joined(u yahtzee.User, gameID string) {
+	if h.userGames == nil {
+		return
+	}
+	if err := h.userGames.AddGame(u, gameID); err != nil {
+		log.Printf("user games: %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// countdownMarks are the times left on the shot clock when the players are
+// sent a countdown event.
+var countdownMarks = []time.Duration{30 * time.Second, 10 * time.Second}
+
+// WithClock sets the clock the shot clocks of the blitz games are measured
+// with. time.Now is used by default.
+func WithClock(now func() time.Time) Option {
+	return func(h *handler) {
+		h.now = now
+	}
+}
+
+// WithClocks sets where the deadlines of the running shot clocks are kept.
+// The clocks are started again from it when the handler is created, without
+// it they stop on a restart until the next move.
+func WithClocks(c store.Clocks) Option {
+	return func(h *handler) {
+		h.clocks = c
+	}
+}
+
+// shotClocks keeps the timers of the blitz games sending the countdown and
+// ending the turn when the time of the decision runs out.
+type shotClocks struct {
+	mu     sync.Mutex
+	timers map[string][]*time.Timer
+}
+
+func newShotClocks() *shotClocks {
+	return &shotClocks{
+		timers: map[string][]*time.Timer{},
+	}
+}
+
+// schedule starts the timers of the current decision of the game, replacing
+// the previous ones. The timers of a game without a running clock are
+// stopped.
+func (h *handler) schedule(gameID string, g *yahtzee.Game) {
+	c := h.shotClocks
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, t := range c.timers[gameID] {
+		t.Stop()
+	}
+	delete(c.timers, gameID)
+
+	if g.HasFeature(yahtzee.Blitz) && h.clocks != nil {
+		if err := h.clocks.SetClock(gameID, g.Deadline); err != nil {
+			log.Printf("clocks: %v", err)
+		}
+	}
+
+	deadline, ok := engine.DeadlineTime(g)
+	if !g.HasFeature(yahtzee.Blitz) || !ok {
+		return
+	}
+
+	current, left := g.Deadline, deadline.Sub(h.now())
+	timers := []*time.Timer{time.AfterFunc(left, func() {
+		h.timeout(gameID)
+	})}
+	for _, mark := range countdownMarks {
+		if left <= mark {
+			continue
+		}
+		timers = append(timers, time.AfterFunc(left-mark, func() {
+			h.countdown(gameID, current)
+		}))
+	}
+	c.timers[gameID] = timers
+}
+
+// stopClock stops the timers of the game, and removes its clock from the
+// store.
+func (h *handler) stopClock(gameID string) {
+	c := h.shotClocks
+
+	c.mu.Lock()
+	for _, t := range c.timers[gameID] {
+		t.Stop()
+	}
+	delete(c.timers, gameID)
+	c.mu.Unlock()
+
+	if h.clocks != nil {
+		if err := h.clocks.SetClock(gameID, 0); err != nil {
+			log.Printf("clocks: %v", err)
+		}
+	}
+}
+
+// resumeClocks starts the timers of the clocks kept in the store.
+func (h *handler) resumeClocks() {
+	if h.clocks == nil {
+		return
+	}
+
+	deadlines, err := h.clocks.Clocks()
+	if err != nil {
+		log.Printf("clocks: %v", err)
+		return
+	}
+
+	for gameID := range deadlines {
+		g, err := h.store.Load(gameID)
+		if errors.Is(err, store.ErrNotExists) {
+			h.clocks.SetClock(gameID, 0)
+			continue
+		}
+		if err != nil {
+			log.Printf("resume clock load: %v", err)
+			continue
+		}
+		h.schedule(gameID, &g)
+	}
+}
+
+// expired ends the turn of the game when the move was refused as the shot
+// clock ran out, in case its timer was lost.
+func (h *handler) expired(gameID string, err error) {
+	if err == engine.ErrClockExpired {
+		go h.timeout(gameID)
+	}
+}
+
+// countdown tells the players of the game the time left of the decision
+// ending at `deadline`. It's a no-op when somebody acted in the meantime.
+func (h *handler) countdown(gameID string, deadline int64) {
+	unlocker, err := h.store.Lock(gameID)
+	if err != nil {
+		log.Printf("countdown locking issue: %v", err)
+		return
+	}
+	defer unlocker()
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		log.Printf("countdown load: %v", err)
+		return
+	}
+	if g.Deadline != deadline {
+		return
+	}
+
+	events, err := engine.Countdown(&g, h.now())
+	if err != nil {
+		return
+	}
+
+	h.emit(gameID, nil, &g, "", events)
+}
+
+// timeout ends the turn of the current player of the game when the shot
+// clock ran out. It's a no-op when somebody acted in the meantime.
+func (h *handler) timeout(gameID string) {
+	unlocker, err := h.store.Lock(gameID)
+	if err != nil {
+		log.Printf("timeout locking issue: %v", err)
+		return
+	}
+	defer unlocker()
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		log.Printf("timeout load: %v", err)
+		return
+	}
+
+	events, err := engine.Timeout(&g, h.now())
+	if err == engine.ErrClockRunning {
+		h.schedule(gameID, &g)
+		return
+	}
+	if err != nil {
+		log.Printf("timeout: %v", err)
+		return
+	}
+
+	if err := h.store.Save(gameID, g); err != nil {
+		log.Printf("timeout save: %v", err)
+		return
+	}
+
+	events = h.unlock(&g, events)
+	h.emit(gameID, nil, &g, "", events)
+	h.turnPlayed(gameID, &g)
+	h.schedule(gameID, &g)
+
+	log.Print("timed out")
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+)
+
+// defaultSolverCacheSize is the number of solver results kept by default.
+// Most games share the same few thousand dice states, so it covers them.
+const defaultSolverCacheSize = 4096
+
+// WithSolverCacheSize sets how many results of the hints and probability
+// calculations are kept. Zero disables the cache.
+func WithSolverCacheSize(size int) Option {
+	return func(h *handler) {
+		h.solverCache = newLRU(size)
+	}
+}
+
+// WithProbabilityTables sets the precalculated tables the probabilities are
+// looked up from. The dice states missing from the tables are calculated.
+func WithProbabilityTables(tables ...*engine.Table) Option {
+	return func(h *handler) {
+		h.probabilityTables = append(h.probabilityTables, tables...)
+	}
+}
+
+// lookup returns the probabilities of the dices from the tables of the
+// features and the sides, and false when none of them has it.
+func (h *handler) lookup(
+	features []yahtzee.Feature,
+	sides int,
+	dices []int,
+	rolls int) (map[yahtzee.Category]float64, bool) {
+	h.tablesMu.RLock()
+	defer h.tablesMu.RUnlock()
+
+	for _, t := range h.probabilityTables {
+		if !t.Matches(features) || t.Sides != sides {
+			continue
+		}
+		if res, ok := t.Probabilities(dices, rolls); ok {
+			return res, true
+		}
+	}
+	return nil, false
+}
+
+// lru keeps the most recently used values up to its size.
+type lru struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value interface{}
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// cached returns the value of the key, and false when it's not cached.
+func (c *lru) cached(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry).value, true
+}
+
+// get returns the value of the key, calculating it with `compute` when it's
+// not cached. The calculation runs without holding the lock, so the same key
+// could be calculated more than once at the same time.
+func (c *lru) get(key string, compute func() interface{}) interface{} {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*lruEntry).value
+	}
+	c.mu.Unlock()
+
+	value := compute()
+	if c.size <= 0 {
+		return value
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*lruEntry).value
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+
+	return value
+}
+
+// put replaces the value of the key.
+func (c *lru) put(key string, value interface{}) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*lruEntry).value = value
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// remove drops the key.
+func (c *lru) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+		delete(c.entries, key)
+	}
+}
+
+// solverKey identifies a calculation by the dice multiset, the rolls left and
+// the rule set. The order of the dices and the features doesn't matter.
+func solverKey(
+	kind string,
+	features []yahtzee.Feature,
+	rules *yahtzee.Rules,
+	dices []int,
+	rolls int) string {
+	sortedDices := append([]int{}, dices...)
+	sort.Ints(sortedDices)
+
+	sortedFeatures := append([]yahtzee.Feature{}, features...)
+	sort.Slice(sortedFeatures, func(i, j int) bool { return sortedFeatures[i] < sortedFeatures[j] })
+
+	var r yahtzee.Rules
+	if rules != nil {
+		r = *rules
+	}
+
+	return fmt.Sprintf("%s|%v|%+v|%v|%d", kind, sortedFeatures, r, sortedDices, rolls)
+}
@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// EventFormat is what the events sent to a client have.
+type EventFormat string
+
+// Available event formats
+const (
+	// DeltaEvents have what changed, the default
+	DeltaEvents EventFormat = "delta"
+
+	// FullEvents also have the game after the change in their `Game`
+	FullEvents EventFormat = "full"
+)
+
+// EventEncoding is how the events are sent to a client.
+type EventEncoding string
+
+// Available event encodings
+const (
+	// JSONEvents are sent as JSON in text messages, the default
+	JSONEvents EventEncoding = "json"
+
+	// ProtobufEvents are sent as protobuf in binary messages
+	ProtobufEvents EventEncoding = "protobuf"
+)
+
+// Capabilities are what a client tells about itself in the
+// `Client-Capabilities` header, like "version=2.1.0, events=full,
+// encoding=protobuf". The browsers can't set the headers of the websockets,
+// so they can be sent in the `capabilities` query parameter too. The keys
+// not known by the server are ignored, so the clients can send the ones of
+// newer servers.
+type Capabilities struct {
+	// Version is the version of the client as major.minor.patch, empty when
+	// the client didn't tell
+	Version string
+
+	Events   EventFormat
+	Encoding EventEncoding
+}
+
+func (c *Capabilities) String() string {
+	version := c.Version
+	if version == "" {
+		version = "unknown"
+	}
+	return "version=" + version + ", events=" + string(c.Events) + ", encoding=" + string(c.Encoding)
+}
+
+var errInvalidCapabilities = errors.New("invalid capabilities")
+
+func parseCapabilities(raw string) (*Capabilities, error) {
+	res := &Capabilities{
+		Events:   DeltaEvents,
+		Encoding: JSONEvents,
+	}
+	for _, field := range strings.Split(raw, ",") {
+		parts := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := strings.ToLower(parts[0]), strings.TrimSpace(parts[1])
+		switch key {
+		case "version":
+			if _, ok := parseVersion(value); !ok {
+				return nil, errInvalidCapabilities
+			}
+			res.Version = value
+		case "events":
+			res.Events = EventFormat(value)
+			if res.Events != DeltaEvents && res.Events != FullEvents {
+				return nil, errInvalidCapabilities
+			}
+		case "encoding":
+			res.Encoding = EventEncoding(value)
+			if res.Encoding != JSONEvents && res.Encoding != ProtobufEvents {
+				return nil, errInvalidCapabilities
+			}
+		}
+	}
+	return res, nil
+}
+
+// parseVersion returns the numbers of a major.minor.patch version, the
+// missing ones are zero.
+func parseVersion(v string) ([3]int, bool) {
+	var res [3]int
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return res, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return res, false
+		}
+		res[i] = n
+	}
+	return res, true
+}
+
+// olderVersion tells if the version `a` is older than `b`.
+func olderVersion(a, b string) bool {
+	va, _ := parseVersion(a)
+	vb, _ := parseVersion(b)
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] < vb[i]
+		}
+	}
+	return false
+}
+
+// WithClientVersions sets the versions of the clients the server works
+// with. The clients older than `minimum` are refused with `426 Upgrade
+// Required`; the clients older than `recommended` and the ones not telling
+// their version get the recommended version in the `Client-Upgrade` header.
+// Either can be empty to turn it off.
+func WithClientVersions(minimum, recommended string) Option {
+	return func(h *handler) {
+		h.minClientVersion = minimum
+		h.recommendedClientVersion = recommended
+	}
+}
+
+type capabilitiesKey struct{}
+
+// capabilitiesMiddleware reads the capabilities of the client, and checks
+// its version.
+func (h *handler) capabilitiesMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw := r.Header.Get("Client-Capabilities")
+		if raw == "" {
+			raw = r.URL.Query().Get("capabilities")
+		}
+		c, err := parseCapabilities(raw)
+		if err != nil {
+			writeError(w, r, err, "invalid capabilities", http.StatusBadRequest)
+			return
+		}
+
+		recommended := h.recommendedClientVersion
+		if recommended == "" {
+			recommended = h.minClientVersion
+		}
+		if recommended != "" && (c.Version == "" || olderVersion(c.Version, recommended)) {
+			w.Header().Set("Client-Upgrade", recommended)
+		}
+		// the clients not telling their version can be scripts as well as
+		// old apps, so they are not refused
+		if h.minClientVersion != "" && c.Version != "" && olderVersion(c.Version, h.minClientVersion) {
+			writeError(w, r, nil, "client "+c.Version+" outdated", http.StatusUpgradeRequired)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), capabilitiesKey{}, c)))
+	})
+}
+
+func clientCapabilities(r *http.Request) *Capabilities {
+	if c, ok := r.Context().Value(capabilitiesKey{}).(*Capabilities); ok {
+		return c
+	}
+	return &Capabilities{Events: DeltaEvents, Encoding: JSONEvents}
+}
+
+// FullEvent is an event with the game after it, sent to the clients asking
+// for the full events.
+type FullEvent struct {
+	*event.Event
+	Game *yahtzee.Game
+}
+
+// marshalProto returns the event in the protobuf wire format of
+//
+//	message Event {
+//	  string user = 1;
+//	  string action = 2;
+//	  bytes data = 3;              // JSON
+//	  string hash = 4;
+//	  string applied_action_id = 5;
+//	  bytes game = 6;              // JSON, only in the full events
+//	}
+func marshalProto(e *event.Event, g *yahtzee.Game) ([]byte, error) {
+	var res []byte
+	field := func(number int, value []byte) {
+		if len(value) == 0 {
+			return
+		}
+		var buf [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(buf[:], uint64(number<<3|2))
+		res = append(res, buf[:n]...)
+		n = binary.PutUvarint(buf[:], uint64(len(value)))
+		res = append(res, buf[:n]...)
+		res = append(res, value...)
+	}
+
+	if e.User != nil {
+		field(1, []byte(*e.User))
+	}
+	field(2, []byte(e.Action))
+	if e.Data != nil {
+		data, err := json.Marshal(e.Data)
+		if err != nil {
+			return nil, err
+		}
+		field(3, data)
+	}
+	field(4, []byte(e.Hash))
+	field(5, []byte(e.AppliedActionID))
+	if g != nil {
+		game, err := json.Marshal(g)
+		if err != nil {
+			return nil, err
+		}
+		field(6, game)
+	}
+	return res, nil
+}
+
+// writeEvent sends the event in the format and the encoding of the client.
+func writeEvent(ws *websocket.Conn, e *event.Event, c *Capabilities, load func() *yahtzee.Game) error {
+	var g *yahtzee.Game
+	full := c.Events == FullEvents && load != nil
+	if full {
+		g = load()
+	}
+
+	if c.Encoding == ProtobufEvents {
+		raw, err := marshalProto(e, g)
+		if err != nil {
+			return err
+		}
+		return ws.WriteMessage(websocket.BinaryMessage, raw)
+	}
+	if full {
+		return ws.WriteJSON(&FullEvent{Event: e, Game: g})
+	}
+	return ws.WriteJSON(e)
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/akarasz/yahtzee/metrics"
+)
+
+// capacityRefresh is the time the number of games counted in the store is
+// used for, the games created since are added to it.
+const capacityRefresh = 30 * time.Second
+
+var errServerFull = errors.New("server is full")
+
+// WithCapacity sets the most games kept in the store and the most open
+// websockets. The new games beyond are refused with `503 Service
+// Unavailable`, like the websockets beyond, so a small server stays up
+// instead of running out of memory. Zero is no limit, the default.
+func WithCapacity(games, websockets int) Option {
+	return func(h *handler) {
+		h.capacity = &capacity{
+			maxGames:      games,
+			maxWebsockets: websockets,
+		}
+	}
+}
+
+// capacity counts the games and the websockets against their limits.
+type capacity struct {
+	maxGames      int
+	maxWebsockets int
+
+	mu         sync.Mutex
+	games      int
+	counted    time.Time
+	websockets int
+}
+
+// reserveGame makes room for a new game, errServerFull is returned when
+// there is none. The games in the store are counted again when the count is
+// older than capacityRefresh, so the expired and the deleted games free
+// their room by then.
+func (h *handler) reserveGame() error {
+	c := h.capacity
+	if c.maxGames <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now := h.now(); now.Sub(c.counted) >= capacityRefresh {
+		ids, err := h.store.List()
+		if err != nil {
+			return err
+		}
+		c.games, c.counted = len(ids), now
+	}
+	defer func() { metrics.DefaultCapacity.Games(c.games, c.maxGames) }()
+
+	if c.games >= c.maxGames {
+		return errServerFull
+	}
+	c.games++
+	return nil
+}
+
+// admitGame reserves the room of a new game, and writes the error when it
+// can't.
+func (h *handler) admitGame(w http.ResponseWriter, r *http.Request) bool {
+	err := h.reserveGame()
+	if errors.Is(err, errServerFull) {
+		writeServerFull(w, r, err)
+		return false
+	}
+	if err != nil {
+		writeError(w, r, err, "count games", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// connect counts an opened websocket, it tells false when the limit is
+// reached.
+func (c *capacity) connect() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxWebsockets > 0 && c.websockets >= c.maxWebsockets {
+		return false
+	}
+	c.websockets++
+	metrics.DefaultCapacity.Websockets(c.websockets, c.maxWebsockets)
+	return true
+}
+
+// disconnect counts a closed websocket.
+func (c *capacity) disconnect() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.websockets--
+	metrics.DefaultCapacity.Websockets(c.websockets, c.maxWebsockets)
+}
+
+// writeServerFull asks the client to come back when the counts are
+// refreshed.
+func writeServerFull(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(capacityRefresh/time.Second)))
+	writeError(w, r, err, "server full", http.StatusServiceUnavailable)
+}
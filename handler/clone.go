@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/akarasz/yahtzee/engine"
+)
+
+// Clone copies the game into a sandbox owned by the user, to explore
+// alternate lines of a finished or a running game. The owner plays every turn
+// of the sandbox, and its results are not recorded in the best scores, the
+// achievements, the matches or the daily challenges.
+func (h *handler) Clone(w http.ResponseWriter, r *http.Request) {
+	user, ok := readUser(w, r)
+	if !ok {
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	sandbox, err := engine.Sandbox(&g, user)
+	if err != nil {
+		writeEngineError(w, r, err)
+		return
+	}
+
+	if ok := h.admitGame(w, r); !ok {
+		return
+	}
+	sandboxID, err := h.generateID()
+	if err != nil {
+		writeError(w, r, err, "generate id", http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.Save(sandboxID, sandbox); err != nil {
+		writeError(w, r, err, "create sandbox", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/%s", sandboxID))
+	w.WriteHeader(http.StatusCreated)
+
+	log.Print("game cloned")
+}
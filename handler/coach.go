@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/i18n"
+)
+
+// Recommendation is an advice of the coach with its reasons explained.
+type Recommendation struct {
+	*engine.Advice
+
+	// Explanations has the message of every reason in the language of the
+	// request
+	Explanations []string
+}
+
+func (h *handler) Coach(w http.ResponseWriter, r *http.Request) {
+	user, ok := readUser(w, r)
+	if !ok {
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+
+	unlocker, err := h.store.Lock(gameID)
+	if err != nil {
+		writeError(w, r, err, "locking issue", http.StatusInternalServerError)
+		return
+	}
+	defer unlocker()
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	user = engine.Actor(&g, user)
+
+	var advice []*engine.Advice
+	if ok := h.simulate(w, r, func() { advice, err = engine.Coach(&g, user) }); !ok {
+		return
+	}
+	if err != nil {
+		writeEngineError(w, r, err)
+		return
+	}
+
+	// the advice costs a hint in the games with hints
+	if g.HasFeature(yahtzee.Hints) {
+		events, err := engine.SpendHint(&g, user)
+		if err != nil {
+			writeEngineError(w, r, err)
+			return
+		}
+
+		if err := h.store.Save(gameID, g); err != nil {
+			writeStoreError(w, r, err)
+			return
+		}
+
+		h.emit(gameID, &user, &g, readActionID(r), events)
+	}
+
+	lang := g.Locale
+	if accept := r.Header.Get("Accept-Language"); accept != "" || lang == "" {
+		lang = i18n.Negotiate(accept)
+	}
+
+	res := make([]*Recommendation, len(advice))
+	for i, a := range advice {
+		res[i] = &Recommendation{Advice: a, Explanations: []string{}}
+		for _, reason := range a.Reasons {
+			res[i].Explanations = append(res[i].Explanations, i18n.Message(lang, reason))
+		}
+	}
+
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("Vary", "Accept-Language")
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("coach advice returned")
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// dayLayout is the format of the days of the daily challenges.
+const dayLayout = "2006-01-02"
+
+// WithDailies sets where the games and the results of the daily challenges
+// are kept. Without it the daily challenges are not enabled.
+func WithDailies(d store.Dailies) Option {
+	return func(h *handler) {
+		h.dailies = d
+	}
+}
+
+// WithDailySecret sets the secret the seeds of the daily challenges are
+// derived with. Without it anyone can calculate the rolls of the days to
+// come.
+func WithDailySecret(secret []byte) Option {
+	return func(h *handler) {
+		h.dailySecret = secret
+	}
+}
+
+// today returns the day of the daily challenge, which changes at midnight
+// UTC for every player.
+func (h *handler) today() string {
+	return h.now().UTC().Format(dayLayout)
+}
+
+// Daily starts the solo game of the user for the daily challenge of today,
+// or returns the one already started.
+func (h *handler) Daily(w http.ResponseWriter, r *http.Request) {
+	if h.dailies == nil {
+		writeError(w, r, nil, "daily challenges are not enabled", http.StatusNotFound)
+		return
+	}
+	user, ok := readUser(w, r)
+	if !ok {
+		return
+	}
+	day := h.today()
+
+	// one game a day, even for requests racing each other
+	unlocker, err := h.store.Lock("daily:" + day + ":" + string(user))
+	if err != nil {
+		writeError(w, r, err, "locking issue", http.StatusInternalServerError)
+		return
+	}
+	defer unlocker()
+
+	gameID, err := h.dailies.DailyGame(day, user)
+	if err == nil {
+		w.Header().Set("Location", fmt.Sprintf("/%s", gameID))
+		w.WriteHeader(http.StatusOK)
+		log.Print("daily game returned")
+		return
+	}
+	if err != store.ErrNotExists {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	g := yahtzee.NewGame(yahtzee.Solo, yahtzee.Duplicate)
+	g.Seed = engine.DailySeed(day, h.dailySecret)
+	g.Daily = day
+	g.Creator = user
+	if _, err := engine.AddPlayer(g, user); err != nil {
+		writeEngineError(w, r, err)
+		return
+	}
+
+	if ok := h.admitGame(w, r); !ok {
+		return
+	}
+	gameID, err = h.generateID()
+	if err != nil {
+		writeError(w, r, err, "generate id", http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.Save(gameID, *g); err != nil {
+		writeError(w, r, err, "create game", http.StatusInternalServerError)
+		return
+	}
+	h.joined(user, gameID)
+	if err := h.dailies.StartDaily(day, user, gameID); err != nil {
+		writeError(w, r, err, "start daily", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/%s", gameID))
+	w.WriteHeader(http.StatusCreated)
+
+	log.Print("daily game created")
+}
+
+// DailyResponse is the leaderboard of a daily challenge.
+type DailyResponse struct {
+	Day string
+
+	// Standings has the players who finished the game of the day, in the
+	// order of their ranks
+	Standings []*DailyStanding
+}
+
+// DailyStanding is the place of a player on the leaderboard of the day.
+type DailyStanding struct {
+	// Rank is the place of the player starting from 1, the players with the
+	// same score share it
+	Rank int
+
+	User  yahtzee.User
+	Score int
+}
+
+// Leaderboard returns the final scores of the daily challenge of the `day`
+// query parameter, of today without it.
+func (h *handler) Leaderboard(w http.ResponseWriter, r *http.Request) {
+	if h.dailies == nil {
+		writeError(w, r, nil, "daily challenges are not enabled", http.StatusNotFound)
+		return
+	}
+	day := r.URL.Query().Get("day")
+	if day == "" {
+		day = h.today()
+	} else if _, err := time.Parse(dayLayout, day); err != nil {
+		writeError(w, r, err, "invalid day", http.StatusBadRequest)
+		return
+	}
+
+	scores, err := h.dailies.DailyScores(day)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	if ok := writeJSON(w, r, &DailyResponse{Day: day, Standings: dailyStandings(scores)}); !ok {
+		return
+	}
+
+	log.Print("daily leaderboard returned")
+}
+
+// dailyStandings ranks the users by their scores, the users with the same
+// score are in the order of their names.
+func dailyStandings(scores map[yahtzee.User]int) []*DailyStanding {
+	res := make([]*DailyStanding, 0, len(scores))
+	for u, score := range scores {
+		res = append(res, &DailyStanding{User: u, Score: score})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		return res[i].User < res[j].User
+	})
+	for i, s := range res {
+		s.Rank = i + 1
+		if i > 0 && s.Score == res[i-1].Score {
+			s.Rank = res[i-1].Rank
+		}
+	}
+	return res
+}
+
+// dailyPlayed records the final score of the player when the game of a daily
+// challenge is over.
+func (h *handler) dailyPlayed(g *yahtzee.Game) {
+	if h.dailies == nil {
+		return
+	}
+
+	p := g.Players[0]
+	if err := h.dailies.RecordDaily(g.Daily, p.User, engine.GameScorer(g).Total(p)); err != nil {
+		log.Printf("record daily score: %v", err)
+	}
+}
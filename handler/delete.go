@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"github.com/akarasz/yahtzee/event"
+)
+
+// deleteGame deletes the game from the store while it's locked, and drops
+// what the server keeps for it: the clients of its events and its spectators
+// are unsubscribed, its shot clock is stopped and its scoreboard is
+// forgotten. Every path deleting a game goes through it, so the websockets
+// of the deleted games don't stay open.
+func (h *handler) deleteGame(gameID string) error {
+	unlock, err := h.store.Lock(gameID)
+	if err != nil {
+		return err
+	}
+	if _, err := h.store.Load(gameID); err != nil {
+		unlock()
+		return err
+	}
+	err = h.store.Delete(gameID)
+	unlock()
+	if err != nil {
+		return err
+	}
+
+	h.stopClock(gameID)
+	h.scoreboards.remove(gameID)
+	return event.Close(h.subscriber, gameID)
+}
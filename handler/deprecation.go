@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Deprecation is a legacy form of an endpoint, which keeps working until its
+// sunset.
+type Deprecation struct {
+	Method string
+
+	// Path is the route of the endpoint, like "/{gameID}/score"
+	Path string
+
+	// Form tells the deprecated form of the requests
+	Form string
+
+	// Replacement tells what the clients should send instead
+	Replacement string
+
+	// Deprecated is when the form was deprecated
+	Deprecated time.Time
+
+	// Sunset is when the form is removed
+	Sunset time.Time
+
+	// legacy tells if the request has the deprecated form
+	legacy func(*http.Request) bool
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func rawCategory(r *http.Request) bool {
+	return !jsonBody(r)
+}
+
+func featuresQuery(r *http.Request) bool {
+	return r.URL.Query().Get("features") != ""
+}
+
+// deprecations are the legacy forms of the endpoints. A form is removed from
+// the list with its code at its sunset.
+var deprecations = []*Deprecation{
+	{
+		Method:      "POST",
+		Path:        "/",
+		Form:        "the features in the `features` query parameter",
+		Replacement: "the `features` of the JSON body",
+		Deprecated:  date(2026, time.October, 16),
+		Sunset:      date(2027, time.April, 16),
+		legacy:      featuresQuery,
+	},
+	{
+		Method:      "POST",
+		Path:        "/{gameID}/score",
+		Form:        "the category as the raw body",
+		Replacement: "a JSON body with the `category`",
+		Deprecated:  date(2026, time.October, 16),
+		Sunset:      date(2027, time.April, 16),
+		legacy:      rawCategory,
+	},
+	{
+		Method:      "POST",
+		Path:        "/{gameID}/scratch",
+		Form:        "the category as the raw body",
+		Replacement: "a JSON body with the `category`",
+		Deprecated:  date(2026, time.October, 16),
+		Sunset:      date(2027, time.April, 16),
+		legacy:      rawCategory,
+	},
+	{
+		Method:      "POST",
+		Path:        "/{gameID}/announce",
+		Form:        "the category as the raw body",
+		Replacement: "a JSON body with the `category`",
+		Deprecated:  date(2026, time.October, 16),
+		Sunset:      date(2027, time.April, 16),
+		legacy:      rawCategory,
+	},
+}
+
+// deprecation returns the deprecation of the route with the form of the
+// request, or nil when the request has no deprecated form.
+func deprecation(r *http.Request) *Deprecation {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return nil
+	}
+	path, err := route.GetPathTemplate()
+	if err != nil {
+		return nil
+	}
+	for _, d := range deprecations {
+		if d.Method == r.Method && d.Path == path && d.legacy(r) {
+			return d
+		}
+	}
+	return nil
+}
+
+// deprecationMiddleware marks the responses of the requests with a
+// deprecated form with the `Deprecation` (RFC 9745) and the `Sunset`
+// (RFC 8594) headers, linking the list of the deprecations.
+func deprecationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d := deprecation(r); d != nil {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(d.Deprecated.Unix(), 10))
+			w.Header().Set("Sunset", d.Sunset.Format(http.TimeFormat))
+			w.Header().Set("Link", `</deprecations>; rel="deprecation"; type="application/json"`)
+			log.Printf("deprecated %s %s: %s", d.Method, d.Path, d.Form)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Deprecations lists the deprecated forms of the endpoints, the closest
+// sunset first.
+func (h *handler) Deprecations(w http.ResponseWriter, r *http.Request) {
+	res := make([]*Deprecation, len(deprecations))
+	copy(res, deprecations)
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Sunset.Before(res[j].Sunset)
+	})
+
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("deprecations returned")
+}
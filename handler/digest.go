@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/event"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// WithActivities sets where the logs of the games and the visits of the
+// users are kept. Without it the digests are not enabled, it also needs the
+// games of the users.
+func WithActivities(a store.Activities) Option {
+	return func(h *handler) {
+		h.activities = a
+	}
+}
+
+// DigestResponse has what happened in the games of a user since the last
+// visit.
+type DigestResponse struct {
+	User yahtzee.User
+
+	// Since is the time of the last visit in unix milliseconds, zero on the
+	// first visit
+	Since int64
+
+	// Games has the games with something happened since the last visit, in
+	// the order they were joined
+	Games []*GameDigest
+}
+
+// GameDigest is what happened in a game since the last visit.
+type GameDigest struct {
+	Game string
+
+	// Turns is the number of turns taken
+	Turns int
+
+	// Finished tells if the game ended
+	Finished bool
+
+	// Winners and Totals are the results of the finished game
+	Winners []yahtzee.User
+	Totals  map[yahtzee.User]int
+
+	// YourTurn tells if the game waits for the user
+	YourTurn bool
+}
+
+// Digest returns what happened in the games of the user since the last
+// visit, and records this visit. With the `since` query parameter in unix
+// milliseconds it returns what happened since then, without recording the
+// visit.
+func (h *handler) Digest(w http.ResponseWriter, r *http.Request) {
+	if h.activities == nil || h.userGames == nil {
+		writeError(w, r, nil, "digests are not enabled", http.StatusNotFound)
+		return
+	}
+	user, ok := readUser(w, r)
+	if !ok {
+		return
+	}
+	if user != yahtzee.User(mux.Vars(r)["user"]) {
+		writeError(w, r, nil, "another user", http.StatusForbidden)
+		return
+	}
+
+	var since int64
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		var err error
+		if since, err = strconv.ParseInt(raw, 10, 64); err != nil || since < 0 {
+			writeError(w, r, err, "invalid since", http.StatusBadRequest)
+			return
+		}
+	} else {
+		var err error
+		if since, err = h.activities.Visit(user, unixMillis(h.now())); err != nil {
+			writeStoreError(w, r, err)
+			return
+		}
+	}
+
+	gameIDs, err := h.userGames.Games(user)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	res := &DigestResponse{User: user, Since: since, Games: []*GameDigest{}}
+	for _, gameID := range gameIDs {
+		d, err := h.gameDigest(gameID, user, since)
+		if err != nil {
+			writeStoreError(w, r, err)
+			return
+		}
+		if d != nil {
+			res.Games = append(res.Games, d)
+		}
+	}
+
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("digest returned")
+}
+
+// gameDigest returns what happened in the game after `since`, nil when
+// nothing did.
+func (h *handler) gameDigest(gameID string, u yahtzee.User, since int64) (*GameDigest, error) {
+	activities, err := h.activities.Activities(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &GameDigest{Game: gameID}
+	for _, a := range activities {
+		if a.At <= since {
+			continue
+		}
+		switch event.Type(a.Action) {
+		case event.Score, event.Scratch, event.Timeout:
+			res.Turns++
+		case event.GameOver:
+			res.Finished = true
+			res.Winners = a.Winners
+			res.Totals = a.Totals
+		}
+	}
+	if res.Turns == 0 && !res.Finished {
+		return nil, nil
+	}
+
+	if !res.Finished {
+		g, err := h.store.Load(gameID)
+		if err != nil {
+			return nil, err
+		}
+		res.YourTurn = !engine.IsOver(&g) && g.Players[g.CurrentPlayer].User == u
+	}
+	return res, nil
+}
+
+// logActivity appends the turns and the results among the events to the log
+// of the game.
+func (h *handler) logActivity(gameID string, u *yahtzee.User, events []*engine.Event) {
+	if h.activities == nil {
+		return
+	}
+
+	for _, e := range events {
+		a := yahtzee.Activity{
+			At:     unixMillis(h.now()),
+			Action: string(e.Action),
+		}
+		switch e.Action {
+		case event.Score, event.Scratch:
+			if u != nil {
+				a.User = *u
+			}
+			g := e.Data.(*yahtzee.Game)
+			a.Dices = make([]int, len(g.Dices))
+			for i, d := range g.Dices {
+				a.Dices[i] = d.Value
+			}
+			a.Totals = engine.RunningTotals(g)
+		case event.Timeout:
+			if u != nil {
+				a.User = *u
+			}
+		case event.GameOver:
+			res := e.Data.(*engine.GameOverResult)
+			a.Winners = res.Winners
+			a.Totals = res.Totals
+		case event.Annotation:
+			if u != nil {
+				a.User = *u
+			}
+			annotation := e.Data.(*Annotation)
+			a.At = annotation.At
+			a.Caption = annotation.Caption
+			a.Ruling = annotation.Ruling
+		default:
+			continue
+		}
+
+		if err := h.activities.AddActivity(gameID, a); err != nil {
+			log.Printf("log activity: %v", err)
+		}
+	}
+}
+
+func unixMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
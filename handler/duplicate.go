@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// TablesResponse has the standings of the players of every table playing the
+// same rolls.
+type TablesResponse struct {
+	// Tables has the IDs of the tables, the first one is where they were
+	// started from
+	Tables []string
+
+	Standings []*engine.Standing
+}
+
+func (h *handler) AddTable(w http.ResponseWriter, r *http.Request) {
+	user, ok := readUser(w, r)
+	if !ok {
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+
+	unlocker, err := h.store.Lock(gameID)
+	if err != nil {
+		writeError(w, r, err, "locking issue", http.StatusInternalServerError)
+		return
+	}
+	defer unlocker()
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	if !g.HasFeature(yahtzee.Duplicate) {
+		writeEngineError(w, r, engine.ErrNotDuplicateGame)
+		return
+	}
+	if !isPlayer(&g, user) {
+		writeError(w, r, nil, "only the players add tables", http.StatusForbidden)
+		return
+	}
+
+	// the tables are listed in the game they were started from
+	sourceID, source := gameID, g
+	if g.Source != "" {
+		sourceID = g.Source
+		sourceUnlocker, err := h.store.Lock(sourceID)
+		if err != nil {
+			writeError(w, r, err, "locking issue", http.StatusInternalServerError)
+			return
+		}
+		defer sourceUnlocker()
+
+		source, err = h.store.Load(sourceID)
+		if err != nil {
+			writeStoreError(w, r, err)
+			return
+		}
+	}
+
+	table := newTable(&source)
+	table.Source = sourceID
+	if ok := h.admitGame(w, r); !ok {
+		return
+	}
+	tableID, err := h.generateID()
+	if err != nil {
+		writeError(w, r, err, "generate id", http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.Save(tableID, *table); err != nil {
+		writeError(w, r, err, "create table", http.StatusInternalServerError)
+		return
+	}
+
+	source.Tables = append(source.Tables, tableID)
+	if err := h.store.Save(sourceID, source); err != nil {
+		writeError(w, r, err, "save game", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/%s", tableID))
+	w.WriteHeader(http.StatusCreated)
+
+	log.Print("table created")
+}
+
+func (h *handler) Tables(w http.ResponseWriter, r *http.Request) {
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	// every table is compared, whichever of them is asked
+	sourceID, source := gameID, g
+	if g.Source != "" {
+		sourceID = g.Source
+		source, err = h.store.Load(sourceID)
+		if err != nil {
+			writeStoreError(w, r, err)
+			return
+		}
+	}
+
+	ids := []string{sourceID}
+	tables := map[string]*yahtzee.Game{sourceID: &source}
+	for _, id := range source.Tables {
+		table, err := h.store.Load(id)
+		if errors.Is(err, store.ErrNotExists) {
+			continue
+		}
+		if err != nil {
+			writeStoreError(w, r, err)
+			return
+		}
+		ids = append(ids, id)
+		tables[id] = &table
+	}
+
+	standings, err := engine.CompareTables(ids, tables)
+	if err != nil {
+		writeEngineError(w, r, err)
+		return
+	}
+
+	res := &TablesResponse{Tables: ids, Standings: standings}
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("tables returned")
+}
+
+// newTable returns an empty game playing the same rolls with the same
+// settings as `g`.
+func newTable(g *yahtzee.Game) *yahtzee.Game {
+	res := yahtzee.NewGame(g.Features...)
+	res.SetDices(len(g.Dices), g.Sides)
+	res.Rules = g.Rules
+	res.Rounds = g.Rounds
+	res.Locale = g.Locale
+	res.TimeZone = g.TimeZone
+	res.Seed = g.Seed
+	return res
+}
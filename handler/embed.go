@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/i18n"
+)
+
+// defaultScoreboardCacheSize is the number of games with their scoreboards
+// kept for the embeds.
+const defaultScoreboardCacheSize = 1024
+
+// The refresh intervals of the embeds in seconds.
+const (
+	defaultEmbedRefresh = 5
+	minEmbedRefresh     = 2
+	maxEmbedRefresh     = 300
+)
+
+var errInvalidRefresh = errors.New("invalid refresh")
+
+// scoreboard is the summary of a game shown by its embeds, kept up to date
+// with the events of the game so the embeds don't load the game.
+type scoreboard struct {
+	Locale  string
+	Round   int
+	Rounds  int
+	Over    bool
+	Current yahtzee.User
+	Players []*scoreboardRow
+}
+
+type scoreboardRow struct {
+	User   yahtzee.User
+	Total  int
+	Winner bool
+}
+
+func newScoreboard(g *yahtzee.Game) *scoreboard {
+	res := &scoreboard{
+		Locale: g.Locale,
+		Round:  g.Round + 1,
+		Rounds: engine.Rounds(g),
+		Over:   engine.IsOver(g),
+	}
+	if res.Round > res.Rounds {
+		res.Round = res.Rounds
+	}
+	if len(g.Players) > 0 && !res.Over {
+		res.Current = g.Players[g.CurrentPlayer].User
+	}
+
+	winners := map[yahtzee.User]bool{}
+	if res.Over {
+		for _, u := range engine.Winners(g) {
+			winners[u] = true
+		}
+	}
+	scorer := engine.GameScorer(g)
+	for i, p := range g.Players {
+		res.Players = append(res.Players, &scoreboardRow{
+			User:   p.User,
+			Total:  scorer.Total(engine.SheetOwner(g, i)),
+			Winner: winners[p.User],
+		})
+	}
+	return res
+}
+
+// summarize updates the scoreboard of the game after its change. The
+// scoreboards of the games with a spectator delay are updated after the
+// delay, as the embeds are public.
+func (h *handler) summarize(gameID string, g *yahtzee.Game) {
+	s := newScoreboard(g)
+	if delay := spectatorDelay(g); delay > 0 {
+		time.AfterFunc(delay, func() { h.scoreboards.put(gameID, s) })
+		return
+	}
+	h.scoreboards.put(gameID, s)
+}
+
+// scoreboard returns the scoreboard of the game, it's made from the game in
+// the store when the game didn't change since the start of the server.
+func (h *handler) scoreboard(gameID string) (*scoreboard, error) {
+	if s, ok := h.scoreboards.cached(gameID); ok {
+		return s.(*scoreboard), nil
+	}
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		return nil, err
+	}
+	s := newScoreboard(&g)
+	h.scoreboards.put(gameID, s)
+	return s, nil
+}
+
+var embedTemplate = template.Must(template.New("embed").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<title>Yahtzee</title>
+<style>
+body { margin: 0; padding: 8px; background: transparent; color: #222; font: 16px/1.4 sans-serif; }
+body.dark { color: #eee; }
+table { border-collapse: collapse; width: 100%; }
+td { padding: 2px 6px; }
+td.total { text-align: right; font-variant-numeric: tabular-nums; }
+tr.current, tr.winner { font-weight: bold; }
+</style>
+</head>
+<body{{if .Dark}} class="dark"{{end}}>
+<p>{{.Status}}</p>
+<table>
+{{- range .Scoreboard.Players}}
+<tr class="{{if .Winner}}winner{{else if eq .User $.Scoreboard.Current}}current{{end}}"><td>{{if .Winner}}&#9733; {{else if eq .User $.Scoreboard.Current}}&#9656; {{end}}{{.User}}</td><td class="total">{{.Total}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// Embed serves the scoreboard of the game as a small HTML page refreshing
+// itself, to put into blogs or the browser sources of streaming software in
+// an iframe. The `refresh` query parameter sets the seconds between the
+// refreshes, `theme=dark` sets light text for dark backgrounds. The page is
+// made from the scoreboard kept with the events of the game, not from the
+// game itself.
+func (h *handler) Embed(w http.ResponseWriter, r *http.Request) {
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+	refresh := defaultEmbedRefresh
+	if raw := r.URL.Query().Get("refresh"); raw != "" {
+		var err error
+		refresh, err = strconv.Atoi(raw)
+		if err != nil || refresh < minEmbedRefresh || refresh > maxEmbedRefresh {
+			writeError(w, r, errInvalidRefresh, "invalid refresh", http.StatusBadRequest)
+			return
+		}
+	}
+
+	s, err := h.scoreboard(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	lang := s.Locale
+	if accept := r.Header.Get("Accept-Language"); accept != "" || lang == "" {
+		lang = i18n.Negotiate(accept)
+	}
+	status := i18n.Message(lang, "embed-round") + " " + strconv.Itoa(s.Round) + "/" + strconv.Itoa(s.Rounds)
+	switch {
+	case s.Over:
+		status = i18n.Message(lang, "game-over")
+	case len(s.Players) == 0:
+		status = i18n.Message(lang, "embed-waiting")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	err = embedTemplate.Execute(w, map[string]interface{}{
+		"Lang":       lang,
+		"Refresh":    refresh,
+		"Dark":       r.URL.Query().Get("theme") == "dark",
+		"Status":     status,
+		"Scoreboard": s,
+	})
+	if err != nil {
+		log.Printf("embed %s: %v", gameID, err)
+		return
+	}
+
+	log.Print("embed returned")
+}
@@ -7,13 +7,19 @@ import (
 	"io/ioutil"
 	"log"
 	"math/rand"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
 	"github.com/akarasz/yahtzee/event"
+	"github.com/akarasz/yahtzee/i18n"
+	"github.com/akarasz/yahtzee/id"
+	"github.com/akarasz/yahtzee/metrics"
 	"github.com/akarasz/yahtzee/store"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -23,36 +29,198 @@ type handler struct {
 	store      store.Store
 	emitter    event.Emitter
 	subscriber event.Subscriber
+	ids        id.Generator
+	bests      store.BestScores
+	matches    store.Matches
+	userGames  store.UserGames
+	notes      store.Notes
+
+	achievements store.Achievements
+	clocks       store.Clocks
+	dailies      store.Dailies
+	activities   store.Activities
+
+	publicURL        string
+	inviteSecret     []byte
+	dailySecret      []byte
+	annotationTokens []string
+	smsToken         string
+	adminToken       string
+	replayer         store.Replayer
+	features         FeaturePolicy
+	healthChecks     map[string]store.Healther
+
+	minClientVersion         string
+	recommendedClientVersion string
+
+	solverCache       *lru
+	probabilityTables []*engine.Table
+	tablesMu          sync.RWMutex
+	simulations       *pool
+	simulationBudget  time.Duration
+	now               func() time.Time
+	shotClocks        *shotClocks
+	spectators        *spectators
+	scoreboards       *lru
+	capacity          *capacity
+
+	warmUp    *WarmUp
+	warmed    chan struct{}
+	warmUpErr error
 }
 
-func New(s store.Store, e event.Emitter, sub event.Subscriber) http.Handler {
-	h := &handler{s, e, sub}
+// Option configures the handler.
+type Option func(*handler)
+
+// WithIDGenerator sets the generator of the new game IDs. Random four
+// character IDs are used by default.
+func WithIDGenerator(g id.Generator) Option {
+	return func(h *handler) {
+		h.ids = g
+	}
+}
+
+// WithBestScores sets where the personal bests of the solo games are kept.
+// Without it the personal bests are not tracked.
+func WithBestScores(b store.BestScores) Option {
+	return func(h *handler) {
+		h.bests = b
+	}
+}
+
+func New(s store.Store, e event.Emitter, sub event.Subscriber, opts ...Option) http.Handler {
+	h := &handler{
+		store:      s,
+		emitter:    e,
+		subscriber: sub,
+		ids:        id.Random(4),
+
+		solverCache:      newLRU(defaultSolverCacheSize),
+		simulations:      defaultPool(),
+		simulationBudget: defaultSimulationBudget,
+		now:              time.Now,
+		shotClocks:       newShotClocks(),
+		spectators:       newSpectators(),
+		scoreboards:      newLRU(defaultScoreboardCacheSize),
+		capacity:         &capacity{},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.resumeClocks()
+	if h.warmUp != nil {
+		h.warmed = make(chan struct{})
+		go h.runWarmUp()
+	}
 
 	r := mux.NewRouter()
 	r.Use(corsMiddleware)
+	r.Use(shapeMiddleware)
+	r.Use(h.capabilitiesMiddleware)
+	r.Use(deprecationMiddleware)
 	r.HandleFunc("/", h.Create).
 		Methods("POST", "OPTIONS")
 	r.HandleFunc("/score", h.Hints).
 		Methods("GET", "OPTIONS")
+	r.HandleFunc("/features", h.Features).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/categories", h.Categories).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/users/{user}/best", h.Best).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/users/{user}/away", h.Away).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/users/{user}/achievements", h.Achievements).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/users/{user}/digest", h.Digest).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/sms", h.SMS).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/ussd", h.USSD).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/import", h.Import).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/validate-sheet", h.ValidateSheet).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/calculate", h.Calculate).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/probabilities", h.Probabilities).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/daily", h.Daily).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/daily", h.Leaderboard).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/matches", h.CreateMatch).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/matches/{matchID}", h.GetMatch).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/matches/{matchID}/ws", h.MatchWS)
+	r.HandleFunc("/admin/backup", h.Backup).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/admin/restore", h.Restore).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/admin/games/{gameID}", h.DeleteGame).
+		Methods("DELETE", "OPTIONS")
+	r.HandleFunc("/admin/{gameID}/state-at", h.StateAt).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/deprecations", h.Deprecations).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/readyz", h.Ready).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/games", h.Games).
+		Methods("GET", "OPTIONS")
 	r.HandleFunc("/{gameID}", h.Get).
 		Methods("GET", "OPTIONS")
 	r.HandleFunc("/{gameID}/join", h.AddPlayer).
 		Methods("POST", "OPTIONS")
+	r.HandleFunc("/{gameID}/join-info", h.JoinInfo).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/{gameID}/clone", h.Clone).
+		Methods("POST", "OPTIONS")
 	r.HandleFunc("/{gameID}/roll", h.Roll).
 		Methods("POST", "OPTIONS")
 	r.HandleFunc("/{gameID}/lock/{dice}", h.Lock).
 		Methods("POST", "OPTIONS")
 	r.HandleFunc("/{gameID}/score", h.Score).
 		Methods("POST", "OPTIONS")
+	r.HandleFunc("/{gameID}/scratch", h.Scratch).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/{gameID}/turn", h.Turn).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/{gameID}/announce", h.Announce).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/{gameID}/extra-roll", h.ExtraRoll).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/{gameID}/notes", h.GetNotes).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/{gameID}/notes", h.PutNotes).
+		Methods("PUT", "OPTIONS")
+	r.HandleFunc("/{gameID}/coach", h.Coach).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/{gameID}/highlights", h.Highlights).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/{gameID}/log", h.Log).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/{gameID}/annotations", h.Annotate).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/{gameID}/win-probability", h.WinProbability).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/{gameID}/tables", h.Tables).
+		Methods("GET", "OPTIONS")
+	r.HandleFunc("/{gameID}/tables", h.AddTable).
+		Methods("POST", "OPTIONS")
+	r.HandleFunc("/{gameID}/embed", h.Embed).
+		Methods("GET", "OPTIONS")
 	r.HandleFunc("/{gameID}/ws", h.WS)
+	r.HandleFunc("/{gameID}/spectate", h.SpectateWS)
 	return r
 }
 
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Headers", "Authorization")
-		w.Header().Set("Access-Control-Expose-Headers", "Location")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Action-ID, Accept-Language, Annotation-Token, Admin-Token, Client-Capabilities")
+		w.Header().Set("Access-Control-Expose-Headers", "Location, Game-Hash, Applied-Action-ID, Hint-Ranking, Client-Upgrade, Deprecation, Sunset, Link")
 
 		if r.Method == "OPTIONS" {
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS")
@@ -63,25 +231,159 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func generateID() string {
-	const (
-		idCharset = "abcdefghijklmnopqrstvwxyz0123456789"
-		length    = 4
-	)
+// maxIDAttempts is the number of generated IDs tried before giving up on
+// finding an unused one.
+const maxIDAttempts = 10
+
+// generateID returns an ID not used by any game in the store.
+func (h *handler) generateID() (string, error) {
+	return h.unusedID(func(id string) error {
+		_, err := h.store.Load(id)
+		return err
+	})
+}
+
+// unusedID returns a generated ID `load` doesn't find.
+func (h *handler) unusedID(load func(id string) error) (string, error) {
+	for i := 0; i < maxIDAttempts; i++ {
+		id := h.ids.Generate()
 
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = idCharset[rand.Intn(len(idCharset))]
+		err := load(id)
+		if errors.Is(err, store.ErrNotExists) {
+			return id, nil
+		} else if err != nil {
+			return "", err
+		}
 	}
-	return string(b)
+	return "", errors.New("no unused id found")
+}
+
+// CreateRequest is the optional body of the create game request.
+type CreateRequest struct {
+	// Dice is the number of dices
+	Dice int
+
+	// Sides is the number of sides of the dices
+	Sides int
+
+	// Rules has the house rules of the game
+	Rules *yahtzee.Rules
+
+	// Rounds is the number of rounds of a shortened game
+	Rounds int
+
+	// Locale is the language of the game
+	Locale string
+
+	// TimeZone is the IANA name of the time zone of the game
+	TimeZone string
+
+	// Features are the features of the game, replacing the `features` query
+	// parameter
+	Features []yahtzee.Feature
+}
+
+// CategoryRequest is the JSON body of the endpoints taking a category,
+// replacing the category as the raw body.
+type CategoryRequest struct {
+	Category yahtzee.Category
+}
+
+const (
+	maxDices        = 10
+	minSides        = 2
+	maxSides        = 20
+	maxRollsPerTurn = 10
+	maxExtraRolls   = 10
+	maxHints        = 10
+	maxShotClock    = 300
+	maxPayout       = 1000
+)
+
+// newGame returns a new game with the features and the settings of the
+// create request.
+func newGame(features []yahtzee.Feature, req *CreateRequest) *yahtzee.Game {
+	g := yahtzee.NewGame(features...)
+	if req.Dice != 0 || req.Sides != 0 {
+		dice, sides := len(g.Dices), g.Sides
+		if req.Dice != 0 {
+			dice = req.Dice
+		}
+		if req.Sides != 0 {
+			sides = req.Sides
+		}
+		g.SetDices(dice, sides)
+	}
+	g.Rules = req.Rules
+	g.Rounds = req.Rounds
+	g.Locale = req.Locale
+	g.TimeZone = req.TimeZone
+	return g
 }
 
 func (h *handler) Create(w http.ResponseWriter, r *http.Request) {
-	gameID := generateID()
-	if err := h.store.Save(gameID, *yahtzee.NewGame()); err != nil {
+	features, ok := readFeatures(w, r)
+	if !ok {
+		return
+	}
+	req, ok := readCreateRequest(w, r)
+	if !ok {
+		return
+	}
+	for _, f := range req.Features {
+		if !knownFeature(f) {
+			writeError(w, r, nil, "unknown feature", http.StatusBadRequest)
+			return
+		}
+	}
+	features, ok = h.checkPolicy(w, r, append(features, req.Features...))
+	if !ok {
+		return
+	}
+	if ok := checkFeatures(w, r, features); !ok {
+		return
+	}
+
+	g := newGame(features, req)
+	if ok := checkSheet(w, r, g); !ok {
+		return
+	}
+
+	if g.HasFeature(yahtzee.Duplicate) {
+		g.Seed = rand.Int63()
+	}
+
+	if user, _, ok := r.BasicAuth(); ok {
+		g.Creator = yahtzee.User(user)
+	}
+
+	if g.HasFeature(yahtzee.Solo) {
+		user, ok := readUser(w, r)
+		if !ok {
+			return
+		}
+		if _, err := engine.AddPlayer(g, user); err != nil {
+			writeEngineError(w, r, err)
+			return
+		}
+	}
+
+	if ok := h.admitGame(w, r); !ok {
+		return
+	}
+	gameID, err := h.generateID()
+	if err != nil {
+		writeError(w, r, err, "generate id", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.store.Save(gameID, *g); err != nil {
 		writeError(w, r, err, "create game", http.StatusInternalServerError)
 		return
 	}
+	for _, p := range g.Players {
+		h.joined(p.User, gameID)
+	}
 
 	w.Header().Set("Location", fmt.Sprintf("/%s", gameID))
 	w.WriteHeader(http.StatusCreated)
@@ -90,26 +392,359 @@ func (h *handler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) Hints(w http.ResponseWriter, r *http.Request) {
-	dices, ok := readDices(w, r)
+	features, ok := readFeatures(w, r)
+	if !ok {
+		return
+	}
+	sides, ok := readSides(w, r)
+	if !ok {
+		return
+	}
+	dices, ok := readDices(w, r, "dices", yahtzee.DiceCount(features...), sides)
+	if !ok {
+		return
+	}
+
+	scorer := engine.NewScorer(features...)
+	res := h.solverCache.get(solverKey("hints", features, nil, dices, 0), func() interface{} {
+		return scores(scorer, dices)
+	})
+
+	ranking := scorer.Rank(res.(map[yahtzee.Category]int))
+	names := make([]string, len(ranking))
+	for i, c := range ranking {
+		names[i] = string(c)
+	}
+	w.Header().Set("Hint-Ranking", strings.Join(names, ","))
+
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("hints returned")
+}
+
+// CalculateRequest is the body of the score calculator request.
+type CalculateRequest struct {
+	// Dices has the face values to score
+	Dices []int
+
+	// Sides is the number of the sides of the dices, 6 when it's 0
+	Sides int
+
+	// Rules has the optional house rules
+	Rules *yahtzee.Rules
+}
+
+func (h *handler) Calculate(w http.ResponseWriter, r *http.Request) {
+	features, ok := readFeatures(w, r)
 	if !ok {
 		return
 	}
+	req := &CalculateRequest{}
+	if ok := readBody(w, r, req); !ok {
+		return
+	}
+	if ok := checkRules(w, r, req.Rules); !ok {
+		return
+	}
+	if len(req.Dices) != yahtzee.DiceCount(features...) {
+		writeError(w, r, nil, "wrong number of dices", http.StatusBadRequest)
+		return
+	}
+	if req.Sides != 0 && (req.Sides < minSides || req.Sides > maxSides) {
+		writeError(w, r, nil, "invalid number of sides", http.StatusBadRequest)
+		return
+	}
+	sides := req.Sides
+	if sides == 0 {
+		sides = yahtzee.NumberOfSides
+	}
+	for _, v := range req.Dices {
+		if v < 1 || sides < v {
+			writeError(w, r, nil, "invalid dice", http.StatusBadRequest)
+			return
+		}
+	}
+
+	res := h.solverCache.get(solverKey("hints", features, req.Rules, req.Dices, 0), func() interface{} {
+		return scores(engine.GameScorer(&yahtzee.Game{
+			Features: features,
+			Rules:    req.Rules,
+		}), req.Dices)
+	})
+
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("scores calculated")
+}
 
+// scores returns the score of the dices in every category of the scorer.
+func scores(s *engine.Scorer, dices []int) map[yahtzee.Category]int {
 	res := map[yahtzee.Category]int{}
-	for _, c := range yahtzee.Categories() {
-		score, err := score(c, dices)
+	for c, action := range s.ScoreActions {
+		res[c] = action(dices)
+	}
+	return res
+}
+
+// ValidateSheetRequest is the body of the sheet validation request. It has
+// the settings of the game like CreateRequest and the turns played offline.
+type ValidateSheetRequest struct {
+	CreateRequest
+
+	// Turns has the final dices and the scored box of every round
+	Turns []engine.Turn
+}
+
+// ValidateSheetResponse tells if a sheet played offline is legal and what
+// its correct scores are.
+type ValidateSheetResponse struct {
+	Valid bool
+
+	// Code and Message describe why the sheet is invalid
+	Code    string
+	Message string
+
+	// Turn is the index of the first turn against the rules of an invalid
+	// sheet
+	Turn int
+
+	// Player has the score sheets filled until the first invalid turn
+	Player *yahtzee.Player
+
+	Total int
+}
+
+func (h *handler) ValidateSheet(w http.ResponseWriter, r *http.Request) {
+	features, ok := readFeatures(w, r)
+	if !ok {
+		return
+	}
+	if ok := checkFeatures(w, r, features); !ok {
+		return
+	}
+	req := &ValidateSheetRequest{}
+	if ok := readBody(w, r, req); !ok {
+		return
+	}
+	if ok := checkCreateRequest(w, r, &req.CreateRequest); !ok {
+		return
+	}
+
+	g := newGame(features, &req.CreateRequest)
+	user, _, _ := r.BasicAuth()
+	if _, err := engine.AddPlayer(g, yahtzee.User(user)); err != nil {
+		writeEngineError(w, r, err)
+		return
+	}
+
+	res := &ValidateSheetResponse{
+		Valid:  true,
+		Player: g.Players[0],
+	}
+	if err := engine.PlaySheet(g, req.Turns); err != nil {
+		var turnErr *engine.TurnError
+		if !errors.As(err, &turnErr) {
+			writeEngineError(w, r, err)
+			return
+		}
+
+		lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+		res.Valid = false
+		res.Code = engineErrorCodes[turnErr.Err]
+		res.Message = i18n.Message(lang, res.Code)
+		res.Turn = turnErr.Turn
+		w.Header().Set("Content-Language", lang)
+		w.Header().Set("Vary", "Accept-Language")
+	}
+	res.Total = engine.GameScorer(g).Total(res.Player)
+
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Printf("sheet validated: %v", res.Valid)
+}
+
+// ImportRequest is the body of the import request. It has the settings of the
+// game like CreateRequest and the players with the boxes filled on paper.
+type ImportRequest struct {
+	CreateRequest
+
+	// Players has the players in the order of their turns with their score
+	// sheets
+	Players []*yahtzee.Player
+}
+
+func (h *handler) Import(w http.ResponseWriter, r *http.Request) {
+	features, ok := readFeatures(w, r)
+	if !ok {
+		return
+	}
+	if ok := checkFeatures(w, r, features); !ok {
+		return
+	}
+	req := &ImportRequest{}
+	if ok := readBody(w, r, req); !ok {
+		return
+	}
+	if ok := checkCreateRequest(w, r, &req.CreateRequest); !ok {
+		return
+	}
+
+	g := newGame(features, &req.CreateRequest)
+	if ok := checkSheet(w, r, g); !ok {
+		return
+	}
+	if g.HasFeature(yahtzee.Duplicate) {
+		g.Seed = rand.Int63()
+	}
+	if err := engine.ImportSheets(g, req.Players); err != nil {
+		writeEngineError(w, r, err)
+		return
+	}
+
+	if ok := h.admitGame(w, r); !ok {
+		return
+	}
+	gameID, err := h.generateID()
+	if err != nil {
+		writeError(w, r, err, "generate id", http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.Save(gameID, *g); err != nil {
+		writeError(w, r, err, "import game", http.StatusInternalServerError)
+		return
+	}
+	for _, p := range g.Players {
+		h.joined(p.User, gameID)
+	}
+	h.schedule(gameID, g)
+
+	w.Header().Set("Location", fmt.Sprintf("/%s", gameID))
+	w.WriteHeader(http.StatusCreated)
+
+	log.Print("game imported")
+}
+
+// Features lists the features, but the ones forbidden on the server.
+func (h *handler) Features(w http.ResponseWriter, r *http.Request) {
+	res := []yahtzee.Feature{}
+	for _, f := range yahtzee.Features() {
+		if !hasFeature(h.features.Forbidden, f) {
+			res = append(res, f)
+		}
+	}
+
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("features returned")
+}
+
+// CategoryResponse describes a category of the score sheet.
+type CategoryResponse struct {
+	Name    yahtzee.Category
+	Section yahtzee.Section
+
+	// DisplayNames has the name of the category in every language
+	DisplayNames map[string]string
+
+	// Descriptions has what the category scores in every language, it's
+	// empty for the categories without one
+	Descriptions map[string]string
+
+	// Max is the highest score of the category with the features
+	Max int
+}
+
+func (h *handler) Categories(w http.ResponseWriter, r *http.Request) {
+	features, ok := readFeatures(w, r)
+	if !ok {
+		return
+	}
+
+	scorer := engine.NewScorer(features...)
+	count := yahtzee.DiceCount(features...)
+	res := []*CategoryResponse{}
+	for _, c := range scorer.Categories() {
+		max, err := scorer.MaxScore(c, count)
 		if err != nil {
-			writeError(w, r, err, "", http.StatusInternalServerError)
+			writeEngineError(w, r, err)
 			return
 		}
-		res[c] = score
+
+		category := &CategoryResponse{
+			Name:         c,
+			Section:      scorer.Section(c),
+			DisplayNames: map[string]string{},
+			Descriptions: map[string]string{},
+			Max:          max,
+		}
+		for _, lang := range i18n.Languages() {
+			category.DisplayNames[lang] = string(c)
+			if name, ok := i18n.Lookup(lang, "category-"+string(c)); ok {
+				category.DisplayNames[lang] = name
+			}
+			if description, ok := i18n.Lookup(lang, "category-"+string(c)+"-description"); ok {
+				category.Descriptions[lang] = description
+			}
+		}
+		res = append(res, category)
 	}
 
 	if ok := writeJSON(w, r, res); !ok {
 		return
 	}
 
-	log.Print("hints returned")
+	log.Print("categories returned")
+}
+
+// BestResponse is the personal best of a user.
+type BestResponse struct {
+	User  yahtzee.User
+	Score int
+}
+
+func (h *handler) Best(w http.ResponseWriter, r *http.Request) {
+	user := yahtzee.User(mux.Vars(r)["user"])
+
+	if h.bests == nil {
+		writeError(w, r, nil, "best scores are not tracked", http.StatusNotFound)
+		return
+	}
+
+	score, err := h.bests.Best(user)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	if ok := writeJSON(w, r, &BestResponse{User: user, Score: score}); !ok {
+		return
+	}
+
+	log.Print("best score")
+}
+
+// GameResponse is the game with the totals of the players, returned for
+// handicap games.
+type GameResponse struct {
+	*yahtzee.Game
+
+	// Totals has the totals of the players in the order of Players
+	Totals []*TotalResponse
+}
+
+// TotalResponse has the total of a player without and with the handicap.
+type TotalResponse struct {
+	User     yahtzee.User
+	Raw      int
+	Adjusted int
 }
 
 func (h *handler) Get(w http.ResponseWriter, r *http.Request) {
@@ -130,16 +765,40 @@ func (h *handler) Get(w http.ResponseWriter, r *http.Request) {
 		writeStoreError(w, r, err)
 		return
 	}
+	if ok := checkLive(w, r, &g); !ok {
+		return
+	}
 
-	if ok := writeJSON(w, r, g); !ok {
+	var res interface{} = g
+	if g.HasFeature(yahtzee.Handicap) {
+		res = gameResponse(&g)
+	}
+
+	w.Header().Set("Game-Hash", g.Hash())
+	if ok := writeJSON(w, r, res); !ok {
 		return
 	}
 
 	log.Print("game returned")
 }
 
-type AddPlayerResponse struct {
-	Players []*yahtzee.Player
+// gameResponse returns the game with the raw and the adjusted totals of the
+// players, which are the totals of their teams in partnership games.
+func gameResponse(g *yahtzee.Game) *GameResponse {
+	scorer := engine.GameScorer(g)
+
+	res := &GameResponse{
+		Game:   g,
+		Totals: []*TotalResponse{},
+	}
+	for i, p := range g.Players {
+		res.Totals = append(res.Totals, &TotalResponse{
+			User:     p.User,
+			Raw:      scorer.RawTotal(engine.SheetOwner(g, i)),
+			Adjusted: scorer.AdjustedTotal(g, i),
+		})
+	}
+	return res
 }
 
 func (h *handler) AddPlayer(w http.ResponseWriter, r *http.Request) {
@@ -151,6 +810,14 @@ func (h *handler) AddPlayer(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
+	if !h.validInvite(gameID, r) {
+		writeError(w, r, nil, "invalid invite", http.StatusForbidden)
+		return
+	}
+	handicap, ok := readHandicap(w, r)
+	if !ok {
+		return
+	}
 
 	unlocker, err := h.store.Lock(gameID)
 	if err != nil {
@@ -165,118 +832,248 @@ func (h *handler) AddPlayer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if g.CurrentPlayer > 0 || g.Round > 0 {
-		writeError(w, r, nil, "game already started", http.StatusBadRequest)
-		return
+	var events []*engine.Event
+	if handicap != 0 {
+		events, err = engine.AddHandicappedPlayer(&g, user, handicap)
+	} else {
+		events, err = engine.AddPlayer(&g, user)
 	}
-	for _, p := range g.Players {
-		if p.User == user {
-			writeError(w, r, nil, "already joined", http.StatusConflict)
-			return
-		}
+	if err != nil {
+		writeEngineError(w, r, err)
+		return
 	}
 
-	g.Players = append(g.Players, yahtzee.NewPlayer(user))
-
 	if err := h.store.Save(gameID, g); err != nil {
 		writeStoreError(w, r, err)
 		return
 	}
 
-	changes := &AddPlayerResponse{
-		Players: g.Players,
-	}
+	h.joined(user, gameID)
+
+	actionID := readActionID(r)
+	h.emit(gameID, &user, &g, actionID, events)
 
-	h.emitter.Emit(gameID, &user, event.AddPlayer, changes)
+	if actionID != "" {
+		w.Header().Set("Applied-Action-ID", actionID)
+	}
 
 	w.WriteHeader(http.StatusCreated)
-	if ok := writeJSON(w, r, changes); !ok {
+	if ok := writeJSON(w, r, events[0].Data); !ok {
 		return
 	}
 
 	log.Print("player added")
 }
 
-type RollResponse struct {
-	Dices     []*yahtzee.Dice
-	RollCount int
+func (h *handler) Roll(w http.ResponseWriter, r *http.Request) {
+	user, ok := readUser(w, r)
+	if !ok {
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+
+	unlocker, err := h.store.Lock(gameID)
+	if err != nil {
+		writeError(w, r, err, "locking issue", http.StatusInternalServerError)
+		return
+	}
+	defer unlocker()
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	user = engine.Actor(&g, user)
+
+	events, err := engine.Roll(&g, user, rand.Intn, h.now())
+	if err != nil {
+		h.expired(gameID, err)
+		writeEngineError(w, r, err)
+		return
+	}
+
+	if err := h.store.Save(gameID, g); err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	h.schedule(gameID, &g)
+
+	actionID := readActionID(r)
+	h.emit(gameID, &user, &g, actionID, events)
+
+	if actionID != "" {
+		w.Header().Set("Applied-Action-ID", actionID)
+	}
+
+	if ok := writeJSON(w, r, events[0].Data); !ok {
+		return
+	}
+
+	log.Print("rolled dices")
 }
 
-func (h *handler) Roll(w http.ResponseWriter, r *http.Request) {
+func (h *handler) Lock(w http.ResponseWriter, r *http.Request) {
 	user, ok := readUser(w, r)
 	if !ok {
 		return
 	}
-	gameID, ok := readGameID(w, r)
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+	diceIndex, ok := readDiceIndex(w, r)
+	if !ok {
+		return
+	}
+
+	unlocker, err := h.store.Lock(gameID)
+	if err != nil {
+		writeError(w, r, err, "locking issue", http.StatusInternalServerError)
+		return
+	}
+	defer unlocker()
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	user = engine.Actor(&g, user)
+
+	events, err := engine.Lock(&g, user, diceIndex)
+	if err != nil {
+		writeEngineError(w, r, err)
+		return
+	}
+
+	if err := h.store.Save(gameID, g); err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	actionID := readActionID(r)
+	h.emit(gameID, &user, &g, actionID, events)
+
+	if actionID != "" {
+		w.Header().Set("Applied-Action-ID", actionID)
+	}
+
+	if ok := writeJSON(w, r, events[0].Data); !ok {
+		return
+	}
+
+	log.Print("toggled dice")
+}
+
+func (h *handler) Score(w http.ResponseWriter, r *http.Request) {
+	if ok := h.endTurn(w, r, engine.ScoreColumn); !ok {
+		return
+	}
+
+	log.Print("scored")
+}
+
+func (h *handler) Scratch(w http.ResponseWriter, r *http.Request) {
+	if ok := h.endTurn(w, r, engine.ScratchColumn); !ok {
+		return
+	}
+
+	log.Print("scratched")
+}
+
+// endTurn records a category with `action` and responds with the game. It
+// tells if the turn ended.
+func (h *handler) endTurn(
+	w http.ResponseWriter,
+	r *http.Request,
+	action func(*yahtzee.Game, yahtzee.User, int, yahtzee.Category, time.Time) ([]*engine.Event, error)) bool {
+	user, ok := readUser(w, r)
+	if !ok {
+		return false
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return false
+	}
+	category, ok := readCategory(w, r)
+	if !ok {
+		return false
+	}
+	column, ok := readColumn(w, r)
 	if !ok {
-		return
+		return false
 	}
 
 	unlocker, err := h.store.Lock(gameID)
 	if err != nil {
 		writeError(w, r, err, "locking issue", http.StatusInternalServerError)
-		return
+		return false
 	}
 	defer unlocker()
 
 	g, err := h.store.Load(gameID)
 	if err != nil {
 		writeStoreError(w, r, err)
-		return
+		return false
 	}
+	user = engine.Actor(&g, user)
 
-	if len(g.Players) == 0 {
-		writeError(w, r, nil, "no players joined", http.StatusBadRequest)
-		return
-	}
-	currentPlayer := g.Players[g.CurrentPlayer]
-	if user != currentPlayer.User {
-		writeError(w, r, nil, "another players turn", http.StatusBadRequest)
-		return
-	}
-	if g.Round >= 13 {
-		writeError(w, r, nil, "game is over", http.StatusBadRequest)
-		return
+	events, err := action(&g, user, column, category, h.now())
+	if err != nil {
+		h.expired(gameID, err)
+		writeEngineError(w, r, err)
+		return false
 	}
-	if g.RollCount >= 3 {
-		writeError(w, r, nil, "no more rolls", http.StatusBadRequest)
-		return
+
+	if err := h.store.Save(gameID, g); err != nil {
+		writeStoreError(w, r, err)
+		return false
 	}
+	h.schedule(gameID, &g)
 
-	for _, d := range g.Dices {
-		if d.Locked {
-			continue
-		}
+	events = h.unlock(&g, events)
+	actionID := readActionID(r)
+	h.emit(gameID, &user, &g, actionID, events)
 
-		d.Value = rand.Intn(6) + 1
+	if actionID != "" {
+		w.Header().Set("Applied-Action-ID", actionID)
 	}
+	h.turnPlayed(gameID, &g)
 
-	g.RollCount++
+	return writeJSON(w, r, &g)
+}
 
-	if err := h.store.Save(gameID, g); err != nil {
-		writeStoreError(w, r, err)
+// turnPlayed records a finished turn of the game, the best score of the
+// player when it finished a solo game, the result when it finished a game of
+// a match, and the score of the day when it finished a daily challenge.
+// Nothing is recorded for the sandbox games.
+func (h *handler) turnPlayed(gameID string, g *yahtzee.Game) {
+	metrics.DefaultLoad.TurnPlayed()
+	if g.Sandbox {
 		return
 	}
 
-	changes := &RollResponse{
-		Dices:     g.Dices,
-		RollCount: g.RollCount,
+	if g.HasFeature(yahtzee.Solo) && !g.HasFeature(yahtzee.Lowball) && engine.IsOver(g) && h.bests != nil {
+		p := g.Players[0]
+		if err := h.bests.Record(p.User, engine.GameScorer(g).Total(p)); err != nil {
+			log.Printf("record best score: %v", err)
+		}
 	}
 
-	h.emitter.Emit(gameID, &user, event.Roll, changes)
-
-	if ok := writeJSON(w, r, changes); !ok {
-		return
+	if g.Match != "" && engine.IsOver(g) {
+		h.matchGamePlayed(gameID, g)
 	}
 
-	log.Print("rolled dices")
-}
-
-type LockResponse struct {
-	Dices []*yahtzee.Dice
+	if g.Daily != "" && engine.IsOver(g) {
+		h.dailyPlayed(g)
+	}
 }
 
-func (h *handler) Lock(w http.ResponseWriter, r *http.Request) {
+func (h *handler) Announce(w http.ResponseWriter, r *http.Request) {
 	user, ok := readUser(w, r)
 	if !ok {
 		return
@@ -285,7 +1082,7 @@ func (h *handler) Lock(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
-	diceIndex, ok := readDiceIndex(w, r)
+	category, ok := readCategory(w, r)
 	if !ok {
 		return
 	}
@@ -302,50 +1099,34 @@ func (h *handler) Lock(w http.ResponseWriter, r *http.Request) {
 		writeStoreError(w, r, err)
 		return
 	}
+	user = engine.Actor(&g, user)
 
-	if len(g.Players) == 0 {
-		writeError(w, r, nil, "no players joined", http.StatusBadRequest)
-		return
-	}
-	currentPlayer := g.Players[g.CurrentPlayer]
-	if user != currentPlayer.User {
-		writeError(w, r, nil, "another players turn", http.StatusBadRequest)
-		return
-	}
-	if g.Round >= 13 {
-		writeError(w, r, nil, "game is over", http.StatusBadRequest)
-		return
-	}
-	if g.RollCount == 0 {
-		writeError(w, r, nil, "roll first", http.StatusBadRequest)
-		return
-	}
-	if g.RollCount >= 3 {
-		writeError(w, r, nil, "no more rolls", http.StatusBadRequest)
+	events, err := engine.Announce(&g, user, category)
+	if err != nil {
+		writeEngineError(w, r, err)
 		return
 	}
 
-	g.Dices[diceIndex].Locked = !g.Dices[diceIndex].Locked
-
 	if err := h.store.Save(gameID, g); err != nil {
 		writeStoreError(w, r, err)
 		return
 	}
 
-	changes := &LockResponse{
-		Dices: g.Dices,
-	}
+	actionID := readActionID(r)
+	h.emit(gameID, &user, &g, actionID, events)
 
-	h.emitter.Emit(gameID, &user, event.Lock, changes)
+	if actionID != "" {
+		w.Header().Set("Applied-Action-ID", actionID)
+	}
 
-	if ok := writeJSON(w, r, changes); !ok {
+	if ok := writeJSON(w, r, events[0].Data); !ok {
 		return
 	}
 
-	log.Print("toggled dice")
+	log.Print("announced")
 }
 
-func (h *handler) Score(w http.ResponseWriter, r *http.Request) {
+func (h *handler) ExtraRoll(w http.ResponseWriter, r *http.Request) {
 	user, ok := readUser(w, r)
 	if !ok {
 		return
@@ -354,10 +1135,6 @@ func (h *handler) Score(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
-	category, ok := readCategory(w, r)
-	if !ok {
-		return
-	}
 
 	unlocker, err := h.store.Lock(gameID)
 	if err != nil {
@@ -371,81 +1148,50 @@ func (h *handler) Score(w http.ResponseWriter, r *http.Request) {
 		writeStoreError(w, r, err)
 		return
 	}
+	user = engine.Actor(&g, user)
 
-	if len(g.Players) == 0 {
-		writeError(w, r, nil, "no players joined", http.StatusBadRequest)
-		return
-	}
-	currentPlayer := g.Players[g.CurrentPlayer]
-	if user != currentPlayer.User {
-		writeError(w, r, nil, "another players turn", http.StatusBadRequest)
-		return
-	}
-	if g.Round >= 13 {
-		writeError(w, r, nil, "game is over", http.StatusBadRequest)
-		return
-	}
-	if g.RollCount == 0 {
-		writeError(w, r, nil, "roll first", http.StatusBadRequest)
-		return
-	}
-	if _, ok := currentPlayer.ScoreSheet[category]; ok {
-		writeError(w, r, nil, "category is already used", http.StatusBadRequest)
-		return
-	}
-
-	dices := make([]int, 5)
-	for i, d := range g.Dices {
-		dices[i] = d.Value
-	}
-
-	score, err := score(category, dices)
+	events, err := engine.ExtraRoll(&g, user)
 	if err != nil {
-		writeError(w, r, err, "invalid category", http.StatusBadRequest)
+		writeEngineError(w, r, err)
 		return
 	}
 
-	currentPlayer.ScoreSheet[category] = score
-
-	if _, ok := currentPlayer.ScoreSheet[yahtzee.Bonus]; !ok {
-		var total, types int
-		for k, v := range currentPlayer.ScoreSheet {
-			if k == yahtzee.Ones || k == yahtzee.Twos || k == yahtzee.Threes ||
-				k == yahtzee.Fours || k == yahtzee.Fives || k == yahtzee.Sixes {
-				types++
-				total += v
-			}
-		}
-
-		if total >= 63 {
-			currentPlayer.ScoreSheet[yahtzee.Bonus] = 35
-		} else if types == 6 {
-			currentPlayer.ScoreSheet[yahtzee.Bonus] = 0
-		}
+	if err := h.store.Save(gameID, g); err != nil {
+		writeStoreError(w, r, err)
+		return
 	}
 
-	for _, d := range g.Dices {
-		d.Locked = false
-	}
+	actionID := readActionID(r)
+	h.emit(gameID, &user, &g, actionID, events)
 
-	g.RollCount = 0
-	g.CurrentPlayer = (g.CurrentPlayer + 1) % len(g.Players)
-	if g.CurrentPlayer == 0 {
-		g.Round++
+	if actionID != "" {
+		w.Header().Set("Applied-Action-ID", actionID)
 	}
 
-	if err := h.store.Save(gameID, g); err != nil {
-		writeStoreError(w, r, err)
+	if ok := writeJSON(w, r, events[0].Data); !ok {
 		return
 	}
 
-	h.emitter.Emit(gameID, &user, event.Score, &g)
+	log.Print("extra roll spent")
+}
 
-	if ok := writeJSON(w, r, &g); !ok {
-		return
+func (h *handler) emit(gameID string, u *yahtzee.User, g *yahtzee.Game, actionID string, events []*engine.Event) {
+	h.project(g, events)
+	h.logActivity(gameID, u, events)
+	h.summarize(gameID, g)
+
+	hash := g.Hash()
+	for _, e := range events {
+		ev := &event.Event{
+			User:            u,
+			Action:          e.Action,
+			Data:            e.Data,
+			Hash:            hash,
+			AppliedActionID: actionID,
+		}
+		h.emitter.Emit(gameID, ev)
+		h.spectate(gameID, g, ev)
 	}
-
-	log.Print("scored")
 }
 
 const (
@@ -457,7 +1203,13 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
-func wsWriter(ws *websocket.Conn, events <-chan *event.Event, s event.Subscriber, gameID string) {
+func wsWriter(
+	ws *websocket.Conn,
+	events <-chan *event.Event,
+	s event.Subscriber,
+	gameID string,
+	c *Capabilities,
+	load func() *yahtzee.Game) {
 	pingTicker := time.NewTicker(wsPingPeriod)
 	defer func() {
 		s.Unsubscribe(gameID, ws)
@@ -467,8 +1219,12 @@ func wsWriter(ws *websocket.Conn, events <-chan *event.Event, s event.Subscriber
 
 	for {
 		select {
-		case e := <-events:
-			if err := ws.WriteJSON(e); err != nil {
+		case e, ok := <-events:
+			if !ok {
+				// the game is gone
+				return
+			}
+			if err := writeEvent(ws, e, c, load); err != nil {
 				return
 			}
 		case <-pingTicker.C:
@@ -506,12 +1262,36 @@ func (h *handler) WS(w http.ResponseWriter, r *http.Request) {
 		writeError(w, r, err, "locking issue", http.StatusInternalServerError)
 		return
 	}
-	_, err = h.store.Load(gameID)
+	g, err := h.store.Load(gameID)
 	unlock()
 	if err != nil {
 		writeStoreError(w, r, err)
 		return
 	}
+	if ok := checkLive(w, r, &g); !ok {
+		return
+	}
+
+	h.serveEvents(w, r, gameID, func() *yahtzee.Game {
+		g, err := h.store.Load(gameID)
+		if err != nil {
+			log.Printf("load game for full event: %v", err)
+			return nil
+		}
+		return &g
+	})
+}
+
+// serveEvents upgrades the request to a websocket and sends the events of
+// the channel on it, in the format and the encoding of the client. The full
+// events have the game returned by `load`, they are sent as delta events
+// when it's nil.
+func (h *handler) serveEvents(w http.ResponseWriter, r *http.Request, channel string, load func() *yahtzee.Game) {
+	if !h.capacity.connect() {
+		writeServerFull(w, r, errServerFull)
+		return
+	}
+	defer h.capacity.disconnect()
 
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -521,14 +1301,20 @@ func (h *handler) WS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	eventChannel, err := h.subscriber.Subscribe(gameID, ws)
+	eventChannel, err := h.subscriber.Subscribe(channel, ws)
 	if err != nil {
 		writeError(w, r, err, "unable to subscribe", http.StatusInternalServerError)
 		return
 	}
 
-	go wsWriter(ws, eventChannel, h.subscriber, gameID)
-	wsReader(ws, h.subscriber, gameID)
+	metrics.DefaultLoad.Connected()
+	defer metrics.DefaultLoad.Disconnected()
+
+	c := clientCapabilities(r)
+	log.Printf("websocket connected with %s", c)
+
+	go wsWriter(ws, eventChannel, h.subscriber, channel, c, load)
+	wsReader(ws, h.subscriber, channel)
 }
 
 func readDiceIndex(w http.ResponseWriter, r *http.Request) (int, bool) {
@@ -538,24 +1324,39 @@ func readDiceIndex(w http.ResponseWriter, r *http.Request) (int, bool) {
 		return 0, false
 	}
 	index, err := strconv.Atoi(raw)
-	if err != nil || index < 0 || index > 4 {
+	if err != nil {
 		writeError(w, r, err, "invalid dice index", http.StatusBadRequest)
 		return index, false
 	}
 	return index, true
 }
 
-func readDices(w http.ResponseWriter, r *http.Request) ([]int, bool) {
-	raw := r.URL.Query().Get("dices")
+// readSides reads the optional `sides` query parameter of the dices, six
+// by default.
+func readSides(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("sides")
+	if raw == "" {
+		return yahtzee.NumberOfSides, true
+	}
+	sides, err := strconv.Atoi(raw)
+	if err != nil || sides < minSides || sides > maxSides {
+		writeError(w, r, err, "invalid number of sides", http.StatusBadRequest)
+		return 0, false
+	}
+	return sides, true
+}
+
+func readDices(w http.ResponseWriter, r *http.Request, key string, n, sides int) ([]int, bool) {
+	raw := r.URL.Query().Get(key)
 	rawDices := strings.Split(raw, ",")
-	if len(rawDices) != 5 {
+	if len(rawDices) != n {
 		writeError(w, r, nil, "wrong number of dices", http.StatusBadRequest)
 		return nil, false
 	}
-	dices := make([]int, 5)
+	dices := make([]int, n)
 	for i, d := range rawDices {
 		v, err := strconv.Atoi(d)
-		if err != nil || v < 1 || 6 < v {
+		if err != nil || v < 1 || sides < v {
 			writeError(w, r, err, "invalid dice", http.StatusBadRequest)
 			return nil, false
 		}
@@ -564,6 +1365,187 @@ func readDices(w http.ResponseWriter, r *http.Request) ([]int, bool) {
 	return dices, true
 }
 
+func readCreateRequest(w http.ResponseWriter, r *http.Request) (*CreateRequest, bool) {
+	res := &CreateRequest{}
+	if ok := readBody(w, r, res); !ok {
+		return nil, false
+	}
+	if ok := checkCreateRequest(w, r, res); !ok {
+		return nil, false
+	}
+	return res, true
+}
+
+// readBody unmarshals the optional JSON body of the request into `v`.
+func readBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Body == nil {
+		return true
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, r, err, "read body", http.StatusInternalServerError)
+		return false
+	}
+	if len(body) == 0 {
+		return true
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		writeError(w, r, err, "invalid body", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func checkCreateRequest(w http.ResponseWriter, r *http.Request, req *CreateRequest) bool {
+	if req.Dice < 0 || req.Dice > maxDices {
+		writeError(w, r, nil, "invalid number of dices", http.StatusBadRequest)
+		return false
+	}
+	if req.Sides != 0 && (req.Sides < minSides || req.Sides > maxSides) {
+		writeError(w, r, nil, "invalid number of sides", http.StatusBadRequest)
+		return false
+	}
+	if req.Locale != "" && !validLocale(req.Locale) {
+		writeError(w, r, nil, "invalid locale", http.StatusBadRequest)
+		return false
+	}
+	if _, err := yahtzee.LoadLocation(req.TimeZone); err != nil {
+		writeError(w, r, err, "invalid time zone", http.StatusBadRequest)
+		return false
+	}
+	return checkRules(w, r, req.Rules)
+}
+
+// checkSheet tells if the game can be shortened to its rounds, and if the
+// payouts of its house rules are for the categories on its score sheet.
+func checkSheet(w http.ResponseWriter, r *http.Request, g *yahtzee.Game) bool {
+	scorer := engine.GameScorer(g)
+	if g.Rounds < 0 || g.Rounds > scorer.Rounds() {
+		writeError(w, r, nil, "invalid number of rounds", http.StatusBadRequest)
+		return false
+	}
+	if g.Rules == nil {
+		return true
+	}
+	for c := range g.Rules.Payouts {
+		if _, ok := scorer.ScoreActions[c]; !ok {
+			writeError(w, r, engine.ErrInvalidCategory, "payout of invalid category", http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
+func validLocale(locale string) bool {
+	for _, lang := range i18n.Languages() {
+		if lang == locale {
+			return true
+		}
+	}
+	return false
+}
+
+func checkRules(w http.ResponseWriter, r *http.Request, rules *yahtzee.Rules) bool {
+	if rules == nil {
+		return true
+	}
+	if rules.UpperBonusThreshold < 0 || rules.UpperBonus < 0 || rules.YahtzeeScore < 0 {
+		writeError(w, r, nil, "invalid scores in rules", http.StatusBadRequest)
+		return false
+	}
+	for _, payout := range rules.Payouts {
+		if payout < 0 || payout > maxPayout {
+			writeError(w, r, nil, "invalid payout in rules", http.StatusBadRequest)
+			return false
+		}
+	}
+	if rules.RollsPerTurn < 0 || rules.RollsPerTurn > maxRollsPerTurn {
+		writeError(w, r, nil, "invalid rolls per turn", http.StatusBadRequest)
+		return false
+	}
+	if rules.ExtraRolls < 0 || rules.ExtraRolls > maxExtraRolls {
+		writeError(w, r, nil, "invalid extra rolls", http.StatusBadRequest)
+		return false
+	}
+	if rules.Hints < 0 || rules.Hints > maxHints {
+		writeError(w, r, nil, "invalid hints", http.StatusBadRequest)
+		return false
+	}
+	if rules.ShotClock < 0 || rules.ShotClock > maxShotClock {
+		writeError(w, r, nil, "invalid shot clock", http.StatusBadRequest)
+		return false
+	}
+	if rules.MaxAway < 0 || rules.MaxAway > maxAway {
+		writeError(w, r, nil, "invalid max away", http.StatusBadRequest)
+		return false
+	}
+	if rules.SpectatorDelay < 0 || rules.SpectatorDelay > maxSpectatorDelay {
+		writeError(w, r, nil, "invalid spectator delay", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func readFeatures(w http.ResponseWriter, r *http.Request) ([]yahtzee.Feature, bool) {
+	raw := r.URL.Query().Get("features")
+	if raw == "" {
+		return nil, true
+	}
+
+	var res []yahtzee.Feature
+	for _, f := range strings.Split(raw, ",") {
+		feature := yahtzee.Feature(f)
+		if !knownFeature(feature) {
+			writeError(w, r, nil, "unknown feature", http.StatusBadRequest)
+			return nil, false
+		}
+
+		res = append(res, feature)
+	}
+	return res, true
+}
+
+func knownFeature(f yahtzee.Feature) bool {
+	for _, available := range yahtzee.Features() {
+		if f == available {
+			return true
+		}
+	}
+	return false
+}
+
+func readColumn(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("column")
+	if raw == "" {
+		return 0, true
+	}
+	column, err := strconv.Atoi(raw)
+	if err != nil {
+		writeError(w, r, err, "invalid column", http.StatusBadRequest)
+		return 0, false
+	}
+	return column, true
+}
+
+// readHandicap reads the optional handicap of the player. The sign of a
+// positive handicap like `+25` is decoded to a space in the query, so it's
+// trimmed.
+func readHandicap(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := strings.TrimSpace(r.URL.Query().Get("handicap"))
+	if raw == "" {
+		return 0, true
+	}
+	handicap, err := strconv.Atoi(raw)
+	if err != nil {
+		writeError(w, r, err, "invalid handicap", http.StatusBadRequest)
+		return 0, false
+	}
+	return handicap, true
+}
+
+// readCategory reads the category from the CategoryRequest of a JSON body,
+// or the deprecated raw body.
 func readCategory(w http.ResponseWriter, r *http.Request) (yahtzee.Category, bool) {
 	if r.Body == nil {
 		writeError(w, r, nil, "no category", http.StatusBadRequest)
@@ -574,7 +1556,26 @@ func readCategory(w http.ResponseWriter, r *http.Request) (yahtzee.Category, boo
 		writeError(w, r, err, "extract category from body", http.StatusInternalServerError)
 		return "", false
 	}
-	return yahtzee.Category(body), true
+	if !jsonBody(r) {
+		return yahtzee.Category(body), true
+	}
+
+	req := &CategoryRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		writeError(w, r, err, "invalid body", http.StatusBadRequest)
+		return "", false
+	}
+	return req.Category, true
+}
+
+// jsonBody tells if the body of the request is JSON by its Content-Type.
+func jsonBody(r *http.Request) bool {
+	t, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && t == "application/json"
+}
+
+func readActionID(r *http.Request) string {
+	return r.Header.Get("Action-ID")
 }
 
 func readGameID(w http.ResponseWriter, r *http.Request) (string, bool) {
@@ -598,6 +1599,15 @@ func readUser(w http.ResponseWriter, r *http.Request) (yahtzee.User, bool) {
 }
 
 func writeJSON(w http.ResponseWriter, r *http.Request, body interface{}) bool {
+	if s := diceShape(r); s != DiceStructs {
+		shaped, err := shape(body, s)
+		if err != nil {
+			writeError(w, r, err, "response json encode", http.StatusInternalServerError)
+			return false
+		}
+		body = shaped
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(body); err != nil {
 		writeError(w, r, err, "response json encode", http.StatusInternalServerError)
@@ -606,134 +1616,144 @@ func writeJSON(w http.ResponseWriter, r *http.Request, body interface{}) bool {
 	return true
 }
 
+// ErrorResponse is the body of the failed requests.
+type ErrorResponse struct {
+	// Code identifies the error
+	Code string
+
+	// Message describes the error in the language of the user
+	Message string
+}
+
+var engineErrorCodes = map[error]string{
+	engine.ErrAlreadyStarted:       "already-started",
+	engine.ErrAlreadyJoined:        "already-joined",
+	engine.ErrNoPlayers:            "no-players",
+	engine.ErrAnotherPlayer:        "another-player",
+	engine.ErrGameOver:             "game-over",
+	engine.ErrNoMoreRolls:          "no-more-rolls",
+	engine.ErrRollFirst:            "roll-first",
+	engine.ErrCategoryUsed:         "category-used",
+	engine.ErrInvalidCategory:      "invalid-category",
+	engine.ErrInvalidDice:          "invalid-dice",
+	engine.ErrInvalidColumn:        "invalid-column",
+	engine.ErrSoloGame:             "solo-game",
+	engine.ErrMatchGame:            "match-game",
+	engine.ErrSandboxGame:          "sandbox-game",
+	engine.ErrJokerUpperBox:        "joker-upper-box",
+	engine.ErrJokerLowerBox:        "joker-lower-box",
+	engine.ErrNotAnnounceGame:      "not-announce-game",
+	engine.ErrAlreadyAnnounced:     "already-announced",
+	engine.ErrAnnounceFirst:        "announce-first",
+	engine.ErrNotAnnouncedCategory: "not-announced-category",
+	engine.ErrIncompleteSheet:      "incomplete-sheet",
+	engine.ErrNotExtraRollGame:     "not-extra-roll-game",
+	engine.ErrNoExtraRolls:         "no-extra-rolls",
+	engine.ErrNotHandicapGame:      "not-handicap-game",
+	engine.ErrInvalidHandicap:      "invalid-handicap",
+	engine.ErrTeamsFull:            "teams-full",
+	engine.ErrTeamsIncomplete:      "teams-incomplete",
+	engine.ErrNotJoined:            "not-joined",
+	engine.ErrAwayNotAllowed:       "away-not-allowed",
+	engine.ErrAwayTooLong:          "away-too-long",
+	engine.ErrLowballScratch:       "lowball-scratch",
+	engine.ErrNotDuplicateGame:     "not-duplicate-game",
+	engine.ErrDifferentRolls:       "different-rolls",
+	engine.ErrUnknownTable:         "unknown-table",
+	engine.ErrNotCoachGame:         "not-coach-game",
+	engine.ErrCoachDices:           "coach-dices",
+	engine.ErrNoHints:              "no-hints",
+	engine.ErrImpossibleScore:      "impossible-score",
+	engine.ErrSheetOrder:           "sheet-order",
+	engine.ErrImportDices:          "import-dices",
+	engine.ErrTiebreakBox:          "tiebreak-box",
+	engine.ErrIncompatibleFeatures: "incompatible-features",
+	engine.ErrGameNotOver:          "game-not-over",
+	engine.ErrTurnStarted:          "turn-started",
+	errServerFull:                  "server-full",
+}
+
+var statusErrorCodes = map[int]string{
+	http.StatusBadRequest:      "bad-request",
+	http.StatusUnauthorized:    "unauthorized",
+	http.StatusForbidden:       "forbidden",
+	http.StatusNotFound:        "not-found",
+	http.StatusConflict:        "conflict",
+	http.StatusTooManyRequests: "busy",
+	http.StatusUpgradeRequired: "client-outdated",
+}
+
 func writeError(w http.ResponseWriter, r *http.Request, err error, msg string, status int) {
 	log.Printf("%s: %v", msg, err)
-	http.Error(w, "", status)
-}
 
-func writeStoreError(w http.ResponseWriter, r *http.Request, err error) {
-	if errors.As(err, &store.ErrNotExists) {
-		writeError(w, r, err, "not exists", http.StatusNotFound)
-	} else {
-		writeError(w, r, err, "unknown error", http.StatusInternalServerError)
+	code, ok := engineErrorCodes[err]
+	if !ok {
+		code, ok = statusErrorCodes[status]
+	}
+	if !ok {
+		code = "internal-error"
 	}
-}
 
-func score(category yahtzee.Category, dices []int) (int, error) {
-	s := 0
-	switch category {
-	case yahtzee.Ones:
-		for _, d := range dices {
-			if d == 1 {
-				s++
-			}
-		}
-	case yahtzee.Twos:
-		for _, d := range dices {
-			if d == 2 {
-				s += 2
-			}
-		}
-	case yahtzee.Threes:
-		for _, d := range dices {
-			if d == 3 {
-				s += 3
-			}
-		}
-	case yahtzee.Fours:
-		for _, d := range dices {
-			if d == 4 {
-				s += 4
-			}
-		}
-	case yahtzee.Fives:
-		for _, d := range dices {
-			if d == 5 {
-				s += 5
-			}
-		}
-	case yahtzee.Sixes:
-		for _, d := range dices {
-			if d == 6 {
-				s += 6
-			}
-		}
-	case yahtzee.ThreeOfAKind:
-		occurrences := map[int]int{}
-		for _, d := range dices {
-			occurrences[d]++
-		}
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("Vary", "Accept-Language")
+	w.WriteHeader(status)
 
-		for k, v := range occurrences {
-			if v >= 3 {
-				s = 3 * k
-			}
-		}
-	case yahtzee.FourOfAKind:
-		occurrences := map[int]int{}
-		for _, d := range dices {
-			occurrences[d]++
-		}
+	json.NewEncoder(w).Encode(&ErrorResponse{
+		Code:    code,
+		Message: i18n.Message(lang, code),
+	})
+}
 
-		for k, v := range occurrences {
-			if v >= 4 {
-				s = 4 * k
-			}
-		}
-	case yahtzee.FullHouse:
-		one, oneCount, other := dices[0], 1, 0
-		for i := 1; i < len(dices); i++ {
-			v := dices[i]
-
-			if one == v {
-				oneCount++
-			} else if other == 0 || other == v {
-				other = v
-			} else {
-				oneCount = 4
-			}
-		}
+// ConflictsResponse is the error of creating a game with incompatible
+// features.
+type ConflictsResponse struct {
+	ErrorResponse
 
-		if oneCount == 2 || oneCount == 3 {
-			s = 25
-		}
-	case yahtzee.SmallStraight:
-		hit := [6]bool{}
-		for _, d := range dices {
-			hit[d-1] = true
-		}
+	// Conflicts has the groups of the requested features that can't be
+	// played together
+	Conflicts [][]yahtzee.Feature
+}
 
-		if (hit[0] && hit[1] && hit[2] && hit[3]) ||
-			(hit[1] && hit[2] && hit[3] && hit[4]) ||
-			(hit[2] && hit[3] && hit[4] && hit[5]) {
-			s = 30
-		}
-	case yahtzee.LargeStraight:
-		hit := [6]bool{}
-		for _, d := range dices {
-			hit[d-1] = true
-		}
+// checkFeatures tells if the features of a new game can be played together.
+func checkFeatures(w http.ResponseWriter, r *http.Request, features []yahtzee.Feature) bool {
+	conflicts := engine.Conflicts(features...)
+	if len(conflicts) == 0 {
+		return true
+	}
+	log.Printf("incompatible features: %v", conflicts)
 
-		if (hit[0] && hit[1] && hit[2] && hit[3] && hit[4]) ||
-			(hit[1] && hit[2] && hit[3] && hit[4] && hit[5]) {
-			s = 40
-		}
-	case yahtzee.Yahtzee:
-		same := true
-		for i := 0; i < len(dices)-1; i++ {
-			same = same && dices[i] == dices[i+1]
-		}
+	code := engineErrorCodes[engine.ErrIncompatibleFeatures]
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("Vary", "Accept-Language")
+	w.WriteHeader(http.StatusBadRequest)
+
+	json.NewEncoder(w).Encode(&ConflictsResponse{
+		ErrorResponse: ErrorResponse{
+			Code:    code,
+			Message: i18n.Message(lang, code),
+		},
+		Conflicts: conflicts,
+	})
+	return false
+}
 
-		if same {
-			s = 50
-		}
-	case yahtzee.Chance:
-		for _, d := range dices {
-			s += d
-		}
+func writeEngineError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case engine.ErrAlreadyJoined:
+		writeError(w, r, err, "invalid action", http.StatusConflict)
 	default:
-		return 0, errors.New("invalid category")
+		writeError(w, r, err, "invalid action", http.StatusBadRequest)
 	}
+}
 
-	return s, nil
+func writeStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, store.ErrNotExists) {
+		writeError(w, r, err, "not exists", http.StatusNotFound)
+	} else {
+		writeError(w, r, err, "unknown error", http.StatusInternalServerError)
+	}
 }
@@ -3,9 +3,14 @@ package handler_test
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -14,10 +19,13 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
 	"github.com/akarasz/yahtzee/event"
 	event_impl "github.com/akarasz/yahtzee/event/embedded"
 	"github.com/akarasz/yahtzee/handler"
+	"github.com/akarasz/yahtzee/id"
 	store "github.com/akarasz/yahtzee/store/embedded"
+	"github.com/akarasz/yahtzee/store/eventlog"
 )
 
 type testSuite struct {
@@ -36,7 +44,7 @@ func TestSuite(t *testing.T) {
 	suite.Run(t, &testSuite{
 		store:   s,
 		event:   e,
-		handler: handler.New(s, e, e),
+		handler: handler.New(s, e, e, handler.WithBestScores(s), handler.WithMatches(s), handler.WithUserGames(s), handler.WithNotes(s), handler.WithAchievements(s), handler.WithDailies(s)),
 	})
 }
 
@@ -47,6 +55,166 @@ func (ts *testSuite) TestCreate() {
 		created := ts.fromStore(strings.TrimLeft(rr.HeaderMap["Location"][0], "/"))
 		ts.Exactly(yahtzee.NewGame(), created)
 	}
+
+	// with features
+	rr = ts.record(request("POST", "/"), withQuery("features", "yahtzee-bonus"))
+	ts.Exactly(http.StatusCreated, rr.Code)
+	if ts.Contains(rr.HeaderMap, "Location") && ts.Len(rr.HeaderMap["Location"], 1) {
+		created := ts.fromStore(strings.TrimLeft(rr.HeaderMap["Location"][0], "/"))
+		ts.Exactly(yahtzee.NewGame(yahtzee.YahtzeeBonus), created)
+	}
+
+	// duplicate rolls are seeded
+	rr = ts.record(request("POST", "/"), withQuery("features", "duplicate"))
+	ts.Exactly(http.StatusCreated, rr.Code)
+	if ts.Contains(rr.HeaderMap, "Location") && ts.Len(rr.HeaderMap["Location"], 1) {
+		created := ts.fromStore(strings.TrimLeft(rr.HeaderMap["Location"][0], "/"))
+		ts.NotZero(created.Seed)
+	}
+
+	// unknown feature
+	rr = ts.record(request("POST", "/"), withQuery("features", "yahtzee-bonus,wat"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// incompatible features
+	rr = ts.record(request("POST", "/"), withQuery("features", "yatzy,maxi,partners,solo"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	ts.JSONEq(`{
+		"Code": "incompatible-features",
+		"Message": "Some of the features can't be played together.",
+		"Conflicts": [["yatzy", "maxi"], ["solo", "partners"]]
+	}`, rr.Body.String())
+	rr = ts.record(request("POST", "/matches", `{"BestOf": 3}`), withQuery("features", "triple,triple-sheet"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("POST", "/import", `{"Players": [{"User": "Alice"}]}`), withQuery("features", "kniffel,yatzy"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// dices and sides
+	rr = ts.record(request("POST", "/", `{"dice": 6, "sides": 8}`))
+	ts.Exactly(http.StatusCreated, rr.Code)
+	if ts.Contains(rr.HeaderMap, "Location") && ts.Len(rr.HeaderMap["Location"], 1) {
+		created := ts.fromStore(strings.TrimLeft(rr.HeaderMap["Location"][0], "/"))
+		ts.Len(created.Dices, 6)
+		ts.Exactly(8, created.Sides)
+	}
+
+	// house rules
+	rr = ts.record(request("POST", "/", `{"rules": {"upperBonus": 50, "rollsPerTurn": 4}}`))
+	ts.Exactly(http.StatusCreated, rr.Code)
+	if ts.Contains(rr.HeaderMap, "Location") && ts.Len(rr.HeaderMap["Location"], 1) {
+		created := ts.fromStore(strings.TrimLeft(rr.HeaderMap["Location"][0], "/"))
+		ts.Exactly(&yahtzee.Rules{UpperBonus: 50, RollsPerTurn: 4}, created.Rules)
+	}
+
+	// payouts
+	rr = ts.record(request("POST", "/", `{"rules": {"payouts": {"yahtzee": 100, "full-house": 30}}}`))
+	ts.Exactly(http.StatusCreated, rr.Code)
+	if ts.Contains(rr.HeaderMap, "Location") && ts.Len(rr.HeaderMap["Location"], 1) {
+		created := ts.fromStore(strings.TrimLeft(rr.HeaderMap["Location"][0], "/"))
+		ts.Exactly(map[yahtzee.Category]int{yahtzee.Yahtzee: 100, yahtzee.FullHouse: 30}, created.Rules.Payouts)
+	}
+
+	// quick game
+	rr = ts.record(request("POST", "/", `{"rounds": 5}`))
+	ts.Exactly(http.StatusCreated, rr.Code)
+	if ts.Contains(rr.HeaderMap, "Location") && ts.Len(rr.HeaderMap["Location"], 1) {
+		created := ts.fromStore(strings.TrimLeft(rr.HeaderMap["Location"][0], "/"))
+		ts.Exactly(5, created.Rounds)
+	}
+	rr = ts.record(request("POST", "/", `{"rounds": 39}`), withQuery("features", "triple"))
+	ts.Exactly(http.StatusCreated, rr.Code)
+
+	// locale and time zone
+	rr = ts.record(request("POST", "/", `{"locale": "hu", "timeZone": "Europe/Budapest"}`))
+	ts.Exactly(http.StatusCreated, rr.Code)
+	if ts.Contains(rr.HeaderMap, "Location") && ts.Len(rr.HeaderMap["Location"], 1) {
+		created := ts.fromStore(strings.TrimLeft(rr.HeaderMap["Location"][0], "/"))
+		ts.Exactly("hu", created.Locale)
+		ts.Exactly("Europe/Budapest", created.TimeZone)
+	}
+
+	badBodies := []struct {
+		description string
+		body        string
+	}{
+		{"invalid json", `{"dice":`},
+		{"too many dices", `{"dice": 11}`},
+		{"negative dices", `{"dice": -1}`},
+		{"too few sides", `{"sides": 1}`},
+		{"too many sides", `{"sides": 21}`},
+		{"negative bonus", `{"rules": {"upperBonus": -1}}`},
+		{"too many rolls", `{"rules": {"rollsPerTurn": 11}}`},
+		{"negative payout", `{"rules": {"payouts": {"chance": -1}}}`},
+		{"payout of a category not on the sheet", `{"rules": {"payouts": {"castle": 50}}}`},
+		{"negative rounds", `{"rounds": -1}`},
+		{"more rounds than boxes", `{"rounds": 14}`},
+		{"unknown locale", `{"locale": "xx"}`},
+		{"unknown time zone", `{"timeZone": "Mars/Olympus"}`},
+	}
+	for _, tc := range badBodies {
+		rr = ts.record(request("POST", "/", tc.body))
+		ts.Exactly(http.StatusBadRequest, rr.Code, "when %s", tc.description)
+	}
+}
+
+func (ts *testSuite) TestCreateWithIDGenerator() {
+	ts.Require().NoError(ts.store.Save("taken", *yahtzee.NewGame()))
+
+	ids := []string{"taken", "fresh"}
+	h := handler.New(ts.store, ts.event, ts.event, handler.WithIDGenerator(id.GeneratorFunc(func() string {
+		res := ids[0]
+		ids = ids[1:]
+		return res
+	})))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, request("POST", "/"))
+	ts.Exactly(http.StatusCreated, rr.Code)
+	ts.Exactly("/fresh", rr.Header().Get("Location"))
+}
+
+func (ts *testSuite) TestFeatures() {
+	rr := ts.record(request("GET", "/features"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`["yahtzee-bonus", "yatzy", "maxi", "triple", "announce", "kniffel", "solo", "duplicate", "extra-roll", "forced-joker", "free-joker", "blitz", "handicap", "partners", "lowball", "coach", "double-sheet", "triple-sheet", "sudden-death", "rainbow", "hints", "projection"]`, rr.Body.String())
+}
+
+func (ts *testSuite) TestCategories() {
+	rr := ts.record(request("GET", "/categories"), withQuery("features", "yatzy"))
+	ts.Exactly(http.StatusOK, rr.Code)
+
+	var got []*handler.CategoryResponse
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	sections := map[yahtzee.Category]yahtzee.Section{}
+	names := []yahtzee.Category{}
+	max := map[yahtzee.Category]int{}
+	for _, c := range got {
+		names = append(names, c.Name)
+		sections[c.Name] = c.Section
+		max[c.Name] = c.Max
+	}
+	ts.Exactly([]yahtzee.Category{
+		"ones", "twos", "threes", "fours", "fives", "sixes", "one-pair",
+		"two-pairs", "three-of-a-kind", "four-of-a-kind", "full-house",
+		"small-straight", "large-straight", "yahtzee", "chance",
+	}, names)
+	ts.Exactly(yahtzee.Upper, sections[yahtzee.Sixes])
+	ts.Exactly(yahtzee.Lower, sections[yahtzee.Chance])
+
+	// the highest scores with the features
+	ts.Exactly(5, max[yahtzee.Ones])
+	ts.Exactly(28, max[yahtzee.FullHouse])
+	ts.Exactly(15, max[yahtzee.SmallStraight])
+	ts.Exactly(50, max[yahtzee.Yahtzee])
+
+	// in every language
+	yahtzeeBox := got[13]
+	ts.Exactly(map[string]string{"de": "Kniffel", "en": "Yahtzee", "hu": "Yahtzee"}, yahtzeeBox.DisplayNames)
+	ts.Exactly("Every dice showing the same face.", yahtzeeBox.Descriptions["en"])
+	ts.Len(yahtzeeBox.Descriptions, 3)
+
+	rr = ts.record(request("GET", "/categories"), withQuery("features", "unknown"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
 }
 
 func (ts *testSuite) TestHints() {
@@ -69,6 +237,9 @@ func (ts *testSuite) TestHints() {
 
 	rr := ts.record(request("GET", "/score"), withQuery("dices", "3,2,6,4,5"))
 	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Exactly(
+		"large-straight,small-straight,chance,sixes,fives,fours,threes,twos,ones,three-of-a-kind,four-of-a-kind,full-house,yahtzee",
+		rr.Header().Get("Hint-Ranking"))
 	ts.JSONEq(`{
 			"ones":0,
 			"twos":2,
@@ -84,6 +255,227 @@ func (ts *testSuite) TestHints() {
 			"yahtzee":0,
 			"chance":20
 		}`, rr.Body.String())
+
+	// with features
+	rr = ts.record(request("GET", "/score"), withQuery("dices", "3,2,6,2,6"), withQuery("features", "yatzy"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{
+			"ones":0,
+			"twos":4,
+			"threes":3,
+			"fours":0,
+			"fives":0,
+			"sixes":12,
+			"one-pair":12,
+			"two-pairs":16,
+			"three-of-a-kind":0,
+			"four-of-a-kind":0,
+			"full-house":0,
+			"small-straight":0,
+			"large-straight":0,
+			"yahtzee":0,
+			"chance":19
+		}`, rr.Body.String())
+
+	// the lowest scores first in lowball games
+	rr = ts.record(request("GET", "/score"), withQuery("dices", "3,2,6,4,5"), withQuery("features", "lowball"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Exactly(
+		"ones,three-of-a-kind,four-of-a-kind,full-house,yahtzee,twos,threes,fours,fives,sixes,chance,small-straight,large-straight",
+		rr.Header().Get("Hint-Ranking"))
+
+	// six dices
+	rr = ts.record(request("GET", "/score"), withQuery("dices", "3,2,6,2,6"), withQuery("features", "maxi"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("GET", "/score"), withQuery("dices", "3,2,6,2,6,6"), withQuery("features", "maxi"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{
+			"ones":0,
+			"twos":4,
+			"threes":3,
+			"fours":0,
+			"fives":0,
+			"sixes":18,
+			"one-pair":12,
+			"two-pairs":16,
+			"three-pairs":0,
+			"three-of-a-kind":18,
+			"four-of-a-kind":0,
+			"five-of-a-kind":0,
+			"full-house":22,
+			"castle":0,
+			"tower":0,
+			"small-straight":0,
+			"large-straight":0,
+			"full-straight":0,
+			"yahtzee":0,
+			"chance":25
+		}`, rr.Body.String())
+
+	// dices with more sides
+	rr = ts.record(request("GET", "/score"), withQuery("dices", "8,8,8,8,7"), withQuery("sides", "7"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("GET", "/score"), withQuery("dices", "8,8,8,8,7"), withQuery("sides", "21"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("GET", "/score"), withQuery("dices", "8,8,8,8,7"), withQuery("sides", "8"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	var got map[string]int
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.Exactly(39, got["chance"])
+	ts.Exactly(32, got["four-of-a-kind"])
+}
+
+func (ts *testSuite) TestSolverCache() {
+	for _, size := range []int{0, 1} {
+		h := handler.New(ts.store, ts.event, ts.event, handler.WithSolverCacheSize(size))
+
+		hints := func(dices string, features string) map[string]int {
+			rr := httptest.NewRecorder()
+			req := withQuery("features", features)(withQuery("dices", dices)(request("GET", "/score")))
+			h.ServeHTTP(rr, req)
+			ts.Require().Exactly(http.StatusOK, rr.Code)
+
+			var res map[string]int
+			ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &res))
+			return res
+		}
+
+		// the same multiset with the same rules
+		ts.Exactly(hints("2,3,2,3,2", ""), hints("3,3,2,2,2", ""), "with size %d", size)
+		ts.Exactly(25, hints("3,3,2,2,2", "")["full-house"], "with size %d", size)
+
+		// other rules and evicted entries
+		ts.Exactly(12, hints("3,3,2,2,2", "yatzy")["full-house"], "with size %d", size)
+		ts.Exactly(25, hints("3,3,2,2,2", "")["full-house"], "with size %d", size)
+		ts.Exactly(0, hints("1,2,3,4,5", "")["full-house"], "with size %d", size)
+	}
+}
+
+func (ts *testSuite) TestProbabilityTables() {
+	table := &engine.Table{
+		Version:  engine.TableVersion,
+		Features: []yahtzee.Feature{yahtzee.Yatzy},
+		Sides:    6,
+		Rolls:    1,
+		Entries: map[string]map[yahtzee.Category]float64{
+			"1,2,3,4,5|1": {yahtzee.Chance: 0.5},
+		},
+	}
+	h := handler.New(ts.store, ts.event, ts.event, handler.WithProbabilityTables(table))
+
+	probabilities := func(dice string, features string) string {
+		rr := httptest.NewRecorder()
+		req := withQuery("rolls", "1")(withQuery("features", features)(withQuery("dice", dice)(request("GET", "/probabilities"))))
+		h.ServeHTTP(rr, req)
+		ts.Require().Exactly(http.StatusOK, rr.Code)
+		return rr.Body.String()
+	}
+
+	ts.JSONEq(`{"chance": 0.5}`, probabilities("5,4,3,2,1", "yatzy"))
+
+	// calculated when missing from the tables
+	var got map[yahtzee.Category]float64
+	ts.Require().NoError(json.Unmarshal([]byte(probabilities("5,4,3,2,1", "")), &got))
+	ts.Exactly(1.0, got[yahtzee.Chance])
+	ts.Require().NoError(json.Unmarshal([]byte(probabilities("6,4,3,2,1", "yatzy")), &got))
+	ts.Exactly(1.0, got[yahtzee.Chance])
+}
+
+func (ts *testSuite) TestCalculate() {
+	badInputs := []struct {
+		description string
+		body        string
+	}{
+		{"no body", ""},
+		{"invalid body", "{"},
+		{"too few dices", `{"Dices": [1, 2, 3, 4]}`},
+		{"too many dices", `{"Dices": [1, 2, 3, 4, 5, 6]}`},
+		{"has low face value", `{"Dices": [1, 1, 1, 0, 1]}`},
+		{"has high face value", `{"Dices": [7, 6, 6, 6, 6]}`},
+		{"invalid rules", `{"Dices": [1, 1, 1, 1, 1], "Rules": {"YahtzeeScore": -1}}`},
+	}
+	for _, tc := range badInputs {
+		rr := ts.record(request("POST", "/calculate", tc.body))
+		ts.Exactly(http.StatusBadRequest, rr.Code, "when %s", tc.description)
+	}
+
+	rr := ts.record(request("POST", "/calculate", `{
+			"Dices": [4, 4, 4, 4, 4],
+			"Rules": {"YahtzeeScore": 75}
+		}`))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{
+			"ones":0,
+			"twos":0,
+			"threes":0,
+			"fours":20,
+			"fives":0,
+			"sixes":0,
+			"three-of-a-kind":12,
+			"four-of-a-kind":16,
+			"full-house":0,
+			"small-straight":0,
+			"large-straight":0,
+			"yahtzee":75,
+			"chance":20
+		}`, rr.Body.String())
+
+	// with features
+	rr = ts.record(request("POST", "/calculate", `{"Dices": [1, 2, 3, 4, 5, 6]}`), withQuery("features", "maxi"))
+	ts.Exactly(http.StatusOK, rr.Code)
+
+	// dices with more sides
+	rr = ts.record(request("POST", "/calculate", `{"Dices": [8, 8, 8, 8, 8], "Sides": 7}`))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("POST", "/calculate", `{"Dices": [8, 8, 8, 8, 8], "Sides": 21}`))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("POST", "/calculate", `{"Dices": [8, 8, 8, 8, 8], "Sides": 8}`))
+	ts.Exactly(http.StatusOK, rr.Code)
+	var got map[string]int
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.Exactly(40, got["chance"])
+	ts.Exactly(50, got["yahtzee"])
+}
+
+func (ts *testSuite) TestProbabilities() {
+	badInputs := []struct {
+		description string
+		dice        string
+		rolls       string
+	}{
+		{"no dice", "", "2"},
+		{"too few dices", "1,2,3,4", "2"},
+		{"has high face value", "7,6,6,6,6", "2"},
+		{"invalid rolls", "1,3,3,5,6", "many"},
+		{"negative rolls", "1,3,3,5,6", "-1"},
+		{"too many rolls", "1,3,3,5,6", "11"},
+	}
+	for _, tc := range badInputs {
+		rr := ts.record(request("GET", "/probabilities"), withQuery("dice", tc.dice), withQuery("rolls", tc.rolls))
+		ts.Exactly(http.StatusBadRequest, rr.Code, "when %s", tc.description)
+	}
+
+	rr := ts.record(request("GET", "/probabilities"), withQuery("dice", "6,1,6,6,6"), withQuery("rolls", "1"))
+	ts.Exactly(http.StatusOK, rr.Code)
+
+	var got map[yahtzee.Category]float64
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.Len(got, 13)
+	ts.Exactly(1.0, got[yahtzee.FourOfAKind])
+	ts.InDelta(1.0/6, got[yahtzee.Yahtzee], 1e-9)
+
+	// same hand in another order
+	cached := ts.record(request("GET", "/probabilities"), withQuery("dice", "6,6,6,6,1"), withQuery("rolls", "1"))
+	ts.Exactly(http.StatusOK, cached.Code)
+	ts.JSONEq(rr.Body.String(), cached.Body.String())
+
+	// dices with more sides
+	rr = ts.record(request("GET", "/probabilities"), withQuery("dice", "8,1,8,8,8"), withQuery("rolls", "1"), withQuery("sides", "8"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.InDelta(1.0/8, got[yahtzee.Yahtzee], 1e-9)
+	rr = ts.record(request("GET", "/probabilities"), withQuery("dice", "8,1,8,8,8"), withQuery("rolls", "1"), withQuery("sides", "1"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
 }
 
 func (ts *testSuite) TestGet() {
@@ -122,6 +514,7 @@ func (ts *testSuite) TestGet() {
 			{Value: 1, Locked: false},
 			{Value: 5, Locked: false},
 		},
+		Sides:         6,
 		Round:         5,
 		CurrentPlayer: 1,
 		RollCount:     1,
@@ -159,27 +552,94 @@ func (ts *testSuite) TestGet() {
 					"fives": 15,
 					"full-house": 25,
 					"twos": 6
-				}
+				},
+				"ExtraSheets": null,
+				"ExtraRolls": 0,
+				"Handicap": 0,
+				"Hints": 0,
+				"Away": 0,
+				"Achievements": null
 			},
 			{
 				"User": "Bob",
 				"ScoreSheet": {
 					"four-of-a-kind": 16,
 					"threes": 6
-				}
+				},
+				"ExtraSheets": null,
+				"ExtraRolls": 0,
+				"Handicap": 0,
+				"Hints": 0,
+				"Away": 0,
+				"Achievements": null
 			},
 			{
 				"User": "Carol",
 				"ScoreSheet": {
 					"small-straight": 30,
 					"twos": 6
-				}
+				},
+				"ExtraSheets": null,
+				"ExtraRolls": 0,
+				"Handicap": 0,
+				"Hints": 0,
+				"Away": 0,
+				"Achievements": null
 			}
 		],
+		"Sides": 6,
 		"Round": 5,
+		"Rounds": 0,
 		"CurrentPlayer": 1,
-		"RollCount": 1
+		"RollCount": 1,
+		"Announcement": "",
+		"ExtraRolls": 0,
+		"Features": null,
+		"Rules": null,
+		"Tables": null,
+		"Source": "",
+		"Daily": "",
+		"Tiebreak": null,
+		"Deadline": 0,
+		"Match": "",
+		"Locale": "",
+		"TimeZone": "",
+		"Creator": "",
+		"Sandbox": false
 	}`, rr.Body.String())
+	ts.Exactly(ts.fromStore("getID").Hash(), rr.Header().Get("Game-Hash"))
+}
+
+func (ts *testSuite) TestDiceShape() {
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	g.RollCount = 1
+	for i, d := range g.Dices {
+		d.Value = i + 1
+	}
+	ts.Require().NoError(ts.store.Save("shapeID", *g))
+
+	// unknown shape, the dice is not locked
+	rr := ts.record(request("POST", "/shapeID/lock/1"), asUser("Alice"), withQuery("shape", "cubes"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	rr = ts.record(request("POST", "/shapeID/lock/1"), asUser("Alice"), withQuery("shape", "objects"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"Dices": [
+		{"ID": 0, "Value": 1, "Locked": false},
+		{"ID": 1, "Value": 2, "Locked": true},
+		{"ID": 2, "Value": 3, "Locked": false},
+		{"ID": 3, "Value": 4, "Locked": false},
+		{"ID": 4, "Value": 5, "Locked": false}
+	]}`, rr.Body.String())
+
+	rr = ts.record(request("GET", "/shapeID"), withQuery("shape", "values"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	var got struct {
+		Dices []int
+	}
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.Exactly([]int{1, 2, 3, 4, 5}, got.Dices)
 }
 
 func (ts *testSuite) TestAddPlayer() {
@@ -210,7 +670,13 @@ func (ts *testSuite) TestAddPlayer() {
 		"Players": [
 			{
 				"User": "Alice",
-				"ScoreSheet": {}
+				"ScoreSheet": {},
+				"ExtraSheets": null,
+				"ExtraRolls": 0,
+				"Handicap": 0,
+				"Hints": 0,
+				"Away": 0,
+				"Achievements": null
 			}
 		]
 	}`, rr.Body.String())
@@ -222,7 +688,7 @@ func (ts *testSuite) TestAddPlayer() {
 	// add player event emitted
 	if got := <-eChan; ts.NotNil(got) {
 		ts.Exactly(event.AddPlayer, got.Action)
-		ts.Exactly(&handler.AddPlayerResponse{
+		ts.Exactly(&engine.AddPlayerResult{
 			Players: []*yahtzee.Player{yahtzee.NewPlayer("Alice")},
 		}, got.Data)
 	}
@@ -290,10 +756,10 @@ func (ts *testSuite) TestRoll() {
 	if got := <-eChan; ts.NotNil(got) {
 		ts.Exactly(event.Roll, got.Action)
 
-		ts.Exactly(saved.RollCount, got.Data.(*handler.RollResponse).RollCount)
-		ts.Exactly(saved.Dices, got.Data.(*handler.RollResponse).Dices)
+		ts.Exactly(saved.RollCount, got.Data.(*engine.RollResult).RollCount)
+		ts.Exactly(saved.Dices, got.Data.(*engine.RollResult).Dices)
 
-		if eventJSON, err := json.Marshal(got.Data.(*handler.RollResponse)); ts.NoError(err) {
+		if eventJSON, err := json.Marshal(got.Data.(*engine.RollResult)); ts.NoError(err) {
 			ts.JSONEq(string(eventJSON), rr.Body.String())
 		}
 	}
@@ -401,8 +867,9 @@ func (ts *testSuite) TestLock() {
 	// successful request
 	eChan := ts.receiveEvents("lockID")
 
-	rr = ts.record(request("POST", "/lockID/lock/2"), asUser("Alice"))
+	rr = ts.record(request("POST", "/lockID/lock/2"), asUser("Alice"), withHeader("Action-ID", "lock-1"))
 	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Exactly("lock-1", rr.Header().Get("Applied-Action-ID"))
 	ts.JSONEq(`{
 		"Dices": [
 			{
@@ -432,7 +899,8 @@ func (ts *testSuite) TestLock() {
 	if got := <-eChan; ts.NotNil(got) {
 		ts.Exactly(event.Lock, got.Action)
 
-		ts.Exactly(saved.Dices, got.Data.(*handler.LockResponse).Dices)
+		ts.Exactly(saved.Dices, got.Data.(*engine.LockResult).Dices)
+		ts.Exactly("lock-1", got.AppliedActionID)
 	}
 }
 
@@ -506,11 +974,23 @@ func (ts *testSuite) TestScore() {
 				"ScoreSheet": {
 					"chance": 5,
 					"full-house": 25
-				}
+				},
+				"ExtraSheets": null,
+				"ExtraRolls": 0,
+				"Handicap": 0,
+				"Hints": 0,
+				"Away": 0,
+				"Achievements": null
 			},
 			{
 				"User": "Bob",
-				"ScoreSheet": {}
+				"ScoreSheet": {},
+				"ExtraSheets": null,
+				"ExtraRolls": 0,
+				"Handicap": 0,
+				"Hints": 0,
+				"Away": 0,
+				"Achievements": null
 			}
 		],
 		"Dices": [
@@ -535,17 +1015,54 @@ func (ts *testSuite) TestScore() {
 				"Locked": false
 			}
 		],
+		"Sides": 6,
 		"Round": 0,
+		"Rounds": 0,
 		"CurrentPlayer": 1,
-		"RollCount": 0
+		"RollCount": 0,
+		"Announcement": "",
+		"ExtraRolls": 0,
+		"Features": [],
+		"Rules": null,
+		"Tables": null,
+		"Source": "",
+		"Daily": "",
+		"Tiebreak": null,
+		"Deadline": 0,
+		"Match": "",
+		"Locale": "",
+		"TimeZone": "",
+		"Creator": "",
+		"Sandbox": false
 	}`, rr.Body.String())
 
 	saved := ts.fromStore("scoreID")
 	if got := <-eChan; ts.NotNil(got) {
 		ts.Exactly(event.Score, got.Action)
 		ts.Exactly(saved, got.Data.(*yahtzee.Game))
+		ts.Exactly(saved.Hash(), got.Hash)
 	}
 
+	// columns
+	triple := yahtzee.NewGame(yahtzee.Triple)
+	triple.Players = []*yahtzee.Player{{
+		User:        "Alice",
+		ScoreSheet:  map[yahtzee.Category]int{yahtzee.Chance: 5},
+		ExtraSheets: []map[yahtzee.Category]int{{}, {}},
+	}}
+	triple.RollCount = 1
+	ts.Require().NoError(ts.store.Save("score_columnID", *triple))
+
+	rr = ts.record(request("POST", "/score_columnID/score", "chance"), asUser("Alice"), withQuery("column", "3"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("POST", "/score_columnID/score", "chance"), asUser("Alice"), withQuery("column", "two"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("POST", "/score_columnID/score", "chance"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("POST", "/score_columnID/score", "chance"), asUser("Alice"), withQuery("column", "2"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Exactly(5, ts.fromStore("score_columnID").Players[0].ExtraSheets[1][yahtzee.Chance])
+
 	// scoring
 	scoringCases := []struct {
 		dices    []int
@@ -697,91 +1214,2159 @@ func (ts *testSuite) TestScore() {
 	}
 }
 
-func (ts *testSuite) TestWS() {
-	server := httptest.NewServer(ts.handler)
-	defer server.Close()
-	baseUrl := "ws" + strings.TrimPrefix(server.URL, "http")
-
-	ts.Require().NoError(ts.store.Save("wsID", *yahtzee.NewGame()))
+func (ts *testSuite) TestScratch() {
+	// missing user
+	rr := ts.record(request("POST", "/scratchID/scratch", "chance"))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
 
-	ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"/wsID/ws", nil)
-	if !ts.NoError(err) {
-		return
-	}
-	defer ws.Close()
+	// game not exists
+	rr = ts.record(request("POST", "/scratchID/scratch", "chance"), asUser("Alice"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
 
-	ts.event.Emit("wsID", yahtzee.NewUser("Alice"), event.AddPlayer, nil)
+	// no roll happened yet
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	ts.Require().NoError(ts.store.Save("scratchID", *g))
 
-	_, p, err := ws.ReadMessage()
-	if ts.NoError(err) {
-		ts.JSONEq(`{
-				"User": "Alice",
-				"Action": "add-player",
-				"Data": null
-			}`, string(p))
-	}
-}
+	rr = ts.record(request("POST", "/scratchID/scratch", "chance"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
 
-func (ts *testSuite) record(
-	req *http.Request,
-	modifiers ...func(*http.Request) *http.Request) *httptest.ResponseRecorder {
-	rr := httptest.NewRecorder()
+	// successful request
+	g.RollCount = 1
+	ts.Require().NoError(ts.store.Save("scratchID", *g))
+	eChan := ts.receiveEvents("scratchID")
 
-	for _, modifier := range modifiers {
-		req = modifier(req)
-	}
+	rr = ts.record(request("POST", "/scratchID/scratch", "chance"), asUser("Alice"))
+	ts.Exactly(http.StatusOK, rr.Code)
 
-	ts.handler.ServeHTTP(rr, req)
+	saved := ts.fromStore("scratchID")
+	ts.Exactly(map[yahtzee.Category]int{yahtzee.Chance: 0}, saved.Players[0].ScoreSheet)
+	ts.Exactly(1, saved.CurrentPlayer)
 
-	return rr
+	if got := <-eChan; ts.NotNil(got) {
+		ts.Exactly(event.Scratch, got.Action)
+	}
 }
 
-func (ts *testSuite) fromStore(id string) *yahtzee.Game {
-	res, err := ts.store.Load(id)
-	ts.Require().NoError(err)
-	return &res
-}
+func (ts *testSuite) TestTurn() {
+	turn := `{"Keep": [[6, 6], [6, 6, 6]], "Category": "chance"}`
 
-func (ts *testSuite) receiveEvents(id string) chan *event.Event {
-	c, err := ts.event.Subscribe(id, id)
-	ts.Require().NoError(err)
+	// missing user
+	rr := ts.record(request("POST", "/turnID/turn", turn))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
 
-	res := make(chan *event.Event, 1)
+	// game not exists
+	rr = ts.record(request("POST", "/turnID/turn", turn), asUser("Alice"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
 
-	go func() {
-		for {
-			select {
-			case got := <-c:
-				res <- got
-			case <-time.After(500 * time.Millisecond):
-				res <- nil
-				return
-			}
-		}
-	}()
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	ts.Require().NoError(ts.store.Save("turnID", *g))
 
-	return res
-}
+	// invalid turns
+	rr = ts.record(request("POST", "/turnID/turn", `{"Keep": [[6], [6], [6]], "Category": "chance"}`), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	ts.Contains(rr.Body.String(), "no-more-rolls")
+	rr = ts.record(request("POST", "/turnID/turn", `{"Category": "wat"}`), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("POST", "/turnID/turn", `[`), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	ts.Exactly(0, ts.fromStore("turnID").RollCount)
 
-func request(method string, url string, body ...interface{}) *http.Request {
-	var bodyReader io.Reader
+	// successful request
+	rr = ts.record(request("POST", "/turnID/turn", turn), asUser("Alice"))
+	ts.Exactly(http.StatusOK, rr.Code)
 
-	if len(body) == 1 {
-		switch body[0].(type) {
-		case string:
-			bodyReader = strings.NewReader(body[0].(string))
-		}
-	}
+	saved := ts.fromStore("turnID")
+	_, scored := saved.Players[0].ScoreSheet[yahtzee.Chance]
+	ts.True(scored)
+	ts.Exactly(1, saved.CurrentPlayer)
+	ts.Exactly(0, saved.RollCount)
 
-	req, err := http.NewRequest(method, url, bodyReader)
-	if err != nil {
-		panic(err)
-	}
-	return req
+	// the turn is started already
+	saved.RollCount = 1
+	ts.Require().NoError(ts.store.Save("turnID", *saved))
+	rr = ts.record(request("POST", "/turnID/turn", turn), asUser("Bob"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	ts.Contains(rr.Body.String(), "turn-started")
 }
 
-func withQuery(key, value string) func(*http.Request) *http.Request {
-	return func(req *http.Request) *http.Request {
+func (ts *testSuite) TestEmbed() {
+	// game not exists
+	rr := ts.record(request("GET", "/embedID/embed"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	ts.Require().NoError(ts.store.Save("embedID", *g))
+
+	rr = ts.record(request("GET", "/embedID/embed"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Exactly("text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	ts.Contains(rr.Body.String(), `<meta http-equiv="refresh" content="5">`)
+	ts.Contains(rr.Body.String(), "Round 1/13")
+	ts.Contains(rr.Body.String(), `<tr class="current"><td>&#9656; Alice</td><td class="total">0</td></tr>`)
+
+	// the scoreboard follows the events
+	rr = ts.record(request("POST", "/embedID/turn", `{"Category": "chance"}`), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	saved := ts.fromStore("embedID")
+	total := saved.Players[0].ScoreSheet[yahtzee.Chance]
+
+	// the game is not loaded again
+	saved.Players[0].ScoreSheet[yahtzee.Chance] = 100
+	ts.Require().NoError(ts.store.Save("embedID", *saved))
+
+	rr = ts.record(withQuery("refresh", "30")(request("GET", "/embedID/embed")))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Contains(rr.Body.String(), `<meta http-equiv="refresh" content="30">`)
+	ts.Contains(rr.Body.String(), fmt.Sprintf(`<td>Alice</td><td class="total">%d</td>`, total))
+	ts.Contains(rr.Body.String(), `<td>&#9656; Bob</td>`)
+
+	rr = ts.record(withQuery("refresh", "1")(request("GET", "/embedID/embed")))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+}
+
+func (ts *testSuite) TestAnnounce() {
+	// missing user
+	rr := ts.record(request("POST", "/announceID/announce", "chance"))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
+
+	// game not exists
+	rr = ts.record(request("POST", "/announceID/announce", "chance"), asUser("Alice"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+
+	// feature not enabled
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	g.RollCount = 1
+	ts.Require().NoError(ts.store.Save("announceID", *g))
+
+	rr = ts.record(request("POST", "/announceID/announce", "chance"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// scoring before announcing
+	g.Features = []yahtzee.Feature{yahtzee.Announce}
+	ts.Require().NoError(ts.store.Save("announceID", *g))
+
+	rr = ts.record(request("POST", "/announceID/score", "chance"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// successful request
+	eChan := ts.receiveEvents("announceID")
+
+	rr = ts.record(request("POST", "/announceID/announce", "chance"), asUser("Alice"), withHeader("Action-ID", "announce-1"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Exactly("announce-1", rr.Header().Get("Applied-Action-ID"))
+	ts.JSONEq(`{"Announcement": "chance"}`, rr.Body.String())
+	ts.Exactly(yahtzee.Category(yahtzee.Chance), ts.fromStore("announceID").Announcement)
+
+	if got := <-eChan; ts.NotNil(got) {
+		ts.Exactly(event.Announce, got.Action)
+		ts.Exactly(yahtzee.Category(yahtzee.Chance), got.Data.(*engine.AnnounceResult).Announcement)
+		ts.Exactly("announce-1", got.AppliedActionID)
+	}
+
+	// scoring another category
+	rr = ts.record(request("POST", "/announceID/score", "ones"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// scoring the announced category
+	rr = ts.record(request("POST", "/announceID/score", "chance"), asUser("Alice"))
+	ts.Exactly(http.StatusOK, rr.Code)
+
+	saved := ts.fromStore("announceID")
+	ts.Exactly(5, saved.Players[0].ScoreSheet[yahtzee.Chance])
+	ts.Exactly(yahtzee.Category(""), saved.Announcement)
+}
+
+func (ts *testSuite) TestExtraRoll() {
+	// missing user
+	rr := ts.record(request("POST", "/extraRollID/extra-roll"))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
+
+	// game not exists
+	rr = ts.record(request("POST", "/extraRollID/extra-roll"), asUser("Alice"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+
+	// feature not enabled
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	g.RollCount = 3
+	ts.Require().NoError(ts.store.Save("extraRollID", *g))
+
+	rr = ts.record(request("POST", "/extraRollID/extra-roll"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// successful request
+	g.Features = []yahtzee.Feature{yahtzee.ExtraRoll}
+	g.Players[0].ExtraRolls = 1
+	ts.Require().NoError(ts.store.Save("extraRollID", *g))
+
+	eChan := ts.receiveEvents("extraRollID")
+
+	rr = ts.record(request("POST", "/extraRollID/extra-roll"), asUser("Alice"), withHeader("Action-ID", "extra-1"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Exactly("extra-1", rr.Header().Get("Applied-Action-ID"))
+	ts.JSONEq(`{"ExtraRolls": 0, "RollsPerTurn": 4}`, rr.Body.String())
+
+	got := <-eChan
+	ts.Require().NotNil(got)
+	ts.Exactly(event.ExtraRoll, got.Action)
+
+	saved := ts.fromStore("extraRollID")
+	ts.Exactly(1, saved.ExtraRolls)
+	ts.Exactly(0, saved.Players[0].ExtraRolls)
+
+	// one more roll
+	rr = ts.record(request("POST", "/extraRollID/roll"), asUser("Alice"))
+	ts.Exactly(http.StatusOK, rr.Code)
+
+	// out of tokens
+	rr = ts.record(request("POST", "/extraRollID/extra-roll"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+}
+
+func (ts *testSuite) TestBlitz() {
+	// invalid shot clock
+	rr := ts.record(request("POST", "/", `{"Rules": {"ShotClock": -1}}`), withQuery("features", "blitz"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	g := yahtzee.NewGame(yahtzee.Blitz)
+	g.Rules = &yahtzee.Rules{ShotClock: 1}
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	ts.Require().NoError(ts.store.Save("blitzID", *g))
+
+	c, err := ts.event.Subscribe("blitzID", "blitzID")
+	ts.Require().NoError(err)
+	events := make(chan *event.Event, 10)
+	go func() {
+		for e := range c {
+			events <- e
+		}
+	}()
+
+	rr = ts.record(request("POST", "/blitzID/roll"), asUser("Alice"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Exactly(event.Roll, (<-events).Action)
+
+	select {
+	case got := <-events:
+		ts.Exactly(event.Timeout, got.Action)
+		ts.Nil(got.User)
+	case <-time.After(3 * time.Second):
+		ts.Fail("no timeout event")
+	}
+	ts.Require().NoError(ts.event.Unsubscribe("blitzID", "blitzID"))
+
+	saved := ts.fromStore("blitzID")
+	ts.Exactly(map[yahtzee.Category]int{yahtzee.Ones: 0}, saved.Players[0].ScoreSheet)
+	ts.Exactly(1, saved.CurrentPlayer)
+	ts.NotZero(saved.Deadline)
+
+	// Bob acts in time, the clock of his roll replaces the previous one
+	rr = ts.record(request("POST", "/blitzID/roll"), asUser("Bob"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	rr = ts.record(request("POST", "/blitzID/score", "chance"), asUser("Bob"))
+	ts.Exactly(http.StatusOK, rr.Code)
+}
+
+func (ts *testSuite) TestResumeClocks() {
+	now := time.Unix(2000, 0)
+	clock := func() time.Time { return now }
+
+	for _, gameID := range []string{"resumeID", "lateID"} {
+		g := yahtzee.NewGame(yahtzee.Blitz)
+		g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+		g.RollCount = 1
+		g.Deadline = 1500000
+		ts.Require().NoError(ts.store.Save(gameID, *g))
+	}
+
+	clocks := store.New()
+	ts.Require().NoError(clocks.SetClock("resumeID", 1500000))
+	ts.Require().NoError(clocks.SetClock("goneID", 1500000))
+
+	events := make(chan *event.Event, 10)
+	for _, gameID := range []string{"resumeID", "lateID"} {
+		c, err := ts.event.Subscribe(gameID, gameID)
+		ts.Require().NoError(err)
+		go func() {
+			for e := range c {
+				events <- e
+			}
+		}()
+	}
+	waitTimeout := func() {
+		for {
+			select {
+			case got := <-events:
+				if got.Action == event.Timeout {
+					return
+				}
+			case <-time.After(3 * time.Second):
+				ts.Fail("no timeout event")
+				return
+			}
+		}
+	}
+
+	// the clock ran out while the server was down
+	h := handler.New(ts.store, ts.event, ts.event, handler.WithClock(clock), handler.WithClocks(clocks))
+	waitTimeout()
+
+	ts.Exactly(1, ts.fromStore("resumeID").CurrentPlayer)
+	ts.Eventually(func() bool {
+		got, err := clocks.Clocks()
+		return err == nil && reflect.DeepEqual(map[string]int64{"resumeID": 2015000}, got)
+	}, 3*time.Second, time.Millisecond)
+
+	// late moves are refused and end the turn
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, asUser("Alice")(request("POST", "/lateID/score", "chance")))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	waitTimeout()
+	ts.Require().NoError(ts.event.Unsubscribe("resumeID", "resumeID"))
+	ts.Require().NoError(ts.event.Unsubscribe("lateID", "lateID"))
+
+	ts.Exactly(map[yahtzee.Category]int{yahtzee.Ones: 0}, ts.fromStore("lateID").Players[0].ScoreSheet)
+}
+
+func (ts *testSuite) TestCountdown() {
+	g := yahtzee.NewGame(yahtzee.Blitz)
+	g.Rules = &yahtzee.Rules{ShotClock: 11}
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	for _, p := range g.Players {
+		for _, c := range yahtzee.Categories()[1:] {
+			p.ScoreSheet[c] = 0
+		}
+	}
+	g.Round = 12
+	ts.Require().NoError(ts.store.Save("countdownID", *g))
+
+	c, err := ts.event.Subscribe("countdownID", "countdownID")
+	ts.Require().NoError(err)
+	events := make(chan *event.Event, 10)
+	go func() {
+		for e := range c {
+			events <- e
+		}
+	}()
+
+	rr := ts.record(request("POST", "/countdownID/roll"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Exactly(event.Roll, (<-events).Action)
+
+	// ten seconds left
+	select {
+	case got := <-events:
+		ts.Exactly(event.Countdown, got.Action)
+		if res, ok := got.Data.(*engine.CountdownResult); ts.True(ok) {
+			ts.Exactly(yahtzee.User("Alice"), res.User)
+			ts.Exactly(10, res.Remaining)
+			ts.Exactly(ts.fromStore("countdownID").Deadline, res.Deadline)
+		}
+	case <-time.After(3 * time.Second):
+		ts.Fail("no countdown event")
+	}
+
+	rr = ts.record(request("POST", "/countdownID/score", "ones"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Exactly(event.Score, (<-events).Action)
+	got := <-events
+	ts.Exactly(event.TurnChanged, got.Action)
+	if res, ok := got.Data.(*engine.TurnChangedResult); ts.True(ok) {
+		ts.Exactly(yahtzee.User("Bob"), res.User)
+		ts.Exactly(ts.fromStore("countdownID").Deadline, res.Deadline)
+	}
+	ts.Require().NoError(ts.event.Unsubscribe("countdownID", "countdownID"))
+
+	// the game is over, the clock stops
+	rr = ts.record(request("POST", "/countdownID/roll"), asUser("Bob"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	rr = ts.record(request("POST", "/countdownID/score", "ones"), asUser("Bob"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Zero(ts.fromStore("countdownID").Deadline)
+}
+
+func (ts *testSuite) TestProjection() {
+	g := yahtzee.NewGame(yahtzee.Projection)
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	ts.Require().NoError(ts.store.Save("projectionID", *g))
+
+	events := ts.receiveEvents("projectionID")
+
+	rr := ts.record(request("POST", "/projectionID/roll"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Exactly(event.Roll, (<-events).Action)
+
+	rr = ts.record(request("POST", "/projectionID/score", "chance"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Exactly(event.Score, (<-events).Action)
+
+	got := <-events
+	ts.Exactly(event.TurnChanged, got.Action)
+	if res, ok := got.Data.(*engine.TurnChangedResult); ts.True(ok) {
+		ts.Exactly(yahtzee.User("Bob"), res.User)
+
+		saved := ts.fromStore("projectionID")
+		expected, err := engine.ProjectionExpectations(saved)
+		ts.Require().NoError(err)
+		ts.Exactly(engine.Projections(saved, expected), res.Projections)
+
+		// bob has every box open
+		full := 0.0
+		for _, e := range expected {
+			full += e
+		}
+		ts.InDelta(full, res.Projections["Bob"], 1e-9)
+	}
+}
+
+func (ts *testSuite) TestWinProbability() {
+	g := yahtzee.NewGame()
+	ts.Require().NoError(ts.store.Save("winID", *g))
+
+	rr := ts.record(request("GET", "/winID/win-probability"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// bob can't catch up in the last turn
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	for _, p := range g.Players {
+		for _, c := range yahtzee.Categories()[1:] {
+			p.ScoreSheet[c] = 0
+		}
+	}
+	g.Players[0].ScoreSheet[yahtzee.Ones] = 0
+	g.Players[0].ScoreSheet[yahtzee.Chance] = 100
+	g.Round = 12
+	g.CurrentPlayer = 1
+	ts.Require().NoError(ts.store.Save("winID", *g))
+
+	rr = ts.record(request("GET", "/winID/win-probability"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{
+		"Round": 12,
+		"User": "Bob",
+		"Simulations": 500,
+		"Probabilities": {"Alice": 1, "Bob": 0}
+	}`, rr.Body.String())
+
+	rr = ts.record(request("GET", "/nonexisting/win-probability"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+
+	// a batch of simulations is played without budget
+	h := handler.New(ts.store, ts.event, ts.event, handler.WithSimulationBudget(0))
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, request("GET", "/winID/win-probability"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{
+		"Round": 12,
+		"User": "Bob",
+		"Simulations": 50,
+		"Probabilities": {"Alice": 1, "Bob": 0}
+	}`, rr.Body.String())
+
+	// the jokers of the faces above six are simulated, a single dice is
+	// always a yahtzee
+	g = yahtzee.NewGame(yahtzee.YahtzeeBonus)
+	g.SetDices(1, 8)
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	g.Players[0].ScoreSheet[yahtzee.Yahtzee] = 50
+	g.Round = 1
+	ts.Require().NoError(ts.store.Save("jokerID", *g))
+
+	rr = ts.record(request("GET", "/jokerID/win-probability"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{
+		"Round": 1,
+		"User": "Alice",
+		"Simulations": 500,
+		"Probabilities": {"Alice": 1}
+	}`, rr.Body.String())
+}
+
+func (ts *testSuite) TestClone() {
+	s := store.New()
+	h := handler.New(s, ts.event, ts.event, handler.WithBestScores(s))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	g := yahtzee.NewGame(yahtzee.Solo)
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	for _, c := range yahtzee.Categories()[1:] {
+		g.Players[0].ScoreSheet[c] = 0
+	}
+	g.Round = 12
+	ts.Require().NoError(s.Save("cloneID", *g))
+
+	rr := serve(request("POST", "/cloneID/clone"))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
+
+	rr = serve(asUser("Bob")(request("POST", "/cloneID/clone")))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	sandboxID := strings.TrimLeft(rr.Header().Get("Location"), "/")
+
+	sandbox, err := s.Load(sandboxID)
+	ts.Require().NoError(err)
+	ts.True(sandbox.Sandbox)
+	ts.Exactly(yahtzee.User("Bob"), sandbox.Creator)
+
+	// the owner plays the turn of alice
+	rr = serve(asUser("Bob")(request("POST", "/"+sandboxID+"/roll")))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	rr = serve(asUser("Bob")(request("POST", "/"+sandboxID+"/score", "ones")))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+
+	sandbox, err = s.Load(sandboxID)
+	ts.Require().NoError(err)
+	ts.True(engine.IsOver(&sandbox))
+
+	// the original is left as it was, and the result is not recorded
+	original, err := s.Load("cloneID")
+	ts.Require().NoError(err)
+	ts.Exactly(12, original.Round)
+	rr = serve(request("GET", "/users/Alice/best"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+
+	// nobody joins the sandbox
+	rr = serve(asUser("Carol")(request("POST", "/"+sandboxID+"/join")))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	rr = serve(request("GET", "/games"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Contains(rr.Body.String(), `"Sandbox":true`)
+
+	rr = serve(asUser("Bob")(request("POST", "/nonexisting/clone")))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+}
+
+func (ts *testSuite) TestSolo() {
+	// missing user
+	rr := ts.record(request("POST", "/"), withQuery("features", "solo"))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
+
+	// creator joins
+	rr = ts.record(request("POST", "/"), withQuery("features", "solo"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	gameID := strings.TrimLeft(rr.Header().Get("Location"), "/")
+
+	g := ts.fromStore(gameID)
+	ts.Require().Len(g.Players, 1)
+	ts.Exactly(yahtzee.User("Alice"), g.Players[0].User)
+
+	// nobody else can join
+	rr = ts.record(request("POST", "/"+gameID+"/join"), asUser("Bob"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// no best score yet
+	rr = ts.record(request("GET", "/users/Alice/best"), asUser("Alice"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+
+	// finishing the game records the best score
+	for _, c := range yahtzee.Categories()[1:] {
+		g.Players[0].ScoreSheet[c] = 10
+	}
+	g.Round = 12
+	g.RollCount = 1
+	ts.Require().NoError(ts.store.Save(gameID, *g))
+
+	rr = ts.record(request("POST", "/"+gameID+"/score", "ones"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+
+	rr = ts.record(request("GET", "/users/Alice/best"), asUser("Alice"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"User": "Alice", "Score": 125}`, rr.Body.String())
+}
+
+func (ts *testSuite) TestDaily() {
+	// missing user
+	rr := ts.record(request("POST", "/daily"))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
+
+	rr = ts.record(request("POST", "/daily"), asUser("Dave"))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	daveID := strings.TrimLeft(rr.Header().Get("Location"), "/")
+
+	dave := ts.fromStore(daveID)
+	ts.Exactly([]yahtzee.Feature{yahtzee.Solo, yahtzee.Duplicate}, dave.Features)
+	ts.Exactly(time.Now().UTC().Format("2006-01-02"), dave.Daily)
+	ts.Require().Len(dave.Players, 1)
+
+	// the game of the day is returned again
+	rr = ts.record(request("POST", "/daily"), asUser("Dave"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Exactly("/"+daveID, rr.Header().Get("Location"))
+
+	// everyone gets the same rolls
+	rr = ts.record(request("POST", "/daily"), asUser("Erin"))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	erinID := strings.TrimLeft(rr.Header().Get("Location"), "/")
+	ts.NotEqual(daveID, erinID)
+	ts.Exactly(dave.Seed, ts.fromStore(erinID).Seed)
+
+	// finishing the game records the score of the day
+	for _, c := range yahtzee.Categories()[1:] {
+		dave.Players[0].ScoreSheet[c] = 10
+	}
+	dave.Round = 12
+	dave.RollCount = 1
+	ts.Require().NoError(ts.store.Save(daveID, *dave))
+
+	rr = ts.record(request("POST", "/"+daveID+"/score", "ones"), asUser("Dave"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+
+	rr = ts.record(request("GET", "/daily"), withQuery("day", dave.Daily))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(fmt.Sprintf(`{
+		"Day": %q,
+		"Standings": [{"Rank": 1, "User": "Dave", "Score": 125}]
+	}`, dave.Daily), rr.Body.String())
+
+	// no scores on other days
+	rr = ts.record(request("GET", "/daily"), withQuery("day", "2021-03-14"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"Day": "2021-03-14", "Standings": []}`, rr.Body.String())
+
+	rr = ts.record(request("GET", "/daily"), withQuery("day", "yesterday"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+}
+
+func (ts *testSuite) TestMatch() {
+	// missing user
+	rr := ts.record(request("POST", "/matches", `{"BestOf": 3}`))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
+
+	// invalid best of
+	rr = ts.record(request("POST", "/matches", `{"BestOf": 10}`), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	rr = ts.record(request("POST", "/matches", `{"BestOf": 1, "Players": ["Bob"]}`), asUser("Alice"))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	matchURL := rr.Header().Get("Location")
+
+	var m yahtzee.Match
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &m))
+	ts.Exactly([]yahtzee.User{"Alice", "Bob"}, m.Players)
+	ts.Require().Len(m.Games, 1)
+
+	gameID := m.Games[0]
+	g := ts.fromStore(gameID)
+	ts.Require().Len(g.Players, 2)
+	ts.Exactly(strings.TrimPrefix(matchURL, "/matches/"), g.Match)
+
+	// nobody else can join
+	rr = ts.record(request("POST", "/"+gameID+"/join"), asUser("Carol"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// the last score of the game is recorded in the match
+	for _, p := range g.Players {
+		for _, c := range yahtzee.Categories()[1:] {
+			p.ScoreSheet[c] = 0
+		}
+	}
+	g.Players[0].ScoreSheet[yahtzee.Ones] = 5
+	g.Round = 12
+	g.CurrentPlayer = 1
+	g.RollCount = 1
+	ts.Require().NoError(ts.store.Save(gameID, *g))
+
+	c, err := ts.event.Subscribe(gameID, gameID)
+	ts.Require().NoError(err)
+	events := make(chan *event.Event, 10)
+	go func() {
+		for e := range c {
+			events <- e
+		}
+	}()
+
+	rr = ts.record(request("POST", "/"+gameID+"/score", "ones"), asUser("Bob"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Exactly(event.Score, (<-events).Action)
+	got := <-events
+	ts.Exactly(event.GameOver, got.Action)
+	if res, ok := got.Data.(*engine.GameOverResult); ts.True(ok) {
+		ts.Exactly([]yahtzee.User{"Alice", "Bob"}, res.Winners)
+	}
+	ts.Exactly(event.MatchGame, (<-events).Action)
+	ts.Require().NoError(ts.event.Unsubscribe(gameID, gameID))
+
+	rr = ts.record(request("GET", matchURL))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	m = yahtzee.Match{}
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &m))
+	ts.Exactly(map[yahtzee.User]int{"Alice": 1, "Bob": 1}, m.Wins)
+	ts.Exactly(map[yahtzee.User]int{"Alice": 5, "Bob": 5}, m.Points)
+	ts.Exactly(yahtzee.User(""), m.Winner)
+
+	// the tie goes on with a new game of the same players
+	ts.Require().Len(m.Games, 2)
+	next := ts.fromStore(m.Games[1])
+	ts.Require().Len(next.Players, 2)
+	ts.Exactly(yahtzee.User("Alice"), next.Players[0].User)
+	ts.Exactly(yahtzee.User("Bob"), next.Players[1].User)
+	ts.Exactly(g.Match, next.Match)
+
+	// match not exists
+	rr = ts.record(request("GET", "/matches/nope"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+}
+
+func (ts *testSuite) TestHandicap() {
+	// not a handicap game
+	ts.Require().NoError(ts.store.Save("noHandicapID", *yahtzee.NewGame()))
+	rr := ts.record(request("POST", "/noHandicapID/join"), withQuery("handicap", "25"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	ts.Require().NoError(ts.store.Save("handicapID", *yahtzee.NewGame(yahtzee.Handicap)))
+
+	// invalid handicap
+	rr = ts.record(request("POST", "/handicapID/join"), withQuery("handicap", "lots"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("POST", "/handicapID/join"), withQuery("handicap", "500"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// the plus sign of the raw query is a space
+	req := request("POST", "/handicapID/join?handicap=+25")
+	rr = ts.record(req, asUser("Alice"))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	rr = ts.record(request("POST", "/handicapID/join"), asUser("Bob"))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+
+	g := ts.fromStore("handicapID")
+	ts.Exactly(25, g.Players[0].Handicap)
+	ts.Exactly(0, g.Players[1].Handicap)
+
+	g.Players[1].ScoreSheet[yahtzee.Chance] = 20
+	ts.Require().NoError(ts.store.Save("handicapID", *g))
+
+	rr = ts.record(request("GET", "/handicapID"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+
+	var got handler.GameResponse
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.Exactly([]*handler.TotalResponse{
+		{User: "Alice", Raw: 0, Adjusted: 25},
+		{User: "Bob", Raw: 20, Adjusted: 20},
+	}, got.Totals)
+	ts.Exactly(g.Players[0].Handicap, got.Players[0].Handicap)
+	ts.Exactly(g.Hash(), rr.Header().Get("Game-Hash"))
+
+	// the totals of the teams with partners
+	g = yahtzee.NewGame(yahtzee.Handicap, yahtzee.Partners)
+	for i, u := range []yahtzee.User{"Alice", "Bob", "Carol", "Dave"} {
+		p := yahtzee.NewPlayer(u)
+		p.Handicap = 5 * i
+		g.Players = append(g.Players, p)
+	}
+	g.Players[0].ScoreSheet[yahtzee.Chance] = 20
+	ts.Require().NoError(ts.store.Save("partnersHandicapID", *g))
+
+	rr = ts.record(request("GET", "/partnersHandicapID"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.Exactly([]*handler.TotalResponse{
+		{User: "Alice", Raw: 20, Adjusted: 30},
+		{User: "Bob", Raw: 0, Adjusted: 20},
+		{User: "Carol", Raw: 20, Adjusted: 30},
+		{User: "Dave", Raw: 0, Adjusted: 20},
+	}, got.Totals)
+}
+
+func (ts *testSuite) TestAway() {
+	g := yahtzee.NewGame(yahtzee.Blitz)
+	g.Rules = &yahtzee.Rules{MaxAway: 3600}
+	ts.Require().NoError(ts.store.Save("awayID", *g))
+	ts.Require().NoError(ts.store.Save("notBlitzAwayID", *yahtzee.NewGame()))
+	for _, id := range []string{"awayID", "notBlitzAwayID"} {
+		for _, u := range []string{"Alice", "Bob"} {
+			rr := ts.record(request("POST", "/"+id+"/join"), asUser(u))
+			ts.Require().Exactly(http.StatusCreated, rr.Code)
+		}
+	}
+	rr := ts.record(request("POST", "/awayID/roll"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+
+	until := time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond)
+	body := fmt.Sprintf(`{"Until": %d}`, until)
+
+	// only for themselves
+	rr = ts.record(request("POST", "/users/Alice/away", body), asUser("Bob"))
+	ts.Exactly(http.StatusForbidden, rr.Code)
+
+	c, err := ts.event.Subscribe("awayID", "awayID")
+	ts.Require().NoError(err)
+	events := make(chan *event.Event, 10)
+	go func() {
+		for e := range c {
+			events <- e
+		}
+	}()
+
+	// the clock is paused in the blitz games
+	rr = ts.record(request("POST", "/users/Alice/away", body), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"Games": ["awayID"]}`, rr.Body.String())
+
+	g = ts.fromStore("awayID")
+	ts.Exactly(until, g.Players[0].Away)
+	ts.Exactly(until+15000, g.Deadline)
+
+	got := <-events
+	ts.Exactly(event.Away, got.Action)
+	if res, ok := got.Data.(*engine.AwayResult); ts.True(ok) {
+		ts.Exactly(yahtzee.User("Alice"), res.User)
+		ts.Exactly(until, res.Away)
+		ts.Exactly(g.Deadline, res.Deadline)
+	}
+	ts.Require().NoError(ts.event.Unsubscribe("awayID", "awayID"))
+
+	// longer than the limit of the game
+	tooLong := time.Now().Add(2*time.Hour).UnixNano() / int64(time.Millisecond)
+	rr = ts.record(request("POST", "/users/Alice/away", fmt.Sprintf(`{"Until": %d}`, tooLong)), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"Games": []}`, rr.Body.String())
+
+	// back early
+	rr = ts.record(request("POST", "/users/Alice/away", `{"Until": 0}`), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Zero(ts.fromStore("awayID").Players[0].Away)
+	ts.True(ts.fromStore("awayID").Deadline < until)
+
+	// invalid limit
+	rr = ts.record(request("POST", "/", `{"Rules": {"MaxAway": -1}}`))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+}
+
+func (ts *testSuite) TestDigest() {
+	now := time.Unix(1000, 0)
+	clock := func() time.Time { return now }
+	s := store.New()
+	h := handler.New(s, ts.event, ts.event, handler.WithClock(clock), handler.WithUserGames(s), handler.WithActivities(s))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	ts.Require().NoError(s.Save("digestID", *g))
+	for _, u := range []yahtzee.User{"Alice", "Bob"} {
+		ts.Require().NoError(s.AddGame(u, "digestID"))
+	}
+
+	// only the user gets the digest
+	rr := serve(request("GET", "/users/Bob/digest"))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
+	rr = serve(asUser("Alice")(request("GET", "/users/Bob/digest")))
+	ts.Exactly(http.StatusForbidden, rr.Code)
+
+	rr = serve(asUser("Bob")(request("GET", "/users/Bob/digest")))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"User": "Bob", "Since": 0, "Games": []}`, rr.Body.String())
+
+	now = now.Add(time.Minute)
+	ts.Exactly(http.StatusOK, serve(asUser("Alice")(request("POST", "/digestID/roll"))).Code)
+	ts.Exactly(http.StatusOK, serve(asUser("Alice")(request("POST", "/digestID/score", "chance"))).Code)
+
+	now = now.Add(time.Minute)
+	rr = serve(asUser("Bob")(request("GET", "/users/Bob/digest")))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{
+		"User": "Bob",
+		"Since": 1000000,
+		"Games": [
+			{"Game": "digestID", "Turns": 1, "Finished": false, "Winners": null, "Totals": null, "YourTurn": true}
+		]
+	}`, rr.Body.String())
+
+	// nothing happened since
+	rr = serve(asUser("Bob")(request("GET", "/users/Bob/digest")))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"User": "Bob", "Since": 1120000, "Games": []}`, rr.Body.String())
+
+	// the last turn finishes the game
+	saved, err := s.Load("digestID")
+	ts.Require().NoError(err)
+	g = &saved
+	for _, p := range g.Players {
+		for _, c := range yahtzee.Categories()[1:] {
+			p.ScoreSheet[c] = 0
+		}
+	}
+	g.Players[1].ScoreSheet[yahtzee.Chance] = 10
+	for _, d := range g.Dices {
+		d.Value = 6
+	}
+	g.Round = 12
+	g.CurrentPlayer = 1
+	g.RollCount = 1
+	ts.Require().NoError(s.Save("digestID", *g))
+
+	now = now.Add(time.Minute)
+	ts.Exactly(http.StatusOK, serve(asUser("Bob")(request("POST", "/digestID/score", "ones"))).Code)
+
+	// the time of the last visit is kept when it's given
+	rr = serve(withQuery("since", "1120000")(asUser("Alice")(request("GET", "/users/Alice/digest"))))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{
+		"User": "Alice",
+		"Since": 1120000,
+		"Games": [{
+			"Game": "digestID",
+			"Turns": 1,
+			"Finished": true,
+			"Winners": ["Bob"],
+			"Totals": {"Alice": 0, "Bob": 10},
+			"YourTurn": false
+		}]
+	}`, rr.Body.String())
+
+	rr = serve(asUser("Alice")(request("GET", "/users/Alice/digest")))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Contains(rr.Body.String(), `"Since":0`)
+
+	rr = serve(withQuery("since", "yesterday")(asUser("Alice")(request("GET", "/users/Alice/digest"))))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+}
+
+func (ts *testSuite) TestHighlights() {
+	s := store.New()
+	h := handler.New(s, ts.event, ts.event, handler.WithActivities(s))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	ts.Require().NoError(s.Save("highlightsID", *g))
+
+	// not without the logs
+	rr := ts.record(request("GET", "/highlightsID/highlights"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+
+	ts.Exactly(http.StatusOK, serve(asUser("Alice")(request("POST", "/highlightsID/roll"))).Code)
+	ts.Exactly(http.StatusOK, serve(asUser("Alice")(request("POST", "/highlightsID/score", "chance"))).Code)
+
+	rr = serve(request("GET", "/highlightsID/highlights"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	ts.Contains(rr.Body.String(), "game-not-over")
+
+	// the last turn is a Yahtzee taking the lead
+	saved, err := s.Load("highlightsID")
+	ts.Require().NoError(err)
+	g = &saved
+	for _, p := range g.Players {
+		for _, c := range yahtzee.Categories()[1:] {
+			p.ScoreSheet[c] = 0
+		}
+	}
+	g.Players[1].ScoreSheet[yahtzee.Chance] = 10
+	for _, d := range g.Dices {
+		d.Value = 6
+	}
+	g.Round = 12
+	g.CurrentPlayer = 1
+	g.RollCount = 1
+	ts.Require().NoError(s.Save("highlightsID", *g))
+	ts.Exactly(http.StatusOK, serve(asUser("Bob")(request("POST", "/highlightsID/score", "ones"))).Code)
+
+	rr = serve(request("GET", "/highlightsID/highlights"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	var got engine.HighlightsResult
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.Require().Len(got.Yahtzees, 1)
+	ts.Exactly(yahtzee.User("Bob"), got.Yahtzees[0].User)
+	ts.Exactly([]int{6, 6, 6, 6, 6}, got.Yahtzees[0].Dices)
+	ts.Require().Len(got.LeadChanges, 1)
+	ts.Exactly([]yahtzee.User{"Bob"}, got.LeadChanges[0].Leaders)
+	ts.Exactly([]yahtzee.User{"Alice"}, got.LeadChanges[0].Previous)
+
+	rr = serve(request("GET", "/nonexisting/highlights"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+}
+
+func (ts *testSuite) TestGames() {
+	s := store.New()
+	h := handler.New(s, ts.event, ts.event)
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	open := yahtzee.NewGame()
+	open.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	ts.Require().NoError(s.Save("openID", *open))
+
+	running := yahtzee.NewGame()
+	running.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	running.CurrentPlayer = 1
+	ts.Require().NoError(s.Save("runningID", *running))
+
+	solo := yahtzee.NewGame(yahtzee.Solo)
+	solo.Players = []*yahtzee.Player{yahtzee.NewPlayer("Bob")}
+	ts.Require().NoError(s.Save("soloID", *solo))
+
+	over := yahtzee.NewGame()
+	over.Players = []*yahtzee.Player{yahtzee.NewPlayer("Carol")}
+	over.Round = 13
+	ts.Require().NoError(s.Save("overID", *over))
+
+	rr := serve(request("GET", "/games"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`[
+		{"ID": "openID", "Players": 1, "Status": "open", "Sandbox": false},
+		{"ID": "overID", "Players": 1, "Status": "over", "Sandbox": false},
+		{"ID": "runningID", "Players": 2, "Status": "running", "Sandbox": false},
+		{"ID": "soloID", "Players": 1, "Status": "running", "Sandbox": false}
+	]`, rr.Body.String())
+
+	rr = serve(withQuery("status", "open")(request("GET", "/games")))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`[{"ID": "openID", "Players": 1, "Status": "open", "Sandbox": false}]`, rr.Body.String())
+
+	rr = serve(withQuery("user", "Bob")(request("GET", "/games")))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`[
+		{"ID": "runningID", "Players": 2, "Status": "running", "Sandbox": false},
+		{"ID": "soloID", "Players": 1, "Status": "running", "Sandbox": false}
+	]`, rr.Body.String())
+
+	rr = serve(withQuery("status", "paused")(request("GET", "/games")))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+}
+
+func (ts *testSuite) TestAnnotate() {
+	s := store.New()
+	now := time.Unix(1000, 0)
+	h := handler.New(s, ts.event, ts.event, handler.WithActivities(s), handler.WithAnnotationTokens("director"),
+		handler.WithClock(func() time.Time { return now }))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	annotate := func(token string, body string) *httptest.ResponseRecorder {
+		return serve(withHeader("Annotation-Token", token)(asUser("Director")(request("POST", "/annotateID/annotations", body))))
+	}
+
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	ts.Require().NoError(s.Save("annotateID", *g))
+
+	// not without the tokens
+	rr := ts.record(withHeader("Annotation-Token", "director")(request("POST", "/annotateID/annotations", `{"Caption": "hi"}`)))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+
+	ts.Exactly(http.StatusForbidden, annotate("player", `{"Caption": "hi"}`).Code)
+	ts.Exactly(http.StatusBadRequest, annotate("director", `{}`).Code)
+	ts.Exactly(http.StatusBadRequest, annotate("director", `{"Ruling": "`+strings.Repeat("a", 501)+`"}`).Code)
+	ts.Exactly(http.StatusNotFound, serve(withHeader("Annotation-Token", "director")(request("POST", "/nonexisting/annotations", `{"Caption": "hi"}`))).Code)
+
+	c, err := ts.event.Subscribe("annotateID", "annotateID")
+	ts.Require().NoError(err)
+	defer ts.event.Unsubscribe("annotateID", "annotateID")
+	events := make(chan *event.Event, 10)
+	go func() {
+		for e := range c {
+			events <- e
+		}
+	}()
+
+	rr = annotate("director", `{"Caption": "Alice goes for the Yahtzee", "Ruling": "the roll stands"}`)
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	ts.JSONEq(`{"Caption": "Alice goes for the Yahtzee", "Ruling": "the roll stands", "At": 1000000}`, rr.Body.String())
+
+	select {
+	case e := <-events:
+		ts.Exactly(event.Annotation, e.Action)
+		ts.Exactly(yahtzee.User("Director"), *e.User)
+	case <-time.After(time.Second):
+		ts.Fail("no annotation event")
+	}
+
+	// the log has it for the replays
+	rr = serve(request("GET", "/annotateID/log"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	var got []yahtzee.Activity
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.Exactly([]yahtzee.Activity{{
+		At:      1000000,
+		Action:  "annotation",
+		User:    "Director",
+		Caption: "Alice goes for the Yahtzee",
+		Ruling:  "the roll stands",
+	}}, got)
+
+	rr = ts.record(request("GET", "/annotateID/log"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+	rr = serve(request("GET", "/nonexisting/log"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+}
+
+func (ts *testSuite) TestBackup() {
+	s := store.New()
+	h := handler.New(s, ts.event, ts.event, handler.WithAdminToken("secret"))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// not without the token
+	ts.Exactly(http.StatusNotFound, ts.record(withHeader("Admin-Token", "secret")(request("GET", "/admin/backup"))).Code)
+	ts.Exactly(http.StatusForbidden, serve(request("GET", "/admin/backup")).Code)
+	ts.Exactly(http.StatusForbidden, serve(withHeader("Admin-Token", "guess")(request("POST", "/admin/restore", ""))).Code)
+
+	first := yahtzee.NewGame()
+	first.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	first.Seed = 42
+	ts.Require().NoError(s.Save("backupA", *first))
+	second := yahtzee.NewGame()
+	ts.Require().NoError(s.Save("backupB", *second))
+
+	rr := serve(withHeader("Admin-Token", "secret")(request("GET", "/admin/backup")))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Exactly("application/x-ndjson", rr.Header().Get("Content-Type"))
+	backup := rr.Body.String()
+	lines := strings.Split(strings.TrimSpace(backup), "\n")
+	ts.Require().Len(lines, 2)
+	var line handler.BackupLine
+	ts.Require().NoError(json.Unmarshal([]byte(lines[0]), &line))
+	ts.Exactly("backupA", line.ID)
+
+	// into an empty store
+	restored := store.New()
+	h = handler.New(restored, ts.event, ts.event, handler.WithAdminToken("secret"))
+	restore := func(query, body string) *httptest.ResponseRecorder {
+		return serve(withHeader("Admin-Token", "secret")(request("POST", "/admin/restore"+query, body)))
+	}
+	rr = restore("", backup)
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"Restored": 2, "Skipped": 0}`, rr.Body.String())
+	if got, err := restored.Load("backupA"); ts.NoError(err) {
+		ts.Exactly(*first, got)
+	}
+	if got, err := restored.Load("backupB"); ts.NoError(err) {
+		ts.Exactly(*second, got)
+	}
+
+	// the games of the store are kept
+	changed := yahtzee.NewGame()
+	changed.Round = 3
+	ts.Require().NoError(restored.Save("backupB", *changed))
+	rr = restore("", backup)
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"Restored": 0, "Skipped": 2}`, rr.Body.String())
+	if got, err := restored.Load("backupB"); ts.NoError(err) {
+		ts.Exactly(3, got.Round)
+	}
+	rr = restore("?overwrite=true", backup)
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"Restored": 2, "Skipped": 0}`, rr.Body.String())
+	if got, err := restored.Load("backupB"); ts.NoError(err) {
+		ts.Exactly(*second, got)
+	}
+
+	// the games of the older versions are upgraded
+	rr = restore("", `{"ID": "backupC", "Game": {"Players": [], "Dices": [{"Value": 1, "Locked": false}], "Seed": 7}}`)
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	if got, err := restored.Load("backupC"); ts.NoError(err) {
+		ts.Exactly(yahtzee.NumberOfSides, got.Sides)
+		ts.Exactly(int64(7), got.Seed)
+	}
+
+	// the lines before the invalid one are restored
+	rr = restore("", `{"ID": "backupD", "Game": {"Players": []}}`+"\n"+`{"ID": ""}`)
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	_, err := restored.Load("backupD")
+	ts.NoError(err)
+	ts.Exactly(http.StatusBadRequest, restore("?overwrite=maybe", backup).Code)
+}
+
+func (ts *testSuite) TestDeleteGame() {
+	s := store.New()
+	h := handler.New(s, ts.event, ts.event, handler.WithAdminToken("secret"))
+	remove := func(token string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, withHeader("Admin-Token", token)(request("DELETE", "/admin/games/deleteID")))
+		return rr
+	}
+
+	ts.Require().NoError(s.Save("deleteID", *yahtzee.NewGame()))
+	players, err := ts.event.Subscribe("deleteID", "player")
+	ts.Require().NoError(err)
+	spectators, err := ts.event.Subscribe(event.SpectatorChannel("deleteID"), "spectator")
+	ts.Require().NoError(err)
+
+	ts.Exactly(http.StatusForbidden, remove("guess").Code)
+	ts.Exactly(http.StatusNoContent, remove("secret").Code)
+
+	_, err = s.Load("deleteID")
+	ts.Error(err)
+	for _, c := range []chan *event.Event{players, spectators} {
+		select {
+		case _, open := <-c:
+			ts.False(open)
+		case <-time.After(time.Second):
+			ts.Fail("the client wasn't unsubscribed")
+		}
+	}
+
+	ts.Exactly(http.StatusNotFound, remove("secret").Code)
+}
+
+func (ts *testSuite) TestStateAt() {
+	s := store.New()
+	logs := eventlog.New(s, s, 2)
+	h := handler.New(logs, ts.event, ts.event,
+		handler.WithAdminToken("secret"), handler.WithReplayer(logs))
+	stateAt := func(h http.Handler, token string, gameID string, seq string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, withHeader("Admin-Token", token)(withQuery("seq", seq)(request("GET", "/admin/"+gameID+"/state-at"))))
+		return rr
+	}
+
+	created := yahtzee.NewGame()
+	created.Seed = 7
+	ts.Require().NoError(logs.Save("stateID", *created))
+	joined := yahtzee.NewGame()
+	joined.Seed = 7
+	joined.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	ts.Require().NoError(logs.Save("stateID", *joined))
+
+	// the stored form of the game, with its seed
+	for seq, players := range map[string]int{"1": 0, "2": 1} {
+		rr := stateAt(h, "secret", "stateID", seq)
+		ts.Require().Exactly(http.StatusOK, rr.Code)
+		var got struct {
+			Players []*yahtzee.Player
+			Seed    int64
+		}
+		ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+		ts.Len(got.Players, players)
+		ts.Exactly(int64(7), got.Seed)
+	}
+
+	ts.Exactly(http.StatusNotFound, stateAt(h, "secret", "stateID", "3").Code)
+	ts.Exactly(http.StatusNotFound, stateAt(h, "secret", "otherID", "1").Code)
+	ts.Exactly(http.StatusBadRequest, stateAt(h, "secret", "stateID", "0").Code)
+	ts.Exactly(http.StatusBadRequest, stateAt(h, "secret", "stateID", "first").Code)
+	ts.Exactly(http.StatusForbidden, stateAt(h, "guess", "stateID", "1").Code)
+
+	// the games without logs
+	h = handler.New(s, ts.event, ts.event, handler.WithAdminToken("secret"))
+	ts.Exactly(http.StatusNotFound, stateAt(h, "secret", "stateID", "1").Code)
+}
+
+func (ts *testSuite) TestDeprecations() {
+	deprecated := func(rr *httptest.ResponseRecorder) {
+		ts.Exactly("@1792108800", rr.Header().Get("Deprecation"))
+		ts.Exactly("Fri, 16 Apr 2027 00:00:00 GMT", rr.Header().Get("Sunset"))
+		ts.Exactly(`</deprecations>; rel="deprecation"; type="application/json"`, rr.Header().Get("Link"))
+	}
+	notDeprecated := func(rr *httptest.ResponseRecorder) {
+		ts.Empty(rr.Header().Get("Deprecation"))
+		ts.Empty(rr.Header().Get("Sunset"))
+	}
+
+	// the features in the query or in the body
+	rr := ts.record(request("POST", "/"), withQuery("features", "yahtzee-bonus"))
+	ts.Exactly(http.StatusCreated, rr.Code)
+	deprecated(rr)
+	rr = ts.record(request("POST", "/", `{"features": ["yahtzee-bonus"]}`))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	notDeprecated(rr)
+	created := ts.fromStore(strings.TrimLeft(rr.Header().Get("Location"), "/"))
+	ts.Exactly(yahtzee.NewGame(yahtzee.YahtzeeBonus), created)
+	rr = ts.record(request("POST", "/", `{"features": ["wat"]}`))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("POST", "/", `{"features": ["yatzy", "maxi"]}`))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// the category as the raw body or in a JSON body
+	newGame := func() yahtzee.Game {
+		g := yahtzee.NewGame()
+		g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+		g.RollCount = 1
+		return *g
+	}
+	ts.Require().NoError(ts.store.Save("deprecatedID", newGame()))
+	rr = ts.record(request("POST", "/deprecatedID/score", "chance"), asUser("Alice"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	deprecated(rr)
+
+	ts.Require().NoError(ts.store.Save("deprecatedID", newGame()))
+	rr = ts.record(request("POST", "/deprecatedID/score", `{"category": "chance"}`),
+		withHeader("Content-Type", "application/json; charset=utf-8"), asUser("Alice"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	notDeprecated(rr)
+	ts.Exactly(5, ts.fromStore("deprecatedID").Players[0].ScoreSheet[yahtzee.Chance])
+	rr = ts.record(request("POST", "/deprecatedID/score", `"chance"`),
+		withHeader("Content-Type", "application/json"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// the other endpoints are not marked
+	notDeprecated(ts.record(request("GET", "/deprecatedID")))
+
+	rr = ts.record(request("GET", "/deprecations"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	notDeprecated(rr)
+	var got []handler.Deprecation
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.Require().Len(got, 4)
+	ts.Exactly("POST", got[0].Method)
+	ts.Exactly("/", got[0].Path)
+	ts.Exactly(time.Date(2027, time.April, 16, 0, 0, 0, 0, time.UTC), got[0].Sunset)
+	ts.Exactly("/{gameID}/score", got[1].Path)
+}
+
+func (ts *testSuite) TestFeaturePolicy() {
+	s := store.New()
+	h := handler.New(s, ts.event, ts.event, handler.WithFeaturePolicy(handler.FeaturePolicy{
+		Defaults:  []yahtzee.Feature{yahtzee.YahtzeeBonus},
+		Required:  []yahtzee.Feature{yahtzee.Hints},
+		Forbidden: []yahtzee.Feature{yahtzee.Blitz, yahtzee.Solo},
+	}))
+	create := func(body string, modifiers ...func(*http.Request) *http.Request) *httptest.ResponseRecorder {
+		req := request("POST", "/", body)
+		for _, m := range modifiers {
+			req = m(req)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	created := func(rr *httptest.ResponseRecorder) []yahtzee.Feature {
+		ts.Require().Exactly(http.StatusCreated, rr.Code)
+		g, err := s.Load(strings.TrimLeft(rr.Header().Get("Location"), "/"))
+		ts.Require().NoError(err)
+		return g.Features
+	}
+
+	// the defaults without features, with the required ones
+	ts.Exactly([]yahtzee.Feature{yahtzee.YahtzeeBonus, yahtzee.Hints}, created(create("")))
+	ts.Exactly([]yahtzee.Feature{yahtzee.Yatzy, yahtzee.Hints}, created(create(`{"features": ["yatzy"]}`)))
+	ts.Exactly([]yahtzee.Feature{yahtzee.Hints}, created(create(`{"features": ["hints"]}`)))
+	ts.Exactly([]yahtzee.Feature{yahtzee.Kniffel, yahtzee.Hints}, created(create("", withQuery("features", "kniffel"))))
+
+	rr := create(`{"features": ["yatzy", "blitz", "solo"]}`)
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	ts.JSONEq(`{
+		"Code": "forbidden-features",
+		"Message": "Some of the features are not played on this server.",
+		"Forbidden": ["blitz", "solo"]
+	}`, rr.Body.String())
+
+	// the required features can't be played with the requested ones
+	rr = create(`{"features": ["coach"]}`)
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	ts.Contains(rr.Body.String(), "incompatible-features")
+
+	// the forbidden features are not listed
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, request("GET", "/features"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.NotContains(rr.Body.String(), `"blitz"`)
+	ts.Contains(rr.Body.String(), `"hints"`)
+
+	// the policy is checked
+	ts.NoError((&handler.FeaturePolicy{}).Validate())
+	ts.Error((&handler.FeaturePolicy{Defaults: []yahtzee.Feature{"wat"}}).Validate())
+	ts.Error((&handler.FeaturePolicy{
+		Required:  []yahtzee.Feature{yahtzee.Hints},
+		Forbidden: []yahtzee.Feature{yahtzee.Hints},
+	}).Validate())
+	ts.Error((&handler.FeaturePolicy{Required: []yahtzee.Feature{yahtzee.Hints, yahtzee.Coach}}).Validate())
+	ts.Error((&handler.FeaturePolicy{
+		Defaults: []yahtzee.Feature{yahtzee.Coach},
+		Required: []yahtzee.Feature{yahtzee.Hints},
+	}).Validate())
+}
+
+func (ts *testSuite) TestCapacity() {
+	s := store.New()
+	ts.Require().NoError(s.Save("fullID", *yahtzee.NewGame()))
+	h := handler.New(s, ts.event, ts.event, handler.WithCapacity(2, 1))
+	create := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, withHeader("Accept-Language", "en")(request("POST", "/")))
+		return rr
+	}
+
+	ts.Exactly(http.StatusCreated, create().Code)
+	rr := create()
+	ts.Exactly(http.StatusServiceUnavailable, rr.Code)
+	ts.Exactly("30", rr.Header().Get("Retry-After"))
+	ts.JSONEq(`{"Code": "server-full", "Message": "The server is full, try again in a minute."}`, rr.Body.String())
+
+	server := httptest.NewServer(h)
+	defer server.Close()
+	baseUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"/fullID/ws", nil)
+	ts.Require().NoError(err)
+	_, res, err := websocket.DefaultDialer.Dial(baseUrl+"/fullID/ws", nil)
+	ts.Error(err)
+	if ts.NotNil(res) {
+		ts.Exactly(http.StatusServiceUnavailable, res.StatusCode)
+	}
+
+	ws.Close()
+	ts.Eventually(func() bool {
+		ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"/fullID/ws", nil)
+		if err != nil {
+			return false
+		}
+		ws.Close()
+		return true
+	}, time.Second, 10*time.Millisecond)
+}
+
+// healther is a health check returning its error.
+type healther func() error
+
+func (h healther) Health() error { return h() }
+
+func (ts *testSuite) TestReadiness() {
+	var broken error
+	h := handler.New(store.New(), ts.event, ts.event,
+		handler.WithHealthCheck("memory", store.New()),
+		handler.WithHealthCheck("postgres", healther(func() error { return broken })))
+	ready := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, request("GET", "/readyz"))
+		return rr
+	}
+
+	rr := ready()
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"Ready": true, "Checks": {"memory": "ok", "postgres": "ok"}}`, rr.Body.String())
+
+	broken = errors.New("connection refused")
+	rr = ready()
+	ts.Exactly(http.StatusServiceUnavailable, rr.Code)
+	ts.JSONEq(`{"Ready": false, "Checks": {"memory": "ok", "postgres": "connection refused"}}`, rr.Body.String())
+}
+
+func (ts *testSuite) TestSMS() {
+	s := store.New()
+	h := handler.New(s, ts.event, ts.event, handler.WithUserGames(s), handler.WithSMSToken("secret"))
+	text := func(path string, form url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := withHeader("Content-Type", "application/x-www-form-urlencoded")(request("POST", path, form.Encode()))
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	send := func(from string, body string) string {
+		rr := text("/sms?token=secret", url.Values{"From": {from}, "Body": {body}})
+		ts.Require().Exactly(http.StatusOK, rr.Code)
+		return rr.Body.String()
+	}
+
+	// not without the token
+	rr := ts.record(request("POST", "/sms?token=secret"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+	rr = text("/sms?token=wrong", url.Values{"From": {"+3611"}, "Body": {"NEW"}})
+	ts.Exactly(http.StatusForbidden, rr.Code)
+
+	ts.Contains(send("+3611", "cheat"), "Unknown command")
+	ts.Contains(send("+3611", "ROLL"), "You have no game")
+
+	created := send("+3611", "NEW")
+	match := regexp.MustCompile(`Game (\S+) created`).FindStringSubmatch(created)
+	ts.Require().Len(match, 2)
+	gameID := match[1]
+
+	ts.Contains(send("+3622", "JOIN "+gameID), "Round 1/13")
+	ts.Contains(send("+3622", "ROLL"), "another player's turn")
+	ts.Contains(send("+3611", "ROLL"), "Roll 1/3")
+	ts.Contains(send("+3611", "KEEP 1 2"), "Roll 2/3: ")
+	ts.Contains(send("+3611", "SCORE NOTHING"), "no such category")
+	ts.Contains(send("+3611", "SCORE CHANCE"), "to play")
+
+	g, err := s.Load(gameID)
+	ts.Require().NoError(err)
+	ts.Require().Len(g.Players, 2)
+	ts.Contains(string(g.Players[0].User), "sms-")
+	ts.NotContains(string(g.Players[0].User), "3611")
+	_, scored := g.Players[0].ScoreSheet[yahtzee.Chance]
+	ts.True(scored)
+
+	// the USSD session keeps going
+	rr = text("/ussd?token=secret", url.Values{"phoneNumber": {"+3622"}, "text": {"SHOW*SHOW"}})
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.True(strings.HasPrefix(rr.Body.String(), "CON Round 1/13. Your turn."))
+}
+
+func (ts *testSuite) TestSpectate() {
+	g := yahtzee.NewGame()
+	g.Rules = &yahtzee.Rules{SpectatorDelay: 1}
+	ts.Require().NoError(ts.store.Save("spectateID", *g))
+
+	subscribe := func(channel string) chan *event.Event {
+		c, err := ts.event.Subscribe(channel, channel)
+		ts.Require().NoError(err)
+		events := make(chan *event.Event, 10)
+		go func() {
+			for e := range c {
+				events <- e
+			}
+		}()
+		return events
+	}
+	players := subscribe("spectateID")
+	spectators := subscribe("spectate:spectateID")
+
+	rr := ts.record(request("POST", "/spectateID/join"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	rr = ts.record(request("POST", "/spectateID/roll"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+
+	// the players get the events right away
+	ts.Exactly(event.AddPlayer, (<-players).Action)
+	ts.Exactly(event.Roll, (<-players).Action)
+
+	// the spectators after the delay, in order
+	select {
+	case <-spectators:
+		ts.Fail("spectator event before the delay")
+	case <-time.After(500 * time.Millisecond):
+	}
+	for _, want := range []event.Type{event.AddPlayer, event.Roll} {
+		select {
+		case got := <-spectators:
+			ts.Exactly(want, got.Action)
+		case <-time.After(2 * time.Second):
+			ts.Fail("no spectator event")
+		}
+	}
+	ts.Require().NoError(ts.event.Unsubscribe("spectateID", "spectateID"))
+	ts.Require().NoError(ts.event.Unsubscribe("spectate:spectateID", "spectate:spectateID"))
+
+	// only the players follow the game live
+	for _, path := range []string{"/spectateID", "/spectateID/ws"} {
+		rr = ts.record(request("GET", path))
+		ts.Exactly(http.StatusUnauthorized, rr.Code, path)
+		rr = ts.record(request("GET", path), asUser("Bob"))
+		ts.Exactly(http.StatusForbidden, rr.Code, path)
+	}
+	rr = ts.record(request("GET", "/spectateID"), asUser("Alice"))
+	ts.Exactly(http.StatusOK, rr.Code)
+
+	// no spectators of missing games
+	rr = ts.record(request("GET", "/nonexisting/spectate"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+
+	// invalid delay
+	rr = ts.record(request("POST", "/", `{"Rules": {"SpectatorDelay": 601}}`))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+}
+
+func (ts *testSuite) TestNotes() {
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	ts.Require().NoError(ts.store.Save("notesID", *g))
+
+	// nothing yet
+	rr := ts.record(request("GET", "/notesID/notes"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"Categories": {}, "Turns": {}}`, rr.Body.String())
+
+	notes := `{"Categories": {"chance": "keep it for later"}, "Turns": {"0": "went for the straight"}}`
+	rr = ts.record(request("PUT", "/notesID/notes", notes), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(notes, rr.Body.String())
+
+	// only for the author
+	rr = ts.record(request("GET", "/notesID/notes"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(notes, rr.Body.String())
+	rr = ts.record(request("GET", "/notesID/notes"), asUser("Bob"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"Categories": {}, "Turns": {}}`, rr.Body.String())
+	rr = ts.record(request("GET", "/notesID"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.NotContains(rr.Body.String(), "keep it for later")
+
+	badNotes := []struct {
+		description string
+		user        string
+		body        string
+		code        int
+	}{
+		{"not a player", "Carol", `{}`, http.StatusBadRequest},
+		{"unknown category", "Alice", `{"Categories": {"two-pairs": "x"}}`, http.StatusBadRequest},
+		{"turn after the game", "Alice", `{"Turns": {"13": "x"}}`, http.StatusBadRequest},
+		{"too long", "Alice", `{"Turns": {"1": "` + strings.Repeat("x", 501) + `"}}`, http.StatusBadRequest},
+	}
+	for _, tc := range badNotes {
+		rr = ts.record(request("PUT", "/notesID/notes", tc.body), asUser(tc.user))
+		ts.Exactly(tc.code, rr.Code, "when %s", tc.description)
+	}
+
+	// missing user or game
+	rr = ts.record(request("GET", "/notesID/notes"))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
+	rr = ts.record(request("GET", "/nonexisting/notes"), asUser("Alice"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+}
+
+func (ts *testSuite) TestAchievements() {
+	yahtzeeRolled := func(id string) {
+		g := yahtzee.NewGame()
+		g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Ann")}
+		g.RollCount = 1
+		for _, d := range g.Dices {
+			d.Value = 6
+		}
+		ts.Require().NoError(ts.store.Save(id, *g))
+	}
+	yahtzeeRolled("achievementID")
+	yahtzeeRolled("achievementAgainID")
+
+	// nothing yet
+	rr := ts.record(request("GET", "/users/Ann/achievements"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"User": "Ann", "Achievements": []}`, rr.Body.String())
+
+	achievements := func(id string) []*engine.AchievementResult {
+		c, err := ts.event.Subscribe(id, id)
+		ts.Require().NoError(err)
+		events := make(chan *event.Event, 10)
+		go func() {
+			for e := range c {
+				events <- e
+			}
+		}()
+		defer func() { ts.Require().NoError(ts.event.Unsubscribe(id, id)) }()
+
+		rr := ts.record(request("POST", "/"+id+"/score", "yahtzee"), asUser("Ann"))
+		ts.Require().Exactly(http.StatusOK, rr.Code)
+
+		var res []*engine.AchievementResult
+		for {
+			select {
+			case e := <-events:
+				if e.Action == event.Achievement {
+					res = append(res, e.Data.(*engine.AchievementResult))
+				}
+			case <-time.After(100 * time.Millisecond):
+				return res
+			}
+		}
+	}
+
+	// the first one is announced
+	got := achievements("achievementID")
+	ts.Exactly([]*engine.AchievementResult{{User: "Ann", Achievement: yahtzee.FirstYahtzee}}, got)
+	ts.Exactly([]yahtzee.Achievement{yahtzee.FirstYahtzee}, ts.fromStore("achievementID").Players[0].Achievements)
+
+	// but not in the next games
+	ts.Empty(achievements("achievementAgainID"))
+	ts.Exactly([]yahtzee.Achievement{yahtzee.FirstYahtzee}, ts.fromStore("achievementAgainID").Players[0].Achievements)
+
+	rr = ts.record(request("GET", "/users/Ann/achievements"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{"User": "Ann", "Achievements": ["first-yahtzee"]}`, rr.Body.String())
+
+	// not tracked
+	h := handler.New(ts.store, ts.event, ts.event)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, request("GET", "/users/Ann/achievements"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+}
+
+func (ts *testSuite) TestTables() {
+	g := yahtzee.NewGame(yahtzee.Duplicate)
+	g.Seed = 42
+	g.Rules = &yahtzee.Rules{RollsPerTurn: 2}
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	g.Players[0].ScoreSheet[yahtzee.Chance] = 20
+	ts.Require().NoError(ts.store.Save("tablesID", *g))
+	ts.Require().NoError(ts.store.Save("notDuplicateTablesID", *yahtzee.NewGame()))
+
+	// only duplicate games
+	rr := ts.record(request("POST", "/notDuplicateTablesID/tables"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("POST", "/nonexisting/tables"), asUser("Alice"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+
+	// only by the players
+	rr = ts.record(request("POST", "/tablesID/tables"))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
+	rr = ts.record(request("POST", "/tablesID/tables"), asUser("Bob"))
+	ts.Exactly(http.StatusForbidden, rr.Code)
+
+	// same rolls and settings
+	rr = ts.record(request("POST", "/tablesID/tables"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	tableID := strings.TrimPrefix(rr.Header().Get("Location"), "/")
+
+	table := ts.fromStore(tableID)
+	ts.Exactly(int64(42), table.Seed)
+	ts.Exactly(g.Features, table.Features)
+	ts.Exactly(g.Rules, table.Rules)
+	ts.Exactly("tablesID", table.Source)
+	ts.Empty(table.Players)
+	ts.Exactly([]string{tableID}, ts.fromStore("tablesID").Tables)
+
+	// the seed is never shown
+	ts.NotContains(rr.Body.String(), "Seed")
+	rr = ts.record(request("GET", "/"+tableID))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.NotContains(rr.Body.String(), "Seed")
+
+	rr = ts.record(request("POST", "/"+tableID+"/join"), asUser("Bob"))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	table = ts.fromStore(tableID)
+	table.Players[0].ScoreSheet[yahtzee.Chance] = 25
+	ts.Require().NoError(ts.store.Save(tableID, *table))
+
+	// the tables started from a table are listed in the first game
+	rr = ts.record(request("POST", "/"+tableID+"/tables"), asUser("Bob"))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	otherID := strings.TrimPrefix(rr.Header().Get("Location"), "/")
+	ts.Exactly("tablesID", ts.fromStore(otherID).Source)
+	ts.Exactly([]string{tableID, otherID}, ts.fromStore("tablesID").Tables)
+
+	// compared across the tables from any of them
+	for _, id := range []string{"tablesID", tableID, otherID} {
+		rr = ts.record(request("GET", "/"+id+"/tables"))
+		ts.Require().Exactly(http.StatusOK, rr.Code)
+		ts.JSONEq(fmt.Sprintf(`{
+			"Tables": ["tablesID", %[1]q, %[2]q],
+			"Standings": [
+				{"Rank": 1, "Game": %[1]q, "User": "Bob", "Total": 25, "Over": false},
+				{"Rank": 2, "Game": "tablesID", "User": "Alice", "Total": 20, "Over": false}
+			]
+		}`, tableID, otherID), rr.Body.String())
+	}
+
+	rr = ts.record(request("GET", "/nonexisting/tables"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+}
+
+func (ts *testSuite) TestCoach() {
+	g := yahtzee.NewGame(yahtzee.Coach)
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	g.Locale = "hu"
+	ts.Require().NoError(ts.store.Save("coachID", *g))
+	ts.Require().NoError(ts.store.Save("noCoachID", *yahtzee.NewGame()))
+
+	// roll first
+	rr := ts.record(request("GET", "/coachID/coach"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	g.RollCount = 3
+	for _, d := range g.Dices {
+		d.Value = 6
+	}
+	ts.Require().NoError(ts.store.Save("coachID", *g))
+
+	rr = ts.record(request("GET", "/coachID/coach"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	var got []*handler.Recommendation
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.Len(got, 13)
+	ts.Exactly(yahtzee.Category(yahtzee.Yahtzee), got[0].Category)
+	ts.Exactly(50.0, got[0].Expected)
+	ts.Exactly([]string{"coach-best", "coach-scores-now"}, got[0].Reasons)
+	ts.Exactly([]string{
+		"A legjobb várható pontszám a hátralévő dobásokkal.",
+		"A kockák már most pontot érnek itt.",
+	}, got[0].Explanations)
+	ts.Exactly("hu", rr.Header().Get("Content-Language"))
+
+	// in the language of the request
+	rr = ts.record(request("GET", "/coachID/coach"), asUser("Alice"), withHeader("Accept-Language", "en"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Contains(rr.Body.String(), "The dices already score here.")
+
+	// only for the current player of a coach game
+	rr = ts.record(request("GET", "/coachID/coach"), asUser("Bob"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("GET", "/noCoachID/coach"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = ts.record(request("GET", "/coachID/coach"))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
+	rr = ts.record(request("GET", "/nonexisting/coach"), asUser("Alice"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+}
+
+func (ts *testSuite) TestHintQuota() {
+	g := yahtzee.NewGame(yahtzee.Hints)
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	g.Players[0].Hints = 1
+	g.RollCount = 3
+	for _, d := range g.Dices {
+		d.Value = 6
+	}
+	ts.Require().NoError(ts.store.Save("hintsID", *g))
+
+	eChan := ts.receiveEvents("hintsID")
+
+	rr := ts.record(request("GET", "/hintsID/coach"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusOK, rr.Code)
+	ts.Contains(rr.Body.String(), "coach-best")
+
+	got := <-eChan
+	ts.Require().NotNil(got)
+	ts.Exactly(event.Hint, got.Action)
+
+	saved := ts.fromStore("hintsID")
+	ts.Exactly(0, saved.Players[0].Hints)
+
+	// out of hints
+	rr = ts.record(request("GET", "/hintsID/coach"), asUser("Alice"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	ts.Contains(rr.Body.String(), "no-hints")
+}
+
+func (ts *testSuite) TestJoinInfo() {
+	// game not exists
+	rr := ts.record(request("GET", "/joinInfoID/join-info"), asUser("Alice"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+
+	// creator of the game
+	rr = ts.record(request("POST", "/"), asUser("Alice"))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+	gameID := strings.TrimPrefix(rr.Header().Get("Location"), "/")
+
+	// relative url without the public url
+	req := request("GET", "/"+gameID+"/join-info")
+	req.Host = "yahtzee.local"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rr = ts.record(req, asUser("Alice"))
+	ts.Exactly(http.StatusOK, rr.Code)
+
+	var got handler.JoinInfoResponse
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.Exactly("/"+gameID, got.URL)
+	ts.Empty(got.InviteToken)
+	ts.True(strings.HasPrefix(got.QR, "<svg"))
+
+	// only the creator and the players
+	rr = ts.record(request("GET", "/"+gameID+"/join-info"))
+	ts.Exactly(http.StatusUnauthorized, rr.Code)
+	rr = ts.record(request("GET", "/"+gameID+"/join-info"), asUser("Bob"))
+	ts.Exactly(http.StatusForbidden, rr.Code)
+
+	ts.Require().NoError(ts.store.Save("joinInfoID", *yahtzee.NewGame()))
+	rr = ts.record(request("GET", "/joinInfoID/join-info"), asUser(""))
+	ts.Exactly(http.StatusForbidden, rr.Code)
+
+	// invites enabled
+	h := handler.New(ts.store, ts.event, ts.event,
+		handler.WithPublicURL("https://example.com/"),
+		handler.WithInviteSecret([]byte("secret")))
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, asUser("Alice")(request("GET", "/"+gameID+"/join-info")))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.NotEmpty(got.InviteToken)
+	ts.Exactly("https://example.com/"+gameID+"?invite="+got.InviteToken, got.URL)
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, asUser("Bob")(request("POST", "/"+gameID+"/join")))
+	ts.Exactly(http.StatusForbidden, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, asUser("Bob")(request("POST", "/"+gameID+"/join?invite="+got.InviteToken)))
+	ts.Exactly(http.StatusCreated, rr.Code)
+
+	// players share it too
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, asUser("Bob")(request("GET", "/"+gameID+"/join-info")))
+	ts.Exactly(http.StatusOK, rr.Code)
+}
+
+func (ts *testSuite) TestImport() {
+	body := `{"Players": [
+		{"User": "Alice", "ScoreSheet": {"sixes": 24, "full-house": 25}},
+		{"User": "Bob", "ScoreSheet": {"chance": 22}}
+	]}`
+	rr := ts.record(request("POST", "/import", body))
+	ts.Require().Exactly(http.StatusCreated, rr.Code)
+
+	gameID := strings.TrimPrefix(rr.Header().Get("Location"), "/")
+	g := ts.fromStore(gameID)
+	ts.Exactly(1, g.Round)
+	ts.Exactly(1, g.CurrentPlayer)
+	ts.Exactly(map[yahtzee.Category]int{yahtzee.Sixes: 24, yahtzee.FullHouse: 25}, g.Players[0].ScoreSheet)
+	ts.Exactly(map[yahtzee.Category]int{yahtzee.Chance: 22}, g.Players[1].ScoreSheet)
+
+	// the game goes on online
+	rr = ts.record(request("POST", "/"+gameID+"/roll"), asUser("Bob"))
+	ts.Exactly(http.StatusOK, rr.Code)
+
+	badImports := []struct {
+		description string
+		body        string
+		code        int
+	}{
+		{"no players", `{}`, http.StatusBadRequest},
+		{"impossible score", `{"Players": [{"User": "Alice", "ScoreSheet": {"yahtzee": 40}}]}`, http.StatusBadRequest},
+		{"out of order", `{"Players": [{"User": "Alice"}, {"User": "Bob", "ScoreSheet": {"chance": 5}}]}`, http.StatusBadRequest},
+		{"same player", `{"Players": [{"User": "Alice"}, {"User": "Alice"}]}`, http.StatusConflict},
+		{"invalid settings", `{"Dice": -1, "Players": [{"User": "Alice"}]}`, http.StatusBadRequest},
+	}
+	for _, tc := range badImports {
+		rr = ts.record(request("POST", "/import", tc.body))
+		ts.Exactly(tc.code, rr.Code, "when %s", tc.description)
+	}
+
+	rr = ts.record(request("POST", "/import", `{"Players": [{"User": "Alice", "ScoreSheet": {"yahtzee": 40}}]}`))
+	ts.Contains(rr.Body.String(), `"impossible-score"`)
+}
+
+func (ts *testSuite) TestValidateSheet() {
+	// invalid body
+	rr := ts.record(request("POST", "/validate-sheet", "{"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+
+	// incomplete sheet
+	rr = ts.record(request("POST", "/validate-sheet", `{
+			"Turns": [
+				{"Dices": [2, 3, 4, 5, 6], "Category": "large-straight"},
+				{"Dices": [6, 6, 6, 6, 1], "Category": "chance"}
+			]
+		}`))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.JSONEq(`{
+			"Valid": false,
+			"Code": "incomplete-sheet",
+			"Message": "Every round of the game has to be played.",
+			"Turn": 2,
+			"Player": {
+				"User": "",
+				"ScoreSheet": {"large-straight": 40, "chance": 25},
+				"ExtraSheets": null,
+				"ExtraRolls": 0,
+				"Handicap": 0,
+				"Hints": 0,
+				"Away": 0,
+				"Achievements": null
+			},
+			"Total": 65
+		}`, rr.Body.String())
+
+	// category used twice
+	rr = ts.record(request("POST", "/validate-sheet", `{
+			"Turns": [
+				{"Dices": [2, 3, 4, 5, 6], "Category": "chance"},
+				{"Dices": [6, 6, 6, 6, 1], "Category": "chance"}
+			]
+		}`), asUser("Alice"), withHeader("Accept-Language", "de"))
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Exactly("de", rr.Header().Get("Content-Language"))
+
+	var got handler.ValidateSheetResponse
+	ts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &got))
+	ts.False(got.Valid)
+	ts.Exactly("category-used", got.Code)
+	ts.Exactly(1, got.Turn)
+	ts.Exactly(yahtzee.User("Alice"), got.Player.User)
+	ts.Exactly(20, got.Total)
+}
+
+func (ts *testSuite) TestErrorMessages() {
+	rr := ts.record(request("GET", "/missingID"))
+	ts.Exactly(http.StatusNotFound, rr.Code)
+	ts.Exactly("en", rr.Header().Get("Content-Language"))
+	ts.JSONEq(`{"Code": "not-found", "Message": "Not found."}`, rr.Body.String())
+
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	ts.Require().NoError(ts.store.Save("errorID", *g))
+
+	rr = ts.record(request("POST", "/errorID/score", "chance"),
+		asUser("Alice"),
+		withHeader("Accept-Language", "hu-HU, en;q=0.5"))
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	ts.Exactly("hu", rr.Header().Get("Content-Language"))
+	ts.JSONEq(`{"Code": "roll-first", "Message": "Előbb dobj a kockákkal."}`, rr.Body.String())
+}
+
+func (ts *testSuite) TestWS() {
+	server := httptest.NewServer(ts.handler)
+	defer server.Close()
+	baseUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	ts.Require().NoError(ts.store.Save("wsID", *yahtzee.NewGame()))
+
+	ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"/wsID/ws", nil)
+	if !ts.NoError(err) {
+		return
+	}
+	defer ws.Close()
+
+	ts.event.Emit("wsID", &event.Event{User: yahtzee.NewUser("Alice"), Action: event.AddPlayer, Hash: "abc"})
+
+	_, p, err := ws.ReadMessage()
+	if ts.NoError(err) {
+		ts.JSONEq(`{
+				"User": "Alice",
+				"Action": "add-player",
+				"Data": null,
+				"Hash": "abc",
+				"AppliedActionID": ""
+			}`, string(p))
+	}
+}
+
+func (ts *testSuite) TestCapabilities() {
+	h := handler.New(ts.store, ts.event, ts.event, handler.WithClientVersions("2.0.0", "2.5"))
+	serve := func(capabilities string) *httptest.ResponseRecorder {
+		req := request("GET", "/features")
+		if capabilities != "" {
+			req.Header.Set("Client-Capabilities", capabilities)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve("version=1.9.3, events=full")
+	ts.Exactly(http.StatusUpgradeRequired, rr.Code)
+	ts.Contains(rr.Body.String(), "client-outdated")
+	ts.Exactly("2.5", rr.Header().Get("Client-Upgrade"))
+
+	rr = serve("version=2.4.10")
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Exactly("2.5", rr.Header().Get("Client-Upgrade"))
+
+	// the clients not telling their version are not refused
+	rr = serve("")
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Exactly("2.5", rr.Header().Get("Client-Upgrade"))
+
+	rr = serve("version=2.5.0, encoding=protobuf, compression=zstd")
+	ts.Exactly(http.StatusOK, rr.Code)
+	ts.Empty(rr.Header().Get("Client-Upgrade"))
+
+	rr = serve("version=latest")
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+	rr = serve("events=some")
+	ts.Exactly(http.StatusBadRequest, rr.Code)
+}
+
+func (ts *testSuite) TestWSCapabilities() {
+	server := httptest.NewServer(ts.handler)
+	defer server.Close()
+	baseUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	ts.Require().NoError(ts.store.Save("wsCapabilitiesID", *g))
+
+	// full events in json
+	ws, _, err := websocket.DefaultDialer.Dial(baseUrl+"/wsCapabilitiesID/ws", http.Header{
+		"Client-Capabilities": {"version=1.0.0, events=full"},
+	})
+	ts.Require().NoError(err)
+	defer ws.Close()
+
+	ts.event.Emit("wsCapabilitiesID", &event.Event{User: yahtzee.NewUser("Alice"), Action: event.AddPlayer, Hash: "abc"})
+
+	kind, p, err := ws.ReadMessage()
+	ts.Require().NoError(err)
+	ts.Exactly(websocket.TextMessage, kind)
+	var full struct {
+		Action event.Type
+		Hash   string
+		Game   *yahtzee.Game
+	}
+	ts.Require().NoError(json.Unmarshal(p, &full))
+	ts.Exactly(event.AddPlayer, full.Action)
+	ts.Exactly("abc", full.Hash)
+	if ts.NotNil(full.Game) {
+		ts.Len(full.Game.Players, 1)
+	}
+	ws.Close()
+
+	// delta events in protobuf, from the query of the browsers
+	capabilities := url.QueryEscape("encoding=protobuf")
+	ws, _, err = websocket.DefaultDialer.Dial(baseUrl+"/wsCapabilitiesID/ws?capabilities="+capabilities, nil)
+	ts.Require().NoError(err)
+	defer ws.Close()
+
+	ts.event.Emit("wsCapabilitiesID", &event.Event{User: yahtzee.NewUser("Alice"), Action: event.Roll, Hash: "abc"})
+
+	kind, p, err = ws.ReadMessage()
+	ts.Require().NoError(err)
+	ts.Exactly(websocket.BinaryMessage, kind)
+	ts.Exactly([]byte("\x0a\x05Alice\x12\x04roll\x22\x03abc"), p)
+}
+
+func (ts *testSuite) record(
+	req *http.Request,
+	modifiers ...func(*http.Request) *http.Request) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+
+	for _, modifier := range modifiers {
+		req = modifier(req)
+	}
+
+	ts.handler.ServeHTTP(rr, req)
+
+	return rr
+}
+
+func (ts *testSuite) fromStore(id string) *yahtzee.Game {
+	res, err := ts.store.Load(id)
+	ts.Require().NoError(err)
+	return &res
+}
+
+func (ts *testSuite) receiveEvents(id string) chan *event.Event {
+	c, err := ts.event.Subscribe(id, id)
+	ts.Require().NoError(err)
+
+	res := make(chan *event.Event, 1)
+
+	go func() {
+		for {
+			select {
+			case got := <-c:
+				res <- got
+			case <-time.After(500 * time.Millisecond):
+				res <- nil
+				return
+			}
+		}
+	}()
+
+	return res
+}
+
+func request(method string, url string, body ...interface{}) *http.Request {
+	var bodyReader io.Reader
+
+	if len(body) == 1 {
+		switch body[0].(type) {
+		case string:
+			bodyReader = strings.NewReader(body[0].(string))
+		}
+	}
+
+	req, err := http.NewRequest(method, url, bodyReader)
+	if err != nil {
+		panic(err)
+	}
+	return req
+}
+
+func withQuery(key, value string) func(*http.Request) *http.Request {
+	return func(req *http.Request) *http.Request {
 		q := req.URL.Query()
 		q.Add(key, value)
 		req.URL.RawQuery = q.Encode()
@@ -789,6 +3374,13 @@ func withQuery(key, value string) func(*http.Request) *http.Request {
 	}
 }
 
+func withHeader(key, value string) func(*http.Request) *http.Request {
+	return func(req *http.Request) *http.Request {
+		req.Header.Set(key, value)
+		return req
+	}
+}
+
 func asUser(name string) func(*http.Request) *http.Request {
 	return func(req *http.Request) *http.Request {
 		req.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(name+":")))
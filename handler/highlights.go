@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+)
+
+// Highlights returns the highlights of a finished game from its log, for the
+// recap screens.
+func (h *handler) Highlights(w http.ResponseWriter, r *http.Request) {
+	if h.activities == nil {
+		writeError(w, r, nil, "highlights are not enabled", http.StatusNotFound)
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	activities, err := h.activities.Activities(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	res, err := engine.Highlights(&g, activities)
+	if err != nil {
+		writeEngineError(w, r, err)
+		return
+	}
+
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("highlights returned")
+}
+
+// Log returns the log of the game with its annotations, for the replays.
+func (h *handler) Log(w http.ResponseWriter, r *http.Request) {
+	if h.activities == nil {
+		writeError(w, r, nil, "logs are not enabled", http.StatusNotFound)
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.store.Load(gameID); err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	activities, err := h.activities.Activities(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	if activities == nil {
+		activities = []yahtzee.Activity{}
+	}
+
+	if ok := writeJSON(w, r, activities); !ok {
+		return
+	}
+
+	log.Print("log returned")
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// qrQuietZone is the width of the empty border around the QR code in modules.
+const qrQuietZone = 4
+
+// WithPublicURL sets the base URL of the join links, like the address of the
+// frontend. The join links are relative to the server without it.
+func WithPublicURL(base string) Option {
+	return func(h *handler) {
+		h.publicURL = strings.TrimRight(base, "/")
+	}
+}
+
+// WithInviteSecret sets the secret the invite tokens are signed with. When
+// it's set, joining a game requires the invite token of the game.
+func WithInviteSecret(secret []byte) Option {
+	return func(h *handler) {
+		h.inviteSecret = secret
+	}
+}
+
+// JoinInfoResponse has what a client needs to show a join screen.
+type JoinInfoResponse struct {
+	// URL is the canonical join link of the game
+	URL string
+
+	// InviteToken is needed to join the game when invites are enabled
+	InviteToken string
+
+	// QR is the join link as an SVG image
+	QR string
+}
+
+func (h *handler) JoinInfo(w http.ResponseWriter, r *http.Request) {
+	user, ok := readUser(w, r)
+	if !ok {
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	// the invite is handed out only by the ones already in
+	isCreator := g.Creator != "" && user == g.Creator
+	if !isCreator && !isPlayer(&g, user) {
+		err := errors.New("not a member of the game")
+		writeError(w, r, err, "join info", http.StatusForbidden)
+		return
+	}
+
+	token := h.inviteToken(gameID)
+	joinURL := h.publicURL + "/" + url.PathEscape(gameID)
+	if token != "" {
+		joinURL += "?invite=" + token
+	}
+
+	code, err := qr.Encode(joinURL, qr.M)
+	if err != nil {
+		writeError(w, r, err, "qr encode", http.StatusInternalServerError)
+		return
+	}
+
+	if ok := writeJSON(w, r, &JoinInfoResponse{
+		URL:         joinURL,
+		InviteToken: token,
+		QR:          renderSVG(code),
+	}); !ok {
+		return
+	}
+
+	log.Print("join info returned")
+}
+
+// inviteToken returns the invite token of the game, or empty string when
+// invites are not enabled.
+func (h *handler) inviteToken(gameID string) string {
+	if h.inviteSecret == nil {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, h.inviteSecret)
+	mac.Write([]byte(gameID))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// validInvite tells if the request can join the game.
+func (h *handler) validInvite(gameID string, r *http.Request) bool {
+	if h.inviteSecret == nil {
+		return true
+	}
+
+	got := r.URL.Query().Get("invite")
+	return hmac.Equal([]byte(got), []byte(h.inviteToken(gameID)))
+}
+
+// renderSVG draws the QR code with one unit wide squares.
+func renderSVG(code *qr.Code) string {
+	size := code.Size + 2*qrQuietZone
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, size, size)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.Black(x, y) {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x+qrQuietZone, y+qrQuietZone)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.String()
+}
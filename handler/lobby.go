@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// GameStatus tells where a game is in its life.
+type GameStatus string
+
+// Available statuses
+const (
+	// Open games are not started yet, and anyone can join them
+	Open GameStatus = "open"
+
+	// Running games are started, or closed for the others before their start
+	Running GameStatus = "running"
+
+	// Over games are finished
+	Over GameStatus = "over"
+)
+
+// GameSummary is a game in the lobby.
+type GameSummary struct {
+	ID      string
+	Players int
+	Status  GameStatus
+
+	// Sandbox tells the game is a copy to explore alternate lines of another
+	// game
+	Sandbox bool
+}
+
+var errInvalidStatus = errors.New("invalid status")
+
+// Games returns the summaries of the games in the store, for the lobby UIs
+// showing the joinable games. The `user` query parameter keeps only the games
+// of the user, the `status` one only the games with the status.
+func (h *handler) Games(w http.ResponseWriter, r *http.Request) {
+	user := yahtzee.User(r.URL.Query().Get("user"))
+	status := GameStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", Open, Running, Over:
+	default:
+		writeError(w, r, errInvalidStatus, "invalid status", http.StatusBadRequest)
+		return
+	}
+
+	ids, err := h.store.List()
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	res := []*GameSummary{}
+	for _, id := range ids {
+		g, err := h.store.Load(id)
+		if errors.Is(err, store.ErrNotExists) {
+			// expired since it was listed
+			continue
+		}
+		if err != nil {
+			writeStoreError(w, r, err)
+			return
+		}
+
+		if user != "" && !isPlayer(&g, user) {
+			continue
+		}
+		s := gameStatus(&g)
+		if status != "" && s != status {
+			continue
+		}
+
+		res = append(res, &GameSummary{
+			ID:      id,
+			Players: len(g.Players),
+			Status:  s,
+			Sandbox: g.Sandbox,
+		})
+	}
+
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("games returned")
+}
+
+func gameStatus(g *yahtzee.Game) GameStatus {
+	switch {
+	case engine.IsOver(g):
+		return Over
+	case g.CurrentPlayer > 0 || g.Round > 0:
+		return Running
+	case g.HasFeature(yahtzee.Solo) && len(g.Players) > 0, g.Match != "", g.Sandbox:
+		// nobody else can join them
+		return Running
+	}
+	return Open
+}
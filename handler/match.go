@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/event"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// maxBestOf is the most games a match can be played for.
+const maxBestOf = 9
+
+// WithMatches sets where the match series are kept. Without it matches can't
+// be created.
+func WithMatches(m store.Matches) Option {
+	return func(h *handler) {
+		h.matches = m
+	}
+}
+
+// MatchRequest is the body of the create match request.
+type MatchRequest struct {
+	CreateRequest
+
+	// BestOf is the number of games the match is played for
+	BestOf int
+
+	// Players has the opponents of the creator
+	Players []yahtzee.User
+}
+
+func (h *handler) CreateMatch(w http.ResponseWriter, r *http.Request) {
+	if h.matches == nil {
+		writeError(w, r, nil, "matches are not enabled", http.StatusNotFound)
+		return
+	}
+	user, ok := readUser(w, r)
+	if !ok {
+		return
+	}
+	features, ok := readFeatures(w, r)
+	if !ok {
+		return
+	}
+	if ok := checkFeatures(w, r, features); !ok {
+		return
+	}
+	req := &MatchRequest{}
+	if ok := readBody(w, r, req); !ok {
+		return
+	}
+	if ok := checkCreateRequest(w, r, &req.CreateRequest); !ok {
+		return
+	}
+	if req.BestOf < 1 || req.BestOf > maxBestOf {
+		writeError(w, r, nil, "invalid best of", http.StatusBadRequest)
+		return
+	}
+
+	players := append([]yahtzee.User{user}, req.Players...)
+	m := yahtzee.NewMatch(req.BestOf, players, features...)
+	m.Dice = req.Dice
+	m.Sides = req.Sides
+	m.Rules = req.Rules
+	m.Rounds = req.Rounds
+	m.Locale = req.Locale
+	m.TimeZone = req.TimeZone
+
+	matchID, err := h.unusedID(func(id string) error {
+		_, err := h.matches.LoadMatch(id)
+		return err
+	})
+	if err != nil {
+		writeError(w, r, err, "generate id", http.StatusInternalServerError)
+		return
+	}
+
+	g, err := newMatchGame(matchID, m)
+	if err != nil {
+		writeEngineError(w, r, err)
+		return
+	}
+	if ok := checkSheet(w, r, g); !ok {
+		return
+	}
+	if ok := h.admitGame(w, r); !ok {
+		return
+	}
+	if err := h.startMatchGame(m, g); err != nil {
+		writeError(w, r, err, "create game", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.matches.SaveMatch(matchID, *m); err != nil {
+		writeError(w, r, err, "create match", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/matches/%s", matchID))
+	w.WriteHeader(http.StatusCreated)
+	if ok := writeJSON(w, r, m); !ok {
+		return
+	}
+
+	log.Print("match created")
+}
+
+func (h *handler) GetMatch(w http.ResponseWriter, r *http.Request) {
+	if h.matches == nil {
+		writeError(w, r, nil, "matches are not enabled", http.StatusNotFound)
+		return
+	}
+	matchID, ok := readMatchID(w, r)
+	if !ok {
+		return
+	}
+
+	m, err := h.matches.LoadMatch(matchID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	if ok := writeJSON(w, r, m); !ok {
+		return
+	}
+
+	log.Print("match returned")
+}
+
+func (h *handler) MatchWS(w http.ResponseWriter, r *http.Request) {
+	if h.matches == nil {
+		writeError(w, r, nil, "matches are not enabled", http.StatusNotFound)
+		return
+	}
+	matchID, ok := readMatchID(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.matches.LoadMatch(matchID); err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	h.serveEvents(w, r, matchChannel(matchID), nil)
+}
+
+// matchGamePlayed rolls the result of the finished game into its match, and
+// starts the next game of the match unless it's over. The events are sent to
+// the players of the finished game and the subscribers of the match.
+func (h *handler) matchGamePlayed(gameID string, g *yahtzee.Game) {
+	if h.matches == nil {
+		return
+	}
+
+	unlocker, err := h.store.Lock(matchChannel(g.Match))
+	if err != nil {
+		log.Printf("match locking issue: %v", err)
+		return
+	}
+	defer unlocker()
+
+	m, err := h.matches.LoadMatch(g.Match)
+	if err != nil {
+		log.Printf("match load: %v", err)
+		return
+	}
+
+	events, err := engine.RecordGame(&m, gameID, g)
+	if err != nil {
+		log.Printf("record match game: %v", err)
+		return
+	}
+
+	if !engine.IsMatchOver(&m) {
+		next, err := newMatchGame(g.Match, &m)
+		if err != nil {
+			log.Printf("next match game: %v", err)
+			return
+		}
+		if err := h.startMatchGame(&m, next); err != nil {
+			log.Printf("next match game: %v", err)
+			return
+		}
+	}
+
+	if err := h.matches.SaveMatch(g.Match, m); err != nil {
+		log.Printf("match save: %v", err)
+		return
+	}
+
+	h.emit(gameID, nil, g, "", events)
+	for _, e := range events {
+		h.emitter.Emit(matchChannel(g.Match), &event.Event{
+			Action: e.Action,
+			Data:   e.Data,
+		})
+	}
+
+	log.Print("match game recorded")
+}
+
+// newMatchGame returns the next game of the match with its players joined.
+func newMatchGame(matchID string, m *yahtzee.Match) (*yahtzee.Game, error) {
+	g := newGame(m.Features, &CreateRequest{
+		Dice:     m.Dice,
+		Sides:    m.Sides,
+		Rules:    m.Rules,
+		Rounds:   m.Rounds,
+		Locale:   m.Locale,
+		TimeZone: m.TimeZone,
+	})
+
+	if g.HasFeature(yahtzee.Duplicate) {
+		g.Seed = rand.Int63()
+	}
+
+	for _, u := range m.Players {
+		if _, err := engine.AddPlayer(g, u); err != nil {
+			return nil, err
+		}
+	}
+	g.Match = matchID
+
+	return g, nil
+}
+
+// startMatchGame saves the game as the current one of the match.
+func (h *handler) startMatchGame(m *yahtzee.Match, g *yahtzee.Game) error {
+	gameID, err := h.generateID()
+	if err != nil {
+		return err
+	}
+	if err := h.store.Save(gameID, *g); err != nil {
+		return err
+	}
+
+	for _, p := range g.Players {
+		h.joined(p.User, gameID)
+	}
+	m.Games = append(m.Games, gameID)
+	return nil
+}
+
+// matchChannel is where the events of the match are sent.
+func matchChannel(matchID string) string {
+	return "match:" + matchID
+}
+
+func readMatchID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	matchID, ok := mux.Vars(r)["matchID"]
+	if !ok {
+		err := errors.New("no matchID")
+		writeError(w, r, err, "no matchID in request", http.StatusInternalServerError)
+		return "", false
+	}
+	return matchID, true
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// maxNoteLength is the most characters a note can have.
+const maxNoteLength = 500
+
+// WithNotes sets where the private notes of the players are kept. Without it
+// the players can't take notes.
+func WithNotes(n store.Notes) Option {
+	return func(h *handler) {
+		h.notes = n
+	}
+}
+
+func (h *handler) GetNotes(w http.ResponseWriter, r *http.Request) {
+	if h.notes == nil {
+		writeError(w, r, nil, "notes are not enabled", http.StatusNotFound)
+		return
+	}
+	user, ok := readUser(w, r)
+	if !ok {
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.store.Load(gameID); err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	notes, err := h.notes.LoadNotes(gameID, user)
+	if errors.Is(err, store.ErrNotExists) {
+		notes = yahtzee.Notes{}
+	} else if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	if ok := writeJSON(w, r, emptyNotes(notes)); !ok {
+		return
+	}
+
+	log.Print("notes returned")
+}
+
+func (h *handler) PutNotes(w http.ResponseWriter, r *http.Request) {
+	if h.notes == nil {
+		writeError(w, r, nil, "notes are not enabled", http.StatusNotFound)
+		return
+	}
+	user, ok := readUser(w, r)
+	if !ok {
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+	notes := &yahtzee.Notes{}
+	if ok := readBody(w, r, notes); !ok {
+		return
+	}
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	if !isPlayer(&g, user) {
+		writeEngineError(w, r, engine.ErrNotJoined)
+		return
+	}
+	if ok := checkNotes(w, r, &g, notes); !ok {
+		return
+	}
+
+	if err := h.notes.SaveNotes(gameID, user, *notes); err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	if ok := writeJSON(w, r, emptyNotes(*notes)); !ok {
+		return
+	}
+
+	log.Print("notes saved")
+}
+
+// checkNotes tells if the notes are on the boxes and the turns of the game.
+func checkNotes(w http.ResponseWriter, r *http.Request, g *yahtzee.Game, notes *yahtzee.Notes) bool {
+	categories := map[yahtzee.Category]bool{}
+	for _, c := range engine.GameScorer(g).Categories() {
+		categories[c] = true
+	}
+	for c, note := range notes.Categories {
+		if !categories[c] {
+			writeEngineError(w, r, engine.ErrInvalidCategory)
+			return false
+		}
+		if len([]rune(note)) > maxNoteLength {
+			writeError(w, r, nil, "note too long", http.StatusBadRequest)
+			return false
+		}
+	}
+	for turn, note := range notes.Turns {
+		if turn < 0 || turn >= engine.Rounds(g) {
+			writeError(w, r, nil, "invalid turn", http.StatusBadRequest)
+			return false
+		}
+		if len([]rune(note)) > maxNoteLength {
+			writeError(w, r, nil, "note too long", http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
+// emptyNotes returns the notes with empty maps instead of missing ones.
+func emptyNotes(n yahtzee.Notes) yahtzee.Notes {
+	if n.Categories == nil {
+		n.Categories = map[yahtzee.Category]string{}
+	}
+	if n.Turns == nil {
+		n.Turns = map[int]string{}
+	}
+	return n
+}
+
+func isPlayer(g *yahtzee.Game, u yahtzee.User) bool {
+	for _, p := range g.Players {
+		if p.User == u {
+			return true
+		}
+	}
+	return false
+}
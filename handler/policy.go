@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/i18n"
+)
+
+// FeaturePolicy is what the server does with the features of the created
+// games, like a kids' server always playing with the hints and never with
+// the blitz.
+type FeaturePolicy struct {
+	// Defaults are the features of the games created without any
+	Defaults []yahtzee.Feature
+
+	// Required are added to every created game, they can't be turned off
+	Required []yahtzee.Feature
+
+	// Forbidden can't be played, the games asking for them are refused
+	Forbidden []yahtzee.Feature
+}
+
+// Validate tells if the features of the policy are known and can be played
+// together.
+func (p *FeaturePolicy) Validate() error {
+	for _, list := range [][]yahtzee.Feature{p.Defaults, p.Required, p.Forbidden} {
+		for _, f := range list {
+			if !knownFeature(f) {
+				return fmt.Errorf("unknown feature %q", f)
+			}
+		}
+	}
+	for _, f := range append(append([]yahtzee.Feature{}, p.Defaults...), p.Required...) {
+		if hasFeature(p.Forbidden, f) {
+			return fmt.Errorf("feature %q is forbidden", f)
+		}
+	}
+	if conflicts := engine.Conflicts(p.Required...); len(conflicts) > 0 {
+		return fmt.Errorf("required features can't be played together: %v", conflicts)
+	}
+	if conflicts := engine.Conflicts(p.apply(p.Defaults)...); len(conflicts) > 0 {
+		return fmt.Errorf("default features can't be played together: %v", conflicts)
+	}
+	return nil
+}
+
+// WithFeaturePolicy sets what the server does with the features of the
+// created games. Without it the games have the features they ask for.
+func WithFeaturePolicy(p FeaturePolicy) Option {
+	return func(h *handler) {
+		h.features = p
+	}
+}
+
+// apply returns the features of a game created with the requested features.
+func (p *FeaturePolicy) apply(requested []yahtzee.Feature) []yahtzee.Feature {
+	if len(requested) == 0 {
+		requested = p.Defaults
+	}
+
+	var res []yahtzee.Feature
+	for _, f := range append(append([]yahtzee.Feature{}, requested...), p.Required...) {
+		if !hasFeature(res, f) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// forbidden returns the forbidden features of the requested ones.
+func (p *FeaturePolicy) forbidden(requested []yahtzee.Feature) []yahtzee.Feature {
+	var res []yahtzee.Feature
+	for _, f := range requested {
+		if hasFeature(p.Forbidden, f) && !hasFeature(res, f) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+func hasFeature(features []yahtzee.Feature, f yahtzee.Feature) bool {
+	for _, has := range features {
+		if has == f {
+			return true
+		}
+	}
+	return false
+}
+
+// ForbiddenResponse is the error of creating a game with the features
+// forbidden on the server.
+type ForbiddenResponse struct {
+	ErrorResponse
+
+	// Forbidden has the requested features the server doesn't play
+	Forbidden []yahtzee.Feature
+}
+
+// checkPolicy tells if the requested features are allowed by the policy of
+// the server, and returns the features of the game.
+func (h *handler) checkPolicy(w http.ResponseWriter, r *http.Request, requested []yahtzee.Feature) ([]yahtzee.Feature, bool) {
+	forbidden := h.features.forbidden(requested)
+	if len(forbidden) == 0 {
+		return h.features.apply(requested), true
+	}
+	log.Printf("forbidden features: %v", forbidden)
+
+	code := "forbidden-features"
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("Vary", "Accept-Language")
+	w.WriteHeader(http.StatusBadRequest)
+
+	json.NewEncoder(w).Encode(&ForbiddenResponse{
+		ErrorResponse: ErrorResponse{
+			Code:    code,
+			Message: i18n.Message(lang, code),
+		},
+		Forbidden: forbidden,
+	})
+	return nil, false
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// Defaults of the simulation pool.
+const (
+	// defaultSimulationQueue is the number of calculations waiting for a
+	// worker for every worker
+	defaultSimulationQueue = 4
+
+	// defaultSimulationBudget is the time a request can spend on simulations
+	defaultSimulationBudget = 2 * time.Second
+)
+
+var errPoolSaturated = errors.New("simulation pool is saturated")
+
+// WithSimulationPool sets the number of workers running the analytical
+// calculations, like the win probabilities and the advice of the coach, and
+// the number of calculations waiting for them. The requests beyond are
+// answered with 429 Too Many Requests, so the calculations can't starve the
+// games. Half of the CPUs are used by default, with four waiting
+// calculations for every worker.
+func WithSimulationPool(workers, queue int) Option {
+	return func(h *handler) {
+		h.simulations = newPool(workers, queue)
+	}
+}
+
+// WithSimulationBudget sets the time a request can spend on the simulations,
+// the estimates are made from the simulations done by then. Two seconds are
+// spent at most by default.
+func WithSimulationBudget(budget time.Duration) Option {
+	return func(h *handler) {
+		h.simulationBudget = budget
+	}
+}
+
+// pool runs the calculations on a bounded number of workers.
+type pool struct {
+	// workers has a token for every running calculation
+	workers chan struct{}
+
+	// slots has a token for every running and waiting calculation
+	slots chan struct{}
+}
+
+func newPool(workers, queue int) *pool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &pool{
+		workers: make(chan struct{}, workers),
+		slots:   make(chan struct{}, workers+queue),
+	}
+}
+
+func defaultPool() *pool {
+	workers := runtime.NumCPU() / 2
+	if workers < 1 {
+		workers = 1
+	}
+	return newPool(workers, defaultSimulationQueue*workers)
+}
+
+// run runs the calculation on a worker, waiting for one in the queue.
+// errPoolSaturated is returned when the queue is full, and the error of the
+// context when it's done while waiting.
+func (p *pool) run(ctx context.Context, calculate func()) error {
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		return errPoolSaturated
+	}
+	defer func() { <-p.slots }()
+
+	select {
+	case p.workers <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-p.workers }()
+
+	calculate()
+	return nil
+}
+
+// simulate runs the calculation in the simulation pool, and writes the error
+// when it can't.
+func (h *handler) simulate(w http.ResponseWriter, r *http.Request, calculate func()) bool {
+	err := h.simulations.run(r.Context(), calculate)
+	if errors.Is(err, errPoolSaturated) {
+		w.Header().Set("Retry-After", "1")
+		writeError(w, r, err, "simulations", http.StatusTooManyRequests)
+		return false
+	}
+	if err != nil {
+		writeError(w, r, err, "simulations", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// solve returns the cached result of the key, or calculates it with
+// `compute` in the simulation pool. The error is written when it can't.
+func (h *handler) solve(
+	w http.ResponseWriter,
+	r *http.Request,
+	key string,
+	compute func() interface{}) (interface{}, bool) {
+	if res, ok := h.solverCache.cached(key); ok {
+		return res, true
+	}
+
+	var res interface{}
+	ok := h.simulate(w, r, func() {
+		res = h.solverCache.get(key, compute)
+	})
+	return res, ok
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	event_impl "github.com/akarasz/yahtzee/event/embedded"
+	store "github.com/akarasz/yahtzee/store/embedded"
+)
+
+func TestPool(t *testing.T) {
+	p := newPool(1, 1)
+
+	running, done := make(chan struct{}), make(chan struct{})
+	go p.run(context.Background(), func() {
+		close(running)
+		<-done
+	})
+	<-running
+
+	// the waiting one gives up with its request
+	ctx, cancel := context.WithCancel(context.Background())
+	waiting := make(chan error)
+	go func() {
+		waiting <- p.run(ctx, func() {})
+	}()
+
+	// the queue is full
+	assert.Eventually(t, func() bool {
+		return p.run(context.Background(), func() {}) == errPoolSaturated
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	assert.Exactly(t, context.Canceled, <-waiting)
+
+	close(done)
+	assert.Eventually(t, func() bool {
+		return p.run(context.Background(), func() {}) == nil
+	}, time.Second, time.Millisecond)
+}
+
+func TestSaturated(t *testing.T) {
+	e := event_impl.New()
+	var p *pool
+	h := New(store.New(), e, e, WithSimulationPool(1, 0), func(h *handler) {
+		p = h.simulations
+	})
+
+	probabilities := func(dice string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", "/probabilities?rolls=1&dice="+dice, nil))
+		return rr
+	}
+	require.Exactly(t, http.StatusOK, probabilities("1,2,3,4,5").Code)
+
+	running, done := make(chan struct{}), make(chan struct{})
+	defer close(done)
+	go p.run(context.Background(), func() {
+		close(running)
+		<-done
+	})
+	<-running
+
+	rr := probabilities("1,2,3,4,6")
+	assert.Exactly(t, http.StatusTooManyRequests, rr.Code)
+	assert.Exactly(t, "1", rr.Header().Get("Retry-After"))
+
+	// the cached results don't need the pool
+	assert.Exactly(t, http.StatusOK, probabilities("1,2,3,4,5").Code)
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+)
+
+func (h *handler) Probabilities(w http.ResponseWriter, r *http.Request) {
+	features, ok := readFeatures(w, r)
+	if !ok {
+		return
+	}
+	sides, ok := readSides(w, r)
+	if !ok {
+		return
+	}
+	dices, ok := readDices(w, r, "dice", yahtzee.DiceCount(features...), sides)
+	if !ok {
+		return
+	}
+	rolls, ok := readRolls(w, r)
+	if !ok {
+		return
+	}
+
+	var res interface{}
+	if found, ok := h.lookup(features, sides, dices, rolls); ok {
+		res = found
+	} else {
+		key := solverKey(fmt.Sprintf("probabilities|%d", sides), features, nil, dices, rolls)
+		res, ok = h.solve(w, r, key, func() interface{} {
+			return engine.NewScorer(features...).Probabilities(dices, sides, rolls)
+		})
+		if !ok {
+			return
+		}
+	}
+
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("probabilities returned")
+}
+
+func readRolls(w http.ResponseWriter, r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("rolls")
+	if raw == "" {
+		return 0, true
+	}
+	rolls, err := strconv.Atoi(raw)
+	if err != nil || rolls < 0 || rolls > maxRollsPerTurn {
+		writeError(w, r, err, "invalid rolls", http.StatusBadRequest)
+		return 0, false
+	}
+	return rolls, true
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+)
+
+// project fills the projections of the turn changed events in games with the
+// projection feature. The projections are left out when the dices have too
+// many outcomes.
+func (h *handler) project(g *yahtzee.Game, events []*engine.Event) {
+	if !g.HasFeature(yahtzee.Projection) {
+		return
+	}
+
+	for _, e := range events {
+		res, ok := e.Data.(*engine.TurnChangedResult)
+		if !ok {
+			continue
+		}
+		if expected := h.expectations(g); expected != nil {
+			res.Projections = engine.Projections(g, expected)
+		}
+	}
+}
+
+// expectations returns the expected scores of the categories for a fresh turn
+// of the game, nil when the dices have too many outcomes. They depend only on
+// the rules, so they are cached with the other solver results.
+func (h *handler) expectations(g *yahtzee.Game) map[yahtzee.Category]float64 {
+	kind := fmt.Sprintf("projection|%d|%d", len(g.Dices), g.Sides)
+	return h.solverCache.get(solverKey(kind, g.Features, g.Rules, nil, 0), func() interface{} {
+		res, err := engine.ProjectionExpectations(g)
+		if err != nil {
+			return map[yahtzee.Category]float64(nil)
+		}
+		return res
+	}).(map[yahtzee.Category]float64)
+}
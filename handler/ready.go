@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/akarasz/yahtzee/store"
+)
+
+// healthTimeout is the longest time a health check can take, it's failed
+// after.
+const healthTimeout = 2 * time.Second
+
+// WithHealthCheck adds the store to the checks of the readiness endpoint
+// under `name`, like "redis" or "postgres". The wrappers of the stores don't
+// tell their health, so the backends are added themselves.
+func WithHealthCheck(name string, s store.Healther) Option {
+	return func(h *handler) {
+		if h.healthChecks == nil {
+			h.healthChecks = map[string]store.Healther{}
+		}
+		h.healthChecks[name] = s
+	}
+}
+
+// ReadinessResponse is the result of the health checks of the stores.
+type ReadinessResponse struct {
+	// Ready tells if every check passed
+	Ready bool
+
+	// Checks has "ok" or the error of every check by its name
+	Checks map[string]string
+}
+
+// Ready runs the health checks at the same time, and answers with `503
+// Service Unavailable` when any of them fails or the warm-up is not done, so
+// the orchestrators stop routing to a server with a broken store.
+func (h *handler) Ready(w http.ResponseWriter, r *http.Request) {
+	res := &ReadinessResponse{
+		Ready:  true,
+		Checks: map[string]string{},
+	}
+
+	if h.warmUp != nil {
+		select {
+		case <-h.warmed:
+			res.Checks["warm-up"] = "ok"
+			if h.warmUpErr != nil {
+				res.Ready = false
+				res.Checks["warm-up"] = h.warmUpErr.Error()
+			}
+		default:
+			res.Ready = false
+			res.Checks["warm-up"] = "in progress"
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, s := range h.healthChecks {
+		wg.Add(1)
+		go func(name string, s store.Healther) {
+			defer wg.Done()
+			err := checkHealth(s)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("health check %s: %v", name, err)
+				res.Ready = false
+				res.Checks[name] = err.Error()
+				return
+			}
+			res.Checks[name] = "ok"
+		}(name, s)
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if !res.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Printf("readiness: %v", err)
+		return
+	}
+
+	log.Print("readiness returned")
+}
+
+var errHealthTimeout = errors.New("health check timed out")
+
+// checkHealth runs the check of the store, giving up after healthTimeout.
+func checkHealth(s store.Healther) error {
+	done := make(chan error, 1)
+	go func() { done <- s.Health() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(healthTimeout):
+		return errHealthTimeout
+	}
+}
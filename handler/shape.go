@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// DiceShape tells how the dices are written in the responses. The clients
+// choose it with the `shape` query parameter of any request.
+type DiceShape string
+
+// Available shapes
+const (
+	// DiceStructs are the dices as they are in the game, the default
+	DiceStructs DiceShape = ""
+
+	// DiceObjects are the dices with their ID, the index used to lock them
+	DiceObjects DiceShape = "objects"
+
+	// DiceValues are only the face values of the dices
+	DiceValues DiceShape = "values"
+)
+
+var errInvalidDiceShape = errors.New("invalid dice shape")
+
+type shapeKey struct{}
+
+// shapeMiddleware rejects the unknown shapes before the request changes
+// anything, and passes the shape to writeJSON.
+func shapeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := DiceShape(r.URL.Query().Get("shape"))
+		switch s {
+		case DiceStructs:
+			next.ServeHTTP(w, r)
+		case DiceObjects, DiceValues:
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), shapeKey{}, s)))
+		default:
+			writeError(w, r, errInvalidDiceShape, "invalid dice shape", http.StatusBadRequest)
+		}
+	})
+}
+
+func diceShape(r *http.Request) DiceShape {
+	shape, _ := r.Context().Value(shapeKey{}).(DiceShape)
+	return shape
+}
+
+// shape returns the body with its dices in the shape. Every list of dices
+// is reshaped, wherever it is in the body.
+func shape(body interface{}, s DiceShape) (interface{}, error) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var res interface{}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	if err := d.Decode(&res); err != nil {
+		return nil, err
+	}
+
+	reshape(res, s)
+	return res, nil
+}
+
+func reshape(v interface{}, s DiceShape) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if dices, ok := value.([]interface{}); ok && key == "Dices" && isDices(dices) {
+				v[key] = reshapeDices(dices, s)
+				continue
+			}
+			reshape(value, s)
+		}
+	case []interface{}:
+		for _, value := range v {
+			reshape(value, s)
+		}
+	}
+}
+
+// isDices tells if the list has dices, not the bare values of the hands.
+func isDices(list []interface{}) bool {
+	for _, v := range list {
+		if d, ok := v.(map[string]interface{}); !ok || d["Value"] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func reshapeDices(dices []interface{}, s DiceShape) []interface{} {
+	res := make([]interface{}, len(dices))
+	for i, v := range dices {
+		d := v.(map[string]interface{})
+		switch s {
+		case DiceObjects:
+			d["ID"] = i
+			res[i] = d
+		case DiceValues:
+			res[i] = d["Value"]
+		}
+	}
+	return res
+}
@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/i18n"
+	"github.com/akarasz/yahtzee/sms"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// WithSMSToken enables the webhooks of the SMS and the USSD gateways for the
+// requests with the token in their `token` query parameter, so the games can
+// be played on the feature phones. The players are named after a hash of
+// their phone numbers, and their last game is found in the user games.
+// Without it the webhooks are not served.
+func WithSMSToken(token string) Option {
+	return func(h *handler) {
+		h.smsToken = token
+	}
+}
+
+// SMS answers the text message of a phone sent by an SMS gateway, with the
+// number in the `From` and the message in the `Body` form values like Twilio
+// sends them. The reply is the plain text to send back.
+func (h *handler) SMS(w http.ResponseWriter, r *http.Request) {
+	if ok := h.checkSMSToken(w, r); !ok {
+		return
+	}
+
+	reply := h.smsReply(r.FormValue("From"), r.FormValue("Body"))
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, reply)
+
+	log.Print("sms answered")
+}
+
+// USSD answers a USSD session, with the number in the `phoneNumber` and the
+// inputs of the session joined by stars in the `text` form values like
+// Africa's Talking sends them. The last input is the command, and the session
+// is kept open for the next one.
+func (h *handler) USSD(w http.ResponseWriter, r *http.Request) {
+	if ok := h.checkSMSToken(w, r); !ok {
+		return
+	}
+
+	inputs := strings.Split(r.FormValue("text"), "*")
+	reply := sms.HelpText
+	if command := inputs[len(inputs)-1]; command != "" {
+		reply = h.smsReply(r.FormValue("phoneNumber"), command)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, "CON "+reply)
+
+	log.Print("ussd answered")
+}
+
+// checkSMSToken tells if the webhooks are enabled and the request has their
+// token, and writes the error when not.
+func (h *handler) checkSMSToken(w http.ResponseWriter, r *http.Request) bool {
+	if h.smsToken == "" {
+		writeError(w, r, nil, "sms disabled", http.StatusNotFound)
+		return false
+	}
+	got := []byte(r.URL.Query().Get("token"))
+	if subtle.ConstantTimeCompare(got, []byte(h.smsToken)) != 1 {
+		writeError(w, r, nil, "invalid sms token", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// smsUser returns the player of the phone number, named after its hash so
+// the other players don't see the number.
+func (h *handler) smsUser(phone string) yahtzee.User {
+	mac := hmac.New(sha256.New, []byte(h.smsToken))
+	mac.Write([]byte(phone))
+	return yahtzee.User("sms-" + hex.EncodeToString(mac.Sum(nil))[:8])
+}
+
+// smsReply plays the command of the message for the phone, and returns the
+// reply to it.
+func (h *handler) smsReply(phone string, text string) string {
+	if phone == "" {
+		return "Unknown phone number."
+	}
+	u := h.smsUser(phone)
+
+	c, err := sms.Parse(text)
+	if err != nil {
+		return "Unknown command. " + sms.HelpText
+	}
+
+	switch c.Kind {
+	case sms.Help:
+		return sms.HelpText
+	case sms.New:
+		return h.smsNew(u)
+	case sms.Join:
+		return h.smsPlay(c.GameID, u, c)
+	}
+
+	gameID, ok := h.lastGame(u)
+	if !ok {
+		return "You have no game. " + sms.HelpText
+	}
+	return h.smsPlay(gameID, u, c)
+}
+
+// smsNew creates a game for the phones, and joins it.
+func (h *handler) smsNew(u yahtzee.User) string {
+	g := yahtzee.NewGame()
+	g.Creator = u
+	if _, err := engine.AddPlayer(g, u); err != nil {
+		return smsError(err)
+	}
+
+	if err := h.reserveGame(); err != nil {
+		log.Printf("count games: %v", err)
+		return smsError(err)
+	}
+	gameID, err := h.generateID()
+	if err != nil {
+		log.Printf("generate id: %v", err)
+		return smsError(err)
+	}
+	if err := h.store.Save(gameID, *g); err != nil {
+		log.Printf("create game: %v", err)
+		return smsError(err)
+	}
+	h.joined(u, gameID)
+
+	return fmt.Sprintf("Game %s created, the others can send JOIN %s.\n%s", gameID, gameID, sms.Summary(g, u))
+}
+
+// smsPlay plays the command on the game, and returns the summary of the game
+// after it.
+func (h *handler) smsPlay(gameID string, u yahtzee.User, c *sms.Command) string {
+	unlocker, err := h.store.Lock(gameID)
+	if err != nil {
+		log.Printf("locking issue: %v", err)
+		return smsError(err)
+	}
+	defer unlocker()
+
+	g, err := h.store.Load(gameID)
+	if errors.Is(err, store.ErrNotExists) {
+		return fmt.Sprintf("Game %s not found.", gameID)
+	} else if err != nil {
+		log.Printf("load game: %v", err)
+		return smsError(err)
+	}
+	if c.Kind == sms.Show {
+		return sms.Summary(&g, u)
+	}
+	user := engine.Actor(&g, u)
+
+	var events []*engine.Event
+	switch c.Kind {
+	case sms.Join:
+		events, err = engine.AddPlayer(&g, user)
+	case sms.Roll:
+		events, err = engine.Roll(&g, user, rand.Intn, h.now())
+	case sms.Keep:
+		events, err = h.smsKeep(&g, user, c.Dices)
+	case sms.Score:
+		category, ok := sms.MatchCategory(c.Category, engine.GameScorer(&g).Categories())
+		if !ok {
+			return smsError(engine.ErrInvalidCategory)
+		}
+		events, err = engine.Score(&g, user, category, h.now())
+	}
+	if err != nil {
+		h.expired(gameID, err)
+		return smsError(err)
+	}
+
+	if err := h.store.Save(gameID, g); err != nil {
+		log.Printf("save game: %v", err)
+		return smsError(err)
+	}
+	h.schedule(gameID, &g)
+
+	if c.Kind == sms.Join {
+		h.joined(u, gameID)
+	}
+	if c.Kind == sms.Score {
+		events = h.unlock(&g, events)
+	}
+	h.emit(gameID, &user, &g, "", events)
+	if c.Kind == sms.Score {
+		h.turnPlayed(gameID, &g)
+	}
+
+	return sms.Summary(&g, u)
+}
+
+// smsKeep locks the dices on the positions and unlocks the rest, then rolls
+// the unlocked ones.
+func (h *handler) smsKeep(g *yahtzee.Game, u yahtzee.User, positions []int) ([]*engine.Event, error) {
+	keep := map[int]bool{}
+	for _, p := range positions {
+		if p > len(g.Dices) {
+			return nil, engine.ErrInvalidDice
+		}
+		keep[p-1] = true
+	}
+
+	var res []*engine.Event
+	for i, d := range g.Dices {
+		if d.Locked == keep[i] {
+			continue
+		}
+		events, err := engine.Lock(g, u, i)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, events...)
+	}
+
+	events, err := engine.Roll(g, u, rand.Intn, h.now())
+	if err != nil {
+		return nil, err
+	}
+	return append(res, events...), nil
+}
+
+// lastGame returns the game the user joined last.
+func (h *handler) lastGame(u yahtzee.User) (string, bool) {
+	if h.userGames == nil {
+		return "", false
+	}
+	ids, err := h.userGames.Games(u)
+	if err != nil {
+		log.Printf("user games: %v", err)
+		return "", false
+	}
+	if len(ids) == 0 {
+		return "", false
+	}
+	return ids[len(ids)-1], true
+}
+
+// smsError returns the message of the error in English, the phones don't
+// tell their language.
+func smsError(err error) string {
+	code, ok := engineErrorCodes[err]
+	if !ok {
+		code = "internal-error"
+	}
+	return i18n.Message("en", code)
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+)
+
+// maxSpectatorDelay is the longest delay in seconds the house rules can set
+// for the spectators.
+const maxSpectatorDelay = 600
+
+// spectators keeps the events of the games waiting for the delay of the
+// spectators, separately from the events sent to the players right away.
+type spectators struct {
+	mu     sync.Mutex
+	queues map[string][]*delayedEvent
+}
+
+// delayedEvent is an event to send to the spectators at a later time.
+type delayedEvent struct {
+	at time.Time
+	e  *event.Event
+}
+
+func newSpectators() *spectators {
+	return &spectators{
+		queues: map[string][]*delayedEvent{},
+	}
+}
+
+// spectate sends the event to the spectators of the game after the delay of
+// the game.
+func (h *handler) spectate(gameID string, g *yahtzee.Game, e *event.Event) {
+	channel := event.SpectatorChannel(gameID)
+
+	delay := spectatorDelay(g)
+	if delay == 0 {
+		h.emitter.Emit(channel, e)
+		return
+	}
+
+	s := h.spectators
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queue := s.queues[channel]
+	s.queues[channel] = append(queue, &delayedEvent{at: time.Now().Add(delay), e: e})
+	if len(queue) == 0 {
+		go h.drain(channel)
+	}
+}
+
+// drain sends the queued events of the channel in order when their time
+// comes, until the queue is empty.
+func (h *handler) drain(channel string) {
+	s := h.spectators
+	for {
+		s.mu.Lock()
+		queue := s.queues[channel]
+		if len(queue) == 0 {
+			delete(s.queues, channel)
+			s.mu.Unlock()
+			return
+		}
+		next := queue[0]
+		s.mu.Unlock()
+
+		time.Sleep(time.Until(next.at))
+		h.emitter.Emit(channel, next.e)
+
+		s.mu.Lock()
+		s.queues[channel] = s.queues[channel][1:]
+		s.mu.Unlock()
+	}
+}
+
+func (h *handler) SpectateWS(w http.ResponseWriter, r *http.Request) {
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.store.Load(gameID); err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+
+	h.serveEvents(w, r, event.SpectatorChannel(gameID), nil)
+}
+
+// checkLive tells if the user of the request can follow the game live. With a
+// spectator delay only the players can, everyone else has to spectate.
+func checkLive(w http.ResponseWriter, r *http.Request, g *yahtzee.Game) bool {
+	if spectatorDelay(g) == 0 {
+		return true
+	}
+	user, ok := readUser(w, r)
+	if !ok {
+		return false
+	}
+	if !isPlayer(g, user) {
+		writeError(w, r, nil, "only the players follow the game live", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// spectatorDelay returns how late the spectators get the events of the game.
+func spectatorDelay(g *yahtzee.Game) time.Duration {
+	if g.Rules == nil {
+		return 0
+	}
+	return time.Duration(g.Rules.SpectatorDelay) * time.Second
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"log"
+	"math/rand"
+	"net/http"
+
+	"github.com/akarasz/yahtzee/engine"
+)
+
+// Turn plays a whole turn of the user in one request, for the clients with
+// a round trip too slow for every roll, like the email bots and the SMS
+// gateways. The body has the faces kept for the rolls after the first and
+// the box scored at the end; the turn is played as a whole or not at all.
+func (h *handler) Turn(w http.ResponseWriter, r *http.Request) {
+	user, ok := readUser(w, r)
+	if !ok {
+		return
+	}
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+	t := &engine.ScriptedTurn{}
+	if ok := readBody(w, r, t); !ok {
+		return
+	}
+
+	unlocker, err := h.store.Lock(gameID)
+	if err != nil {
+		writeError(w, r, err, "locking issue", http.StatusInternalServerError)
+		return
+	}
+	defer unlocker()
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	user = engine.Actor(&g, user)
+
+	events, err := engine.PlayTurn(&g, user, *t, rand.Intn, h.now())
+	if err != nil {
+		h.expired(gameID, err)
+		writeEngineError(w, r, err)
+		return
+	}
+
+	if err := h.store.Save(gameID, g); err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	h.schedule(gameID, &g)
+
+	events = h.unlock(&g, events)
+	actionID := readActionID(r)
+	h.emit(gameID, &user, &g, actionID, events)
+
+	if actionID != "" {
+		w.Header().Set("Applied-Action-ID", actionID)
+	}
+	h.turnPlayed(gameID, &g)
+
+	if ok := writeJSON(w, r, &g); !ok {
+		return
+	}
+
+	log.Print("turn played")
+}
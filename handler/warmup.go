@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+)
+
+// WarmUp is the work done before the server is ready, so the first requests
+// don't pay for it.
+type WarmUp struct {
+	// Tables loads the probability tables, it can be nil
+	Tables func() ([]*engine.Table, error)
+
+	// Hints has the rule sets the hints of every dice state are cached for,
+	// nil is the standard rules
+	Hints [][]yahtzee.Feature
+}
+
+// WithWarmUp runs the warm-up in the background when the handler is made.
+// The stores of the health checks are checked first to open their
+// connections, then the tables are loaded and the hints are cached. The
+// readiness endpoint fails until it's done, and after it when it failed.
+func WithWarmUp(w WarmUp) Option {
+	return func(h *handler) {
+		h.warmUp = &w
+	}
+}
+
+// Validate tells if the features of the hints are known.
+func (w *WarmUp) Validate() error {
+	for _, features := range w.Hints {
+		for _, f := range features {
+			if !knownFeature(f) {
+				return fmt.Errorf("unknown feature %q", f)
+			}
+		}
+	}
+	return nil
+}
+
+// runWarmUp does the warm-up, and closes `warmed` when it's done.
+func (h *handler) runWarmUp() {
+	defer close(h.warmed)
+	start := time.Now()
+
+	for name, s := range h.healthChecks {
+		if err := checkHealth(s); err != nil {
+			// the readiness endpoint tells it, the warm-up goes on
+			log.Printf("warm-up %s: %v", name, err)
+		}
+	}
+
+	if h.warmUp.Tables != nil {
+		tables, err := h.warmUp.Tables()
+		if err != nil {
+			log.Printf("warm-up tables: %v", err)
+			h.warmUpErr = err
+			return
+		}
+		h.tablesMu.Lock()
+		h.probabilityTables = append(h.probabilityTables, tables...)
+		h.tablesMu.Unlock()
+	}
+
+	var hints int
+	for _, features := range h.warmUp.Hints {
+		scorer := engine.NewScorer(features...)
+		for _, dices := range diceStates(yahtzee.DiceCount(features...), yahtzee.NumberOfSides) {
+			h.solverCache.get(solverKey("hints", features, nil, dices, 0), func() interface{} {
+				return scores(scorer, dices)
+			})
+			hints++
+		}
+	}
+
+	log.Printf("warmed up in %v with %d tables and %d hints",
+		time.Since(start), len(h.probabilityTables), hints)
+}
+
+// diceStates returns every state of `n` dices in ascending order, the order
+// of the dices doesn't matter for the solver.
+func diceStates(n, sides int) [][]int {
+	if n == 0 {
+		return [][]int{{}}
+	}
+
+	var res [][]int
+	for _, rest := range diceStates(n-1, sides) {
+		from := 1
+		if len(rest) > 0 {
+			from = rest[len(rest)-1]
+		}
+		for v := from; v <= sides; v++ {
+			res = append(res, append(append([]int{}, rest...), v))
+		}
+	}
+	return res
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	event_impl "github.com/akarasz/yahtzee/event/embedded"
+	store "github.com/akarasz/yahtzee/store/embedded"
+)
+
+func TestDiceStates(t *testing.T) {
+	states := diceStates(5, 6)
+	require.Len(t, states, 252)
+	assert.Exactly(t, []int{1, 1, 1, 1, 1}, states[0])
+	assert.Exactly(t, []int{6, 6, 6, 6, 6}, states[len(states)-1])
+}
+
+func TestWarmUp(t *testing.T) {
+	e := event_impl.New()
+	loading := make(chan struct{})
+	var internal *handler
+	h := New(store.New(), e, e, WithWarmUp(WarmUp{
+		Tables: func() ([]*engine.Table, error) {
+			<-loading
+			return []*engine.Table{engine.GenerateTable(nil, yahtzee.NumberOfSides, 0)}, nil
+		},
+		Hints: [][]yahtzee.Feature{nil},
+	}), func(h *handler) {
+		internal = h
+	})
+	ready := func() int {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+		return rr.Code
+	}
+
+	assert.Exactly(t, http.StatusServiceUnavailable, ready())
+	close(loading)
+	assert.Eventually(t, func() bool {
+		return ready() == http.StatusOK
+	}, time.Second, time.Millisecond)
+
+	_, ok := internal.solverCache.cached(solverKey("hints", nil, nil, []int{6, 2, 3, 4, 5}, 0))
+	assert.True(t, ok)
+	_, ok = internal.lookup(nil, yahtzee.NumberOfSides, []int{1, 2, 3, 4, 5}, 0)
+	assert.True(t, ok)
+}
+
+func TestFailedWarmUp(t *testing.T) {
+	e := event_impl.New()
+	h := New(store.New(), e, e, WithWarmUp(WarmUp{
+		Tables: func() ([]*engine.Table, error) {
+			return nil, errors.New("no such file")
+		},
+	}))
+
+	assert.Eventually(t, func() bool {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+		return rr.Code == http.StatusServiceUnavailable &&
+			rr.Body.String() == `{"Ready":false,"Checks":{"warm-up":"no such file"}}`+"\n"
+	}, time.Second, time.Millisecond)
+}
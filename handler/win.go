@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+)
+
+const (
+	// winSimulations is the number of times the rest of a game is played for
+	// its win probabilities.
+	winSimulations = 500
+
+	// winBatch is the number of simulations played between the checks of the
+	// budget.
+	winBatch = 50
+)
+
+// WinProbabilityResponse has the chances of the players to win the game from
+// the start of the current turn.
+type WinProbabilityResponse struct {
+	// Round and User are the turn the chances are estimated from
+	Round int
+	User  yahtzee.User
+
+	// Simulations is the number of the simulations played, fewer than usual
+	// when the simulation budget was spent
+	Simulations int
+
+	// Probabilities has the chance of every player to win, between 0 and 1
+	Probabilities map[yahtzee.User]float64
+}
+
+// WinProbability estimates the chances of the players to win by simulating
+// the rest of the game, for the broadcast overlays and the analysis after
+// the game. The estimates are cached for every turn. The simulations run in
+// the simulation pool, 429 Too Many Requests is returned when it's saturated.
+func (h *handler) WinProbability(w http.ResponseWriter, r *http.Request) {
+	gameID, ok := readGameID(w, r)
+	if !ok {
+		return
+	}
+
+	g, err := h.store.Load(gameID)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	if len(g.Players) == 0 {
+		writeEngineError(w, r, engine.ErrNoPlayers)
+		return
+	}
+
+	key := fmt.Sprintf("win-probability|%s|%d|%d|%d", gameID, len(g.Players), g.Round, g.CurrentPlayer)
+	res, ok := h.solve(w, r, key, func() interface{} {
+		return h.winProbabilities(&g)
+	})
+	if !ok {
+		return
+	}
+	if err, ok := res.(error); ok {
+		writeEngineError(w, r, err)
+		return
+	}
+
+	if ok := writeJSON(w, r, res); !ok {
+		return
+	}
+
+	log.Print("win probability returned")
+}
+
+// winProbabilities simulates the game in batches until all the simulations are
+// played, or the simulation budget is spent. The error is returned when the
+// game can't be simulated.
+func (h *handler) winProbabilities(g *yahtzee.Game) interface{} {
+	expected := h.expectations(g)
+	deadline := time.Now().Add(h.simulationBudget)
+
+	wins := map[yahtzee.User]float64{}
+	played := 0
+	for played < winSimulations && (played == 0 || time.Now().Before(deadline)) {
+		probabilities, err := engine.WinProbabilities(g, expected, winBatch, rand.Intn)
+		if err != nil {
+			return err
+		}
+		for u, p := range probabilities {
+			wins[u] += p * winBatch
+		}
+		played += winBatch
+	}
+
+	for u := range wins {
+		wins[u] /= float64(played)
+	}
+	return &WinProbabilityResponse{
+		Round:         g.Round,
+		User:          g.Players[g.CurrentPlayer].User,
+		Simulations:   played,
+		Probabilities: wins,
+	}
+}
@@ -0,0 +1,335 @@
+package i18n
+
+// catalog has the messages by language and code.
+var catalog = map[string]map[string]string{
+	"en": {
+		"bad-request":     "The request is invalid.",
+		"unauthorized":    "Log in to play.",
+		"forbidden":       "You are not allowed to do this.",
+		"not-found":       "Not found.",
+		"conflict":        "This has already happened.",
+		"busy":            "The server is busy, try again later.",
+		"client-outdated": "The app is outdated, update it to play.",
+		"internal-error":  "Something went wrong, try again.",
+
+		"already-started":        "The game has already started.",
+		"already-joined":         "You have already joined.",
+		"no-players":             "Nobody has joined yet.",
+		"another-player":         "It's another player's turn.",
+		"game-over":              "The game is over.",
+		"no-more-rolls":          "You have no more rolls.",
+		"roll-first":             "Roll the dices first.",
+		"category-used":          "This category is already used.",
+		"invalid-category":       "There is no such category.",
+		"invalid-dice":           "There is no such dice.",
+		"invalid-column":         "There is no such column.",
+		"solo-game":              "Only one player can play a solo game.",
+		"match-game":             "Players of a match game can't change.",
+		"sandbox-game":           "Players of a sandbox game can't change.",
+		"joker-upper-box":        "The Joker has to be scored in the upper section.",
+		"joker-lower-box":        "The Joker has to be scored in the lower section.",
+		"not-announce-game":      "Announcing is not enabled in this game.",
+		"already-announced":      "A category is already announced.",
+		"announce-first":         "Announce a category first.",
+		"not-announced-category": "Only the announced category can be scored.",
+		"incomplete-sheet":       "Every round of the game has to be played.",
+		"not-extra-roll-game":    "Extra rolls are not enabled in this game.",
+		"no-extra-rolls":         "No extra rolls left.",
+		"not-handicap-game":      "Handicaps are not enabled in this game.",
+		"invalid-handicap":       "The handicap has to be between -100 and 100.",
+		"teams-full":             "Both teams are full.",
+		"teams-incomplete":       "Both teams need two players.",
+		"not-joined":             "You are not playing in this game.",
+		"away-not-allowed":       "Being away is not allowed in this game.",
+		"away-too-long":          "You can't be away for this long.",
+		"lowball-scratch":        "Boxes can't be scratched in lowball games.",
+		"not-duplicate-game":     "The game is not played with duplicate rolls.",
+		"different-rolls":        "The tables are not playing the same rolls.",
+		"unknown-table":          "One of the tables doesn't exist.",
+		"not-coach-game":         "The coach is not enabled in this game.",
+		"coach-dices":            "The coach can't advise with this many dices or sides.",
+		"no-hints":               "No hints left.",
+		"coach-best":             "The best expected score with the rolls left.",
+		"coach-scores-now":       "The dices already score here.",
+		"coach-bonus":            "Scoring here earns the upper section bonus.",
+		"coach-risky":            "It only scores with luck on the rolls left.",
+		"coach-sacrifice":        "It can't score anymore, a place to give up the turn.",
+		"impossible-score":       "A score of the sheet is not possible in its box.",
+		"sheet-order":            "The filled boxes don't fit the order of the turns.",
+		"import-dices":           "The game has too many dices or sides to check the sheets.",
+		"tiebreak-box":           "Tiebreak turns are scored in the tiebreak box.",
+		"incompatible-features":  "Some of the features can't be played together.",
+		"forbidden-features":     "Some of the features are not played on this server.",
+		"game-not-over":          "The game is not over yet.",
+		"turn-started":           "The turn has already started.",
+		"server-full":            "The server is full, try again in a minute.",
+
+		"category-ones":                         "Ones",
+		"category-twos":                         "Twos",
+		"category-threes":                       "Threes",
+		"category-fours":                        "Fours",
+		"category-fives":                        "Fives",
+		"category-sixes":                        "Sixes",
+		"category-one-pair":                     "One Pair",
+		"category-two-pairs":                    "Two Pairs",
+		"category-three-pairs":                  "Three Pairs",
+		"category-three-of-a-kind":              "Three of a Kind",
+		"category-four-of-a-kind":               "Four of a Kind",
+		"category-five-of-a-kind":               "Five of a Kind",
+		"category-full-house":                   "Full House",
+		"category-castle":                       "Castle",
+		"category-tower":                        "Tower",
+		"category-small-straight":               "Small Straight",
+		"category-large-straight":               "Large Straight",
+		"category-full-straight":                "Full Straight",
+		"category-flush":                        "Flush",
+		"category-rainbow-straight":             "Rainbow Straight",
+		"category-yahtzee":                      "Yahtzee",
+		"category-chance":                       "Chance",
+		"category-ones-description":             "The sum of the dices showing one.",
+		"category-twos-description":             "The sum of the dices showing two.",
+		"category-threes-description":           "The sum of the dices showing three.",
+		"category-fours-description":            "The sum of the dices showing four.",
+		"category-fives-description":            "The sum of the dices showing five.",
+		"category-sixes-description":            "The sum of the dices showing six.",
+		"category-one-pair-description":         "The sum of the highest pair.",
+		"category-two-pairs-description":        "The sum of two different pairs.",
+		"category-three-pairs-description":      "The sum of three different pairs.",
+		"category-three-of-a-kind-description":  "At least three dices of the same face.",
+		"category-four-of-a-kind-description":   "At least four dices of the same face.",
+		"category-five-of-a-kind-description":   "At least five dices of the same face.",
+		"category-full-house-description":       "Three dices of one face and two of another.",
+		"category-castle-description":           "Two times three dices of the same face.",
+		"category-tower-description":            "Four dices of one face and two of another.",
+		"category-small-straight-description":   "Four faces in a row, 1-2-3-4-5 in yatzy.",
+		"category-large-straight-description":   "Five faces in a row, 2-3-4-5-6 in yatzy.",
+		"category-full-straight-description":    "Every face from one to six.",
+		"category-flush-description":            "The sum of the dices, every dice showing the same color.",
+		"category-rainbow-straight-description": "Five faces in a row, every dice showing a different color.",
+		"category-yahtzee-description":          "Every dice showing the same face.",
+		"category-chance-description":           "The sum of every dice.",
+
+		"embed-round":   "Round",
+		"embed-waiting": "Waiting for the players.",
+	},
+	"hu": {
+		"bad-request":     "Érvénytelen kérés.",
+		"unauthorized":    "Jelentkezz be a játékhoz.",
+		"forbidden":       "Ehhez nincs jogosultságod.",
+		"not-found":       "Nem található.",
+		"conflict":        "Ez már megtörtént.",
+		"busy":            "A szerver foglalt, próbáld újra később.",
+		"client-outdated": "Az alkalmazás elavult, frissítsd a játékhoz.",
+		"internal-error":  "Valami hiba történt, próbáld újra.",
+
+		"already-started":        "A játék már elkezdődött.",
+		"already-joined":         "Már csatlakoztál.",
+		"no-players":             "Még senki sem csatlakozott.",
+		"another-player":         "Egy másik játékos következik.",
+		"game-over":              "A játék véget ért.",
+		"no-more-rolls":          "Nincs több dobásod.",
+		"roll-first":             "Előbb dobj a kockákkal.",
+		"category-used":          "Ez a kategória már foglalt.",
+		"invalid-category":       "Nincs ilyen kategória.",
+		"invalid-dice":           "Nincs ilyen kocka.",
+		"invalid-column":         "Nincs ilyen oszlop.",
+		"solo-game":              "Egyszemélyes játékban csak egy játékos lehet.",
+		"match-game":             "Egy meccs játékosai nem változhatnak.",
+		"sandbox-game":           "Egy homokozó játék játékosai nem változhatnak.",
+		"joker-upper-box":        "A Jokert a felső részbe kell beírni.",
+		"joker-lower-box":        "A Jokert az alsó részbe kell beírni.",
+		"not-announce-game":      "Ebben a játékban nincs bemondás.",
+		"already-announced":      "Már bemondtál egy kategóriát.",
+		"announce-first":         "Előbb mondj be egy kategóriát.",
+		"not-announced-category": "Csak a bemondott kategóriába írhatsz.",
+		"incomplete-sheet":       "A játék minden körét le kell játszani.",
+		"not-extra-roll-game":    "Ebben a játékban nincsenek extra dobások.",
+		"no-extra-rolls":         "Nincs több extra dobásod.",
+		"not-handicap-game":      "Ebben a játékban nincs előny.",
+		"invalid-handicap":       "Az előny -100 és 100 között lehet.",
+		"teams-full":             "Mindkét csapat megtelt.",
+		"teams-incomplete":       "Mindkét csapatba két játékos kell.",
+		"not-joined":             "Nem játszol ebben a játékban.",
+		"away-not-allowed":       "Ebben a játékban nem lehetsz távol.",
+		"away-too-long":          "Ilyen sokáig nem lehetsz távol.",
+		"lowball-scratch":        "Lowball játékban nem lehet áthúzni.",
+		"not-duplicate-game":     "A játékban nincsenek közös dobások.",
+		"different-rolls":        "Az asztalok nem ugyanazokkal a dobásokkal játszanak.",
+		"unknown-table":          "Az egyik asztal nem létezik.",
+		"not-coach-game":         "Az edző nincs bekapcsolva ebben a játékban.",
+		"coach-dices":            "Az edző ennyi kockával vagy oldallal nem tud tanácsot adni.",
+		"no-hints":               "Nincs több tipped.",
+		"coach-best":             "A legjobb várható pontszám a hátralévő dobásokkal.",
+		"coach-scores-now":       "A kockák már most pontot érnek itt.",
+		"coach-bonus":            "Ezzel megvan a felső rész bónusza.",
+		"coach-risky":            "Csak szerencsével ér pontot a hátralévő dobásokkal.",
+		"coach-sacrifice":        "Már nem érhet pontot, ide érdemes feladni a kört.",
+		"impossible-score":       "A lap egyik pontszáma nem lehetséges a rovatában.",
+		"sheet-order":            "A kitöltött rovatok nem illenek a körök sorrendjéhez.",
+		"import-dices":           "A játékban túl sok a kocka vagy az oldal a lapok ellenőrzéséhez.",
+		"tiebreak-box":           "A rájátszás köreit a rájátszás rovatába kell írni.",
+		"incompatible-features":  "Néhány játékmód nem játszható együtt.",
+		"forbidden-features":     "Néhány játékmód nem játszható ezen a szerveren.",
+		"game-not-over":          "A játék még nem ért véget.",
+		"turn-started":           "A kör már elkezdődött.",
+		"server-full":            "A szerver megtelt, próbáld újra egy perc múlva.",
+
+		"category-ones":                         "Egyesek",
+		"category-twos":                         "Kettesek",
+		"category-threes":                       "Hármasok",
+		"category-fours":                        "Négyesek",
+		"category-fives":                        "Ötösök",
+		"category-sixes":                        "Hatosok",
+		"category-one-pair":                     "Egy pár",
+		"category-two-pairs":                    "Két pár",
+		"category-three-pairs":                  "Három pár",
+		"category-three-of-a-kind":              "Drill",
+		"category-four-of-a-kind":               "Póker",
+		"category-five-of-a-kind":               "Öt egyforma",
+		"category-full-house":                   "Full",
+		"category-castle":                       "Vár",
+		"category-tower":                        "Torony",
+		"category-small-straight":               "Kis sor",
+		"category-large-straight":               "Nagy sor",
+		"category-full-straight":                "Teljes sor",
+		"category-flush":                        "Flöss",
+		"category-rainbow-straight":             "Szivárványsor",
+		"category-yahtzee":                      "Yahtzee",
+		"category-chance":                       "Esély",
+		"category-ones-description":             "Az egyest mutató kockák összege.",
+		"category-twos-description":             "A kettest mutató kockák összege.",
+		"category-threes-description":           "A hármast mutató kockák összege.",
+		"category-fours-description":            "A négyest mutató kockák összege.",
+		"category-fives-description":            "Az ötöst mutató kockák összege.",
+		"category-sixes-description":            "A hatost mutató kockák összege.",
+		"category-one-pair-description":         "A legnagyobb pár összege.",
+		"category-two-pairs-description":        "Két különböző pár összege.",
+		"category-three-pairs-description":      "Három különböző pár összege.",
+		"category-three-of-a-kind-description":  "Legalább három egyforma kocka.",
+		"category-four-of-a-kind-description":   "Legalább négy egyforma kocka.",
+		"category-five-of-a-kind-description":   "Legalább öt egyforma kocka.",
+		"category-full-house-description":       "Három egyforma és két másik egyforma kocka.",
+		"category-castle-description":           "Kétszer három egyforma kocka.",
+		"category-tower-description":            "Négy egyforma és két másik egyforma kocka.",
+		"category-small-straight-description":   "Négy egymást követő szám, yatzyban 1-2-3-4-5.",
+		"category-large-straight-description":   "Öt egymást követő szám, yatzyban 2-3-4-5-6.",
+		"category-full-straight-description":    "Minden szám egytől hatig.",
+		"category-flush-description":            "A kockák összege, ha mindegyik azonos színű.",
+		"category-rainbow-straight-description": "Öt egymást követő szám, minden kocka más színű.",
+		"category-yahtzee-description":          "Minden kocka ugyanazt mutatja.",
+		"category-chance-description":           "Az összes kocka összege.",
+
+		"embed-round":   "Kör",
+		"embed-waiting": "Várakozás a játékosokra.",
+	},
+	"de": {
+		"bad-request":     "Die Anfrage ist ungültig.",
+		"unauthorized":    "Melde dich an, um zu spielen.",
+		"forbidden":       "Das darfst du nicht.",
+		"not-found":       "Nicht gefunden.",
+		"conflict":        "Das ist bereits geschehen.",
+		"busy":            "Der Server ist ausgelastet, versuche es später erneut.",
+		"client-outdated": "Die App ist veraltet, aktualisiere sie zum Spielen.",
+		"internal-error":  "Etwas ist schiefgelaufen, versuche es erneut.",
+
+		"already-started":        "Das Spiel hat bereits begonnen.",
+		"already-joined":         "Du bist bereits beigetreten.",
+		"no-players":             "Es ist noch niemand beigetreten.",
+		"another-player":         "Ein anderer Spieler ist am Zug.",
+		"game-over":              "Das Spiel ist vorbei.",
+		"no-more-rolls":          "Du hast keine Würfe mehr.",
+		"roll-first":             "Würfle zuerst.",
+		"category-used":          "Diese Kategorie ist bereits belegt.",
+		"invalid-category":       "Diese Kategorie gibt es nicht.",
+		"invalid-dice":           "Diesen Würfel gibt es nicht.",
+		"invalid-column":         "Diese Spalte gibt es nicht.",
+		"solo-game":              "Ein Solospiel hat nur einen Spieler.",
+		"match-game":             "Die Spieler eines Matchspiels stehen fest.",
+		"sandbox-game":           "Die Spieler eines Sandkastenspiels stehen fest.",
+		"joker-upper-box":        "Der Joker muss im oberen Teil eingetragen werden.",
+		"joker-lower-box":        "Der Joker muss im unteren Teil eingetragen werden.",
+		"not-announce-game":      "Ansagen ist in diesem Spiel nicht aktiviert.",
+		"already-announced":      "Es wurde bereits eine Kategorie angesagt.",
+		"announce-first":         "Sage zuerst eine Kategorie an.",
+		"not-announced-category": "Nur die angesagte Kategorie kann eingetragen werden.",
+		"incomplete-sheet":       "Jede Runde des Spiels muss gespielt werden.",
+		"not-extra-roll-game":    "Extrawürfe sind in diesem Spiel nicht aktiviert.",
+		"no-extra-rolls":         "Keine Extrawürfe mehr übrig.",
+		"not-handicap-game":      "Handicaps sind in diesem Spiel nicht aktiviert.",
+		"invalid-handicap":       "Das Handicap muss zwischen -100 und 100 liegen.",
+		"teams-full":             "Beide Teams sind voll.",
+		"teams-incomplete":       "Beide Teams brauchen zwei Spieler.",
+		"not-joined":             "Du spielst nicht in diesem Spiel.",
+		"away-not-allowed":       "Abwesenheit ist in diesem Spiel nicht erlaubt.",
+		"away-too-long":          "So lange kannst du nicht abwesend sein.",
+		"lowball-scratch":        "Im Lowball-Spiel kann nicht gestrichen werden.",
+		"not-duplicate-game":     "Das Spiel wird nicht mit gleichen Würfen gespielt.",
+		"different-rolls":        "Die Tische spielen nicht mit den gleichen Würfen.",
+		"unknown-table":          "Einer der Tische existiert nicht.",
+		"not-coach-game":         "Der Trainer ist in diesem Spiel nicht aktiviert.",
+		"coach-dices":            "Der Trainer kann bei so vielen Würfeln oder Seiten nicht beraten.",
+		"no-hints":               "Keine Tipps mehr übrig.",
+		"coach-best":             "Die beste erwartete Punktzahl mit den restlichen Würfen.",
+		"coach-scores-now":       "Die Würfel punkten hier schon.",
+		"coach-bonus":            "Damit gibt es den Bonus im oberen Teil.",
+		"coach-risky":            "Punktet nur mit Glück bei den restlichen Würfen.",
+		"coach-sacrifice":        "Hier gibt es keine Punkte mehr, ein Platz zum Streichen.",
+		"impossible-score":       "Eine Punktzahl des Blocks ist in ihrem Feld nicht möglich.",
+		"sheet-order":            "Die ausgefüllten Felder passen nicht zur Reihenfolge der Züge.",
+		"import-dices":           "Das Spiel hat zu viele Würfel oder Seiten, um die Blätter zu prüfen.",
+		"tiebreak-box":           "Stechen-Züge werden im Stechen-Feld eingetragen.",
+		"incompatible-features":  "Einige der Spielvarianten passen nicht zusammen.",
+		"forbidden-features":     "Einige der Spielvarianten werden auf diesem Server nicht gespielt.",
+		"game-not-over":          "Das Spiel ist noch nicht vorbei.",
+		"turn-started":           "Der Zug hat schon begonnen.",
+		"server-full":            "Der Server ist voll, versuche es in einer Minute erneut.",
+
+		"category-ones":                         "Einser",
+		"category-twos":                         "Zweier",
+		"category-threes":                       "Dreier",
+		"category-fours":                        "Vierer",
+		"category-fives":                        "Fünfer",
+		"category-sixes":                        "Sechser",
+		"category-one-pair":                     "Ein Paar",
+		"category-two-pairs":                    "Zwei Paare",
+		"category-three-pairs":                  "Drei Paare",
+		"category-three-of-a-kind":              "Dreierpasch",
+		"category-four-of-a-kind":               "Viererpasch",
+		"category-five-of-a-kind":               "Fünferpasch",
+		"category-full-house":                   "Full House",
+		"category-castle":                       "Burg",
+		"category-tower":                        "Turm",
+		"category-small-straight":               "Kleine Straße",
+		"category-large-straight":               "Große Straße",
+		"category-full-straight":                "Volle Straße",
+		"category-flush":                        "Flush",
+		"category-rainbow-straight":             "Regenbogenstraße",
+		"category-yahtzee":                      "Kniffel",
+		"category-chance":                       "Chance",
+		"category-ones-description":             "Die Summe der Würfel mit einer Eins.",
+		"category-twos-description":             "Die Summe der Würfel mit einer Zwei.",
+		"category-threes-description":           "Die Summe der Würfel mit einer Drei.",
+		"category-fours-description":            "Die Summe der Würfel mit einer Vier.",
+		"category-fives-description":            "Die Summe der Würfel mit einer Fünf.",
+		"category-sixes-description":            "Die Summe der Würfel mit einer Sechs.",
+		"category-one-pair-description":         "Die Summe des höchsten Paares.",
+		"category-two-pairs-description":        "Die Summe von zwei verschiedenen Paaren.",
+		"category-three-pairs-description":      "Die Summe von drei verschiedenen Paaren.",
+		"category-three-of-a-kind-description":  "Mindestens drei gleiche Würfel.",
+		"category-four-of-a-kind-description":   "Mindestens vier gleiche Würfel.",
+		"category-five-of-a-kind-description":   "Mindestens fünf gleiche Würfel.",
+		"category-full-house-description":       "Drei gleiche Würfel und zwei andere gleiche.",
+		"category-castle-description":           "Zweimal drei gleiche Würfel.",
+		"category-tower-description":            "Vier gleiche Würfel und zwei andere gleiche.",
+		"category-small-straight-description":   "Vier Augenzahlen in Folge, 1-2-3-4-5 bei Yatzy.",
+		"category-large-straight-description":   "Fünf Augenzahlen in Folge, 2-3-4-5-6 bei Yatzy.",
+		"category-full-straight-description":    "Jede Augenzahl von eins bis sechs.",
+		"category-flush-description":            "Die Summe der Würfel, alle Würfel in derselben Farbe.",
+		"category-rainbow-straight-description": "Fünf Augenzahlen in Folge, jeder Würfel in einer anderen Farbe.",
+		"category-yahtzee-description":          "Alle Würfel zeigen die gleiche Augenzahl.",
+		"category-chance-description":           "Die Summe aller Würfel.",
+
+		"embed-round":   "Runde",
+		"embed-waiting": "Warten auf die Spieler.",
+	},
+}
@@ -0,0 +1,72 @@
+// Package i18n has the translations of the messages shown to the users.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when none of the accepted languages are available.
+const DefaultLanguage = "en"
+
+// Languages returns the available languages.
+func Languages() []string {
+	res := make([]string, 0, len(catalog))
+	for lang := range catalog {
+		res = append(res, lang)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Negotiate returns the available language preferred by the value of an
+// Accept-Language header.
+func Negotiate(acceptLanguage string) string {
+	best, bestQ := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+
+		lang := strings.ToLower(strings.TrimSpace(fields[0]))
+		if i := strings.Index(lang, "-"); i >= 0 {
+			lang = lang[:i]
+		}
+		if _, ok := catalog[lang]; !ok {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// Message returns the message with the `code` in the language. It falls back
+// to the default language, then to the code itself.
+func Message(lang, code string) string {
+	if msg, ok := Lookup(lang, code); ok {
+		return msg
+	}
+	return code
+}
+
+// Lookup returns the message with the `code` in the language, falling back to
+// the default language, and false when there is no such message.
+func Lookup(lang, code string) (string, bool) {
+	if msg, ok := catalog[lang][code]; ok {
+		return msg, true
+	}
+	msg, ok := catalog[DefaultLanguage][code]
+	return msg, ok
+}
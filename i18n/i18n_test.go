@@ -0,0 +1,63 @@
+package i18n_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akarasz/yahtzee/i18n"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		header string
+		lang   string
+	}{
+		{"", "en"},
+		{"hu", "hu"},
+		{"de-DE,de;q=0.9,en;q=0.8", "de"},
+		{"fr-FR,fr;q=0.9,hu;q=0.5,en;q=0.4", "hu"},
+		{"en;q=0.3, hu-HU;q=0.7", "hu"},
+		{"fr", "en"},
+	}
+
+	for _, tc := range cases {
+		assert.Exactly(t, tc.lang, i18n.Negotiate(tc.header), "for %q", tc.header)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	assert.Exactly(t, "Előbb dobj a kockákkal.", i18n.Message("hu", "roll-first"))
+	assert.Exactly(t, "Roll the dices first.", i18n.Message("fr", "roll-first"))
+	assert.Exactly(t, "wat", i18n.Message("en", "wat"))
+
+	msg, ok := i18n.Lookup("de", "category-yahtzee")
+	assert.True(t, ok)
+	assert.Exactly(t, "Kniffel", msg)
+	_, ok = i18n.Lookup("de", "wat")
+	assert.False(t, ok)
+}
+
+func TestCatalogComplete(t *testing.T) {
+	langs := i18n.Languages()
+	assert.Exactly(t, []string{"de", "en", "hu"}, langs)
+
+	codes := []string{
+		"bad-request", "unauthorized", "forbidden", "not-found", "conflict",
+		"internal-error", "already-started", "already-joined", "no-players",
+		"another-player", "game-over", "no-more-rolls", "roll-first",
+		"category-used", "invalid-category", "invalid-dice", "invalid-column",
+		"solo-game", "joker-upper-box", "joker-lower-box", "not-announce-game",
+		"already-announced", "announce-first", "not-announced-category",
+		"category-ones", "category-chance-description",
+	}
+	for _, lang := range langs {
+		for _, code := range codes {
+			msg := i18n.Message(lang, code)
+			assert.NotEqual(t, code, msg, "%s in %s", code, lang)
+			if lang != i18n.DefaultLanguage {
+				assert.NotEqual(t, i18n.Message(i18n.DefaultLanguage, code), msg, "%s in %s", code, lang)
+			}
+		}
+	}
+}
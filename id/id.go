@@ -0,0 +1,66 @@
+// Package id has the generators of the game IDs.
+package id
+
+import (
+	"crypto/rand"
+	"fmt"
+	mathrand "math/rand"
+)
+
+// Generator creates the IDs of new games.
+type Generator interface {
+	// Generate returns a new ID.
+	Generate() string
+}
+
+// GeneratorFunc is a function used as a Generator.
+type GeneratorFunc func() string
+
+// Generate returns the value of the function.
+func (f GeneratorFunc) Generate() string {
+	return f()
+}
+
+const randomCharset = "abcdefghijklmnopqrstvwxyz0123456789"
+
+// Random generates short IDs of random letters and numbers.
+func Random(length int) Generator {
+	return GeneratorFunc(func() string {
+		b := make([]byte, length)
+		for i := range b {
+			b[i] = randomCharset[mathrand.Intn(len(randomCharset))]
+		}
+		return string(b)
+	})
+}
+
+// WordPairs generates IDs easy to dictate like `blue-walrus-42`.
+func WordPairs() Generator {
+	return GeneratorFunc(func() string {
+		return fmt.Sprintf("%s-%s-%d",
+			colors[mathrand.Intn(len(colors))],
+			animals[mathrand.Intn(len(animals))],
+			mathrand.Intn(90)+10)
+	})
+}
+
+// Vanity generates IDs starting with `prefix` followed by the ID of `next`.
+func Vanity(prefix string, next Generator) Generator {
+	return GeneratorFunc(func() string {
+		return prefix + "-" + next.Generate()
+	})
+}
+
+// UUID generates random (version 4) UUIDs.
+func UUID() Generator {
+	return GeneratorFunc(func() string {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			panic(err)
+		}
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	})
+}
@@ -0,0 +1,18 @@
+package id_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akarasz/yahtzee/id"
+)
+
+func TestGenerators(t *testing.T) {
+	assert.Regexp(t, `^[a-z0-9]{4}$`, id.Random(4).Generate())
+	assert.Regexp(t, `^[a-z]+-[a-z]+-[1-9][0-9]$`, id.WordPairs().Generate())
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, id.UUID().Generate())
+
+	fixed := id.GeneratorFunc(func() string { return "abcd" })
+	assert.Exactly(t, "party-abcd", id.Vanity("party", fixed).Generate())
+}
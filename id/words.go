@@ -0,0 +1,19 @@
+package id
+
+// The words of the word pair IDs. They are short, common and sound different
+// from each other, so they can be dictated over voice chat without spelling.
+var (
+	colors = []string{
+		"amber", "black", "blue", "brown", "coral", "gold", "green", "grey",
+		"lime", "navy", "olive", "orange", "pink", "purple", "red", "silver",
+		"teal", "white", "yellow",
+	}
+
+	animals = []string{
+		"badger", "beaver", "bison", "camel", "cobra", "crane", "donkey",
+		"eagle", "falcon", "ferret", "gecko", "goose", "hippo", "horse",
+		"koala", "lemur", "lion", "llama", "lobster", "monkey", "moose",
+		"otter", "panda", "parrot", "penguin", "puffin", "rabbit", "raven",
+		"salmon", "shark", "spider", "tiger", "turtle", "walrus", "zebra",
+	}
+)
@@ -0,0 +1,84 @@
+// Package janitor deletes the games nobody played for a while in the
+// background, so the stores without expiration of their own don't grow
+// forever. The websocket clients of the deleted games are disconnected.
+package janitor
+
+import (
+	"log"
+	"time"
+
+	"github.com/akarasz/yahtzee/event"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// DefaultInterval is the time between the sweeps.
+const DefaultInterval = time.Minute
+
+// Janitor sweeps the expired games of a store.
+type Janitor struct {
+	games       store.Expirer
+	subscribers event.Subscriber
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New starts sweeping the games in every `interval`. The clients and the
+// spectators of the deleted games are unsubscribed when `s` implements
+// event.Closer. Close stops the sweeping.
+func New(games store.Expirer, s event.Subscriber, interval time.Duration) *Janitor {
+	res := &Janitor{
+		games:       games,
+		subscribers: s,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+
+	go res.run(interval)
+
+	return res
+}
+
+func (j *Janitor) run(interval time.Duration) {
+	defer close(j.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := j.Sweep(); err != nil {
+				log.Printf("sweep games: %v", err)
+			}
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// Sweep deletes the expired games, and unsubscribes their clients.
+func (j *Janitor) Sweep() error {
+	expired, err := j.games.Expire(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, gameID := range expired {
+		if err := event.Close(j.subscribers, gameID); err != nil {
+			return err
+		}
+	}
+
+	if len(expired) > 0 {
+		log.Printf("%d expired games deleted", len(expired))
+	}
+	return nil
+}
+
+// Close stops the sweeping.
+func (j *Janitor) Close() error {
+	close(j.stop)
+	<-j.done
+	return nil
+}
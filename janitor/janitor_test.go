@@ -0,0 +1,58 @@
+package janitor_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+	event_impl "github.com/akarasz/yahtzee/event/embedded"
+	"github.com/akarasz/yahtzee/janitor"
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/embedded"
+)
+
+func TestSweep(t *testing.T) {
+	s := embedded.New(embedded.WithExpiration(-time.Second))
+	e := event_impl.New()
+
+	require.NoError(t, s.Save("aaaaa", *yahtzee.NewGame()))
+	c, err := e.Subscribe("aaaaa", "ws")
+	require.NoError(t, err)
+	spectator, err := e.Subscribe(event.SpectatorChannel("aaaaa"), "ws")
+	require.NoError(t, err)
+
+	j := janitor.New(s, e, time.Hour)
+	defer j.Close()
+	require.NoError(t, j.Sweep())
+
+	_, err = s.Load("aaaaa")
+	assert.Exactly(t, store.ErrNotExists, err)
+
+	_, open := <-c
+	assert.False(t, open)
+	_, open = <-spectator
+	assert.False(t, open)
+}
+
+func TestRun(t *testing.T) {
+	s := embedded.New(embedded.WithExpiration(time.Millisecond))
+	e := event_impl.New()
+
+	require.NoError(t, s.Save("aaaaa", *yahtzee.NewGame()))
+	c, err := e.Subscribe("aaaaa", "ws")
+	require.NoError(t, err)
+
+	j := janitor.New(s, e, time.Millisecond)
+	defer j.Close()
+
+	select {
+	case _, open := <-c:
+		assert.False(t, open)
+	case <-time.After(time.Second):
+		assert.Fail(t, "the game wasn't swept")
+	}
+}
@@ -0,0 +1,66 @@
+package yahtzee
+
+// Match is a series of games played by the same players. The player winning
+// the majority of the games wins the match.
+type Match struct {
+	// Players has the users playing every game of the match
+	Players []User
+
+	// BestOf is the number of games the match is played for
+	BestOf int
+
+	// Features has the optional rules enabled for the games.
+	Features []Feature
+
+	// Dice is the number of dices of the games
+	Dice int
+
+	// Sides is the number of sides of the dices
+	Sides int
+
+	// Rules has the house rules of the games, the defaults are used when it's
+	// nil.
+	Rules *Rules
+
+	// Rounds is the number of rounds of the games, zero for every box of
+	// the score sheet
+	Rounds int
+
+	// Locale is the language of the games
+	Locale string
+
+	// TimeZone is the IANA name of the time zone of the games
+	TimeZone string
+
+	// Games has the IDs of the games of the match in the order they were
+	// played, the last one is the game in progress.
+	Games []string
+
+	// Wins has the number of games won by each player
+	Wins map[User]int
+
+	// Points has the total score of each player in the finished games
+	Points map[User]int
+
+	// Winner is the player who won the match, it's empty until it's decided.
+	Winner User
+}
+
+// NewMatch initializes a match of `bestOf` games between the players with the
+// given features enabled.
+func NewMatch(bestOf int, players []User, features ...Feature) *Match {
+	m := &Match{
+		Players:  append([]User{}, players...),
+		BestOf:   bestOf,
+		Features: append([]Feature{}, features...),
+		Games:    []string{},
+		Wins:     map[User]int{},
+		Points:   map[User]int{},
+	}
+	for _, u := range players {
+		m.Wins[u] = 0
+		m.Points[u] = 0
+	}
+
+	return m
+}
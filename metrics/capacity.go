@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// DefaultCapacity is the utilization of the limits of the running server.
+var DefaultCapacity = &Capacity{}
+
+func init() {
+	promauto.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "yahtzee_capacity_games",
+			Help: "The number of games counted against the game limit",
+		},
+		func() float64 { return float64(DefaultCapacity.Utilization().Games) })
+
+	promauto.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "yahtzee_capacity_games_limit",
+			Help: "The most games kept by the server, 0 without a limit",
+		},
+		func() float64 { return float64(DefaultCapacity.Utilization().MaxGames) })
+
+	promauto.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "yahtzee_capacity_websockets",
+			Help: "The number of websockets counted against the websocket limit",
+		},
+		func() float64 { return float64(DefaultCapacity.Utilization().Websockets) })
+
+	promauto.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "yahtzee_capacity_websockets_limit",
+			Help: "The most open websockets of the server, 0 without a limit",
+		},
+		func() float64 { return float64(DefaultCapacity.Utilization().MaxWebsockets) })
+}
+
+// Utilization is how much of its limits the server uses.
+type Utilization struct {
+	Games    int
+	MaxGames int
+
+	Websockets    int
+	MaxWebsockets int
+}
+
+// Capacity keeps the last reported utilization.
+type Capacity struct {
+	mu sync.Mutex
+	u  Utilization
+}
+
+// Games records the number of games and their limit.
+func (c *Capacity) Games(current, limit int) {
+	c.mu.Lock()
+	c.u.Games, c.u.MaxGames = current, limit
+	c.mu.Unlock()
+}
+
+// Websockets records the number of open websockets and their limit.
+func (c *Capacity) Websockets(current, limit int) {
+	c.mu.Lock()
+	c.u.Websockets, c.u.MaxWebsockets = current, limit
+	c.mu.Unlock()
+}
+
+// Utilization returns the last reported utilization.
+func (c *Capacity) Utilization() Utilization {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.u
+}
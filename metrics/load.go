@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const window = 60
+
+// DefaultLoad is the load tracker of the running server.
+var DefaultLoad = NewLoad(time.Now)
+
+func init() {
+	promauto.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "yahtzee_load_turns_per_minute",
+			Help: "The number of turns finished in the last minute",
+		},
+		func() float64 { return float64(DefaultLoad.Signal().TurnsPerMinute) })
+
+	promauto.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "yahtzee_load_connections",
+			Help: "The number of open websocket connections",
+		},
+		func() float64 { return float64(DefaultLoad.Signal().Connections) })
+}
+
+// Signal is the machine-readable load of the server, suitable for
+// autoscalers.
+type Signal struct {
+	// TurnsPerMinute shows how many turns were finished in the last minute.
+	TurnsPerMinute int
+
+	// Connections shows the number of open websocket connections.
+	Connections int
+}
+
+// Load keeps track of the activity on the server.
+type Load struct {
+	mu sync.Mutex
+
+	turns       [window]int
+	stamps      [window]int64
+	connections int
+
+	now func() time.Time
+}
+
+// NewLoad creates an empty load tracker reading the time from `now`.
+func NewLoad(now func() time.Time) *Load {
+	return &Load{
+		now: now,
+	}
+}
+
+// TurnPlayed records a finished turn.
+func (l *Load) TurnPlayed() {
+	sec := l.now().Unix()
+	i := sec % window
+
+	l.mu.Lock()
+	if l.stamps[i] != sec {
+		l.stamps[i] = sec
+		l.turns[i] = 0
+	}
+	l.turns[i]++
+	l.mu.Unlock()
+}
+
+// Connected records an opened websocket connection.
+func (l *Load) Connected() {
+	l.mu.Lock()
+	l.connections++
+	l.mu.Unlock()
+}
+
+// Disconnected records a closed websocket connection.
+func (l *Load) Disconnected() {
+	l.mu.Lock()
+	l.connections--
+	l.mu.Unlock()
+}
+
+// Signal returns the current load.
+func (l *Load) Signal() Signal {
+	sec := l.now().Unix()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var turns int
+	for i := range l.turns {
+		if sec-l.stamps[i] < window {
+			turns += l.turns[i]
+		}
+	}
+
+	return Signal{
+		TurnsPerMinute: turns,
+		Connections:    l.connections,
+	}
+}
+
+// ServeHTTP writes the current load signal as json.
+func (l *Load) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(l.Signal()); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+	}
+}
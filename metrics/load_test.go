@@ -0,0 +1,51 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akarasz/yahtzee/metrics"
+)
+
+func TestLoad(t *testing.T) {
+	l := metrics.NewLoad(time.Now)
+	assert.Exactly(t, metrics.Signal{}, l.Signal())
+
+	l.TurnPlayed()
+	l.TurnPlayed()
+	l.Connected()
+	l.Connected()
+	l.Disconnected()
+	assert.Exactly(t, metrics.Signal{TurnsPerMinute: 2, Connections: 1}, l.Signal())
+
+	rr := httptest.NewRecorder()
+	l.ServeHTTP(rr, httptest.NewRequest("GET", "/load", nil))
+	assert.Exactly(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"TurnsPerMinute":2,"Connections":1}`, rr.Body.String())
+}
+
+func TestLoadWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := metrics.NewLoad(func() time.Time { return now })
+
+	l.TurnPlayed()
+	now = now.Add(30 * time.Second)
+	l.TurnPlayed()
+	l.TurnPlayed()
+	assert.Exactly(t, 3, l.Signal().TurnsPerMinute)
+
+	// the first turn expires
+	now = now.Add(30 * time.Second)
+	assert.Exactly(t, 2, l.Signal().TurnsPerMinute)
+
+	// the slot of the first turn is reused
+	l.TurnPlayed()
+	assert.Exactly(t, 3, l.Signal().TurnsPerMinute)
+
+	now = now.Add(time.Hour)
+	assert.Exactly(t, 0, l.Signal().TurnsPerMinute)
+}
@@ -1,8 +1,23 @@
 package yahtzee
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInvalidTimeZone is returned for a time zone name that is not in the
+// IANA database.
+var ErrInvalidTimeZone = errors.New("invalid time zone")
+
 var (
 	// NumberOfDices shows how many dices are used for a game.
 	NumberOfDices int = 5
+
+	// NumberOfSides shows how many sides the dices have.
+	NumberOfSides int = 6
 )
 
 // Dice represents a dice you use for the Game.
@@ -12,6 +27,28 @@ type Dice struct {
 
 	// Locked shows if the dice will roll or not
 	Locked bool
+
+	// Color is the color the dice shows with the rainbow feature, it's left
+	// out without it
+	Color Color `json:",omitempty"`
+}
+
+// Color is a color the dices show with the rainbow feature.
+type Color string
+
+// Available colors
+const (
+	Red    Color = "red"
+	Orange Color = "orange"
+	Yellow Color = "yellow"
+	Green  Color = "green"
+	Blue   Color = "blue"
+	Purple Color = "purple"
+)
+
+// Colors returns every color the dices can show.
+func Colors() []Color {
+	return []Color{Red, Orange, Yellow, Green, Blue, Purple}
 }
 
 // Category represents the formations players try to roll.
@@ -27,6 +64,11 @@ const (
 	Sixes           = "sixes"
 	Bonus           = "bonus"
 
+	YahtzeeBonuses = "yahtzee-bonuses"
+	BlitzBonus     = "blitz-bonus"
+	HandicapPoints = "handicap"
+	TiebreakBox    = "tiebreak"
+
 	ThreeOfAKind  = "three-of-a-kind"
 	FourOfAKind   = "four-of-a-kind"
 	FullHouse     = "full-house"
@@ -34,6 +76,18 @@ const (
 	LargeStraight = "large-straight"
 	Yahtzee       = "yahtzee"
 	Chance        = "chance"
+
+	OnePair  = "one-pair"
+	TwoPairs = "two-pairs"
+
+	ThreePairs   = "three-pairs"
+	FiveOfAKind  = "five-of-a-kind"
+	FullStraight = "full-straight"
+	Castle       = "castle"
+	Tower        = "tower"
+
+	Flush           = "flush"
+	RainbowStraight = "rainbow-straight"
 )
 
 func Categories() []Category {
@@ -54,6 +108,146 @@ func Categories() []Category {
 	}
 }
 
+// Feature represents an optional rule of a game.
+type Feature string
+
+// Available features
+const (
+	// YahtzeeBonus awards 100 points for every Yahtzee after the first one and
+	// applies the official Joker rules.
+	YahtzeeBonus Feature = "yahtzee-bonus"
+
+	// Yatzy uses the Scandinavian scoring table.
+	Yatzy Feature = "yatzy"
+
+	// Maxi is played with six dices and the extended Scandinavian scoring
+	// table.
+	Maxi Feature = "maxi"
+
+	// Triple gives three score columns to every player, worth one, two and
+	// three times their value.
+	Triple Feature = "triple"
+
+	// Announce makes the player announce a category after the first roll,
+	// the turn can only be scored in the announced category.
+	Announce Feature = "announce"
+
+	// Kniffel uses the German scoring table.
+	Kniffel Feature = "kniffel"
+
+	// Solo is a practice game for a single player, the final score counts
+	// for the personal best.
+	Solo Feature = "solo"
+
+	// Duplicate gives every player the same rolls in a round, so skill and
+	// not luck decides the winner.
+	Duplicate Feature = "duplicate"
+
+	// ExtraRoll gives every player tokens to spend on an additional roll in
+	// a turn.
+	ExtraRoll Feature = "extra-roll"
+
+	// ForcedJoker scores a Yahtzee as a Joker by the official rules when the
+	// Yahtzee box is filled: in the upper box of its face while it's open,
+	// then in the lower section.
+	ForcedJoker Feature = "forced-joker"
+
+	// FreeJoker scores a Yahtzee as a Joker in any open box when the Yahtzee
+	// box is filled. It overrides the forced Joker of the other features.
+	FreeJoker Feature = "free-joker"
+
+	// Blitz gives the players limited time for every decision. The turn is
+	// scratched when the time runs out, and the unused time of scoring is
+	// worth bonus points.
+	Blitz Feature = "blitz"
+
+	// Handicap gives the players the points they set when joining at the end
+	// of the game, so players of different skills can play fairly.
+	Handicap Feature = "handicap"
+
+	// Partners is played by two teams of two players. The players of a team
+	// share a score sheet and take the turns of the team alternately.
+	Partners Feature = "partners"
+
+	// Lowball is won by the lowest total. The boxes can't be scratched, and
+	// the points given for the handicap and the unused time of blitz games
+	// are taken instead.
+	Lowball Feature = "lowball"
+
+	// Coach gives the current player ranked advice on the boxes with the
+	// reasons for them, ranked games are played without it.
+	Coach Feature = "coach"
+
+	// DoubleSheet gives two score columns to every player, filled in any
+	// order and added up at their value.
+	DoubleSheet Feature = "double-sheet"
+
+	// TripleSheet gives three score columns to every player, filled in any
+	// order and added up at their value.
+	TripleSheet Feature = "triple-sheet"
+
+	// SuddenDeath goes on with tiebreak rounds when more players have the
+	// best total at the end, until one of them has the best tiebreak score.
+	SuddenDeath Feature = "sudden-death"
+
+	// Rainbow rolls a color with the face of every dice, and adds the flush
+	// and the rainbow straight categories scored by the colors.
+	Rainbow Feature = "rainbow"
+
+	// Hints gives every player a few questions to the coach in a game, the
+	// hints left are shown to everyone.
+	Hints Feature = "hints"
+
+	// Projection follows every turn with the projected final totals of the
+	// players, for the live standings of the clients.
+	Projection Feature = "projection"
+)
+
+var builtinFeatures = []Feature{
+	YahtzeeBonus,
+	Yatzy,
+	Maxi,
+	Triple,
+	Announce,
+	Kniffel,
+	Solo,
+	Duplicate,
+	ExtraRoll,
+	ForcedJoker,
+	FreeJoker,
+	Blitz,
+	Handicap,
+	Partners,
+	Lowball,
+	Coach,
+	DoubleSheet,
+	TripleSheet,
+	SuddenDeath,
+	Rainbow,
+	Hints,
+	Projection,
+}
+
+// Features returns every available feature, including the ones enabling the
+// registered categories.
+func Features() []Feature {
+	res := append([]Feature{}, builtinFeatures...)
+	for _, c := range RegisteredCategories() {
+		res = append(res, Feature(c.Name))
+	}
+	return res
+}
+
+// DiceCount returns how many dices are used for a game with the features.
+func DiceCount(features ...Feature) int {
+	for _, f := range features {
+		if f == Maxi {
+			return 6
+		}
+	}
+	return NumberOfDices
+}
+
 // Player contains all data representing a player.
 type Player struct {
 	// User who plays
@@ -61,6 +255,36 @@ type Player struct {
 
 	// ScoreSheet keeps the scores of the player
 	ScoreSheet map[Category]int
+
+	// ExtraSheets keeps the scores of the additional columns when the game is
+	// played with more than one
+	ExtraSheets []map[Category]int
+
+	// ExtraRolls is the number of extra roll tokens the player has left
+	ExtraRolls int
+
+	// Hints is the number of hints the player has left with the hints
+	// feature
+	Hints int
+
+	// Handicap is the points the player gets at the end of the game with the
+	// handicap feature
+	Handicap int
+
+	// Away is the time in unix milliseconds until the shot clock of the
+	// player is paused, it's zero when the player is not away.
+	Away int64
+
+	// Achievements has the achievements the player earned in the game
+	Achievements []Achievement
+}
+
+// Sheet returns the score sheet of the `column`, the first one is ScoreSheet.
+func (p *Player) Sheet(column int) map[Category]int {
+	if column == 0 {
+		return p.ScoreSheet
+	}
+	return p.ExtraSheets[column-1]
 }
 
 // NewPlayer returns a new named player with an empty score sheet.
@@ -71,6 +295,47 @@ func NewPlayer(u User) *Player {
 	}
 }
 
+// Rules has the house rules of a game. Zero values keep the defaults of the
+// features.
+type Rules struct {
+	// UpperBonusThreshold is the total of the upper section needed for the
+	// bonus
+	UpperBonusThreshold int
+
+	// UpperBonus is the value of the upper section bonus
+	UpperBonus int
+
+	// YahtzeeScore is the value of a Yahtzee
+	YahtzeeScore int
+
+	// Payouts has the values of the categories replacing their usual
+	// scores whenever the dices make them, like 30 for a full house. It
+	// overrides the YahtzeeScore.
+	Payouts map[Category]int
+
+	// RollsPerTurn is the number of rolls a player has in a turn
+	RollsPerTurn int
+
+	// ExtraRolls is the number of extra roll tokens every player gets with
+	// the extra-roll feature
+	ExtraRolls int
+
+	// Hints is the number of hints every player gets with the hints feature
+	Hints int
+
+	// ShotClock is the number of seconds a player has for a decision with
+	// the blitz feature
+	ShotClock int
+
+	// MaxAway is the number of seconds a player can be away for with the
+	// blitz feature, being away is not allowed when it's zero
+	MaxAway int
+
+	// SpectatorDelay is the number of seconds the spectators get the events
+	// of the game later than the players
+	SpectatorDelay int
+}
+
 // Game contains all data representing a game.
 type Game struct {
 	// Players has the list of the players in an ordered manner
@@ -79,29 +344,175 @@ type Game struct {
 	// Dices has the dices the game played with
 	Dices []*Dice
 
+	// Sides shows how many sides the dices have
+	Sides int
+
 	// Round shows how many rounds were passed already.
 	Round int
 
+	// Rounds is the number of rounds of a shortened game, every box of the
+	// score sheet is played when it's zero.
+	Rounds int
+
 	// CurrentPlayer shows the index of the current player in the Players array.
 	CurrentPlayer int
 
 	// RollCount shows how many times the dices were rolled for the current user in this round.
 	RollCount int
+
+	// Announcement is the category announced by the current player for this
+	// round, it's empty until one is announced.
+	Announcement Category
+
+	// ExtraRolls is the number of extra rolls spent by the current player in
+	// this round.
+	ExtraRolls int
+
+	// Features has the optional rules enabled for the game.
+	Features []Feature
+
+	// Rules has the house rules of the game, the defaults are used when it's
+	// nil.
+	Rules *Rules
+
+	// Seed is the source of the rolls with the duplicate feature. It's kept
+	// out of the JSON form of the game, as it would give away the rolls to
+	// come; only the stores keep it.
+	Seed int64 `json:"-"`
+
+	// Tables has the IDs of the other tables playing the same rolls as the
+	// game with the duplicate feature.
+	Tables []string
+
+	// Source is the ID of the game a duplicate table was started from, which
+	// lists every table; it's empty for the game itself.
+	Source string
+
+	// Daily is the day of the daily challenge the game is played for, like
+	// "2021-03-14"; it's empty for other games.
+	Daily string
+
+	// Tiebreak has the sudden-death tiebreak of the game, it's nil until
+	// the game ends in a tie.
+	Tiebreak *Tiebreak
+
+	// Deadline is the time in unix milliseconds until the current decision
+	// has to be made with the blitz feature, it's zero when the clock is not
+	// running.
+	Deadline int64
+
+	// Match is the ID of the match the game is part of, it's empty for
+	// standalone games.
+	Match string
+
+	// Locale is the language of the game set by the host, the default
+	// language is used when it's empty.
+	Locale string
+
+	// TimeZone is the IANA name of the time zone of the game set by the host,
+	// like "Europe/Budapest". The times and the days of the game are in UTC
+	// when it's empty.
+	TimeZone string
+
+	// Creator is the user who created the game, it's empty when the game was
+	// created without a user. The creator of a sandbox owns it.
+	Creator User
+
+	// Sandbox tells the game is a copy of another game to explore alternate
+	// lines of it. Its owner plays every turn, and its results are not
+	// recorded.
+	Sandbox bool
+}
+
+// NewGame initializes an empty Game with the given features enabled.
+func NewGame(features ...Feature) *Game {
+	g := &Game{
+		Players:  []*Player{},
+		Features: append([]Feature{}, features...),
+	}
+	g.SetDices(DiceCount(features...), NumberOfSides)
+
+	return g
 }
 
-// NewGame initializes an empty Game.
-func NewGame() *Game {
-	dd := make([]*Dice, NumberOfDices)
-	for i := 0; i < NumberOfDices; i++ {
-		dd[i] = &Dice{
+// SetDices replaces the dices of the game with `count` new ones having
+// `sides` sides.
+func (g *Game) SetDices(count, sides int) {
+	g.Dices = make([]*Dice, count)
+	for i := 0; i < count; i++ {
+		g.Dices[i] = &Dice{
 			Value: 1,
 		}
 	}
+	g.Sides = sides
+}
 
-	return &Game{
-		Players: []*Player{},
-		Dices:   dd,
+// HasFeature tells if the feature is enabled for the game.
+func (g *Game) HasFeature(f Feature) bool {
+	for _, gf := range g.Features {
+		if gf == f {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadLocation returns the time zone with the IANA `name`, UTC when it's
+// empty.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
 	}
+	return loc, nil
+}
+
+// SetTimeZone sets the time zone of the game to the IANA `name`, or clears it
+// when it's empty.
+func (g *Game) SetTimeZone(name string) error {
+	if _, err := LoadLocation(name); err != nil {
+		return err
+	}
+	g.TimeZone = name
+	return nil
+}
+
+// Location returns the time zone of the game, UTC when it's not set.
+func (g *Game) Location() (*time.Location, error) {
+	return LoadLocation(g.TimeZone)
+}
+
+// StartOfDay returns the beginning of the day of `t` in the time zone of the
+// game, where the days of the game change.
+func (g *Game) StartOfDay(t time.Time) (time.Time, error) {
+	loc, err := g.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc), nil
+}
+
+// Hash returns a deterministic hash of the game state: the hex encoded
+// SHA-256 sum of its compact JSON representation.
+func (g *Game) Hash() string {
+	raw, _ := json.Marshal(g)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// Tiebreak is the sudden-death tiebreak of the players tied at the end of
+// the game. The rounds of the tiebreak go on after the last round of the
+// game.
+type Tiebreak struct {
+	// Players has the players still tied, the winner when it's decided
+	Players []User
+
+	// Scores has the tiebreak scores of the players in the current round
+	Scores map[User]int
 }
 
 type User string
@@ -0,0 +1,73 @@
+package yahtzee_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+)
+
+func TestHash(t *testing.T) {
+	g := yahtzee.NewGame()
+	g.Players = append(g.Players, yahtzee.NewPlayer("Alice"))
+	g.Players[0].ScoreSheet[yahtzee.Chance] = 20
+	g.Players[0].ScoreSheet[yahtzee.Ones] = 3
+
+	same := yahtzee.NewGame()
+	same.Players = append(same.Players, yahtzee.NewPlayer("Alice"))
+	same.Players[0].ScoreSheet[yahtzee.Ones] = 3
+	same.Players[0].ScoreSheet[yahtzee.Chance] = 20
+
+	assert.Len(t, g.Hash(), 64)
+	assert.Exactly(t, g.Hash(), same.Hash())
+
+	same.Dices[2].Locked = true
+	assert.NotEqual(t, g.Hash(), same.Hash())
+}
+
+func TestStartOfDay(t *testing.T) {
+	now := time.Date(2021, 3, 1, 23, 30, 0, 0, time.UTC)
+
+	g := yahtzee.NewGame()
+	loc, err := g.Location()
+	require.NoError(t, err)
+	assert.Exactly(t, time.UTC, loc)
+	start, err := g.StartOfDay(now)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC).Equal(start))
+
+	// the day starts earlier east of UTC
+	require.NoError(t, g.SetTimeZone("Europe/Budapest"))
+	loc, err = g.Location()
+	require.NoError(t, err)
+	assert.Exactly(t, "Europe/Budapest", loc.String())
+	start, err = g.StartOfDay(now)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2021, 3, 1, 23, 0, 0, 0, time.UTC).Equal(start))
+
+	// unknown zones are refused
+	assert.Exactly(t, yahtzee.ErrInvalidTimeZone, g.SetTimeZone("Mars/Olympus"))
+	assert.Exactly(t, "Europe/Budapest", g.TimeZone)
+	g.TimeZone = "Mars/Olympus"
+	_, err = g.StartOfDay(now)
+	assert.Exactly(t, yahtzee.ErrInvalidTimeZone, err)
+}
+
+func TestRegisterCategory(t *testing.T) {
+	score := func(dices []int) int { return 0 }
+
+	yahtzee.RegisterCategory("evens", score, yahtzee.Lower)
+	assert.Contains(t, yahtzee.Features(), yahtzee.Feature("evens"))
+
+	registered := yahtzee.RegisteredCategories()
+	assert.Exactly(t, yahtzee.Category("evens"), registered[len(registered)-1].Name)
+	assert.Exactly(t, yahtzee.Lower, registered[len(registered)-1].Section)
+
+	assert.Panics(t, func() { yahtzee.RegisterCategory("evens", score, yahtzee.Lower) })
+	assert.Panics(t, func() { yahtzee.RegisterCategory("maxi", score, yahtzee.Lower) })
+	assert.Panics(t, func() { yahtzee.RegisterCategory("odds", nil, yahtzee.Lower) })
+	assert.Panics(t, func() { yahtzee.RegisterCategory("odds", score, "middle") })
+}
@@ -0,0 +1,279 @@
+// Package mqtt publishes the events of the games to an MQTT broker, on a
+// topic for every game, so the LED scoreboards and the displays of the tables
+// can subscribe to their game on the broker instead of the server.
+//
+// Only the part of MQTT 3.1.1 needed for publishing is spoken: the events are
+// published with QoS 0 on a plain TCP connection, which is opened again when
+// the broker drops it.
+package mqtt
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"time"
+
+	"github.com/akarasz/yahtzee/event"
+)
+
+// DefaultPrefix is the first level of the topics when the config has none.
+const DefaultPrefix = "yahtzee"
+
+// DefaultTimeout is the time connecting to the broker and writing a packet
+// can take.
+const DefaultTimeout = 5 * time.Second
+
+// keepAlive is the time the connection can be idle before it's pinged.
+const keepAlive = 30 * time.Second
+
+// retryAfter is the time after a failed connection the broker is not tried
+// again, the events in between are dropped.
+const retryAfter = 5 * time.Second
+
+// queueSize is the number of events waiting for the publishing, the events
+// over it are dropped.
+const queueSize = 100
+
+var (
+	// ErrRefused is returned when the broker refuses the connection.
+	ErrRefused = errors.New("connection refused")
+
+	// ErrProtocol is returned when the broker answers with something else
+	// than expected.
+	ErrProtocol = errors.New("protocol error")
+)
+
+// Config is the broker the events are published to.
+type Config struct {
+	// Broker is the host:port of the broker
+	Broker string
+
+	// ClientID is the client identifier of the connection, a random one is
+	// used when it's empty
+	ClientID string
+
+	// Username and Password are sent to the broker when the username is set
+	Username string
+	Password string
+
+	// Prefix is the first level of the topics, DefaultPrefix when it's empty
+	Prefix string
+}
+
+// Topic returns the topic the events of the game are published to.
+func (c *Config) Topic(gameID string) string {
+	prefix := c.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return prefix + "/games/" + gameID + "/events"
+}
+
+type message struct {
+	topic   string
+	payload []byte
+}
+
+type mqttEmitter struct {
+	next  event.Emitter
+	queue chan message
+	Config
+}
+
+// Emitter returns `e` also publishing its events to the broker of the config,
+// or `e` itself without a broker. The event is published as JSON like it's
+// sent to the subscribers, on the Topic of its game. The events are published
+// in the background one after the other; the events are dropped while the
+// broker is unreachable or too slow.
+func Emitter(e event.Emitter, c Config) event.Emitter {
+	if c.Broker == "" {
+		return e
+	}
+	if c.ClientID == "" {
+		c.ClientID = randomClientID()
+	}
+
+	res := &mqttEmitter{
+		next:   e,
+		queue:  make(chan message, queueSize),
+		Config: c,
+	}
+	p := &publisher{Config: c}
+	go p.run(res.queue)
+	return res
+}
+
+func (e *mqttEmitter) Emit(gameID string, ev *event.Event) {
+	e.next.Emit(gameID, ev)
+
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("mqtt %s: %v", e.Broker, err)
+		return
+	}
+
+	select {
+	case e.queue <- message{topic: e.Topic(gameID), payload: payload}:
+	default:
+		log.Printf("mqtt %s: queue is full, %s event dropped", e.Broker, ev.Action)
+	}
+}
+
+func randomClientID() string {
+	raw := make([]byte, 4)
+	if _, err := rand.Read(raw); err != nil {
+		return "yahtzee"
+	}
+	return "yahtzee-" + hex.EncodeToString(raw)
+}
+
+// publisher keeps the connection to the broker.
+type publisher struct {
+	Config
+	conn    net.Conn
+	retryAt time.Time
+}
+
+// run publishes the messages of the queue, and pings the broker when the
+// connection is idle.
+func (p *publisher) run(queue chan message) {
+	ticker := time.NewTicker(keepAlive / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case m := <-queue:
+			if err := p.publish(m); err != nil {
+				log.Printf("mqtt %s: %v", p.Broker, err)
+			}
+		case <-ticker.C:
+			if p.conn == nil {
+				continue
+			}
+			if err := p.write(packet(0xc0, nil)); err != nil {
+				log.Printf("mqtt %s: ping: %v", p.Broker, err)
+				p.close()
+			}
+		}
+	}
+}
+
+// publish sends the message, on a new connection when the broker dropped
+// the old one.
+func (p *publisher) publish(m message) error {
+	body := append(str(m.topic), m.payload...)
+
+	if p.conn != nil {
+		if err := p.write(packet(0x30, body)); err == nil {
+			return nil
+		}
+		p.close()
+	}
+
+	if time.Now().Before(p.retryAt) {
+		return errors.New("broker unreachable, event dropped")
+	}
+	if err := p.connect(); err != nil {
+		p.retryAt = time.Now().Add(retryAfter)
+		return err
+	}
+	if err := p.write(packet(0x30, body)); err != nil {
+		p.close()
+		return err
+	}
+	return nil
+}
+
+// connect opens the connection and waits for the broker to accept it.
+func (p *publisher) connect() error {
+	conn, err := net.DialTimeout("tcp", p.Broker, DefaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	flags := byte(0x02) // clean session
+	payload := str(p.ClientID)
+	if p.Username != "" {
+		flags |= 0x80 | 0x40
+		payload = append(payload, str(p.Username)...)
+		payload = append(payload, str(p.Password)...)
+	}
+	body := append(str("MQTT"), 4, flags)
+	body = append(body, byte(keepAlive/time.Second>>8), byte(keepAlive/time.Second))
+	body = append(body, payload...)
+
+	conn.SetDeadline(time.Now().Add(DefaultTimeout))
+	if _, err := conn.Write(packet(0x10, body)); err != nil {
+		conn.Close()
+		return err
+	}
+	r := bufio.NewReader(conn)
+	ack := make([]byte, 4)
+	if _, err := io.ReadFull(r, ack); err != nil {
+		conn.Close()
+		return err
+	}
+	if ack[0] != 0x20 || ack[1] != 2 {
+		conn.Close()
+		return ErrProtocol
+	}
+	if ack[3] != 0 {
+		conn.Close()
+		return fmt.Errorf("%w: return code %d", ErrRefused, ack[3])
+	}
+	conn.SetDeadline(time.Time{})
+
+	// the answers to the pings are not needed, only the closing of the
+	// connection by the broker
+	go func() {
+		io.Copy(ioutil.Discard, r)
+		conn.Close()
+	}()
+
+	p.conn = conn
+	return nil
+}
+
+func (p *publisher) write(raw []byte) error {
+	p.conn.SetWriteDeadline(time.Now().Add(DefaultTimeout))
+	_, err := p.conn.Write(raw)
+	return err
+}
+
+func (p *publisher) close() {
+	p.conn.Close()
+	p.conn = nil
+}
+
+// packet returns the control packet of the type with the body.
+func packet(kind byte, body []byte) []byte {
+	res := []byte{kind}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		res = append(res, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(res, body...)
+}
+
+// str returns the string prefixed with its length.
+func str(s string) []byte {
+	res := make([]byte, 2, 2+len(s))
+	binary.BigEndian.PutUint16(res, uint16(len(s)))
+	return append(res, s...)
+}
@@ -0,0 +1,151 @@
+package mqtt_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+	"github.com/akarasz/yahtzee/mqtt"
+)
+
+type recorder struct {
+	events []*event.Event
+}
+
+func (r *recorder) Emit(gameID string, e *event.Event) {
+	r.events = append(r.events, e)
+}
+
+type published struct {
+	topic   string
+	payload string
+}
+
+// broker returns the address of a broker accepting the connections and
+// sending the connect packets and the publishes to the channels.
+func broker(t *testing.T) (string, chan []byte, chan published) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	connects := make(chan []byte, 10)
+	publishes := make(chan published, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn, connects, publishes)
+		}
+	}()
+	return l.Addr().String(), connects, publishes
+}
+
+func serve(conn net.Conn, connects chan []byte, publishes chan published) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		kind, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch kind >> 4 {
+		case 1:
+			connects <- body
+			conn.Write([]byte{0x20, 2, 0, 0})
+		case 3:
+			n := int(binary.BigEndian.Uint16(body))
+			publishes <- published{topic: string(body[2 : 2+n]), payload: string(body[2+n:])}
+		}
+	}
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return kind, body, err
+}
+
+func receive(t *testing.T, publishes chan published) published {
+	select {
+	case got := <-publishes:
+		return got
+	case <-time.After(3 * time.Second):
+		t.Fatal("nothing published")
+		return published{}
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	r := &recorder{}
+	assert.Same(t, r, mqtt.Emitter(r, mqtt.Config{}))
+}
+
+func TestTopic(t *testing.T) {
+	assert.Exactly(t, "yahtzee/games/aaaaa/events", (&mqtt.Config{}).Topic("aaaaa"))
+	assert.Exactly(t, "pub/games/aaaaa/events", (&mqtt.Config{Prefix: "pub"}).Topic("aaaaa"))
+}
+
+func TestEmitter(t *testing.T) {
+	address, connects, publishes := broker(t)
+
+	r := &recorder{}
+	e := mqtt.Emitter(r, mqtt.Config{
+		Broker:   address,
+		ClientID: "scoreboard",
+		Username: "alice",
+		Password: "secret",
+		Prefix:   "pub",
+	})
+
+	u := yahtzee.User("Alice")
+	e.Emit("aaaaa", &event.Event{User: &u, Action: event.Roll, Data: []int{1, 2, 3, 4, 5}})
+	e.Emit("bbbbb", &event.Event{User: &u, Action: event.Lock})
+
+	assert.Len(t, r.events, 2)
+
+	got := receive(t, publishes)
+	assert.Exactly(t, "pub/games/aaaaa/events", got.topic)
+	assert.JSONEq(t,
+		`{"User":"Alice","Action":"roll","Data":[1,2,3,4,5],"Hash":"","AppliedActionID":""}`,
+		got.payload)
+
+	got = receive(t, publishes)
+	assert.Exactly(t, "pub/games/bbbbb/events", got.topic)
+
+	select {
+	case connect := <-connects:
+		assert.Contains(t, string(connect), "MQTT")
+		assert.Contains(t, string(connect), "scoreboard")
+		assert.Contains(t, string(connect), "alice")
+		assert.Contains(t, string(connect), "secret")
+	default:
+		t.Fatal("not connected")
+	}
+	assert.Len(t, connects, 0, "connected once")
+}
@@ -0,0 +1,11 @@
+package yahtzee
+
+// Notes are the private notes of a player on a game, only shown to the
+// player.
+type Notes struct {
+	// Categories has the notes on the boxes of the score sheet
+	Categories map[Category]string
+
+	// Turns has the notes on the turns by their round
+	Turns map[int]string
+}
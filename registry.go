@@ -0,0 +1,74 @@
+package yahtzee
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Section is the part of the score sheet a category belongs to.
+type Section string
+
+// Sections of the score sheet
+const (
+	// Upper categories count for the upper section bonus
+	Upper Section = "upper"
+
+	Lower Section = "lower"
+)
+
+// ScoreFunc returns the score of the dices in a category.
+type ScoreFunc func(dices []int) int
+
+// CustomCategory is a category added by RegisterCategory.
+type CustomCategory struct {
+	Name    Category
+	Score   ScoreFunc
+	Section Section
+}
+
+var registry struct {
+	sync.RWMutex
+	categories []CustomCategory
+}
+
+// RegisterCategory adds a custom category scored by `score` in the `section`
+// of the score sheet. The category is played in the games having the feature
+// with the same name. It panics when the name is already taken, like the
+// registration of the database drivers does.
+func RegisterCategory(name Category, score ScoreFunc, section Section) {
+	if score == nil {
+		panic("yahtzee: register category with nil score func")
+	}
+	if section != Upper && section != Lower {
+		panic(fmt.Sprintf("yahtzee: register category with invalid section %q", section))
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	for _, f := range builtinFeatures {
+		if Feature(name) == f {
+			panic(fmt.Sprintf("yahtzee: category %q clashes with a feature", name))
+		}
+	}
+	for _, c := range registry.categories {
+		if c.Name == name {
+			panic(fmt.Sprintf("yahtzee: category %q registered twice", name))
+		}
+	}
+
+	registry.categories = append(registry.categories, CustomCategory{
+		Name:    name,
+		Score:   score,
+		Section: section,
+	})
+}
+
+// RegisteredCategories returns the custom categories in the order of the
+// registration.
+func RegisteredCategories() []CustomCategory {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return append([]CustomCategory{}, registry.categories...)
+}
@@ -0,0 +1,178 @@
+// Package showcase plays an endless exhibition game between bots. It gives
+// the client developers a live game to connect to, and keeps the event
+// pipeline busy as a soak test.
+package showcase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/event"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// DefaultPace is the time the bots think before every move.
+const DefaultPace = 2 * time.Second
+
+// Bots are the players of the exhibition games.
+var Bots = []yahtzee.User{"Bot Alice", "Bot Bob"}
+
+// Run plays exhibition games on `gameID` one after the other, making a move
+// in every `pace`, until the context is done. The moves are saved to the
+// store and emitted like the moves of real players.
+func Run(ctx context.Context, s store.Store, e event.Emitter, gameID string, pace time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pace):
+		}
+
+		if err := step(s, e, gameID); err != nil {
+			log.Printf("showcase: %v", err)
+		}
+	}
+}
+
+// step makes the next move in the game, or starts a new one when there is no
+// game running.
+func step(s store.Store, e event.Emitter, gameID string) error {
+	unlocker, err := s.Lock(gameID)
+	if err != nil {
+		return err
+	}
+	defer unlocker()
+
+	g, err := s.Load(gameID)
+	if errors.Is(err, store.ErrNotExists) || err == nil && engine.IsOver(&g) {
+		return start(s, e, gameID)
+	}
+	if err != nil {
+		return err
+	}
+
+	u := g.Players[g.CurrentPlayer].User
+	g, events, err := engine.Apply(g, nextMove(&g, u))
+	if err != nil {
+		return err
+	}
+	if err := s.Save(gameID, g); err != nil {
+		return err
+	}
+
+	emit(e, gameID, &u, &g, events)
+	return nil
+}
+
+// start saves a new game with the bots joined.
+func start(s store.Store, e event.Emitter, gameID string) error {
+	g := *yahtzee.NewGame()
+
+	var all []*engine.Event
+	for _, u := range Bots {
+		var (
+			events []*engine.Event
+			err    error
+		)
+		g, events, err = engine.Apply(g, engine.AddPlayerAction{User: u})
+		if err != nil {
+			return err
+		}
+		all = append(all, events...)
+	}
+
+	if err := s.Save(gameID, g); err != nil {
+		return err
+	}
+
+	emit(e, gameID, nil, &g, all)
+	return nil
+}
+
+func emit(e event.Emitter, gameID string, u *yahtzee.User, g *yahtzee.Game, events []*engine.Event) {
+	hash := g.Hash()
+	for _, ev := range events {
+		e.Emit(gameID, &event.Event{
+			User:   u,
+			Action: ev.Action,
+			Data:   ev.Data,
+			Hash:   hash,
+		})
+	}
+}
+
+// nextMove returns the move of the bot `u` on turn. It keeps the dices
+// showing the most common face, and scores the best box when the rolls run
+// out or every dice is kept.
+func nextMove(g *yahtzee.Game, u yahtzee.User) engine.Action {
+	if g.RollCount == 0 {
+		return engine.RollAction{User: u, Rand: rand.Intn}
+	}
+
+	dices := make([]int, len(g.Dices))
+	for i, d := range g.Dices {
+		dices[i] = d.Value
+	}
+
+	if g.RollCount < engine.RollsPerTurn(g) {
+		keep := mostCommon(dices)
+		kept := 0
+		for i, d := range g.Dices {
+			if (d.Value == keep) != d.Locked {
+				return engine.LockAction{User: u, Dice: i}
+			}
+			if d.Locked {
+				kept++
+			}
+		}
+		if kept < len(g.Dices) {
+			return engine.RollAction{User: u, Rand: rand.Intn}
+		}
+	}
+
+	return engine.ScoreCategoryAction{User: u, Category: bestCategory(g, dices)}
+}
+
+// mostCommon returns the face showing on the most dices, the higher one on a
+// tie.
+func mostCommon(dices []int) int {
+	counts := map[int]int{}
+	best := 0
+	for _, d := range dices {
+		counts[d]++
+		if counts[d] > counts[best] || counts[d] == counts[best] && d > best {
+			best = d
+		}
+	}
+	return best
+}
+
+// bestCategory returns the open box of the current player scoring the most
+// with the dices.
+func bestCategory(g *yahtzee.Game, dices []int) yahtzee.Category {
+	scorer := engine.GameScorer(g)
+	sheet := engine.SheetOwner(g, g.CurrentPlayer).ScoreSheet
+
+	var (
+		best      yahtzee.Category
+		bestScore = -1
+	)
+	for _, c := range scorer.Categories() {
+		if _, ok := sheet[c]; ok {
+			continue
+		}
+		score, err := scorer.Evaluate(c, dices)
+		if err != nil {
+			continue
+		}
+		if score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
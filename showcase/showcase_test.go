@@ -0,0 +1,117 @@
+package showcase_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+	"github.com/akarasz/yahtzee/showcase"
+	"github.com/akarasz/yahtzee/store"
+)
+
+type memoryStore struct {
+	mu    sync.Mutex
+	games map[string]yahtzee.Game
+}
+
+func (s *memoryStore) Load(id string) (yahtzee.Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.games[id]
+	if !ok {
+		return g, store.ErrNotExists
+	}
+	return g, nil
+}
+
+func (s *memoryStore) Save(id string, g yahtzee.Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.games[id] = g
+	return nil
+}
+
+func (s *memoryStore) Lock(id string) (func(), error) {
+	return func() {}, nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.games, id)
+	return nil
+}
+
+func (s *memoryStore) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := []string{}
+	for id := range s.games {
+		res = append(res, id)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	events []*event.Event
+}
+
+func (r *recorder) Emit(gameID string, e *event.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) count(action event.Type) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := 0
+	for _, e := range r.events {
+		if e.Action == action {
+			res++
+		}
+	}
+	return res
+}
+
+func TestRun(t *testing.T) {
+	s := &memoryStore{games: map[string]yahtzee.Game{}}
+	e := &recorder{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		showcase.Run(ctx, s, e, "showcase", time.Microsecond)
+		close(done)
+	}()
+
+	// two full games of the bots
+	rounds := len(yahtzee.Categories()) * len(showcase.Bots)
+	require.Eventually(t, func() bool {
+		return e.count(event.Score) >= 2*rounds
+	}, 10*time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	assert.GreaterOrEqual(t, e.count(event.AddPlayer), 2*len(showcase.Bots))
+	assert.Greater(t, e.count(event.Roll), 0)
+	for _, ev := range e.events {
+		assert.NotEmpty(t, ev.Hash)
+	}
+
+	g, err := s.Load("showcase")
+	require.NoError(t, err)
+	require.Len(t, g.Players, len(showcase.Bots))
+	for i, p := range g.Players {
+		assert.Exactly(t, showcase.Bots[i], p.User)
+	}
+}
@@ -0,0 +1,183 @@
+// Package sms translates the text messages of the feature phones into the
+// moves of a game, and the games into summaries short enough for an SMS or a
+// USSD screen.
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+)
+
+// Kind is the kind of a command.
+type Kind string
+
+// Available commands
+const (
+	// Help lists the commands
+	Help Kind = "HELP"
+
+	// New creates a game and joins it
+	New Kind = "NEW"
+
+	// Join joins the game of `GameID`
+	Join Kind = "JOIN"
+
+	// Roll rolls the unlocked dices
+	Roll Kind = "ROLL"
+
+	// Keep keeps the dices of `Dices` and rolls the rest
+	Keep Kind = "KEEP"
+
+	// Score scores the dices in the box of `Category`
+	Score Kind = "SCORE"
+
+	// Show shows the game
+	Show Kind = "SHOW"
+)
+
+// HelpText lists the commands.
+const HelpText = "NEW, JOIN id, ROLL, KEEP 1 3 5, SCORE FULLHOUSE, SHOW"
+
+var (
+	// ErrUnknownCommand is returned for a message without a known command.
+	ErrUnknownCommand = errors.New("unknown command")
+
+	// ErrInvalidCommand is returned when the arguments of a command are
+	// missing or invalid.
+	ErrInvalidCommand = errors.New("invalid command")
+)
+
+// Command is a move sent in a text message.
+type Command struct {
+	Kind Kind
+
+	// GameID is the game to join
+	GameID string
+
+	// Dices has the positions of the dices to keep, from 1
+	Dices []int
+
+	// Category is the name of the box to score as it was sent, resolved by
+	// MatchCategory
+	Category string
+}
+
+// Parse reads the command of a message, like "KEEP 1 3 5". The commands and
+// their arguments are case insensitive, the positions of the dices can be
+// separated by spaces or commas.
+func Parse(text string) (*Command, error) {
+	fields := strings.Fields(strings.ReplaceAll(text, ",", " "))
+	if len(fields) == 0 {
+		return nil, ErrUnknownCommand
+	}
+
+	res := &Command{Kind: Kind(strings.ToUpper(fields[0]))}
+	args := fields[1:]
+	switch res.Kind {
+	case Help, New, Roll, Show:
+	case Join:
+		if len(args) != 1 {
+			return nil, ErrInvalidCommand
+		}
+		res.GameID = args[0]
+	case Keep:
+		for _, a := range args {
+			dice, err := strconv.Atoi(a)
+			if err != nil || dice < 1 {
+				return nil, ErrInvalidCommand
+			}
+			res.Dices = append(res.Dices, dice)
+		}
+	case Score:
+		if len(args) == 0 {
+			return nil, ErrInvalidCommand
+		}
+		res.Category = strings.Join(args, " ")
+	default:
+		return nil, ErrUnknownCommand
+	}
+	return res, nil
+}
+
+// MatchCategory returns the category of `categories` with the name, ignoring
+// the case, the spaces and the dashes, so "FULLHOUSE" is "full-house".
+func MatchCategory(name string, categories []yahtzee.Category) (yahtzee.Category, bool) {
+	want := normalize(name)
+	for _, c := range categories {
+		if normalize(string(c)) == want {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+func normalize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// Summary returns the state of the game for `u` in a few lines: the round,
+// the player on turn with the dices, and the scores. The locked dices are
+// marked with a star.
+func Summary(g *yahtzee.Game, u yahtzee.User) string {
+	var lines []string
+
+	switch {
+	case len(g.Players) == 0:
+		lines = append(lines, "Nobody joined yet.")
+	case engine.IsOver(g):
+		var winners []string
+		for _, w := range engine.Winners(g) {
+			winners = append(winners, name(w, u))
+		}
+		lines = append(lines, "Game over, won by "+strings.Join(winners, ", ")+".")
+	default:
+		turn := name(g.Players[g.CurrentPlayer].User, u) + " to play"
+		if g.Players[g.CurrentPlayer].User == u {
+			turn = "Your turn"
+		}
+		lines = append(lines, fmt.Sprintf("Round %d/%d. %s.", g.Round+1, engine.Rounds(g), turn))
+		if g.RollCount > 0 {
+			lines = append(lines, fmt.Sprintf("Roll %d/%d: %s", g.RollCount, engine.RollsPerTurn(g), dices(g.Dices)))
+		}
+	}
+
+	if len(g.Players) > 0 {
+		scorer := engine.GameScorer(g)
+		scores := make([]string, len(g.Players))
+		for i, p := range g.Players {
+			scores[i] = fmt.Sprintf("%s %d", name(p.User, u), scorer.Total(engine.SheetOwner(g, i)))
+		}
+		lines = append(lines, strings.Join(scores, ", "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func name(player, u yahtzee.User) string {
+	if player == u {
+		return "You"
+	}
+	return string(player)
+}
+
+func dices(ds []*yahtzee.Dice) string {
+	res := make([]string, len(ds))
+	for i, d := range ds {
+		res[i] = strconv.Itoa(d.Value)
+		if d.Locked {
+			res[i] += "*"
+		}
+	}
+	return strings.Join(res, " ")
+}
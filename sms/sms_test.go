@@ -0,0 +1,74 @@
+package sms_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/sms"
+)
+
+func TestParse(t *testing.T) {
+	for text, want := range map[string]*sms.Command{
+		"roll":             {Kind: sms.Roll},
+		" Show ":           {Kind: sms.Show},
+		"JOIN abcd":        {Kind: sms.Join, GameID: "abcd"},
+		"KEEP 1 3 5":       {Kind: sms.Keep, Dices: []int{1, 3, 5}},
+		"keep 1,3,5":       {Kind: sms.Keep, Dices: []int{1, 3, 5}},
+		"KEEP":             {Kind: sms.Keep},
+		"SCORE FULLHOUSE":  {Kind: sms.Score, Category: "FULLHOUSE"},
+		"score full house": {Kind: sms.Score, Category: "full house"},
+	} {
+		got, err := sms.Parse(text)
+		if assert.NoError(t, err, text) {
+			assert.Exactly(t, want, got, text)
+		}
+	}
+
+	for text, want := range map[string]error{
+		"":           sms.ErrUnknownCommand,
+		"cheat":      sms.ErrUnknownCommand,
+		"JOIN":       sms.ErrInvalidCommand,
+		"KEEP 1 six": sms.ErrInvalidCommand,
+		"KEEP 0":     sms.ErrInvalidCommand,
+		"SCORE":      sms.ErrInvalidCommand,
+	} {
+		_, err := sms.Parse(text)
+		assert.Exactly(t, want, err, text)
+	}
+}
+
+func TestMatchCategory(t *testing.T) {
+	got, ok := sms.MatchCategory("FULLHOUSE", yahtzee.Categories())
+	require.True(t, ok)
+	assert.Exactly(t, yahtzee.Category(yahtzee.FullHouse), got)
+
+	got, ok = sms.MatchCategory("three of a kind", yahtzee.Categories())
+	require.True(t, ok)
+	assert.Exactly(t, yahtzee.Category(yahtzee.ThreeOfAKind), got)
+
+	_, ok = sms.MatchCategory("castle", yahtzee.Categories())
+	assert.False(t, ok)
+}
+
+func TestSummary(t *testing.T) {
+	g := yahtzee.NewGame()
+	assert.Exactly(t, "Nobody joined yet.", sms.Summary(g, "Alice"))
+
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice"), yahtzee.NewPlayer("Bob")}
+	g.Players[0].ScoreSheet[yahtzee.Chance] = 20
+	g.CurrentPlayer = 1
+	g.RollCount = 2
+	for i, d := range g.Dices {
+		d.Value = i + 1
+	}
+	g.Dices[0].Locked = true
+
+	assert.Exactly(t, "Round 1/13. Bob to play.\nRoll 2/3: 1* 2 3 4 5\nYou 20, Bob 0", sms.Summary(g, "Alice"))
+	assert.Exactly(t, "Round 1/13. Your turn.\nRoll 2/3: 1* 2 3 4 5\nAlice 20, You 0", sms.Summary(g, "Bob"))
+
+	g.Round = 13
+	assert.Exactly(t, "Game over, won by You.\nYou 20, Bob 0", sms.Summary(g, "Alice"))
+}
@@ -0,0 +1,71 @@
+// Package archive moves the finished games out of the store of the running
+// games into an archive, like a directory or an S3 bucket, so the store only
+// keeps the games being played. The archived games can still be loaded, they
+// are read-only as the rules don't change a finished game.
+package archive
+
+import (
+	"errors"
+	"log"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/engine"
+	"github.com/akarasz/yahtzee/store"
+)
+
+type archivedStore struct {
+	hot  store.Store
+	cold store.Archive
+}
+
+// Store returns `hot` moving the games into `cold` when they are saved
+// finished. The archived games are deleted from `hot`, and a game failing to
+// be archived stays in it. Only the methods of Store are kept.
+func Store(hot store.Store, cold store.Archive) store.Store {
+	return &archivedStore{
+		hot:  hot,
+		cold: cold,
+	}
+}
+
+// Load returns the game from the hot store, or from the archive when it's
+// not there.
+func (s *archivedStore) Load(id string) (yahtzee.Game, error) {
+	g, err := s.hot.Load(id)
+	if errors.Is(err, store.ErrNotExists) {
+		return s.cold.LoadArchived(id)
+	}
+	return g, err
+}
+
+func (s *archivedStore) Save(id string, g yahtzee.Game) error {
+	if !engine.IsOver(&g) {
+		return s.hot.Save(id, g)
+	}
+
+	if err := s.cold.ArchiveGame(id, g); err != nil {
+		log.Printf("archive %s: %v", id, err)
+		return s.hot.Save(id, g)
+	}
+
+	if err := s.hot.Delete(id); err != nil {
+		// the hot store can't have an older state than the archive
+		log.Printf("delete archived %s: %v", id, err)
+		return s.hot.Save(id, g)
+	}
+	return nil
+}
+
+// Delete deletes the game from the hot store, the archived games are kept.
+func (s *archivedStore) Delete(id string) error {
+	return s.hot.Delete(id)
+}
+
+func (s *archivedStore) Lock(id string) (func(), error) {
+	return s.hot.Lock(id)
+}
+
+// List returns the games of the hot store, the archived ones are not listed.
+func (s *archivedStore) List() ([]string, error) {
+	return s.hot.List()
+}
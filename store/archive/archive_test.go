@@ -0,0 +1,118 @@
+package archive_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/archive"
+	"github.com/akarasz/yahtzee/store/embedded"
+	"github.com/akarasz/yahtzee/store/storetest"
+)
+
+// fakeBucket serves the objects of a bucket from memory.
+type fakeBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") ||
+		r.Header.Get("X-Amz-Content-Sha256") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case "PUT":
+		raw, _ := ioutil.ReadAll(r.Body)
+		f.objects[r.URL.Path] = raw
+	case "GET":
+		raw, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+			return
+		}
+		w.Write(raw)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newDir(t *testing.T) *archive.Dir {
+	path, err := ioutil.TempDir("", "archive")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(path) })
+
+	d, err := archive.NewDir(path)
+	require.NoError(t, err)
+	return d
+}
+
+func TestSuite(t *testing.T) {
+	storetest.RunArchive(t, func() store.Archive {
+		return newDir(t)
+	})
+	storetest.RunArchive(t, func() store.Archive {
+		server := httptest.NewServer(&fakeBucket{objects: map[string][]byte{}})
+		t.Cleanup(server.Close)
+
+		return archive.NewS3(archive.S3Config{
+			Bucket:   "yahtzee",
+			Endpoint: server.URL,
+			AWS: aws.Config{
+				Region:      "eu-central-1",
+				Credentials: credentials.NewStaticCredentialsProvider("key", "secret", ""),
+			},
+		})
+	})
+	storetest.Run(t, func() store.Store {
+		return archive.Store(embedded.New(), newDir(t))
+	})
+}
+
+func TestStore(t *testing.T) {
+	hot, cold := embedded.New(), newDir(t)
+	s := archive.Store(hot, cold)
+
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	require.NoError(t, s.Save("aaaaa", *g))
+
+	_, err := cold.LoadArchived("aaaaa")
+	assert.Exactly(t, store.ErrNotExists, err)
+
+	// the finished game is moved to the archive
+	g.Round = 13
+	require.NoError(t, s.Save("aaaaa", *g))
+
+	_, err = hot.Load("aaaaa")
+	assert.Exactly(t, store.ErrNotExists, err)
+	if got, err := cold.LoadArchived("aaaaa"); assert.NoError(t, err) {
+		assert.Exactly(t, *g, got)
+	}
+	if got, err := s.Load("aaaaa"); assert.NoError(t, err) {
+		assert.Exactly(t, *g, got)
+	}
+	if got, err := s.List(); assert.NoError(t, err) {
+		assert.Empty(t, got)
+	}
+
+	_, err = s.Load("../aaaaa")
+	assert.Exactly(t, store.ErrNotExists, err)
+}
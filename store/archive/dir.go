@@ -0,0 +1,70 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// Dir keeps the archived games in a directory, in a JSON file for every game.
+type Dir struct {
+	path string
+}
+
+// NewDir returns the archive in the directory of `path`, created when it
+// doesn't exist.
+func NewDir(path string) (*Dir, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	return &Dir{path: path}, nil
+}
+
+// ArchiveGame writes the file of the game through a temporary file, so a
+// file is never read half written.
+func (d *Dir) ArchiveGame(id string, g yahtzee.Game) error {
+	raw, err := store.Marshal(g)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(d.path, ".archive-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), d.file(id))
+}
+
+func (d *Dir) LoadArchived(id string) (yahtzee.Game, error) {
+	// the IDs can't point out of the directory
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return yahtzee.Game{}, store.ErrNotExists
+	}
+
+	raw, err := ioutil.ReadFile(d.file(id))
+	if os.IsNotExist(err) {
+		return yahtzee.Game{}, store.ErrNotExists
+	}
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+
+	return store.Unmarshal(raw)
+}
+
+func (d *Dir) file(id string) string {
+	return filepath.Join(d.path, id+".json")
+}
@@ -0,0 +1,105 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+var ctx = context.Background()
+
+// S3Config tells where the bucket is, and how the requests are signed.
+type S3Config struct {
+	Bucket string
+
+	// Endpoint is the URL of the S3 API, the endpoint of the region is used
+	// when it's empty. The bucket is in the path of the requests sent to it,
+	// so any S3 compatible storage works.
+	Endpoint string
+
+	// AWS has the region and the credentials of the requests
+	AWS aws.Config
+}
+
+// S3ConfigFromEnv returns the config of the bucket with the region and the
+// credentials found by the SDK, like the environment variables of AWS.
+func S3ConfigFromEnv(bucket string) (S3Config, error) {
+	c, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return S3Config{}, err
+	}
+	return S3Config{
+		Bucket: bucket,
+		AWS:    c,
+	}, nil
+}
+
+// S3 keeps the archived games in an S3 bucket, as the JSON objects under
+// "games/".
+type S3 struct {
+	client *s3.Client
+	bucket *string
+}
+
+// NewS3 returns the archive in the bucket of the config.
+func NewS3(c S3Config) *S3 {
+	return &S3{
+		client: s3.NewFromConfig(c.AWS, func(o *s3.Options) {
+			if c.Endpoint != "" {
+				o.BaseEndpoint = aws.String(c.Endpoint)
+				o.UsePathStyle = true
+			}
+		}),
+		bucket: aws.String(c.Bucket),
+	}
+}
+
+func (s *S3) ArchiveGame(id string, g yahtzee.Game) error {
+	raw, err := store.Marshal(g)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      s.bucket,
+		Key:         key(id),
+		Body:        bytes.NewReader(raw),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+func (s *S3) LoadArchived(id string) (yahtzee.Game, error) {
+	res, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: s.bucket,
+		Key:    key(id),
+	})
+	var notFound *types.NoSuchKey
+	if errors.As(err, &notFound) {
+		return yahtzee.Game{}, store.ErrNotExists
+	}
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+	defer res.Body.Close()
+
+	raw, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+	return store.Unmarshal(raw)
+}
+
+// key returns the key of the object of the game.
+func key(id string) *string {
+	return aws.String("games/" + id + ".json")
+}
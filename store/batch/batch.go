@@ -0,0 +1,149 @@
+// Package batch buffers the entries of the game logs, and writes them to the
+// store in batches. A busy game costs one write in every flush interval
+// instead of one for every entry.
+package batch
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// Defaults of the batches.
+const (
+	DefaultInterval = time.Second
+	DefaultSize     = 100
+)
+
+// Activities wraps a store of the game logs. The entries are kept in memory
+// until the flush interval ticks or the game has a full batch. Reading the
+// log of a game flushes it first, so the readers see every entry.
+type Activities struct {
+	next store.Activities
+	size int
+
+	pending map[string][]yahtzee.Activity
+	mu      sync.Mutex
+
+	// writeMu keeps the batches of a game in order when they are flushed
+	// at the same time
+	writeMu sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New returns the batching wrapper of `next` flushing in every `interval`,
+// and when a game has `size` entries. The store is written with
+// AddActivities when it implements store.ActivityBatches. Close stops the
+// flushing.
+func New(next store.Activities, interval time.Duration, size int) *Activities {
+	res := &Activities{
+		next:    next,
+		size:    size,
+		pending: map[string][]yahtzee.Activity{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go res.run(interval)
+
+	return res
+}
+
+func (b *Activities) run(interval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.Flush(); err != nil {
+				log.Printf("flush activities: %v", err)
+			}
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// AddActivity buffers the entry, and writes the batch of the game when it's
+// full.
+func (b *Activities) AddActivity(gameID string, a yahtzee.Activity) error {
+	b.mu.Lock()
+	b.pending[gameID] = append(b.pending[gameID], a)
+	full := len(b.pending[gameID]) >= b.size
+	b.mu.Unlock()
+
+	if full {
+		return b.flush(gameID)
+	}
+	return nil
+}
+
+func (b *Activities) Activities(gameID string) ([]yahtzee.Activity, error) {
+	if err := b.flush(gameID); err != nil {
+		return nil, err
+	}
+	return b.next.Activities(gameID)
+}
+
+func (b *Activities) Visit(u yahtzee.User, at int64) (int64, error) {
+	return b.next.Visit(u, at)
+}
+
+// Flush writes the buffered entries of every game. The entries of a failed
+// write are dropped, the first error is returned.
+func (b *Activities) Flush() error {
+	b.mu.Lock()
+	games := make([]string, 0, len(b.pending))
+	for gameID := range b.pending {
+		games = append(games, gameID)
+	}
+	b.mu.Unlock()
+
+	var res error
+	for _, gameID := range games {
+		if err := b.flush(gameID); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
+
+// Close stops the flushing, and writes the entries left.
+func (b *Activities) Close() error {
+	close(b.stop)
+	<-b.done
+
+	return b.Flush()
+}
+
+func (b *Activities) flush(gameID string) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	b.mu.Lock()
+	batch := b.pending[gameID]
+	delete(b.pending, gameID)
+	b.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if batches, ok := b.next.(store.ActivityBatches); ok {
+		return batches.AddActivities(gameID, batch)
+	}
+	for _, a := range batch {
+		if err := b.next.AddActivity(gameID, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
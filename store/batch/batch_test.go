@@ -0,0 +1,125 @@
+package batch_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/batch"
+	"github.com/akarasz/yahtzee/store/embedded"
+	"github.com/akarasz/yahtzee/store/storetest"
+)
+
+func TestSuite(t *testing.T) {
+	storetest.RunActivities(t, func() store.Activities {
+		return batch.New(embedded.New(), time.Hour, 2)
+	})
+}
+
+// counter counts the writes of the store.
+type counter struct {
+	*embedded.InMemory
+
+	mu     sync.Mutex
+	writes int
+}
+
+func newCounter() *counter {
+	return &counter{InMemory: embedded.New()}
+}
+
+func (c *counter) AddActivity(gameID string, a yahtzee.Activity) error {
+	c.count()
+	return c.InMemory.AddActivity(gameID, a)
+}
+
+func (c *counter) AddActivities(gameID string, as []yahtzee.Activity) error {
+	c.count()
+	return c.InMemory.AddActivities(gameID, as)
+}
+
+func (c *counter) count() {
+	c.mu.Lock()
+	c.writes++
+	c.mu.Unlock()
+}
+
+func (c *counter) Writes() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.writes
+}
+
+// single hides the batches of the store.
+type single struct {
+	next *counter
+}
+
+func (s single) AddActivity(gameID string, a yahtzee.Activity) error {
+	return s.next.AddActivity(gameID, a)
+}
+
+func (s single) Activities(gameID string) ([]yahtzee.Activity, error) {
+	return s.next.Activities(gameID)
+}
+
+func (s single) Visit(u yahtzee.User, at int64) (int64, error) {
+	return s.next.Visit(u, at)
+}
+
+var score = yahtzee.Activity{At: 1000, Action: "score", User: "Alice"}
+
+func TestSize(t *testing.T) {
+	next := newCounter()
+	b := batch.New(next, time.Hour, 3)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, b.AddActivity("aaaaa", score))
+	}
+	require.NoError(t, b.AddActivity("bbbbb", score))
+	assert.Exactly(t, 1, next.Writes())
+
+	// reading flushes the game
+	if got, err := b.Activities("aaaaa"); assert.NoError(t, err) {
+		assert.Len(t, got, 5)
+	}
+	assert.Exactly(t, 2, next.Writes())
+
+	require.NoError(t, b.Close())
+	assert.Exactly(t, 3, next.Writes())
+	if got, err := next.Activities("bbbbb"); assert.NoError(t, err) {
+		assert.Len(t, got, 1)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	next := newCounter()
+	b := batch.New(next, time.Millisecond, batch.DefaultSize)
+	defer b.Close()
+
+	require.NoError(t, b.AddActivity("aaaaa", score))
+	require.NoError(t, b.AddActivity("aaaaa", score))
+
+	// written without reading or filling the batch
+	require.Eventually(t, func() bool {
+		got, err := next.Activities("aaaaa")
+		return err == nil && len(got) == 2
+	}, time.Second, time.Millisecond)
+}
+
+func TestWithoutBatches(t *testing.T) {
+	next := newCounter()
+	b := batch.New(single{next}, time.Hour, batch.DefaultSize)
+
+	require.NoError(t, b.AddActivity("aaaaa", score))
+	require.NoError(t, b.AddActivity("aaaaa", score))
+	require.NoError(t, b.Close())
+
+	// the entries are written one by one
+	assert.Exactly(t, 2, next.Writes())
+}
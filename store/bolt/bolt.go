@@ -0,0 +1,315 @@
+// Package bolt keeps the games in a local file, so a self-hosted server
+// survives the restarts without any other service running.
+package bolt
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+var (
+	gamesBucket = []byte("games")
+
+	// changesBucket has a bucket for the log of every game, with the changes
+	// keyed by their big-endian Seq so they're iterated in order
+	changesBucket = []byte("changes")
+
+	snapshotsBucket = []byte("snapshots")
+)
+
+// openTimeout is the longest time New waits for another process to close
+// the file.
+var openTimeout = time.Second
+
+type Bolt struct {
+	db         *bbolt.DB
+	expiration time.Duration
+
+	locks     map[string]*sync.Mutex
+	locksLock sync.Mutex
+}
+
+// New opens the file of the store at `path`, creating it when it doesn't
+// exist. The games expire after `expiration` without a save. The file can't
+// be opened by other processes until Close.
+func New(path string, expiration time.Duration) (*Bolt, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{gamesBucket, changesBucket, snapshotsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Bolt{
+		db:         db,
+		expiration: expiration,
+		locks:      map[string]*sync.Mutex{},
+	}, nil
+}
+
+// Close closes the file of the store.
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+// Health starts a read transaction, it fails after the store is closed.
+func (b *Bolt) Health() error {
+	return b.db.View(func(*bbolt.Tx) error { return nil })
+}
+
+// expiry is the field of the stored games next to their store.Marshal form.
+type expiry struct {
+	// Expires is the time the game expires in unix nanoseconds
+	Expires int64
+}
+
+func (b *Bolt) Load(id string) (yahtzee.Game, error) {
+	var (
+		res yahtzee.Game
+		e   expiry
+	)
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(gamesBucket).Get([]byte(id))
+		if raw == nil {
+			return store.ErrNotExists
+		}
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return err
+		}
+		var err error
+		res, err = store.Unmarshal(raw)
+		return err
+	})
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+	if e.Expires < time.Now().UnixNano() {
+		return yahtzee.Game{}, store.ErrNotExists
+	}
+
+	return res, nil
+}
+
+func (b *Bolt) Save(id string, g yahtzee.Game) error {
+	raw, err := store.Marshal(g)
+	if err != nil {
+		return err
+	}
+	// the object of the game is closed after the expiry
+	raw = append(raw[:len(raw)-1], fmt.Sprintf(`,"Expires":%d}`,
+		time.Now().Add(b.expiration).UnixNano())...)
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(gamesBucket).Put([]byte(id), raw)
+	})
+}
+
+func (b *Bolt) Delete(id string) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(gamesBucket).Delete([]byte(id))
+	})
+}
+
+func (b *Bolt) List() ([]string, error) {
+	now := time.Now().UnixNano()
+	res := []string{}
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(gamesBucket).ForEach(func(k, v []byte) error {
+			var g expiry
+			if err := json.Unmarshal(v, &g); err != nil {
+				return err
+			}
+			if g.Expires >= now {
+				res = append(res, string(k))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Expire deletes the games expired by `now`. Bolt doesn't expire anything on
+// its own.
+func (b *Bolt) Expire(now time.Time) ([]string, error) {
+	var res []string
+
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(gamesBucket)
+		err := bucket.ForEach(func(k, v []byte) error {
+			var g expiry
+			if err := json.Unmarshal(v, &g); err != nil {
+				return err
+			}
+			if g.Expires < now.UnixNano() {
+				res = append(res, string(k))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// the bucket can't be changed while it's iterated
+		for _, id := range res {
+			if err := bucket.Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (b *Bolt) AppendChange(id string, c store.Change) error {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		changes, err := tx.Bucket(changesBucket).CreateBucketIfNotExists([]byte(id))
+		if err != nil {
+			return err
+		}
+		return changes.Put(seqKey(c.Seq), raw)
+	})
+}
+
+func (b *Bolt) Changes(id string, after int) ([]store.Change, error) {
+	res := []store.Change{}
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		changes := tx.Bucket(changesBucket).Bucket([]byte(id))
+		if changes == nil {
+			return nil
+		}
+
+		cursor := changes.Cursor()
+		for k, v := cursor.Seek(seqKey(after + 1)); k != nil; k, v = cursor.Next() {
+			var c store.Change
+			if err := json.Unmarshal(v, &c); err != nil {
+				return err
+			}
+			res = append(res, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (b *Bolt) SaveSnapshot(id string, s store.Snapshot) error {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(snapshotsBucket).Put([]byte(id), raw)
+	})
+}
+
+func (b *Bolt) LoadSnapshot(id string) (store.Snapshot, error) {
+	var res store.Snapshot
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(snapshotsBucket).Get([]byte(id))
+		if raw == nil {
+			return store.ErrNotExists
+		}
+		raw, err := store.Upgrade(raw)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(raw, &res)
+	})
+	if err != nil {
+		return store.Snapshot{}, err
+	}
+
+	return res, nil
+}
+
+// Journals returns the IDs of the games with a log. The logs are kept for the
+// audits, they don't expire with the games.
+func (b *Bolt) Journals() ([]string, error) {
+	res := []string{}
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(changesBucket).ForEach(func(k, v []byte) error {
+			res = append(res, string(k))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// DeleteJournal removes the log and the snapshot of the game.
+func (b *Bolt) DeleteJournal(id string) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		err := tx.Bucket(changesBucket).DeleteBucket([]byte(id))
+		if err != nil && err != bbolt.ErrBucketNotFound {
+			return err
+		}
+		return tx.Bucket(snapshotsBucket).Delete([]byte(id))
+	})
+}
+
+func seqKey(seq int) []byte {
+	res := make([]byte, 8)
+	binary.BigEndian.PutUint64(res, uint64(seq))
+	return res
+}
+
+// Lock locks the game in memory: only this process has the file open.
+func (b *Bolt) Lock(id string) (func(), error) {
+	b.locksLock.Lock()
+	l, ok := b.locks[id]
+	if !ok {
+		l = &sync.Mutex{}
+		b.locks[id] = l
+	}
+	b.locksLock.Unlock()
+
+	l.Lock()
+
+	return func() {
+		l.Unlock()
+	}, nil
+}
@@ -0,0 +1,91 @@
+package bolt_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/bolt"
+	"github.com/akarasz/yahtzee/store/storetest"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "yahtzee")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	return dir
+}
+
+func TestSuite(t *testing.T) {
+	storetest.Run(t, func() store.Store {
+		s, err := bolt.New(filepath.Join(tempDir(t), "games.db"), 5*time.Minute)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			s.Close()
+		})
+		return s
+	})
+	storetest.RunJournal(t, func() store.Journal {
+		s, err := bolt.New(filepath.Join(tempDir(t), "games.db"), 5*time.Minute)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			s.Close()
+		})
+		return s
+	})
+}
+
+func TestRestart(t *testing.T) {
+	path := filepath.Join(tempDir(t), "games.db")
+
+	s, err := bolt.New(path, 5*time.Minute)
+	require.NoError(t, err)
+	g := yahtzee.NewGame()
+	g.Seed = 42
+	require.NoError(t, s.Save("aaaaa", *g))
+	require.NoError(t, s.Close())
+
+	s, err = bolt.New(path, 5*time.Minute)
+	require.NoError(t, err)
+	defer s.Close()
+
+	if got, err := s.Load("aaaaa"); assert.NoError(t, err) {
+		assert.Exactly(t, *g, got)
+	}
+}
+
+func TestExpiration(t *testing.T) {
+	s, err := bolt.New(filepath.Join(tempDir(t), "games.db"), -time.Second)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Save("aaaaa", *yahtzee.NewGame()))
+	_, err = s.Load("aaaaa")
+	assert.Exactly(t, store.ErrNotExists, err)
+
+	expired, err := s.Expire(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aaaaa"}, expired)
+
+	expired, err = s.Expire(time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+}
+
+func TestHealth(t *testing.T) {
+	s, err := bolt.New(filepath.Join(tempDir(t), "games.db"), 5*time.Minute)
+	require.NoError(t, err)
+	assert.NoError(t, s.Health())
+
+	require.NoError(t, s.Close())
+	assert.Error(t, s.Health())
+}
@@ -0,0 +1,161 @@
+// Package cache keeps the recently used games of a store in memory, so the
+// hot games are loaded without asking the backend while every save is still
+// written to it. The games survive a restart in the backend, only the cache is
+// lost.
+//
+// The cache is local to the server: the games saved by another replica are
+// only seen once the cached copies are older than MaxAge. It suits a single
+// server, or the replicas serving their own games.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// DefaultSize is the number of games kept in the cache by default.
+const DefaultSize = 1024
+
+// MaxAge is the time a game is served from the cache since it was saved or
+// loaded from the backend.
+var MaxAge = time.Minute
+
+// Cache is a write-through cache of a store. The games are written to the
+// backend on every save before they are cached, and a failed save drops the
+// cached game, as the game in the backend is not known anymore.
+type Cache struct {
+	next store.Store
+	size int
+
+	order   *list.List
+	entries map[string]*list.Element
+	mu      sync.Mutex
+}
+
+type entry struct {
+	id string
+
+	// raw is the stored form of the game, so the callers can't change the
+	// cached one
+	raw    []byte
+	cached time.Time
+}
+
+// New returns the cache of `next` keeping the `size` most recently used
+// games.
+func New(next store.Store, size int) *Cache {
+	return &Cache{
+		next:    next,
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// Load returns the game from the cache, or from the backend when it's not
+// cached or it's older than MaxAge.
+func (c *Cache) Load(id string) (yahtzee.Game, error) {
+	if raw, ok := c.cached(id); ok {
+		return store.Unmarshal(raw)
+	}
+
+	loaded := time.Now()
+	g, err := c.next.Load(id)
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+	c.put(id, g, loaded)
+
+	return g, nil
+}
+
+// Save writes the game to the backend, and caches it when it's written.
+func (c *Cache) Save(id string, g yahtzee.Game) error {
+	if err := c.next.Save(id, g); err != nil {
+		c.invalidate(id)
+		return err
+	}
+	c.put(id, g, time.Time{})
+
+	return nil
+}
+
+func (c *Cache) Lock(id string) (func(), error) {
+	return c.next.Lock(id)
+}
+
+func (c *Cache) List() ([]string, error) {
+	return c.next.List()
+}
+
+// Delete deletes the game from the store and the cache, it stays cached when
+// the store fails to delete it.
+func (c *Cache) Delete(id string) error {
+	if err := c.next.Delete(id); err != nil {
+		return err
+	}
+	c.invalidate(id)
+	return nil
+}
+
+func (c *Cache) cached(id string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	cached := e.Value.(*entry)
+	if time.Since(cached.cached) > MaxAge {
+		c.order.Remove(e)
+		delete(c.entries, id)
+		return nil, false
+	}
+
+	c.order.MoveToFront(e)
+	return cached.raw, true
+}
+
+// put caches the game, unless it was loaded at `loaded` and the cached one is
+// newer, like the game of a save finished during the load.
+func (c *Cache) put(id string, g yahtzee.Game, loaded time.Time) {
+	if c.size <= 0 {
+		return
+	}
+	raw, err := store.Marshal(g)
+	if err != nil {
+		c.invalidate(id)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[id]; ok {
+		if !loaded.IsZero() && e.Value.(*entry).cached.After(loaded) {
+			return
+		}
+		c.order.Remove(e)
+	}
+	c.entries[id] = c.order.PushFront(&entry{id: id, raw: raw, cached: time.Now()})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).id)
+	}
+}
+
+func (c *Cache) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[id]; ok {
+		c.order.Remove(e)
+		delete(c.entries, id)
+	}
+}
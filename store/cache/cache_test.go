@@ -0,0 +1,97 @@
+package cache_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/cache"
+	"github.com/akarasz/yahtzee/store/embedded"
+	"github.com/akarasz/yahtzee/store/storetest"
+)
+
+// countingStore counts the loads of the backend, and fails the saves when
+// `failing` is set.
+type countingStore struct {
+	store.Store
+	loads   int
+	failing bool
+}
+
+func (s *countingStore) Load(id string) (yahtzee.Game, error) {
+	s.loads++
+	return s.Store.Load(id)
+}
+
+func (s *countingStore) Save(id string, g yahtzee.Game) error {
+	if s.failing {
+		return errors.New("backend is down")
+	}
+	return s.Store.Save(id, g)
+}
+
+func TestSuite(t *testing.T) {
+	storetest.Run(t, func() store.Store {
+		return cache.New(embedded.New(), cache.DefaultSize)
+	})
+}
+
+func TestCache(t *testing.T) {
+	backend := &countingStore{Store: embedded.New()}
+	c := cache.New(backend, 1)
+
+	g := yahtzee.NewGame()
+	g.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	g.Seed = 42
+	require.NoError(t, c.Save("aaaaa", *g))
+
+	// the saved game is cached
+	got, err := c.Load("aaaaa")
+	require.NoError(t, err)
+	assert.Exactly(t, *g, got)
+	assert.Exactly(t, 0, backend.loads)
+
+	// the cached game doesn't change with the loaded one
+	got.Players[0].ScoreSheet[yahtzee.Chance] = 20
+	got, err = c.Load("aaaaa")
+	require.NoError(t, err)
+	assert.NotContains(t, got.Players[0].ScoreSheet, yahtzee.Chance)
+
+	// the least recently used game is dropped
+	require.NoError(t, c.Save("bbbbb", *g))
+	_, err = c.Load("aaaaa")
+	require.NoError(t, err)
+	assert.Exactly(t, 1, backend.loads)
+
+	// a failed save drops the cached game
+	backend.failing = true
+	g.Round = 1
+	assert.Error(t, c.Save("aaaaa", *g))
+	got, err = c.Load("aaaaa")
+	require.NoError(t, err)
+	assert.Exactly(t, 0, got.Round)
+	assert.Exactly(t, 2, backend.loads)
+
+	_, err = c.Load("nonexisting")
+	assert.Exactly(t, store.ErrNotExists, err)
+}
+
+func TestMaxAge(t *testing.T) {
+	defer func(maxAge time.Duration) {
+		cache.MaxAge = maxAge
+	}(cache.MaxAge)
+	cache.MaxAge = 0
+
+	backend := &countingStore{Store: embedded.New()}
+	c := cache.New(backend, cache.DefaultSize)
+
+	require.NoError(t, c.Save("aaaaa", *yahtzee.NewGame()))
+	_, err := c.Load("aaaaa")
+	require.NoError(t, err)
+	assert.Exactly(t, 1, backend.loads)
+}
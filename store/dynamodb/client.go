@@ -0,0 +1,100 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var ctx = context.Background()
+
+var (
+	// maxAttempts is the number of times a throttled request is sent
+	maxAttempts = 5
+
+	// maxBackoff is the longest wait before a retry, the waits grow
+	// exponentially up to it
+	maxBackoff = 20 * time.Second
+)
+
+// Config tells where the table is, and how the requests are signed.
+type Config struct {
+	// Table is the name of the table of the store
+	Table string
+
+	// Endpoint is the URL of the DynamoDB API, the endpoint of the region is
+	// used when it's empty
+	Endpoint string
+
+	// AWS has the region and the credentials of the requests
+	AWS aws.Config
+}
+
+// ConfigFromEnv returns the config of the table with the region and the
+// credentials found by the SDK, like the environment variables of AWS, the
+// shared config files or the role of a Lambda function.
+func ConfigFromEnv(table string) (Config, error) {
+	c, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return Config{}, err
+	}
+	return Config{
+		Table: table,
+		AWS:   c,
+	}, nil
+}
+
+// client returns the client of the config. Throttled requests are retried
+// with exponential backoff.
+func (c *Config) client() *ddb.Client {
+	return ddb.NewFromConfig(c.AWS, func(o *ddb.Options) {
+		if c.Endpoint != "" {
+			o.BaseEndpoint = aws.String(c.Endpoint)
+		}
+		o.Retryer = retry.NewStandard(func(o *retry.StandardOptions) {
+			o.MaxAttempts = maxAttempts
+			o.Backoff = retry.NewExponentialJitterBackoff(maxBackoff)
+		})
+	})
+}
+
+// conditionFailed tells if the write failed on its condition.
+func conditionFailed(err error) bool {
+	var e *types.ConditionalCheckFailedException
+	return errors.As(err, &e)
+}
+
+// item is the form of the items in the requests of the client.
+type item = map[string]types.AttributeValue
+
+func stringValue(s string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: s}
+}
+
+func numberValue(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+// str returns the string attribute of the item, or "" when it's missing.
+func str(i item, name string) string {
+	if v, ok := i[name].(*types.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
+
+// number returns the number attribute of the item.
+func number(i item, name string) (int64, error) {
+	v, ok := i[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, errors.New("dynamodb: no number attribute " + name)
+	}
+	return strconv.ParseInt(v.Value, 10, 64)
+}
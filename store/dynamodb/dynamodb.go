@@ -0,0 +1,289 @@
+// Package dynamodb keeps the games in a DynamoDB table, for the serverless
+// deployments. It uses the DynamoDB client of the AWS SDK.
+//
+// The table has a string partition key named "id", and the time to live of
+// its items is the "expires" attribute.
+package dynamodb
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ddb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+var (
+	// ErrConflict is returned when the game was saved by someone else since
+	// it was loaded.
+	ErrConflict = errors.New("game changed since it was loaded")
+
+	// ErrLockTimeout is returned when the game stays locked by someone else.
+	ErrLockTimeout = errors.New("game is locked")
+)
+
+var (
+	lockExpiration = 5 * time.Second
+	lockTimeout    = 5 * time.Second
+	lockBackoff    = 50 * time.Millisecond
+)
+
+// The conditions of the writes.
+const (
+	// newGame is met when there is no game with the ID, or only an expired
+	// one not deleted yet
+	newGame = "attribute_not_exists(#id) OR #expires < :now"
+
+	// sameVersion is met when the game wasn't saved since it was loaded
+	sameVersion = "#version = :version"
+
+	// freeLock is met when nobody holds the lock, or its holder didn't
+	// release it in time
+	freeLock = "attribute_not_exists(#id) OR #until < :nowMillis"
+
+	// ownLock is met when the lock is held by the owner
+	ownLock = "#owner = :owner"
+
+	// liveGame is met by the games not expired
+	liveGame = "begins_with(#id, :prefix) AND #expires >= :now"
+)
+
+type DynamoDB struct {
+	client     *ddb.Client
+	table      *string
+	expiration time.Duration
+
+	// versions has the versions of the games loaded, a save only succeeds
+	// on the same version
+	versions map[string]int64
+	mu       sync.Mutex
+}
+
+// New returns the store on the table of the config. The games expire after
+// `expiration` without a save.
+func New(c Config, expiration time.Duration) *DynamoDB {
+	return &DynamoDB{
+		client:     c.client(),
+		table:      aws.String(c.Table),
+		expiration: expiration,
+		versions:   map[string]int64{},
+	}
+}
+
+func (d *DynamoDB) Load(id string) (yahtzee.Game, error) {
+	res, err := d.client.GetItem(ctx, &ddb.GetItemInput{
+		TableName:      d.table,
+		Key:            item{"id": stringValue("game:" + id)},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+
+	if res.Item == nil {
+		return yahtzee.Game{}, store.ErrNotExists
+	}
+
+	// expired items are deleted by DynamoDB in a while
+	expires, err := number(res.Item, "expires")
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+	if expires < time.Now().Unix() {
+		return yahtzee.Game{}, store.ErrNotExists
+	}
+
+	g, err := store.Unmarshal([]byte(str(res.Item, "game")))
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+
+	version, err := number(res.Item, "version")
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+	d.mu.Lock()
+	d.versions[id] = version
+	d.mu.Unlock()
+
+	return g, nil
+}
+
+// Save writes the game when it's new, or when it wasn't saved by someone else
+// since it was loaded. ErrConflict is returned otherwise.
+func (d *DynamoDB) Save(id string, g yahtzee.Game) error {
+	raw, err := store.Marshal(g)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	version, loaded := d.versions[id]
+	d.mu.Unlock()
+
+	now := time.Now()
+	req := &ddb.PutItemInput{
+		TableName: d.table,
+		Item: item{
+			"id":      stringValue("game:" + id),
+			"game":    stringValue(string(raw)),
+			"version": numberValue(version + 1),
+			"expires": numberValue(now.Add(d.expiration).Unix()),
+		},
+	}
+	if loaded {
+		req.ConditionExpression = aws.String(sameVersion)
+		req.ExpressionAttributeNames = map[string]string{"#version": "version"}
+		req.ExpressionAttributeValues = item{":version": numberValue(version)}
+	} else {
+		req.ConditionExpression = aws.String(newGame)
+		req.ExpressionAttributeNames = map[string]string{"#id": "id", "#expires": "expires"}
+		req.ExpressionAttributeValues = item{":now": numberValue(now.Unix())}
+	}
+
+	_, err = d.client.PutItem(ctx, req)
+	if conditionFailed(err) {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.versions[id] = version + 1
+	d.mu.Unlock()
+
+	return nil
+}
+
+func (d *DynamoDB) Delete(id string) error {
+	_, err := d.client.DeleteItem(ctx, &ddb.DeleteItemInput{
+		TableName: d.table,
+		Key:       item{"id": stringValue("game:" + id)},
+	})
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	delete(d.versions, id)
+	d.mu.Unlock()
+
+	return nil
+}
+
+// Health describes the table, it has to be active or updating to be used.
+func (d *DynamoDB) Health() error {
+	res, err := d.client.DescribeTable(ctx, &ddb.DescribeTableInput{
+		TableName: d.table,
+	})
+	if err != nil {
+		return err
+	}
+	if status := res.Table.TableStatus; status != types.TableStatusActive && status != types.TableStatusUpdating {
+		return fmt.Errorf("dynamodb: table is %s", status)
+	}
+	return nil
+}
+
+// List scans the table for the games, page by page.
+func (d *DynamoDB) List() ([]string, error) {
+	res := []string{}
+
+	pages := ddb.NewScanPaginator(d.client, &ddb.ScanInput{
+		TableName:                d.table,
+		ProjectionExpression:     aws.String("#id"),
+		FilterExpression:         aws.String(liveGame),
+		ExpressionAttributeNames: map[string]string{"#id": "id", "#expires": "expires"},
+		ExpressionAttributeValues: item{
+			":prefix": stringValue("game:"),
+			":now":    numberValue(time.Now().Unix()),
+		},
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range page.Items {
+			res = append(res, strings.TrimPrefix(str(i, "id"), "game:"))
+		}
+	}
+
+	sort.Strings(res)
+	return res, nil
+}
+
+// Lock writes the lock item of the game with a random owner, when nobody
+// holds it. The lock is released when it's not unlocked in time.
+func (d *DynamoDB) Lock(id string) (func(), error) {
+	owner, err := newOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		now := time.Now()
+		_, err := d.client.PutItem(ctx, &ddb.PutItemInput{
+			TableName: d.table,
+			Item: item{
+				"id":      stringValue("lock:" + id),
+				"owner":   stringValue(owner),
+				"until":   numberValue(unixMillis(now.Add(lockExpiration))),
+				"expires": numberValue(now.Add(lockExpiration).Unix() + 1),
+			},
+			ConditionExpression:      aws.String(freeLock),
+			ExpressionAttributeNames: map[string]string{"#id": "id", "#until": "until"},
+			ExpressionAttributeValues: item{
+				":nowMillis": numberValue(unixMillis(now)),
+			},
+		})
+		if err == nil {
+			break
+		}
+		if !conditionFailed(err) {
+			return nil, err
+		}
+		if now.After(deadline) {
+			return nil, ErrLockTimeout
+		}
+		time.Sleep(lockBackoff)
+	}
+
+	return func() {
+		_, err := d.client.DeleteItem(ctx, &ddb.DeleteItemInput{
+			TableName:                 d.table,
+			Key:                       item{"id": stringValue("lock:" + id)},
+			ConditionExpression:       aws.String(ownLock),
+			ExpressionAttributeNames:  map[string]string{"#owner": "owner"},
+			ExpressionAttributeValues: item{":owner": stringValue(owner)},
+		})
+		if err != nil {
+			log.Printf("unlock %s: %v", id, err)
+		}
+	}, nil
+}
+
+func newOwner() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+func unixMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,255 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/storetest"
+)
+
+// attribute is a value of an item in the JSON form of the API.
+type attribute struct {
+	S string `json:",omitempty"`
+	N string `json:",omitempty"`
+}
+
+type fakeItem map[string]attribute
+
+// fakeTable serves the operations of the store on an in-memory table. The
+// conditions of the writes are known by their expressions.
+type fakeTable struct {
+	mu    sync.Mutex
+	items map[string]fakeItem
+
+	// throttle is the number of requests failing with throttling
+	throttle int
+	requests int
+
+	// status is the status of the table, described as ACTIVE when it's empty
+	status string
+}
+
+type fakeRequest struct {
+	Key                       fakeItem
+	Item                      fakeItem
+	ConditionExpression       string
+	FilterExpression          string
+	ExpressionAttributeValues fakeItem
+	ExclusiveStartKey         fakeItem
+}
+
+func (f *fakeTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests++
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type": "com.amazon.coral.service#MissingAuthenticationTokenException"}`))
+		return
+	}
+	if f.throttle > 0 {
+		f.throttle--
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type": "com.amazonaws.dynamodb.v20120810#ProvisionedThroughputExceededException"}`))
+		return
+	}
+
+	var req fakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	key := req.Key["id"].S
+	if req.Item != nil {
+		key = req.Item["id"].S
+	}
+	if !f.met(req.ConditionExpression, f.items[key], req.ExpressionAttributeValues) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type": "com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException"}`))
+		return
+	}
+
+	switch r.Header.Get("X-Amz-Target") {
+	case "DynamoDB_20120810.GetItem":
+		json.NewEncoder(w).Encode(map[string]fakeItem{"Item": f.items[key]})
+	case "DynamoDB_20120810.PutItem":
+		f.items[key] = req.Item
+		w.Write([]byte(`{}`))
+	case "DynamoDB_20120810.DeleteItem":
+		delete(f.items, key)
+		w.Write([]byte(`{}`))
+	case "DynamoDB_20120810.Scan":
+		f.scan(w, &req)
+	case "DynamoDB_20120810.DescribeTable":
+		status := f.status
+		if status == "" {
+			status = "ACTIVE"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"Table": map[string]string{"TableStatus": status}})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// scan answers the scan with the items meeting its filter one page at a
+// time, so the store has to follow the pages.
+func (f *fakeTable) scan(w http.ResponseWriter, req *fakeRequest) {
+	keys := []string{}
+	for key := range f.items {
+		if key > req.ExclusiveStartKey["id"].S && f.met(req.FilterExpression, f.items[key], req.ExpressionAttributeValues) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	res := map[string]interface{}{"Items": []fakeItem{}}
+	if len(keys) > 0 {
+		res["Items"] = []fakeItem{{"id": {S: keys[0]}}}
+	}
+	if len(keys) > 1 {
+		res["LastEvaluatedKey"] = fakeItem{"id": {S: keys[0]}}
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+// throttleNext throttles the next `n` requests, and returns the number of
+// the requests served so far.
+func (f *fakeTable) throttleNext(n int) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.throttle = n
+	return f.requests
+}
+
+func (f *fakeTable) met(condition string, current fakeItem, values fakeItem) bool {
+	number := func(a attribute) int64 {
+		res, _ := strconv.ParseInt(a.N, 10, 64)
+		return res
+	}
+
+	switch condition {
+	case "":
+		return true
+	case newGame:
+		return current == nil || number(current["expires"]) < number(values[":now"])
+	case sameVersion:
+		return current != nil && current["version"] == values[":version"]
+	case freeLock:
+		return current == nil || number(current["until"]) < number(values[":nowMillis"])
+	case ownLock:
+		return current != nil && current["owner"] == values[":owner"]
+	case liveGame:
+		return strings.HasPrefix(current["id"].S, values[":prefix"].S) &&
+			number(current["expires"]) >= number(values[":now"])
+	}
+	panic("unknown condition " + condition)
+}
+
+func newFake(t *testing.T) (*fakeTable, Config) {
+	f := &fakeTable{items: map[string]fakeItem{}}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	return f, Config{
+		Table:    "yahtzee",
+		Endpoint: server.URL,
+		AWS: aws.Config{
+			Region:      "eu-central-1",
+			Credentials: credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		},
+	}
+}
+
+func TestSuite(t *testing.T) {
+	storetest.Run(t, func() store.Store {
+		_, c := newFake(t)
+		return New(c, 5*time.Minute)
+	})
+}
+
+func TestConflict(t *testing.T) {
+	_, c := newFake(t)
+	alice, bob := New(c, 5*time.Minute), New(c, 5*time.Minute)
+
+	g := *yahtzee.NewGame()
+	require.NoError(t, alice.Save("aaaaa", g))
+
+	// the game is taken
+	assert.Exactly(t, ErrConflict, bob.Save("aaaaa", g))
+
+	_, err := bob.Load("aaaaa")
+	require.NoError(t, err)
+	_, err = alice.Load("aaaaa")
+	require.NoError(t, err)
+
+	require.NoError(t, bob.Save("aaaaa", g))
+
+	// the game was changed since alice loaded it
+	assert.Exactly(t, ErrConflict, alice.Save("aaaaa", g))
+}
+
+func TestLockTimeout(t *testing.T) {
+	defer func(timeout time.Duration) {
+		lockTimeout = timeout
+	}(lockTimeout)
+	lockTimeout = 100 * time.Millisecond
+
+	_, c := newFake(t)
+	alice, bob := New(c, 5*time.Minute), New(c, 5*time.Minute)
+
+	unlock, err := alice.Lock("aaaaa")
+	require.NoError(t, err)
+
+	_, err = bob.Lock("aaaaa")
+	assert.Exactly(t, ErrLockTimeout, err)
+
+	unlock()
+	unlock, err = bob.Lock("aaaaa")
+	require.NoError(t, err)
+	unlock()
+}
+
+func TestThrottling(t *testing.T) {
+	defer func(backoff time.Duration) {
+		maxBackoff = backoff
+	}(maxBackoff)
+	maxBackoff = time.Millisecond
+
+	f, c := newFake(t)
+	s := New(c, 5*time.Minute)
+
+	before := f.throttleNext(maxAttempts - 1)
+	assert.NoError(t, s.Save("aaaaa", *yahtzee.NewGame()))
+	assert.Exactly(t, before+maxAttempts, f.throttleNext(maxAttempts))
+
+	// it gives up in the end
+	_, err := s.Load("aaaaa")
+	assert.Error(t, err)
+}
+
+func TestHealth(t *testing.T) {
+	f, c := newFake(t)
+	s := New(c, 5*time.Minute)
+	assert.NoError(t, s.Health())
+
+	f.mu.Lock()
+	f.status = "DELETING"
+	f.mu.Unlock()
+	assert.Error(t, s.Health())
+}
@@ -1,7 +1,12 @@
 package embedded
 
 import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -10,18 +15,83 @@ import (
 	"github.com/akarasz/yahtzee/store"
 )
 
+// DefaultExpiration is the time the games are kept without a save.
+const DefaultExpiration = 24 * time.Hour
+
+// ErrMemoryPressure is the health of the store when the process uses more
+// memory than its limit.
+var ErrMemoryPressure = errors.New("memory pressure")
+
 // InMemory is the in-memory implementation of Store.
 type InMemory struct {
+	expiration  time.Duration
+	memoryLimit uint64
+
 	repo  map[string]yahtzee.Game
 	locks map[string]*sync.Mutex
+	bests map[yahtzee.User]int
+
+	// expires has the time the games expire in unix nanoseconds
+	expires map[string]int64
+
+	matches   map[string]yahtzee.Match
+	userGames map[yahtzee.User][]string
+	notes     map[notesKey]yahtzee.Notes
+
+	achievements map[yahtzee.User][]yahtzee.Achievement
+	clocks       map[string]int64
+
+	dailyGames  map[dailyKey]string
+	dailyScores map[string]map[yahtzee.User]int
+
+	activities map[string][]yahtzee.Activity
+	visits     map[yahtzee.User]int64
+
+	changes   map[string][]store.Change
+	snapshots map[string]store.Snapshot
 
 	repoLock  *sync.RWMutex
 	locksLock *sync.Mutex
 }
 
+// Option configures the store.
+type Option func(*InMemory)
+
+// WithExpiration keeps the games for `d` after their last save, instead of
+// DefaultExpiration.
+func WithExpiration(d time.Duration) Option {
+	return func(s *InMemory) {
+		s.expiration = d
+	}
+}
+
+// WithMemoryLimit makes the store unhealthy when the heap of the process is
+// larger than `bytes`, so the server is taken out of the rotation before it
+// runs out of memory.
+func WithMemoryLimit(bytes uint64) Option {
+	return func(s *InMemory) {
+		s.memoryLimit = bytes
+	}
+}
+
+// Health checks the heap of the process against the memory limit, the store
+// is always healthy without one.
+func (s *InMemory) Health() error {
+	if s.memoryLimit == 0 {
+		return nil
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	if m.HeapAlloc > s.memoryLimit {
+		return fmt.Errorf("%w: %d bytes of heap, the limit is %d", ErrMemoryPressure, m.HeapAlloc, s.memoryLimit)
+	}
+	return nil
+}
+
 func (s *InMemory) Save(id string, g yahtzee.Game) error {
 	s.repoLock.Lock()
 	s.repo[id] = g
+	s.expires[id] = time.Now().Add(s.expiration).UnixNano()
 	s.repoLock.Unlock()
 
 	return nil
@@ -30,14 +100,92 @@ func (s *InMemory) Save(id string, g yahtzee.Game) error {
 func (s *InMemory) Load(id string) (yahtzee.Game, error) {
 	s.repoLock.RLock()
 	g, ok := s.repo[id]
+	expires := s.expires[id]
 	s.repoLock.RUnlock()
-	if !ok {
-		return g, store.ErrNotExists
+	if !ok || expires < time.Now().UnixNano() {
+		return yahtzee.Game{}, store.ErrNotExists
 	}
 
 	return g, nil
 }
 
+func (s *InMemory) Delete(id string) error {
+	s.repoLock.Lock()
+	delete(s.repo, id)
+	delete(s.expires, id)
+	s.repoLock.Unlock()
+
+	return nil
+}
+
+func (s *InMemory) List() ([]string, error) {
+	now := time.Now().UnixNano()
+
+	res := []string{}
+	s.repoLock.RLock()
+	for id := range s.repo {
+		if s.expires[id] >= now {
+			res = append(res, id)
+		}
+	}
+	s.repoLock.RUnlock()
+
+	sort.Strings(res)
+	return res, nil
+}
+
+// Expire deletes the games expired by `now` with everything kept about them:
+// their locks, clocks, logs and notes.
+func (s *InMemory) Expire(now time.Time) ([]string, error) {
+	var expired []string
+	s.repoLock.RLock()
+	for id, expires := range s.expires {
+		if expires < now.UnixNano() {
+			expired = append(expired, id)
+		}
+	}
+	s.repoLock.RUnlock()
+
+	var res []string
+	for _, id := range expired {
+		if s.expire(id, now) {
+			res = append(res, id)
+		}
+	}
+
+	return res, nil
+}
+
+// expire deletes the game when it's still expired. The game is locked
+// meanwhile, so it's not saved again while it's deleted.
+func (s *InMemory) expire(id string, now time.Time) bool {
+	unlock, _ := s.Lock(id)
+	defer unlock()
+
+	s.repoLock.Lock()
+	expires, ok := s.expires[id]
+	if !ok || expires >= now.UnixNano() {
+		s.repoLock.Unlock()
+		return false
+	}
+	delete(s.repo, id)
+	delete(s.expires, id)
+	delete(s.clocks, id)
+	delete(s.activities, id)
+	for key := range s.notes {
+		if key.gameID == id {
+			delete(s.notes, key)
+		}
+	}
+	s.repoLock.Unlock()
+
+	s.locksLock.Lock()
+	delete(s.locks, id)
+	s.locksLock.Unlock()
+
+	return true
+}
+
 func (s *InMemory) Lock(id string) (func(), error) {
 	s.locksLock.Lock()
 	l, ok := s.locks[id]
@@ -54,22 +202,330 @@ func (s *InMemory) Lock(id string) (func(), error) {
 	}, nil
 }
 
-// NewInMemory creates an empty in-memory store.
-func New() *InMemory {
+func (s *InMemory) Best(u yahtzee.User) (int, error) {
+	s.repoLock.RLock()
+	best, ok := s.bests[u]
+	s.repoLock.RUnlock()
+	if !ok {
+		return 0, store.ErrNotExists
+	}
+
+	return best, nil
+}
+
+func (s *InMemory) Record(u yahtzee.User, score int) error {
+	s.repoLock.Lock()
+	if best, ok := s.bests[u]; !ok || score > best {
+		s.bests[u] = score
+	}
+	s.repoLock.Unlock()
+
+	return nil
+}
+
+func (s *InMemory) LoadMatch(id string) (yahtzee.Match, error) {
+	s.repoLock.RLock()
+	m, ok := s.matches[id]
+	s.repoLock.RUnlock()
+	if !ok {
+		return m, store.ErrNotExists
+	}
+
+	return m, nil
+}
+
+func (s *InMemory) SaveMatch(id string, m yahtzee.Match) error {
+	s.repoLock.Lock()
+	s.matches[id] = m
+	s.repoLock.Unlock()
+
+	return nil
+}
+
+func (s *InMemory) AddGame(u yahtzee.User, id string) error {
+	s.repoLock.Lock()
+	defer s.repoLock.Unlock()
+
+	for _, g := range s.userGames[u] {
+		if g == id {
+			return nil
+		}
+	}
+	s.userGames[u] = append(s.userGames[u], id)
+
+	return nil
+}
+
+func (s *InMemory) Games(u yahtzee.User) ([]string, error) {
+	s.repoLock.RLock()
+	res := append([]string{}, s.userGames[u]...)
+	s.repoLock.RUnlock()
+
+	return res, nil
+}
+
+type notesKey struct {
+	gameID string
+	user   yahtzee.User
+}
+
+func (s *InMemory) LoadNotes(gameID string, u yahtzee.User) (yahtzee.Notes, error) {
+	s.repoLock.RLock()
+	n, ok := s.notes[notesKey{gameID, u}]
+	s.repoLock.RUnlock()
+	if !ok {
+		return n, store.ErrNotExists
+	}
+
+	return n, nil
+}
+
+func (s *InMemory) SaveNotes(gameID string, u yahtzee.User, n yahtzee.Notes) error {
+	s.repoLock.Lock()
+	s.notes[notesKey{gameID, u}] = n
+	s.repoLock.Unlock()
+
+	return nil
+}
+
+func (s *InMemory) Unlock(u yahtzee.User, a yahtzee.Achievement) (bool, error) {
+	s.repoLock.Lock()
+	defer s.repoLock.Unlock()
+
+	for _, unlocked := range s.achievements[u] {
+		if unlocked == a {
+			return false, nil
+		}
+	}
+	s.achievements[u] = append(s.achievements[u], a)
+
+	return true, nil
+}
+
+func (s *InMemory) Achievements(u yahtzee.User) ([]yahtzee.Achievement, error) {
+	s.repoLock.RLock()
+	res := append([]yahtzee.Achievement{}, s.achievements[u]...)
+	s.repoLock.RUnlock()
+
+	return res, nil
+}
+
+func (s *InMemory) SetClock(gameID string, deadline int64) error {
+	s.repoLock.Lock()
+	if deadline == 0 {
+		delete(s.clocks, gameID)
+	} else {
+		s.clocks[gameID] = deadline
+	}
+	s.repoLock.Unlock()
+
+	return nil
+}
+
+func (s *InMemory) Clocks() (map[string]int64, error) {
+	s.repoLock.RLock()
+	res := make(map[string]int64, len(s.clocks))
+	for gameID, deadline := range s.clocks {
+		res[gameID] = deadline
+	}
+	s.repoLock.RUnlock()
+
+	return res, nil
+}
+
+type dailyKey struct {
+	day  string
+	user yahtzee.User
+}
+
+func (s *InMemory) DailyGame(day string, u yahtzee.User) (string, error) {
+	s.repoLock.RLock()
+	gameID, ok := s.dailyGames[dailyKey{day, u}]
+	s.repoLock.RUnlock()
+	if !ok {
+		return "", store.ErrNotExists
+	}
+
+	return gameID, nil
+}
+
+func (s *InMemory) StartDaily(day string, u yahtzee.User, gameID string) error {
+	s.repoLock.Lock()
+	s.dailyGames[dailyKey{day, u}] = gameID
+	s.repoLock.Unlock()
+
+	return nil
+}
+
+func (s *InMemory) RecordDaily(day string, u yahtzee.User, score int) error {
+	s.repoLock.Lock()
+	if s.dailyScores[day] == nil {
+		s.dailyScores[day] = map[yahtzee.User]int{}
+	}
+	s.dailyScores[day][u] = score
+	s.repoLock.Unlock()
+
+	return nil
+}
+
+func (s *InMemory) DailyScores(day string) (map[yahtzee.User]int, error) {
+	s.repoLock.RLock()
+	res := make(map[yahtzee.User]int, len(s.dailyScores[day]))
+	for u, score := range s.dailyScores[day] {
+		res[u] = score
+	}
+	s.repoLock.RUnlock()
+
+	return res, nil
+}
+
+func (s *InMemory) AddActivity(gameID string, a yahtzee.Activity) error {
+	s.repoLock.Lock()
+	s.activities[gameID] = append(s.activities[gameID], a)
+	s.repoLock.Unlock()
+
+	return nil
+}
+
+func (s *InMemory) AddActivities(gameID string, as []yahtzee.Activity) error {
+	s.repoLock.Lock()
+	s.activities[gameID] = append(s.activities[gameID], as...)
+	s.repoLock.Unlock()
+
+	return nil
+}
+
+func (s *InMemory) Activities(gameID string) ([]yahtzee.Activity, error) {
+	s.repoLock.RLock()
+	res := append([]yahtzee.Activity{}, s.activities[gameID]...)
+	s.repoLock.RUnlock()
+
+	return res, nil
+}
+
+func (s *InMemory) Visit(u yahtzee.User, at int64) (int64, error) {
+	s.repoLock.Lock()
+	res := s.visits[u]
+	s.visits[u] = at
+	s.repoLock.Unlock()
+
+	return res, nil
+}
+
+func (s *InMemory) AppendChange(id string, c store.Change) error {
+	s.repoLock.Lock()
+	s.changes[id] = append(s.changes[id], c)
+	s.repoLock.Unlock()
+
+	return nil
+}
+
+func (s *InMemory) Changes(id string, after int) ([]store.Change, error) {
+	res := []store.Change{}
+	s.repoLock.RLock()
+	for _, c := range s.changes[id] {
+		if c.Seq > after {
+			res = append(res, c)
+		}
+	}
+	s.repoLock.RUnlock()
+
+	return res, nil
+}
+
+func (s *InMemory) SaveSnapshot(id string, snapshot store.Snapshot) error {
+	s.repoLock.Lock()
+	s.snapshots[id] = snapshot
+	s.repoLock.Unlock()
+
+	return nil
+}
+
+func (s *InMemory) LoadSnapshot(id string) (store.Snapshot, error) {
+	s.repoLock.RLock()
+	res, ok := s.snapshots[id]
+	s.repoLock.RUnlock()
+	if !ok {
+		return store.Snapshot{}, store.ErrNotExists
+	}
+
+	return res, nil
+}
+
+// Journals returns the IDs of the games with a log. The logs are kept for the
+// audits, they don't expire with the games.
+func (s *InMemory) Journals() ([]string, error) {
+	res := []string{}
+	s.repoLock.RLock()
+	for id := range s.changes {
+		res = append(res, id)
+	}
+	s.repoLock.RUnlock()
+
+	sort.Strings(res)
+	return res, nil
+}
+
+func (s *InMemory) DeleteJournal(id string) error {
+	s.repoLock.Lock()
+	delete(s.changes, id)
+	delete(s.snapshots, id)
+	s.repoLock.Unlock()
+
+	return nil
+}
+
+// metricsOnce registers the metrics of the first store created, more stores
+// would panic on the duplicate registration.
+var metricsOnce sync.Once
+
+// New creates an empty in-memory store.
+func New(opts ...Option) *InMemory {
 	res := InMemory{
+		expiration: DefaultExpiration,
+
 		repo:  map[string]yahtzee.Game{},
 		locks: map[string]*sync.Mutex{},
+		bests: map[yahtzee.User]int{},
+
+		expires: map[string]int64{},
+
+		matches:   map[string]yahtzee.Match{},
+		userGames: map[yahtzee.User][]string{},
+		notes:     map[notesKey]yahtzee.Notes{},
+
+		achievements: map[yahtzee.User][]yahtzee.Achievement{},
+		clocks:       map[string]int64{},
+
+		dailyGames:  map[dailyKey]string{},
+		dailyScores: map[string]map[yahtzee.User]int{},
+
+		activities: map[string][]yahtzee.Activity{},
+		visits:     map[yahtzee.User]int64{},
+
+		changes:   map[string][]store.Change{},
+		snapshots: map[string]store.Snapshot{},
 
 		repoLock:  &sync.RWMutex{},
 		locksLock: &sync.Mutex{},
 	}
 
-	promauto.NewGaugeFunc(
-		prometheus.GaugeOpts{
-			Name: "yahtzee_store_size",
-			Help: "The total number of games in the in memory store",
-		},
-		func() float64 { return float64(len(res.repo)) })
+	for _, opt := range opts {
+		opt(&res)
+	}
+
+	metricsOnce.Do(func() {
+		promauto.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "yahtzee_store_size",
+				Help: "The total number of games in the in memory store",
+			},
+			func() float64 {
+				res.repoLock.RLock()
+				defer res.repoLock.RUnlock()
+				return float64(len(res.repo))
+			})
+	})
 
 	return &res
 }
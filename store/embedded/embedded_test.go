@@ -1,15 +1,85 @@
 package embedded_test
 
 import (
+	"errors"
 	"testing"
+	"time"
 
-	"github.com/stretchr/testify/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/akarasz/yahtzee"
 	"github.com/akarasz/yahtzee/store"
 	"github.com/akarasz/yahtzee/store/embedded"
+	"github.com/akarasz/yahtzee/store/storetest"
 )
 
 func TestSuite(t *testing.T) {
-	s := embedded.New()
-	suite.Run(t, &store.TestSuite{Subject: s})
+	storetest.Run(t, func() store.Store {
+		return embedded.New()
+	})
+	storetest.RunBestScores(t, func() store.BestScores {
+		return embedded.New()
+	})
+	storetest.RunMatches(t, func() store.Matches {
+		return embedded.New()
+	})
+	storetest.RunUserGames(t, func() store.UserGames {
+		return embedded.New()
+	})
+	storetest.RunNotes(t, func() store.Notes {
+		return embedded.New()
+	})
+	storetest.RunAchievements(t, func() store.Achievements {
+		return embedded.New()
+	})
+	storetest.RunClocks(t, func() store.Clocks {
+		return embedded.New()
+	})
+	storetest.RunDailies(t, func() store.Dailies {
+		return embedded.New()
+	})
+	storetest.RunActivities(t, func() store.Activities {
+		return embedded.New()
+	})
+	storetest.RunJournal(t, func() store.Journal {
+		return embedded.New()
+	})
+}
+
+func TestExpire(t *testing.T) {
+	s := embedded.New(embedded.WithExpiration(time.Hour))
+	g := *yahtzee.NewGame()
+	require.NoError(t, s.Save("aaaaa", g))
+	require.NoError(t, s.SaveNotes("aaaaa", "Alice", yahtzee.Notes{}))
+
+	expired, err := s.Expire(time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+
+	expired, err = s.Expire(time.Now().Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aaaaa"}, expired)
+
+	_, err = s.Load("aaaaa")
+	assert.Exactly(t, store.ErrNotExists, err)
+	_, err = s.LoadNotes("aaaaa", "Alice")
+	assert.Exactly(t, store.ErrNotExists, err)
+}
+
+func TestLoadExpired(t *testing.T) {
+	s := embedded.New(embedded.WithExpiration(-time.Second))
+	require.NoError(t, s.Save("aaaaa", *yahtzee.NewGame()))
+
+	// not swept yet
+	_, err := s.Load("aaaaa")
+	assert.Exactly(t, store.ErrNotExists, err)
+}
+
+func TestHealth(t *testing.T) {
+	assert.NoError(t, embedded.New().Health())
+	assert.NoError(t, embedded.New(embedded.WithMemoryLimit(1<<40)).Health())
+
+	err := embedded.New(embedded.WithMemoryLimit(1)).Health()
+	assert.True(t, errors.Is(err, embedded.ErrMemoryPressure))
 }
@@ -0,0 +1,212 @@
+// Package eventlog keeps the games as the append-only logs of their changes
+// instead of their latest state, so every game can be rebuilt, replayed and
+// audited save by save. Every save appends the JSON merge patch from the
+// previous state of the game to its log, and the game is snapshotted now and
+// then so the loads don't apply the whole log.
+package eventlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// DefaultSnapshotInterval is the number of changes between the snapshots by
+// default.
+const DefaultSnapshotInterval = 50
+
+// Store keeps the games in the logs of a journal.
+type Store struct {
+	journal  store.Journal
+	locker   store.Locker
+	interval int
+}
+
+// New returns the store keeping the games in `j`, locked by `l`. The game is
+// snapshotted after every `interval` changes.
+func New(j store.Journal, l store.Locker, interval int) *Store {
+	if interval < 1 {
+		interval = 1
+	}
+	return &Store{
+		journal:  j,
+		locker:   l,
+		interval: interval,
+	}
+}
+
+// Load rebuilds the game by applying the changes after its latest snapshot.
+func (s *Store) Load(id string) (yahtzee.Game, error) {
+	doc, _, err := s.latest(id)
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+	return decodeGame(doc)
+}
+
+// Save appends the changes of the game since its last save to its log, and
+// snapshots it when it's due. Nothing is appended without a change.
+func (s *Store) Save(id string, g yahtzee.Game) error {
+	previous, seq, err := s.latest(id)
+	if errors.Is(err, store.ErrNotExists) {
+		previous = map[string]interface{}{}
+	} else if err != nil {
+		return err
+	}
+
+	doc, err := encodeGame(g)
+	if err != nil {
+		return err
+	}
+	patch, changed := diff(previous, doc)
+	if !changed && seq > 0 {
+		return nil
+	}
+	rawPatch, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	seq++
+	err = s.journal.AppendChange(id, store.Change{
+		Seq:   seq,
+		At:    time.Now().UnixNano() / int64(time.Millisecond),
+		Patch: rawPatch,
+	})
+	if err != nil {
+		return err
+	}
+
+	if seq%s.interval != 0 {
+		return nil
+	}
+	rawGame, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return s.journal.SaveSnapshot(id, store.Snapshot{Seq: seq, Game: rawGame})
+}
+
+func (s *Store) Lock(id string) (func(), error) {
+	return s.locker.Lock(id)
+}
+
+func (s *Store) List() ([]string, error) {
+	return s.journal.Journals()
+}
+
+// Delete removes the log of the game with its snapshot, so the game can't be
+// replayed anymore.
+func (s *Store) Delete(id string) error {
+	return s.journal.DeleteJournal(id)
+}
+
+// History returns the log of the game, from its creation to its last save.
+func (s *Store) History(id string) ([]store.Change, error) {
+	res, err := s.journal.Changes(id, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, store.ErrNotExists
+	}
+	return res, nil
+}
+
+// At replays the log of the game up to the change `seq`, returning the game as
+// it was saved then.
+func (s *Store) At(id string, seq int) (yahtzee.Game, error) {
+	changes, err := s.History(id)
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+	if seq < 1 || seq > changes[len(changes)-1].Seq {
+		return yahtzee.Game{}, store.ErrNotExists
+	}
+
+	var doc interface{} = map[string]interface{}{}
+	for _, c := range changes {
+		if c.Seq > seq {
+			break
+		}
+		if doc, err = applyChange(doc, c); err != nil {
+			return yahtzee.Game{}, err
+		}
+	}
+	return decodeGame(doc)
+}
+
+// latest returns the game after the last change of its log in its JSON form,
+// with the Seq of the change.
+func (s *Store) latest(id string) (interface{}, int, error) {
+	var doc interface{} = map[string]interface{}{}
+	seq := 0
+
+	snapshot, err := s.journal.LoadSnapshot(id)
+	switch {
+	case err == nil:
+		if doc, err = decode(snapshot.Game); err != nil {
+			return nil, 0, err
+		}
+		seq = snapshot.Seq
+	case !errors.Is(err, store.ErrNotExists):
+		return nil, 0, err
+	}
+
+	changes, err := s.journal.Changes(id, seq)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, c := range changes {
+		if doc, err = applyChange(doc, c); err != nil {
+			return nil, 0, err
+		}
+		seq = c.Seq
+	}
+
+	if seq == 0 {
+		return nil, 0, store.ErrNotExists
+	}
+	return doc, seq, nil
+}
+
+func applyChange(doc interface{}, c store.Change) (interface{}, error) {
+	patch, err := decode(c.Patch)
+	if err != nil {
+		return nil, err
+	}
+	return apply(doc, patch), nil
+}
+
+// decode reads the JSON keeping the numbers as they are, so the big ones like
+// the seeds don't lose their precision in a float64.
+func decode(raw []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+
+	var res interface{}
+	if err := d.Decode(&res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func encodeGame(g yahtzee.Game) (interface{}, error) {
+	raw, err := store.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+	return decode(raw)
+}
+
+func decodeGame(doc interface{}) (yahtzee.Game, error) {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+	return store.Unmarshal(raw)
+}
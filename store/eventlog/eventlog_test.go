@@ -0,0 +1,74 @@
+package eventlog_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/embedded"
+	"github.com/akarasz/yahtzee/store/eventlog"
+	"github.com/akarasz/yahtzee/store/storetest"
+)
+
+func TestSuite(t *testing.T) {
+	storetest.Run(t, func() store.Store {
+		j := embedded.New()
+		return eventlog.New(j, j, 2)
+	})
+}
+
+func TestHistory(t *testing.T) {
+	j := embedded.New()
+	s := eventlog.New(j, j, 2)
+
+	created := yahtzee.NewGame()
+	created.Seed = 1 << 60
+	require.NoError(t, s.Save("aaaaa", *created))
+
+	joined := yahtzee.NewGame()
+	joined.Seed = created.Seed
+	joined.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	require.NoError(t, s.Save("aaaaa", *joined))
+
+	// nothing is appended without a change
+	require.NoError(t, s.Save("aaaaa", *joined))
+
+	started := yahtzee.NewGame()
+	started.Seed = created.Seed
+	started.Players = []*yahtzee.Player{yahtzee.NewPlayer("Alice")}
+	started.Round = 1
+	require.NoError(t, s.Save("aaaaa", *started))
+
+	history, err := s.History("aaaaa")
+	require.NoError(t, err)
+	if assert.Len(t, history, 3) {
+		for i, c := range history {
+			assert.Exactly(t, i+1, c.Seq)
+			assert.NotZero(t, c.At)
+		}
+		assert.JSONEq(t, `{"Round":1}`, string(history[2].Patch))
+	}
+
+	// the snapshot is taken after every second change
+	snapshot, err := j.LoadSnapshot("aaaaa")
+	require.NoError(t, err)
+	assert.Exactly(t, 2, snapshot.Seq)
+
+	if got, err := s.Load("aaaaa"); assert.NoError(t, err) {
+		assert.Exactly(t, *started, got)
+	}
+	if got, err := s.At("aaaaa", 1); assert.NoError(t, err) {
+		assert.Exactly(t, *created, got)
+	}
+	if got, err := s.At("aaaaa", 2); assert.NoError(t, err) {
+		assert.Exactly(t, *joined, got)
+	}
+
+	_, err = s.At("aaaaa", 4)
+	assert.Exactly(t, store.ErrNotExists, err)
+	_, err = s.History("nonexisting")
+	assert.Exactly(t, store.ErrNotExists, err)
+}
@@ -0,0 +1,62 @@
+package eventlog
+
+import "reflect"
+
+// diff returns the JSON merge patch turning `from` into `to`, and tells if
+// they differ. The arrays are replaced as a whole, and the removed members are
+// set to null.
+func diff(from, to interface{}) (interface{}, bool) {
+	fromObject, ok := from.(map[string]interface{})
+	toObject, isObject := to.(map[string]interface{})
+	if !ok || !isObject {
+		return to, !reflect.DeepEqual(from, to)
+	}
+
+	res := map[string]interface{}{}
+	for k := range fromObject {
+		if _, ok := toObject[k]; !ok {
+			res[k] = nil
+		}
+	}
+	for k, v := range toObject {
+		old, ok := fromObject[k]
+		if !ok {
+			if v != nil {
+				res[k] = v
+			}
+			continue
+		}
+		_, wasObject := old.(map[string]interface{})
+		if _, isObject := v.(map[string]interface{}); isObject && !wasObject {
+			// the object replaces the value, its null members are dropped
+			// like the missing ones
+			res[k] = v
+			continue
+		}
+		if patch, changed := diff(old, v); changed {
+			res[k] = patch
+		}
+	}
+	return res, len(res) > 0
+}
+
+// apply applies the JSON merge patch on the document.
+func apply(doc, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	res, ok := doc.(map[string]interface{})
+	if !ok {
+		res = map[string]interface{}{}
+	}
+	for k, v := range patchObject {
+		if v == nil {
+			delete(res, k)
+			continue
+		}
+		res[k] = apply(res[k], v)
+	}
+	return res
+}
@@ -0,0 +1,97 @@
+// Package instrumented wraps the stores to record their calls in prometheus
+// metrics: the number, the latency and the errors of every operation,
+// labelled with the backend, so the stores can be compared on the same
+// dashboard.
+package instrumented
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+var labels = []string{"backend", "operation"}
+
+var (
+	calls = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yahtzee_store_calls_total",
+			Help: "The number of calls of the game store",
+		}, labels)
+
+	failures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "yahtzee_store_errors_total",
+			Help: "The number of failed calls of the game store",
+		}, labels)
+
+	latency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "yahtzee_store_latency_seconds",
+			Help:    "The time of the calls of the game store, waiting for the lock included",
+			Buckets: prometheus.DefBuckets,
+		}, labels)
+)
+
+type instrumentedStore struct {
+	next    store.Store
+	backend string
+}
+
+// Store returns `s` with its calls recorded with the `backend` label. The
+// games not found are not counted as errors.
+func Store(s store.Store, backend string) store.Store {
+	return &instrumentedStore{
+		next:    s,
+		backend: backend,
+	}
+}
+
+// observe records the call of the operation started at `start`.
+func (s *instrumentedStore) observe(operation string, start time.Time, err error) {
+	calls.WithLabelValues(s.backend, operation).Inc()
+	latency.WithLabelValues(s.backend, operation).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, store.ErrNotExists) {
+		failures.WithLabelValues(s.backend, operation).Inc()
+	}
+}
+
+func (s *instrumentedStore) Load(id string) (yahtzee.Game, error) {
+	start := time.Now()
+	g, err := s.next.Load(id)
+	s.observe("load", start, err)
+	return g, err
+}
+
+func (s *instrumentedStore) Save(id string, g yahtzee.Game) error {
+	start := time.Now()
+	err := s.next.Save(id, g)
+	s.observe("save", start, err)
+	return err
+}
+
+func (s *instrumentedStore) Lock(id string) (func(), error) {
+	start := time.Now()
+	unlock, err := s.next.Lock(id)
+	s.observe("lock", start, err)
+	return unlock, err
+}
+
+func (s *instrumentedStore) List() ([]string, error) {
+	start := time.Now()
+	ids, err := s.next.List()
+	s.observe("list", start, err)
+	return ids, err
+}
+
+func (s *instrumentedStore) Delete(id string) error {
+	start := time.Now()
+	err := s.next.Delete(id)
+	s.observe("delete", start, err)
+	return err
+}
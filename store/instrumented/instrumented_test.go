@@ -0,0 +1,16 @@
+package instrumented_test
+
+import (
+	"testing"
+
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/embedded"
+	"github.com/akarasz/yahtzee/store/instrumented"
+	"github.com/akarasz/yahtzee/store/storetest"
+)
+
+func TestSuite(t *testing.T) {
+	storetest.Run(t, func() store.Store {
+		return instrumented.Store(embedded.New(), "embedded")
+	})
+}
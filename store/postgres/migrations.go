@@ -0,0 +1,27 @@
+package postgres
+
+// createMigrations creates the table of the migrations already run.
+const createMigrations = `
+CREATE TABLE IF NOT EXISTS migrations (
+	version INTEGER PRIMARY KEY,
+	run_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+// migrations are the changes of the schema in the order they are run. Only
+// append to the list, the index of a migration is its version.
+var migrations = []string{
+	// 1: games and their locks
+	`
+CREATE TABLE games (
+	id         TEXT PRIMARY KEY,
+	game       JSONB NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX games_expires_at ON games (expires_at);
+CREATE TABLE locks (
+	id TEXT PRIMARY KEY
+)`,
+
+	// 2: versions of the games for the read replicas
+	`ALTER TABLE games ADD COLUMN version BIGINT NOT NULL DEFAULT 0`,
+}
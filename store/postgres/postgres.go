@@ -0,0 +1,329 @@
+// Package postgres keeps the games in PostgreSQL, as JSONB documents. The
+// store works with any database/sql driver of PostgreSQL, the server uses
+// github.com/lib/pq.
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+var (
+	// lockTimeout is the longest time Lock waits for another lock on the
+	// game.
+	lockTimeout = 5 * time.Second
+
+	// pinTimeout is the longest time a saved game is read from the primary
+	// while the replica is behind.
+	pinTimeout = time.Minute
+)
+
+type Postgres struct {
+	db         *sql.DB
+	replica    *sql.DB
+	expiration time.Duration
+
+	// pins has the versions of the games saved by the store, which have to
+	// be read from the primary until the replica has them
+	pins map[string]pin
+
+	// locked has the number of locks held on the games by the store, these
+	// are read from the primary
+	locked map[string]int
+
+	mu sync.Mutex
+}
+
+type pin struct {
+	version int64
+	at      time.Time
+}
+
+// Option changes the default settings of the store.
+type Option func(*Postgres)
+
+// WithReplica reads the games from the read replica of the database. The
+// games locked by the store, and the games it saved but the replica doesn't
+// have yet are still read from the primary.
+func WithReplica(db *sql.DB) Option {
+	return func(p *Postgres) {
+		p.replica = db
+	}
+}
+
+// New returns the store on the database. The games expire after
+// `expiration` without a save. Migrate has to be called before the store is
+// used.
+func New(db *sql.DB, expiration time.Duration, opts ...Option) *Postgres {
+	res := &Postgres{
+		db:         db,
+		expiration: expiration,
+		pins:       map[string]pin{},
+		locked:     map[string]int{},
+	}
+
+	for _, opt := range opts {
+		opt(res)
+	}
+
+	return res
+}
+
+// Migrate creates or upgrades the tables of the store. It's safe to call it
+// every time the server starts.
+func (p *Postgres) Migrate() error {
+	if _, err := p.db.Exec(createMigrations); err != nil {
+		return err
+	}
+
+	for version, migration := range migrations {
+		if err := p.migrate(version+1, migration); err != nil {
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
+
+// migrate runs the migration in a transaction, unless it was already run.
+// The migrations table is locked, only one of the starting servers migrates.
+func (p *Postgres) migrate(version int, migration string) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("LOCK TABLE migrations IN EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+
+	var done bool
+	err = tx.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM migrations WHERE version = $1)",
+		version).Scan(&done)
+	if err != nil || done {
+		return err
+	}
+
+	if _, err := tx.Exec(migration); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO migrations (version) VALUES ($1)", version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p *Postgres) Load(id string) (yahtzee.Game, error) {
+	db, pinned := p.route(id)
+
+	raw, version, err := load(db, id)
+	if db == p.replica {
+		switch {
+		case errors.Is(err, store.ErrNotExists), err == nil && version < pinned:
+			// the replica is behind
+			raw, _, err = load(p.db, id)
+		case err == nil:
+			p.unpin(id, version)
+		}
+	}
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+
+	return store.Unmarshal(raw)
+}
+
+func load(db *sql.DB, id string) ([]byte, int64, error) {
+	var (
+		raw     []byte
+		version int64
+	)
+
+	err := db.QueryRow(
+		"SELECT game, version FROM games WHERE id = $1 AND expires_at > now()",
+		id).Scan(&raw, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, store.ErrNotExists
+	}
+	return raw, version, err
+}
+
+// route returns the database to read the game from, and the version of the
+// game the replica has to have to be read from it.
+func (p *Postgres) route(id string) (*sql.DB, int64) {
+	if p.replica == nil {
+		return p.db, 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// the game is about to be changed, a stale read would lose the
+	// changes of the other servers
+	if p.locked[id] > 0 {
+		return p.db, 0
+	}
+	return p.replica, p.pins[id].version
+}
+
+func (p *Postgres) unpin(id string, version int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pin, ok := p.pins[id]; ok && pin.version <= version {
+		delete(p.pins, id)
+	}
+}
+
+func (p *Postgres) Save(id string, g yahtzee.Game) error {
+	raw, err := store.Marshal(g)
+	if err != nil {
+		return err
+	}
+
+	var version int64
+	err = p.db.QueryRow(`
+		INSERT INTO games (id, game, expires_at, version) VALUES ($1, $2, $3, 1)
+		ON CONFLICT (id) DO UPDATE SET game = $2, expires_at = $3, version = games.version + 1
+		RETURNING version`,
+		id, raw, time.Now().Add(p.expiration)).Scan(&version)
+	if err != nil || p.replica == nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for pinned, pin := range p.pins {
+		if now.Sub(pin.at) > pinTimeout {
+			delete(p.pins, pinned)
+		}
+	}
+	p.pins[id] = pin{version: version, at: now}
+
+	return nil
+}
+
+// Delete deletes the row of the game. The game is read from the primary
+// until the replica deletes it too.
+func (p *Postgres) Delete(id string) error {
+	if _, err := p.db.Exec("DELETE FROM games WHERE id = $1", id); err != nil || p.replica == nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// no version of the replica is new enough
+	p.pins[id] = pin{version: math.MaxInt64, at: time.Now()}
+
+	return nil
+}
+
+// Health pings the primary, and the replica when there is one.
+func (p *Postgres) Health() error {
+	if err := p.db.Ping(); err != nil {
+		return err
+	}
+	if p.replica != nil {
+		if err := p.replica.Ping(); err != nil {
+			return fmt.Errorf("replica: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *Postgres) List() ([]string, error) {
+	rows, err := p.db.Query("SELECT id FROM games WHERE expires_at > now() ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+	return res, rows.Err()
+}
+
+// Expire deletes the games expired by `now` with the rows of their locks.
+func (p *Postgres) Expire(now time.Time) ([]string, error) {
+	rows, err := p.db.Query(`
+		WITH expired AS (DELETE FROM games WHERE expires_at < $1 RETURNING id),
+			unlocked AS (DELETE FROM locks WHERE id IN (SELECT id FROM expired))
+		SELECT id FROM expired`,
+		now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+	return res, rows.Err()
+}
+
+// Lock locks the row of the game in the locks table in a transaction, which
+// is committed by the returned unlocker. The row is created for the games
+// locked the first time.
+func (p *Postgres) Lock(id string) (func(), error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockRow(tx, id); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.locked[id]++
+	p.mu.Unlock()
+
+	return func() {
+		p.mu.Lock()
+		p.locked[id]--
+		if p.locked[id] == 0 {
+			delete(p.locked, id)
+		}
+		p.mu.Unlock()
+
+		tx.Commit()
+	}, nil
+}
+
+func lockRow(tx *sql.Tx, id string) error {
+	_, err := tx.Exec(fmt.Sprintf("SET LOCAL lock_timeout = %d", lockTimeout.Milliseconds()))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO locks (id) VALUES ($1) ON CONFLICT (id) DO NOTHING", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("SELECT id FROM locks WHERE id = $1 FOR UPDATE", id)
+	return err
+}
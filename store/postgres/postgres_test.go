@@ -0,0 +1,85 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/postgres"
+	"github.com/akarasz/yahtzee/store/storetest"
+)
+
+func TestSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping store/postgres test")
+	}
+
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "postgres:13-alpine",
+			ExposedPorts: []string{"5432/tcp"},
+			Env: map[string]string{
+				"POSTGRES_PASSWORD": "yahtzee",
+			},
+			WaitingFor: wait.ForListeningPort("5432/tcp"),
+		},
+		Started: true,
+	})
+	require.NoError(t, err)
+	defer container.Terminate(ctx)
+
+	ip, err := container.Host(ctx)
+	require.NoError(t, err)
+	port, err := container.MappedPort(ctx, "5432")
+	require.NoError(t, err)
+
+	db, err := sql.Open("postgres", fmt.Sprintf(
+		"postgres://postgres:yahtzee@%s:%s/postgres?sslmode=disable", ip, port.Port()))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// the port is open before the database accepts connections
+	require.Eventually(t, func() bool {
+		return db.Ping() == nil
+	}, 30*time.Second, 100*time.Millisecond)
+
+	subject := postgres.New(db, 5*time.Minute)
+	require.NoError(t, subject.Migrate())
+
+	// migrating again is a no-op
+	require.NoError(t, subject.Migrate())
+
+	// every test starts on an empty database
+	newStore := func(s *postgres.Postgres) func() store.Store {
+		return func() store.Store {
+			_, err := db.Exec("TRUNCATE games, locks")
+			require.NoError(t, err)
+			return s
+		}
+	}
+	storetest.Run(t, newStore(subject))
+
+	// the database is its own replica, the routing has to keep the
+	// store conformant
+	storetest.Run(t, newStore(postgres.New(db, 5*time.Minute, postgres.WithReplica(db))))
+
+	newStore(subject)()
+	require.NoError(t, subject.Save("aaaaa", *yahtzee.NewGame()))
+	expired, err := subject.Expire(time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+	expired, err = subject.Expire(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aaaaa"}, expired)
+}
@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/bsm/redislock"
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultLease is the time a lock is held for without being renewed.
+const DefaultLease = 5 * time.Second
+
+// ErrLockTimeout is returned when the game stays locked by someone else for
+// a whole lease.
+var ErrLockTimeout = errors.New("game is locked")
+
+// Locker locks the games with leases in redis, so the replicas of the server
+// sharing the redis take turns on a game. The lease of a lock is renewed
+// while it's held, and it expires when its holder dies without unlocking.
+type Locker struct {
+	locker *redislock.Client
+	lease  time.Duration
+}
+
+// NewLocker returns the locker on the redis of the client with leases of
+// `lease`.
+func NewLocker(client *redis.Client, lease time.Duration) *Locker {
+	return &Locker{
+		locker: redislock.New(client),
+		lease:  lease,
+	}
+}
+
+// Lock waits for the lock of the game for at most a lease, and keeps
+// renewing it in the background until the returned unlocker is called.
+func (l *Locker) Lock(id string) (func(), error) {
+	lock, err := l.locker.Obtain(ctx, "lock:"+id, l.lease, &redislock.Options{
+		RetryStrategy: lockBackoff,
+	})
+	if errors.Is(err, redislock.ErrNotObtained) {
+		return nil, ErrLockTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go l.renew(lock, stop, done)
+
+	return func() {
+		close(stop)
+		<-done
+		if err := lock.Release(ctx); err != nil {
+			log.Printf("unlock %s: %v", id, err)
+		}
+	}, nil
+}
+
+// renew renews the lease of the lock at half of its time until `stop` is
+// closed. A lock lost to an expired lease is not taken again.
+func (l *Locker) renew(lock *redislock.Lock, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(l.lease / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := lock.Refresh(ctx, l.lease, nil); err != nil {
+				log.Printf("renew %s: %v", lock.Key(), err)
+				return
+			}
+		}
+	}
+}
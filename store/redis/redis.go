@@ -3,7 +3,10 @@ package redis
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/bsm/redislock"
@@ -17,73 +20,326 @@ import (
 
 var ctx = context.Background()
 
-var (
-	lockExpiration = 5 * time.Second
-	lockBackoff    = redislock.LinearBackoff(50 * time.Millisecond)
-)
+var lockBackoff = redislock.LinearBackoff(50 * time.Millisecond)
 
 type Redis struct {
 	client     *redis.Client
-	locker     *redislock.Client
+	locker     *Locker
 	expiration time.Duration
 }
 
-func New(client *redis.Client, expiration time.Duration) store.Store {
-	promauto.NewGaugeFunc(
-		prometheus.GaugeOpts{
-			Name: "yahtzee_redis_store_size",
-			Help: "The total number of games in the redis store",
-		},
-		func() float64 {
-			return float64(client.DBSize(ctx).Val())
-		})
+// metricsOnce registers the metrics of the first store created, more stores
+// would panic on the duplicate registration.
+var metricsOnce sync.Once
+
+func New(client *redis.Client, expiration time.Duration) *Redis {
+	metricsOnce.Do(func() {
+		promauto.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "yahtzee_redis_store_size",
+				Help: "The total number of games in the redis store",
+			},
+			func() float64 {
+				return float64(client.DBSize(ctx).Val())
+			})
+	})
 
 	return &Redis{
 		client:     client,
-		locker:     redislock.New(client),
+		locker:     NewLocker(client, DefaultLease),
 		expiration: expiration,
 	}
 }
 
 func (r *Redis) Load(id string) (yahtzee.Game, error) {
-	var res yahtzee.Game
-
 	raw, err := r.client.Get(ctx, "game:"+id).Bytes()
 	if err != nil {
 		return yahtzee.Game{}, store.ErrNotExists
 	}
 
+	return store.Unmarshal(raw)
+}
+
+func (r *Redis) Save(id string, g yahtzee.Game) error {
+	raw, err := store.Marshal(g)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Set(ctx, "game:"+id, string(raw), r.expiration).Err()
+}
+
+func (r *Redis) Delete(id string) error {
+	return r.client.Del(ctx, "game:"+id).Err()
+}
+
+// Health pings the server.
+func (r *Redis) Health() error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *Redis) List() ([]string, error) {
+	res := []string{}
+
+	iter := r.client.Scan(ctx, 0, "game:*", 100).Iterator()
+	for iter.Next(ctx) {
+		res = append(res, strings.TrimPrefix(iter.Val(), "game:"))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(res)
+	return res, nil
+}
+
+func (r *Redis) LoadMatch(id string) (yahtzee.Match, error) {
+	var res yahtzee.Match
+
+	raw, err := r.client.Get(ctx, "match:"+id).Bytes()
+	if err != nil {
+		return yahtzee.Match{}, store.ErrNotExists
+	}
+
 	err = json.Unmarshal(raw, &res)
 
 	return res, err
 }
 
-func (r *Redis) Save(id string, g yahtzee.Game) error {
-	raw, err := json.Marshal(g)
+func (r *Redis) SaveMatch(id string, m yahtzee.Match) error {
+	raw, err := json.Marshal(m)
 	if err != nil {
 		return err
 	}
 
-	return r.client.Set(ctx, "game:"+id, string(raw), r.expiration).Err()
+	return r.client.Set(ctx, "match:"+id, string(raw), r.expiration).Err()
 }
 
-func (r *Redis) Lock(id string) (func(), error) {
-	lock, err := r.locker.Obtain(
-		context.Background(),
-		"lock:"+id,
-		lockExpiration,
-		&redislock.Options{
-			RetryStrategy: lockBackoff,
-		})
+func (r *Redis) LoadNotes(gameID string, u yahtzee.User) (yahtzee.Notes, error) {
+	var res yahtzee.Notes
+
+	raw, err := r.client.Get(ctx, notesKey(gameID, u)).Bytes()
+	if err != nil {
+		return yahtzee.Notes{}, store.ErrNotExists
+	}
+
+	err = json.Unmarshal(raw, &res)
+
+	return res, err
+}
+
+func (r *Redis) SaveNotes(gameID string, u yahtzee.User, n yahtzee.Notes) error {
+	raw, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Set(ctx, notesKey(gameID, u), string(raw), r.expiration).Err()
+}
+
+func notesKey(gameID string, u yahtzee.User) string {
+	return "notes:" + gameID + ":" + string(u)
+}
+
+// addGameScript appends the game to the list of the user when it's not in it
+// yet, and refreshes the expiration of the list.
+var addGameScript = redis.NewScript(`
+local games = redis.call("LRANGE", KEYS[1], 0, -1)
+local found = false
+for _, g in ipairs(games) do
+	if g == ARGV[1] then
+		found = true
+	end
+end
+if not found then
+	redis.call("RPUSH", KEYS[1], ARGV[1])
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[2])
+return 1
+`)
+
+func (r *Redis) AddGame(u yahtzee.User, id string) error {
+	return addGameScript.Run(
+		ctx,
+		r.client,
+		[]string{"games:" + string(u)},
+		id,
+		r.expiration.Milliseconds()).Err()
+}
+
+func (r *Redis) Games(u yahtzee.User) ([]string, error) {
+	return r.client.LRange(ctx, "games:"+string(u), 0, -1).Result()
+}
+
+// unlockScript appends the achievement to the list of the user when it's not
+// in it yet, and tells if it was appended.
+var unlockScript = redis.NewScript(`
+local unlocked = redis.call("LRANGE", KEYS[1], 0, -1)
+for _, a in ipairs(unlocked) do
+	if a == ARGV[1] then
+		return 0
+	end
+end
+redis.call("RPUSH", KEYS[1], ARGV[1])
+return 1
+`)
+
+func (r *Redis) Unlock(u yahtzee.User, a yahtzee.Achievement) (bool, error) {
+	added, err := unlockScript.Run(
+		ctx,
+		r.client,
+		[]string{"achievements:" + string(u)},
+		string(a)).Int()
+	return added == 1, err
+}
+
+func (r *Redis) Achievements(u yahtzee.User) ([]yahtzee.Achievement, error) {
+	raw, err := r.client.LRange(ctx, "achievements:"+string(u), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]yahtzee.Achievement, len(raw))
+	for i, a := range raw {
+		res[i] = yahtzee.Achievement(a)
+	}
+	return res, nil
+}
+
+func (r *Redis) SetClock(gameID string, deadline int64) error {
+	if deadline == 0 {
+		return r.client.HDel(ctx, "clocks", gameID).Err()
+	}
+	return r.client.HSet(ctx, "clocks", gameID, deadline).Err()
+}
 
+func (r *Redis) Clocks() (map[string]int64, error) {
+	raw, err := r.client.HGetAll(ctx, "clocks").Result()
 	if err != nil {
-		if err == redislock.ErrNotObtained {
-			log.Println("could not obtain lock")
-		} else if err != nil {
-			log.Fatalln(err)
+		return nil, err
+	}
+
+	res := make(map[string]int64, len(raw))
+	for gameID, deadline := range raw {
+		if res[gameID], err = strconv.ParseInt(deadline, 10, 64); err != nil {
+			return nil, err
 		}
+	}
+	return res, nil
+}
+
+func (r *Redis) DailyGame(day string, u yahtzee.User) (string, error) {
+	gameID, err := r.client.HGet(ctx, "daily-games:"+day, string(u)).Result()
+	if err == redis.Nil {
+		return "", store.ErrNotExists
+	}
+
+	return gameID, err
+}
+
+func (r *Redis) StartDaily(day string, u yahtzee.User, gameID string) error {
+	return r.setDaily("daily-games:"+day, string(u), gameID)
+}
+
+func (r *Redis) RecordDaily(day string, u yahtzee.User, score int) error {
+	return r.setDaily("daily-scores:"+day, string(u), score)
+}
+
+// setDaily sets the field of the hash of the day, and refreshes the
+// expiration of the hash.
+func (r *Redis) setDaily(key string, field string, value interface{}) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, field, value)
+		pipe.PExpire(ctx, key, r.expiration)
+		return nil
+	})
+	return err
+}
+
+func (r *Redis) DailyScores(day string) (map[yahtzee.User]int, error) {
+	raw, err := r.client.HGetAll(ctx, "daily-scores:"+day).Result()
+	if err != nil {
 		return nil, err
 	}
 
-	return func() { lock.Release(context.Background()) }, nil
+	res := make(map[yahtzee.User]int, len(raw))
+	for u, score := range raw {
+		if res[yahtzee.User(u)], err = strconv.Atoi(score); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (r *Redis) AddActivity(gameID string, a yahtzee.Activity) error {
+	return r.AddActivities(gameID, []yahtzee.Activity{a})
+}
+
+func (r *Redis) AddActivities(gameID string, as []yahtzee.Activity) error {
+	raw := make([]interface{}, len(as))
+	for i, a := range as {
+		entry, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		raw[i] = string(entry)
+	}
+
+	key := "activities:" + gameID
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, raw...)
+		pipe.PExpire(ctx, key, r.expiration)
+		return nil
+	})
+	return err
+}
+
+func (r *Redis) Activities(gameID string) ([]yahtzee.Activity, error) {
+	raw, err := r.client.LRange(ctx, "activities:"+gameID, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]yahtzee.Activity, len(raw))
+	for i, a := range raw {
+		if err := json.Unmarshal([]byte(a), &res[i]); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (r *Redis) Visit(u yahtzee.User, at int64) (int64, error) {
+	res, err := r.client.GetSet(ctx, "visit:"+string(u), at).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	return res, err
+}
+
+func (r *Redis) Best(u yahtzee.User) (int, error) {
+	best, err := r.client.Get(ctx, "best:"+string(u)).Int()
+	if err == redis.Nil {
+		return 0, store.ErrNotExists
+	}
+
+	return best, err
+}
+
+// recordScript sets the best score when the new one is higher.
+var recordScript = redis.NewScript(`
+local best = tonumber(redis.call("GET", KEYS[1]))
+if not best or best < tonumber(ARGV[1]) then
+	redis.call("SET", KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+func (r *Redis) Record(u yahtzee.User, score int) error {
+	return recordScript.Run(ctx, r.client, []string{"best:" + string(u)}, score).Err()
+}
+
+func (r *Redis) Lock(id string) (func(), error) {
+	return r.locker.Lock(id)
 }
@@ -8,12 +8,12 @@ import (
 
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/require"
-	"github.com/stretchr/testify/suite"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 
 	"github.com/akarasz/yahtzee/store"
 	redis_store "github.com/akarasz/yahtzee/store/redis"
+	"github.com/akarasz/yahtzee/store/storetest"
 )
 
 func TestSuite(t *testing.T) {
@@ -43,6 +43,54 @@ func TestSuite(t *testing.T) {
 	})
 	defer rdb.Close()
 
-	s := redis_store.New(rdb, 5*time.Minute)
-	suite.Run(t, &store.TestSuite{Subject: s})
+	// every test starts on an empty database
+	newStore := func() *redis_store.Redis {
+		require.NoError(t, rdb.FlushDB(ctx).Err())
+		return redis_store.New(rdb, 5*time.Minute)
+	}
+	storetest.Run(t, func() store.Store {
+		return newStore()
+	})
+	storetest.RunBestScores(t, func() store.BestScores {
+		return newStore()
+	})
+	storetest.RunMatches(t, func() store.Matches {
+		return newStore()
+	})
+	storetest.RunUserGames(t, func() store.UserGames {
+		return newStore()
+	})
+	storetest.RunNotes(t, func() store.Notes {
+		return newStore()
+	})
+	storetest.RunAchievements(t, func() store.Achievements {
+		return newStore()
+	})
+	storetest.RunClocks(t, func() store.Clocks {
+		return newStore()
+	})
+	storetest.RunDailies(t, func() store.Dailies {
+		return newStore()
+	})
+	storetest.RunActivities(t, func() store.Activities {
+		return newStore()
+	})
+
+	t.Run("Locker", func(t *testing.T) {
+		lease := 200 * time.Millisecond
+		alice, bob := redis_store.NewLocker(rdb, lease), redis_store.NewLocker(rdb, lease)
+
+		unlock, err := alice.Lock("aaaaa")
+		require.NoError(t, err)
+
+		// the lease is renewed while it's held
+		time.Sleep(2 * lease)
+		_, err = bob.Lock("aaaaa")
+		require.Exactly(t, redis_store.ErrLockTimeout, err)
+
+		unlock()
+		unlock, err = bob.Lock("aaaaa")
+		require.NoError(t, err)
+		unlock()
+	})
 }
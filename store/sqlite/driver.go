@@ -0,0 +1,4 @@
+package sqlite
+
+// the cgo driver of SQLite, the store needs a C compiler to build
+import _ "github.com/mattn/go-sqlite3"
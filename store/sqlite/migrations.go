@@ -0,0 +1,20 @@
+package sqlite
+
+// migrations are the changes of the schema in the order they are run. Only
+// append to the list, the index of a migration is its version, kept in the
+// user_version of the file.
+var migrations = []string{
+	// 1: games and their locks
+	`
+CREATE TABLE games (
+	id         TEXT PRIMARY KEY,
+	game       TEXT NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+CREATE INDEX games_expires_at ON games (expires_at);
+CREATE TABLE locks (
+	id    TEXT PRIMARY KEY,
+	owner TEXT NOT NULL,
+	until INTEGER NOT NULL
+)`,
+}
@@ -0,0 +1,259 @@
+// Package sqlite keeps the games in a local SQLite file, so a hobby server
+// keeps its games without running any other service. The store works with
+// any database/sql driver of SQLite; Open uses github.com/mattn/go-sqlite3,
+// so the servers using it are built with cgo.
+//
+// The file is in WAL mode, so the readers don't wait for the writers, and the
+// writers wait for each other for the busy timeout instead of failing. The
+// games are locked with the rows of the locks table, so the servers sharing
+// the file take turns on a game.
+package sqlite
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// DriverName is the name of the database/sql driver Open uses.
+const DriverName = "sqlite3"
+
+// ErrLockTimeout is returned when the game stays locked by someone else.
+var ErrLockTimeout = errors.New("game is locked")
+
+var (
+	// busyTimeout is the longest time a write waits for the other writers
+	// of the file.
+	busyTimeout = 5 * time.Second
+
+	lockExpiration = 5 * time.Second
+	lockTimeout    = 5 * time.Second
+	lockBackoff    = 20 * time.Millisecond
+)
+
+type SQLite struct {
+	db         *sql.DB
+	expiration time.Duration
+}
+
+// Open opens the file of the store at `path` in WAL mode with the busy
+// timeout, creating and migrating it when needed. The games expire after
+// `expiration` without a save.
+func Open(path string, expiration time.Duration) (*SQLite, error) {
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=%d&_journal_mode=WAL&_synchronous=NORMAL",
+		path, busyTimeout.Milliseconds())
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	res := New(db, expiration)
+	if err := res.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return res, nil
+}
+
+// New returns the store on the database. The games expire after
+// `expiration` without a save. Migrate has to be called before the store is
+// used.
+func New(db *sql.DB, expiration time.Duration) *SQLite {
+	return &SQLite{
+		db:         db,
+		expiration: expiration,
+	}
+}
+
+// Close closes the database of the store.
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// Health reads from the database, so a deleted or a broken file is found.
+func (s *SQLite) Health() error {
+	var version int
+	return s.db.QueryRow("PRAGMA user_version").Scan(&version)
+}
+
+// Migrate creates or upgrades the tables of the store. It's safe to call it
+// every time the server starts.
+func (s *SQLite) Migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for ; version < len(migrations); version++ {
+		if err := s.migrate(version+1, migrations[version]); err != nil {
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
+
+// migrate runs the migration and sets the version of the file in a
+// transaction.
+func (s *SQLite) migrate(version int, migration string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migration); err != nil {
+		return err
+	}
+	// the pragma can't have parameters
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) Load(id string) (yahtzee.Game, error) {
+	var raw []byte
+	err := s.db.QueryRow(
+		"SELECT game FROM games WHERE id = ? AND expires_at > ?",
+		id, time.Now().UnixNano()).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return yahtzee.Game{}, store.ErrNotExists
+	}
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+
+	return store.Unmarshal(raw)
+}
+
+func (s *SQLite) Save(id string, g yahtzee.Game) error {
+	raw, err := store.Marshal(g)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO games (id, game, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET game = excluded.game, expires_at = excluded.expires_at`,
+		id, string(raw), time.Now().Add(s.expiration).UnixNano())
+	return err
+}
+
+func (s *SQLite) Delete(id string) error {
+	_, err := s.db.Exec("DELETE FROM games WHERE id = ?", id)
+	return err
+}
+
+func (s *SQLite) List() ([]string, error) {
+	rows, err := s.db.Query(
+		"SELECT id FROM games WHERE expires_at > ? ORDER BY id",
+		time.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+	return res, rows.Err()
+}
+
+// Expire deletes the games expired by `now` with the rows of their locks.
+// SQLite doesn't expire anything on its own.
+func (s *SQLite) Expire(now time.Time) ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id FROM games WHERE expires_at < ?", now.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		res = append(res, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range res {
+		if _, err := tx.Exec("DELETE FROM games WHERE id = ?", id); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("DELETE FROM locks WHERE id = ?", id); err != nil {
+			return nil, err
+		}
+	}
+	return res, tx.Commit()
+}
+
+// Lock takes the row of the game in the locks table with a random owner,
+// when nobody holds it. The write waits for the other writers of the file
+// for the busy timeout, the game held by someone else is tried again until
+// the lock timeout. The lock is released when it's not unlocked in time.
+func (s *SQLite) Lock(id string) (func(), error) {
+	owner, err := newOwner()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		// the free lock is taken over, or a new one is inserted; nothing
+		// changes while someone holds it
+		now := time.Now()
+		res, err := s.db.Exec(`
+			INSERT INTO locks (id, owner, until) VALUES (?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET owner = excluded.owner, until = excluded.until
+			WHERE locks.until < ?`,
+			id, owner, now.Add(lockExpiration).UnixNano(), now.UnixNano())
+		if err != nil {
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, err
+		} else if n == 1 {
+			break
+		}
+		if now.After(deadline) {
+			return nil, ErrLockTimeout
+		}
+		time.Sleep(lockBackoff)
+	}
+
+	return func() {
+		if _, err := s.db.Exec("DELETE FROM locks WHERE id = ? AND owner = ?", id, owner); err != nil {
+			log.Printf("unlock %s: %v", id, err)
+		}
+	}, nil
+}
+
+func newOwner() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
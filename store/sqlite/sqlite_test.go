@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+	"github.com/akarasz/yahtzee/store/storetest"
+)
+
+func tempFile(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "yahtzee")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	return filepath.Join(dir, "games.db")
+}
+
+func TestSuite(t *testing.T) {
+	storetest.Run(t, func() store.Store {
+		s, err := Open(tempFile(t), 5*time.Minute)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			s.Close()
+		})
+		return s
+	})
+}
+
+func TestOpen(t *testing.T) {
+	path := tempFile(t)
+
+	s, err := Open(path, 5*time.Minute)
+	require.NoError(t, err)
+
+	var mode string
+	require.NoError(t, s.db.QueryRow("PRAGMA journal_mode").Scan(&mode))
+	assert.Exactly(t, "wal", mode)
+	var timeout int64
+	require.NoError(t, s.db.QueryRow("PRAGMA busy_timeout").Scan(&timeout))
+	assert.Exactly(t, busyTimeout.Milliseconds(), timeout)
+
+	g := yahtzee.NewGame()
+	g.Seed = 42
+	require.NoError(t, s.Save("aaaaa", *g))
+	require.NoError(t, s.Close())
+
+	// the migrations are not run again
+	s, err = Open(path, 5*time.Minute)
+	require.NoError(t, err)
+	defer s.Close()
+
+	if got, err := s.Load("aaaaa"); assert.NoError(t, err) {
+		assert.Exactly(t, *g, got)
+	}
+}
+
+func TestExpiration(t *testing.T) {
+	s, err := Open(tempFile(t), -time.Second)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Save("aaaaa", *yahtzee.NewGame()))
+	_, err = s.Load("aaaaa")
+	assert.Exactly(t, store.ErrNotExists, err)
+	ids, err := s.List()
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	expired, err := s.Expire(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aaaaa"}, expired)
+
+	expired, err = s.Expire(time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+}
+
+func TestLockTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { lockTimeout = timeout }(lockTimeout)
+	lockTimeout = 100 * time.Millisecond
+
+	path := tempFile(t)
+	s, err := Open(path, time.Hour)
+	require.NoError(t, err)
+	defer s.Close()
+	// another server on the same file
+	other, err := Open(path, time.Hour)
+	require.NoError(t, err)
+	defer other.Close()
+
+	unlock, err := s.Lock("aaaaa")
+	require.NoError(t, err)
+
+	_, err = other.Lock("aaaaa")
+	assert.Exactly(t, ErrLockTimeout, err)
+
+	unlock()
+	unlock, err = other.Lock("aaaaa")
+	require.NoError(t, err)
+	unlock()
+}
+
+func TestUpgrade(t *testing.T) {
+	s, err := Open(tempFile(t), time.Hour)
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, err = s.db.Exec(
+		"INSERT INTO games (id, game, expires_at) VALUES (?, ?, ?)",
+		"aaaaa", `{"Players":[],"Dices":[{"Value":1,"Locked":false}],"Seed":7}`,
+		time.Now().Add(time.Hour).UnixNano())
+	require.NoError(t, err)
+
+	got, err := s.Load("aaaaa")
+	require.NoError(t, err)
+	assert.Exactly(t, yahtzee.NumberOfSides, got.Sides)
+	assert.Exactly(t, int64(7), got.Seed)
+}
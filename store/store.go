@@ -1,10 +1,9 @@
 package store
 
 import (
+	"encoding/json"
 	"errors"
-	"sync"
-
-	"github.com/stretchr/testify/suite"
+	"time"
 
 	"github.com/akarasz/yahtzee"
 )
@@ -24,100 +23,196 @@ type Store interface {
 
 	// Lock reserves the `id` so another locking on the same would block.
 	Lock(id string) (func(), error)
+
+	// List returns the IDs of the games in the store in order.
+	List() ([]string, error)
+
+	// Delete removes the game from the store, it's not an error when it
+	// doesn't exist.
+	Delete(id string) error
+}
+
+// BestScores contains the best score of every user.
+type BestScores interface {
+	// Best returns the best score of the user.
+	Best(u yahtzee.User) (int, error)
+
+	// Record saves the score when it's better than the best of the user.
+	Record(u yahtzee.User, score int) error
+}
+
+// Matches contains match series by their IDs.
+type Matches interface {
+	// LoadMatch returns a match from the store.
+	LoadMatch(id string) (yahtzee.Match, error)
+
+	// SaveMatch adds the match to the store.
+	SaveMatch(id string, m yahtzee.Match) error
+}
+
+// UserGames contains the games every user joined.
+type UserGames interface {
+	// AddGame records that the user joined the game.
+	AddGame(u yahtzee.User, id string) error
+
+	// Games returns the IDs of the games the user joined, in the order they
+	// were added.
+	Games(u yahtzee.User) ([]string, error)
+}
+
+// Notes contains the private notes of the players on the games.
+type Notes interface {
+	// LoadNotes returns the notes of the user on the game.
+	LoadNotes(gameID string, u yahtzee.User) (yahtzee.Notes, error)
+
+	// SaveNotes replaces the notes of the user on the game.
+	SaveNotes(gameID string, u yahtzee.User, n yahtzee.Notes) error
+}
+
+// Achievements contains the achievements unlocked by every user.
+type Achievements interface {
+	// Unlock records the achievement of the user, and tells if it's new.
+	Unlock(u yahtzee.User, a yahtzee.Achievement) (bool, error)
+
+	// Achievements returns the achievements of the user in the order they
+	// were unlocked.
+	Achievements(u yahtzee.User) ([]yahtzee.Achievement, error)
+}
+
+// Clocks contains the deadlines of the running shot clocks, so their timers
+// can be started again after a restart.
+type Clocks interface {
+	// SetClock records the deadline of the game in unix milliseconds, zero
+	// removes it.
+	SetClock(gameID string, deadline int64) error
+
+	// Clocks returns the deadlines of the games with a running clock.
+	Clocks() (map[string]int64, error)
+}
+
+// Dailies contains the games and the results of the daily challenges by
+// their days.
+type Dailies interface {
+	// DailyGame returns the ID of the game of the user on the day.
+	DailyGame(day string, u yahtzee.User) (string, error)
+
+	// StartDaily records the game of the user on the day.
+	StartDaily(day string, u yahtzee.User, gameID string) error
+
+	// RecordDaily saves the final score of the user on the day.
+	RecordDaily(day string, u yahtzee.User, score int) error
+
+	// DailyScores returns the final scores of the users on the day.
+	DailyScores(day string) (map[yahtzee.User]int, error)
+}
+
+// Activities contains the log of every game and the last visits of the users.
+type Activities interface {
+	// AddActivity appends the entry to the log of the game.
+	AddActivity(gameID string, a yahtzee.Activity) error
+
+	// Activities returns the log of the game in the order it was added.
+	Activities(gameID string) ([]yahtzee.Activity, error)
+
+	// Visit records the visit of the user at `at` in unix milliseconds, and
+	// returns the time of the previous visit, zero for the first one.
+	Visit(u yahtzee.User, at int64) (int64, error)
+}
+
+// ActivityBatches can append many entries to the log of a game in one write.
+type ActivityBatches interface {
+	// AddActivities appends the entries to the log of the game in order.
+	AddActivities(gameID string, as []yahtzee.Activity) error
+}
+
+// Expirer can delete the games nobody saved for a while.
+type Expirer interface {
+	// Expire deletes the games expired by `now`, and returns their IDs.
+	Expire(now time.Time) ([]string, error)
+}
+
+// Healther can tell if a store works, for the readiness checks of the
+// server.
+type Healther interface {
+	// Health returns why the store can't be used, nil when it can.
+	Health() error
+}
+
+// Archive keeps the finished games out of the store of the running ones.
+type Archive interface {
+	// ArchiveGame adds the game to the archive.
+	ArchiveGame(id string, g yahtzee.Game) error
+
+	// LoadArchived returns a game from the archive.
+	LoadArchived(id string) (yahtzee.Game, error)
+}
+
+// Change is an entry of the log of a game: the JSON merge patch (RFC 7386)
+// turning the game of the previous entry into the saved one.
+type Change struct {
+	// Seq is the number of the change in the log of the game, from one
+	Seq int
+
+	// At is the time of the save in unix milliseconds
+	At int64
+
+	Patch json.RawMessage
+}
+
+// Snapshot is the game as it was after a change in its log.
+type Snapshot struct {
+	Seq  int
+	Game json.RawMessage
+}
+
+// Journal keeps the games as the append-only logs of their changes, with
+// snapshots of them so they're not rebuilt from the first change.
+type Journal interface {
+	// AppendChange adds the change to the end of the log of the game.
+	AppendChange(id string, c Change) error
+
+	// Changes returns the changes of the game after the `after` one in order.
+	Changes(id string, after int) ([]Change, error)
+
+	// SaveSnapshot replaces the snapshot of the game.
+	SaveSnapshot(id string, s Snapshot) error
+
+	// LoadSnapshot returns the latest snapshot of the game.
+	LoadSnapshot(id string) (Snapshot, error)
+
+	// Journals returns the IDs of the games with a log in order.
+	Journals() ([]string, error)
+
+	// DeleteJournal removes the log and the snapshot of the game, it's not
+	// an error when it doesn't have them.
+	DeleteJournal(id string) error
+}
+
+// Replayer rebuilds the games as they were saved, like the eventlog store
+// from the logs of their changes.
+type Replayer interface {
+	// At returns the game as it was saved with the change `seq` of its log.
+	At(id string, seq int) (yahtzee.Game, error)
+}
+
+// Locker reserves the games by their IDs, like the Lock of the stores.
+type Locker interface {
+	// Lock reserves the `id` so another locking on the same would block.
+	Lock(id string) (func(), error)
+}
+
+// WithLocker returns the store with its games locked by `l` instead of its
+// own Lock, like a distributed lock for a store locking in memory. Only the
+// methods of Store are kept.
+func WithLocker(s Store, l Locker) Store {
+	return &lockedStore{Store: s, locker: l}
+}
+
+type lockedStore struct {
+	Store
+	locker Locker
 }
 
-type TestSuite struct {
-	suite.Suite
-
-	Subject Store
-}
-
-func (ts *TestSuite) TestLoad() {
-	s := ts.Subject
-
-	_, err := s.Load("aaaaa")
-	ts.Exactly(ErrNotExists, err)
-
-	saved := *ts.newAdvancedGame()
-
-	ts.Require().NoError(s.Save("aaaaa", saved))
-
-	if got, err := s.Load("aaaaa"); ts.NoError(err) {
-		ts.Exactly(saved, got)
-	}
-}
-
-func (ts *TestSuite) TestSave() {
-	s := ts.Subject
-
-	empty := *yahtzee.NewGame()
-	ts.NoError(s.Save("bbbbb", empty))
-
-	if got, err := s.Load("bbbbb"); ts.NoError(err) {
-		ts.Exactly(empty, got)
-	}
-
-	advanced := *ts.newAdvancedGame()
-	ts.NoError(s.Save("bbbbb", advanced))
-
-	if got, err := s.Load("bbbbb"); ts.NoError(err) {
-		ts.Exactly(advanced, got)
-	}
-}
-
-func (ts *TestSuite) TestRace() {
-	s := ts.Subject
-	wg := &sync.WaitGroup{}
-
-	for i := 0; i < 3; i++ {
-		wg.Add(1)
-		go func() {
-			unlock, err := s.Lock("ccccc")
-			ts.Require().NoError(err)
-
-			s.Save("ccccc", *ts.newAdvancedGame())
-			s.Load("ccccc")
-
-			unlock()
-			wg.Done()
-		}()
-	}
-	wg.Wait()
-}
-
-func (ts *TestSuite) newAdvancedGame() *yahtzee.Game {
-	return &yahtzee.Game{
-		Players: []*yahtzee.Player{
-			{
-				User: yahtzee.User("Alice"),
-				ScoreSheet: map[yahtzee.Category]int{
-					yahtzee.Twos:      6,
-					yahtzee.Fives:     15,
-					yahtzee.FullHouse: 25,
-				},
-			}, {
-				User: yahtzee.User("Bob"),
-				ScoreSheet: map[yahtzee.Category]int{
-					yahtzee.Threes:      6,
-					yahtzee.FourOfAKind: 16,
-				},
-			}, {
-				User: yahtzee.User("Carol"),
-				ScoreSheet: map[yahtzee.Category]int{
-					yahtzee.Twos:          6,
-					yahtzee.SmallStraight: 30,
-				},
-			},
-		},
-		Dices: []*yahtzee.Dice{
-			{Value: 3, Locked: true},
-			{Value: 2, Locked: false},
-			{Value: 3, Locked: true},
-			{Value: 1, Locked: false},
-			{Value: 5, Locked: false},
-		},
-		Round:         5,
-		CurrentPlayer: 1,
-		RollCount:     1,
-	}
+func (s *lockedStore) Lock(id string) (func(), error) {
+	return s.locker.Lock(id)
 }
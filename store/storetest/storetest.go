@@ -0,0 +1,646 @@
+// Package storetest has the conformance tests every store implementation has
+// to pass.
+package storetest
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+// Run runs the conformance tests on the stores created by `newStore`. A new
+// store is created for every test.
+func Run(t *testing.T, newStore func() store.Store) {
+	suite.Run(t, &storeSuite{newStore: newStore})
+}
+
+// RunBestScores runs the conformance tests on the best scores created by
+// `newBestScores`. A new one is created for every test.
+func RunBestScores(t *testing.T, newBestScores func() store.BestScores) {
+	suite.Run(t, &bestScoresSuite{newBestScores: newBestScores})
+}
+
+// RunMatches runs the conformance tests on the matches created by
+// `newMatches`. A new one is created for every test.
+func RunMatches(t *testing.T, newMatches func() store.Matches) {
+	suite.Run(t, &matchesSuite{newMatches: newMatches})
+}
+
+// RunUserGames runs the conformance tests on the user games created by
+// `newUserGames`. A new one is created for every test.
+func RunUserGames(t *testing.T, newUserGames func() store.UserGames) {
+	suite.Run(t, &userGamesSuite{newUserGames: newUserGames})
+}
+
+// RunNotes runs the conformance tests on the notes created by
+// `newNotes`. A new one is created for every test.
+func RunNotes(t *testing.T, newNotes func() store.Notes) {
+	suite.Run(t, &notesSuite{newNotes: newNotes})
+}
+
+// RunAchievements runs the conformance tests on the achievements created by
+// `newAchievements`. A new one is created for every test.
+func RunAchievements(t *testing.T, newAchievements func() store.Achievements) {
+	suite.Run(t, &achievementsSuite{newAchievements: newAchievements})
+}
+
+// RunClocks runs the conformance tests on the clocks created by `newClocks`.
+// A new one is created for every test.
+func RunClocks(t *testing.T, newClocks func() store.Clocks) {
+	suite.Run(t, &clocksSuite{newClocks: newClocks})
+}
+
+// RunDailies runs the conformance tests on the dailies created by
+// `newDailies`. A new one is created for every test.
+func RunDailies(t *testing.T, newDailies func() store.Dailies) {
+	suite.Run(t, &dailiesSuite{newDailies: newDailies})
+}
+
+// RunActivities runs the conformance tests on the activities created by
+// `newActivities`. A new one is created for every test.
+func RunActivities(t *testing.T, newActivities func() store.Activities) {
+	suite.Run(t, &activitiesSuite{newActivities: newActivities})
+}
+
+// RunArchive runs the conformance tests on the archives created by
+// `newArchive`. A new one is created for every test.
+func RunArchive(t *testing.T, newArchive func() store.Archive) {
+	suite.Run(t, &archiveSuite{newArchive: newArchive})
+}
+
+// RunJournal runs the conformance tests on the journals created by
+// `newJournal`. A new one is created for every test.
+func RunJournal(t *testing.T, newJournal func() store.Journal) {
+	suite.Run(t, &journalSuite{newJournal: newJournal})
+}
+
+type storeSuite struct {
+	suite.Suite
+
+	newStore func() store.Store
+	subject  store.Store
+}
+
+func (ts *storeSuite) SetupTest() {
+	ts.subject = ts.newStore()
+}
+
+func (ts *storeSuite) TestLoad() {
+	s := ts.subject
+
+	_, err := s.Load("aaaaa")
+	ts.True(errors.Is(err, store.ErrNotExists))
+
+	saved := *newAdvancedGame()
+
+	ts.Require().NoError(s.Save("aaaaa", saved))
+
+	if got, err := s.Load("aaaaa"); ts.NoError(err) {
+		ts.Exactly(saved, got)
+	}
+}
+
+func (ts *storeSuite) TestSave() {
+	s := ts.subject
+
+	empty := *yahtzee.NewGame()
+	ts.NoError(s.Save("bbbbb", empty))
+
+	if got, err := s.Load("bbbbb"); ts.NoError(err) {
+		ts.Exactly(empty, got)
+	}
+
+	advanced := *newAdvancedGame()
+	ts.NoError(s.Save("bbbbb", advanced))
+
+	if got, err := s.Load("bbbbb"); ts.NoError(err) {
+		ts.Exactly(advanced, got)
+	}
+}
+
+func (ts *storeSuite) TestList() {
+	s := ts.subject
+
+	if got, err := s.List(); ts.NoError(err) {
+		ts.Empty(got)
+	}
+
+	ts.Require().NoError(s.Save("bbbbb", *yahtzee.NewGame()))
+	ts.Require().NoError(s.Save("aaaaa", *yahtzee.NewGame()))
+
+	if got, err := s.List(); ts.NoError(err) {
+		ts.Exactly([]string{"aaaaa", "bbbbb"}, got)
+	}
+}
+
+func (ts *storeSuite) TestDelete() {
+	s := ts.subject
+
+	ts.NoError(s.Delete("aaaaa"))
+
+	ts.Require().NoError(s.Save("aaaaa", *newAdvancedGame()))
+	ts.Require().NoError(s.Save("bbbbb", *yahtzee.NewGame()))
+	ts.Require().NoError(s.Delete("aaaaa"))
+
+	_, err := s.Load("aaaaa")
+	ts.True(errors.Is(err, store.ErrNotExists))
+	if got, err := s.List(); ts.NoError(err) {
+		ts.Exactly([]string{"bbbbb"}, got)
+	}
+}
+
+func (ts *storeSuite) TestRace() {
+	s := ts.subject
+	wg := &sync.WaitGroup{}
+
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			unlock, err := s.Lock("ccccc")
+			ts.Require().NoError(err)
+
+			s.Save("ccccc", *newAdvancedGame())
+			s.Load("ccccc")
+
+			unlock()
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+}
+
+func newAdvancedGame() *yahtzee.Game {
+	return &yahtzee.Game{
+		Players: []*yahtzee.Player{
+			{
+				User: yahtzee.User("Alice"),
+				ScoreSheet: map[yahtzee.Category]int{
+					yahtzee.Twos:      6,
+					yahtzee.Fives:     15,
+					yahtzee.FullHouse: 25,
+				},
+				ExtraSheets: []map[yahtzee.Category]int{
+					{yahtzee.Yahtzee: 50},
+					{},
+				},
+			}, {
+				User: yahtzee.User("Bob"),
+				ScoreSheet: map[yahtzee.Category]int{
+					yahtzee.Threes:      6,
+					yahtzee.FourOfAKind: 16,
+				},
+			}, {
+				User: yahtzee.User("Carol"),
+				ScoreSheet: map[yahtzee.Category]int{
+					yahtzee.Twos:          6,
+					yahtzee.SmallStraight: 30,
+				},
+			},
+		},
+		Dices: []*yahtzee.Dice{
+			{Value: 3, Locked: true, Color: yahtzee.Red},
+			{Value: 2, Locked: false},
+			{Value: 3, Locked: true, Color: yahtzee.Red},
+			{Value: 1, Locked: false},
+			{Value: 5, Locked: false},
+		},
+		Sides:         6,
+		Round:         5,
+		CurrentPlayer: 1,
+		RollCount:     1,
+		Announcement:  yahtzee.Chance,
+		Features:      []yahtzee.Feature{yahtzee.Triple, yahtzee.Announce},
+		Seed:          42,
+	}
+}
+
+type bestScoresSuite struct {
+	suite.Suite
+
+	newBestScores func() store.BestScores
+	subject       store.BestScores
+}
+
+func (ts *bestScoresSuite) SetupTest() {
+	ts.subject = ts.newBestScores()
+}
+
+func (ts *bestScoresSuite) TestRecord() {
+	s := ts.subject
+
+	_, err := s.Best("Alice")
+	ts.True(errors.Is(err, store.ErrNotExists))
+
+	ts.Require().NoError(s.Record("Alice", 150))
+	ts.Require().NoError(s.Record("Bob", 300))
+
+	if got, err := s.Best("Alice"); ts.NoError(err) {
+		ts.Exactly(150, got)
+	}
+
+	ts.Require().NoError(s.Record("Alice", 120))
+	if got, err := s.Best("Alice"); ts.NoError(err) {
+		ts.Exactly(150, got)
+	}
+
+	ts.Require().NoError(s.Record("Alice", 210))
+	if got, err := s.Best("Alice"); ts.NoError(err) {
+		ts.Exactly(210, got)
+	}
+}
+
+type matchesSuite struct {
+	suite.Suite
+
+	newMatches func() store.Matches
+	subject    store.Matches
+}
+
+func (ts *matchesSuite) SetupTest() {
+	ts.subject = ts.newMatches()
+}
+
+func (ts *matchesSuite) TestSaveMatch() {
+	s := ts.subject
+
+	_, err := s.LoadMatch("aaaaa")
+	ts.True(errors.Is(err, store.ErrNotExists))
+
+	m := *yahtzee.NewMatch(3, []yahtzee.User{"Alice", "Bob"}, yahtzee.Yatzy)
+	m.Games = append(m.Games, "bbbbb")
+	ts.Require().NoError(s.SaveMatch("aaaaa", m))
+
+	if got, err := s.LoadMatch("aaaaa"); ts.NoError(err) {
+		ts.Exactly(m, got)
+	}
+
+	m.Games = append(m.Games, "ccccc")
+	m.Wins["Alice"] = 1
+	m.Points["Alice"] = 210
+	m.Points["Bob"] = 180
+	ts.Require().NoError(s.SaveMatch("aaaaa", m))
+
+	if got, err := s.LoadMatch("aaaaa"); ts.NoError(err) {
+		ts.Exactly(m, got)
+	}
+}
+
+type userGamesSuite struct {
+	suite.Suite
+
+	newUserGames func() store.UserGames
+	subject      store.UserGames
+}
+
+func (ts *userGamesSuite) SetupTest() {
+	ts.subject = ts.newUserGames()
+}
+
+func (ts *userGamesSuite) TestAddGame() {
+	s := ts.subject
+
+	if got, err := s.Games("Alice"); ts.NoError(err) {
+		ts.Empty(got)
+	}
+
+	ts.Require().NoError(s.AddGame("Alice", "aaaaa"))
+	ts.Require().NoError(s.AddGame("Alice", "bbbbb"))
+	ts.Require().NoError(s.AddGame("Bob", "aaaaa"))
+	ts.Require().NoError(s.AddGame("Alice", "aaaaa"))
+
+	if got, err := s.Games("Alice"); ts.NoError(err) {
+		ts.Exactly([]string{"aaaaa", "bbbbb"}, got)
+	}
+	if got, err := s.Games("Bob"); ts.NoError(err) {
+		ts.Exactly([]string{"aaaaa"}, got)
+	}
+}
+
+type notesSuite struct {
+	suite.Suite
+
+	newNotes func() store.Notes
+	subject  store.Notes
+}
+
+func (ts *notesSuite) SetupTest() {
+	ts.subject = ts.newNotes()
+}
+
+func (ts *notesSuite) TestSaveNotes() {
+	s := ts.subject
+
+	_, err := s.LoadNotes("aaaaa", "Alice")
+	ts.True(errors.Is(err, store.ErrNotExists))
+
+	notes := yahtzee.Notes{
+		Categories: map[yahtzee.Category]string{yahtzee.Chance: "keep it for later"},
+		Turns:      map[int]string{2: "went for the straight"},
+	}
+	ts.Require().NoError(s.SaveNotes("aaaaa", "Alice", notes))
+
+	if got, err := s.LoadNotes("aaaaa", "Alice"); ts.NoError(err) {
+		ts.Exactly(notes, got)
+	}
+	_, err = s.LoadNotes("aaaaa", "Bob")
+	ts.True(errors.Is(err, store.ErrNotExists))
+	_, err = s.LoadNotes("bbbbb", "Alice")
+	ts.True(errors.Is(err, store.ErrNotExists))
+}
+
+type achievementsSuite struct {
+	suite.Suite
+
+	newAchievements func() store.Achievements
+	subject         store.Achievements
+}
+
+func (ts *achievementsSuite) SetupTest() {
+	ts.subject = ts.newAchievements()
+}
+
+func (ts *achievementsSuite) TestUnlock() {
+	s := ts.subject
+
+	if got, err := s.Achievements("Alice"); ts.NoError(err) {
+		ts.Empty(got)
+	}
+
+	for _, tc := range []struct {
+		user yahtzee.User
+		a    yahtzee.Achievement
+		new  bool
+	}{
+		{"Alice", yahtzee.AllStraights, true},
+		{"Alice", yahtzee.FirstYahtzee, true},
+		{"Bob", yahtzee.FirstYahtzee, true},
+		{"Alice", yahtzee.AllStraights, false},
+	} {
+		if got, err := s.Unlock(tc.user, tc.a); ts.NoError(err) {
+			ts.Exactly(tc.new, got, "%s unlocking %s", tc.user, tc.a)
+		}
+	}
+
+	if got, err := s.Achievements("Alice"); ts.NoError(err) {
+		ts.Exactly([]yahtzee.Achievement{yahtzee.AllStraights, yahtzee.FirstYahtzee}, got)
+	}
+	if got, err := s.Achievements("Bob"); ts.NoError(err) {
+		ts.Exactly([]yahtzee.Achievement{yahtzee.FirstYahtzee}, got)
+	}
+}
+
+type clocksSuite struct {
+	suite.Suite
+
+	newClocks func() store.Clocks
+	subject   store.Clocks
+}
+
+func (ts *clocksSuite) SetupTest() {
+	ts.subject = ts.newClocks()
+}
+
+func (ts *clocksSuite) TestSetClock() {
+	s := ts.subject
+
+	if got, err := s.Clocks(); ts.NoError(err) {
+		ts.Empty(got)
+	}
+
+	ts.NoError(s.SetClock("aaaaa", 1000))
+	ts.NoError(s.SetClock("bbbbb", 2000))
+	ts.NoError(s.SetClock("aaaaa", 3000))
+	if got, err := s.Clocks(); ts.NoError(err) {
+		ts.Exactly(map[string]int64{"aaaaa": 3000, "bbbbb": 2000}, got)
+	}
+
+	// zero removes the clock
+	ts.NoError(s.SetClock("bbbbb", 0))
+	ts.NoError(s.SetClock("ccccc", 0))
+	if got, err := s.Clocks(); ts.NoError(err) {
+		ts.Exactly(map[string]int64{"aaaaa": 3000}, got)
+	}
+}
+
+type dailiesSuite struct {
+	suite.Suite
+
+	newDailies func() store.Dailies
+	subject    store.Dailies
+}
+
+func (ts *dailiesSuite) SetupTest() {
+	ts.subject = ts.newDailies()
+}
+
+func (ts *dailiesSuite) TestDailyGame() {
+	s := ts.subject
+
+	_, err := s.DailyGame("2021-03-14", "Alice")
+	ts.True(errors.Is(err, store.ErrNotExists))
+
+	ts.NoError(s.StartDaily("2021-03-14", "Alice", "aaaaa"))
+	ts.NoError(s.StartDaily("2021-03-15", "Alice", "bbbbb"))
+	if got, err := s.DailyGame("2021-03-14", "Alice"); ts.NoError(err) {
+		ts.Exactly("aaaaa", got)
+	}
+
+	// the games are kept by day and user
+	_, err = s.DailyGame("2021-03-14", "Bob")
+	ts.True(errors.Is(err, store.ErrNotExists))
+}
+
+func (ts *dailiesSuite) TestDailyScores() {
+	s := ts.subject
+
+	if got, err := s.DailyScores("2021-03-14"); ts.NoError(err) {
+		ts.Empty(got)
+	}
+
+	ts.NoError(s.RecordDaily("2021-03-14", "Alice", 200))
+	ts.NoError(s.RecordDaily("2021-03-14", "Bob", 150))
+	ts.NoError(s.RecordDaily("2021-03-15", "Alice", 100))
+	if got, err := s.DailyScores("2021-03-14"); ts.NoError(err) {
+		ts.Exactly(map[yahtzee.User]int{"Alice": 200, "Bob": 150}, got)
+	}
+}
+
+type activitiesSuite struct {
+	suite.Suite
+
+	newActivities func() store.Activities
+	subject       store.Activities
+}
+
+func (ts *activitiesSuite) SetupTest() {
+	ts.subject = ts.newActivities()
+}
+
+func (ts *activitiesSuite) TestActivities() {
+	s := ts.subject
+
+	if got, err := s.Activities("aaaaa"); ts.NoError(err) {
+		ts.Empty(got)
+	}
+
+	score := yahtzee.Activity{At: 1000, Action: "score", User: "Alice"}
+	over := yahtzee.Activity{
+		At:      2000,
+		Action:  "game-over",
+		Winners: []yahtzee.User{"Alice"},
+		Totals:  map[yahtzee.User]int{"Alice": 200, "Bob": 150},
+	}
+	ts.NoError(s.AddActivity("aaaaa", score))
+	ts.NoError(s.AddActivity("bbbbb", score))
+	ts.NoError(s.AddActivity("aaaaa", over))
+	if got, err := s.Activities("aaaaa"); ts.NoError(err) {
+		ts.Exactly([]yahtzee.Activity{score, over}, got)
+	}
+}
+
+func (ts *activitiesSuite) TestAddActivities() {
+	s, ok := ts.subject.(store.ActivityBatches)
+	if !ok {
+		ts.T().Skip("the activities are not written in batches")
+	}
+
+	score := yahtzee.Activity{At: 1000, Action: "score", User: "Alice"}
+	scratch := yahtzee.Activity{At: 2000, Action: "scratch", User: "Bob"}
+	timeout := yahtzee.Activity{At: 3000, Action: "timeout", User: "Alice"}
+	ts.NoError(s.AddActivities("aaaaa", []yahtzee.Activity{score, scratch}))
+	ts.NoError(s.AddActivities("aaaaa", []yahtzee.Activity{timeout}))
+	if got, err := ts.subject.Activities("aaaaa"); ts.NoError(err) {
+		ts.Exactly([]yahtzee.Activity{score, scratch, timeout}, got)
+	}
+}
+
+func (ts *activitiesSuite) TestVisit() {
+	s := ts.subject
+
+	if got, err := s.Visit("Alice", 1000); ts.NoError(err) {
+		ts.Zero(got)
+	}
+	if got, err := s.Visit("Alice", 2000); ts.NoError(err) {
+		ts.Exactly(int64(1000), got)
+	}
+	if got, err := s.Visit("Bob", 3000); ts.NoError(err) {
+		ts.Zero(got)
+	}
+}
+
+type archiveSuite struct {
+	suite.Suite
+
+	newArchive func() store.Archive
+	subject    store.Archive
+}
+
+func (ts *archiveSuite) SetupTest() {
+	ts.subject = ts.newArchive()
+}
+
+func (ts *archiveSuite) TestArchiveGame() {
+	s := ts.subject
+
+	_, err := s.LoadArchived("aaaaa")
+	ts.True(errors.Is(err, store.ErrNotExists))
+
+	g := *newAdvancedGame()
+	ts.Require().NoError(s.ArchiveGame("aaaaa", g))
+
+	if got, err := s.LoadArchived("aaaaa"); ts.NoError(err) {
+		ts.Exactly(g, got)
+	}
+}
+
+type journalSuite struct {
+	suite.Suite
+
+	newJournal func() store.Journal
+	subject    store.Journal
+}
+
+func (ts *journalSuite) SetupTest() {
+	ts.subject = ts.newJournal()
+}
+
+func (ts *journalSuite) TestChanges() {
+	s := ts.subject
+
+	if got, err := s.Changes("aaaaa", 0); ts.NoError(err) {
+		ts.Empty(got)
+	}
+
+	changes := []store.Change{
+		{Seq: 1, At: 1000, Patch: json.RawMessage(`{"Round":0}`)},
+		{Seq: 2, At: 2000, Patch: json.RawMessage(`{"Round":1}`)},
+		{Seq: 3, At: 3000, Patch: json.RawMessage(`{"Players":null}`)},
+	}
+	for _, c := range changes {
+		ts.Require().NoError(s.AppendChange("aaaaa", c))
+	}
+	ts.Require().NoError(s.AppendChange("bbbbb", changes[0]))
+
+	if got, err := s.Changes("aaaaa", 0); ts.NoError(err) {
+		ts.Exactly(changes, got)
+	}
+	if got, err := s.Changes("aaaaa", 1); ts.NoError(err) {
+		ts.Exactly(changes[1:], got)
+	}
+	if got, err := s.Changes("aaaaa", 3); ts.NoError(err) {
+		ts.Empty(got)
+	}
+}
+
+func (ts *journalSuite) TestSnapshot() {
+	s := ts.subject
+
+	_, err := s.LoadSnapshot("aaaaa")
+	ts.True(errors.Is(err, store.ErrNotExists))
+
+	first := store.Snapshot{Seq: 1, Game: json.RawMessage(`{"Round":0}`)}
+	ts.Require().NoError(s.SaveSnapshot("aaaaa", first))
+	if got, err := s.LoadSnapshot("aaaaa"); ts.NoError(err) {
+		ts.Exactly(first, got)
+	}
+
+	second := store.Snapshot{Seq: 50, Game: json.RawMessage(`{"Round":3}`)}
+	ts.Require().NoError(s.SaveSnapshot("aaaaa", second))
+	if got, err := s.LoadSnapshot("aaaaa"); ts.NoError(err) {
+		ts.Exactly(second, got)
+	}
+}
+
+func (ts *journalSuite) TestJournals() {
+	s := ts.subject
+
+	if got, err := s.Journals(); ts.NoError(err) {
+		ts.Empty(got)
+	}
+
+	c := store.Change{Seq: 1, At: 1000, Patch: json.RawMessage(`{}`)}
+	ts.Require().NoError(s.AppendChange("bbbbb", c))
+	ts.Require().NoError(s.AppendChange("aaaaa", c))
+
+	if got, err := s.Journals(); ts.NoError(err) {
+		ts.Exactly([]string{"aaaaa", "bbbbb"}, got)
+	}
+
+	ts.Require().NoError(s.SaveSnapshot("aaaaa", store.Snapshot{Seq: 1, Game: json.RawMessage(`{}`)}))
+	ts.Require().NoError(s.DeleteJournal("aaaaa"))
+	ts.NoError(s.DeleteJournal("ccccc"))
+	if got, err := s.Journals(); ts.NoError(err) {
+		ts.Exactly([]string{"bbbbb"}, got)
+	}
+	if got, err := s.Changes("aaaaa", 0); ts.NoError(err) {
+		ts.Empty(got)
+	}
+	_, err := s.LoadSnapshot("aaaaa")
+	ts.True(errors.Is(err, store.ErrNotExists))
+}
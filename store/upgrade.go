@@ -0,0 +1,97 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/akarasz/yahtzee"
+)
+
+// SchemaVersion is the version of the stored form of the games, the stores
+// stamp the saved games with it in their `Version`. It's raised with the
+// changes of the Game model the games saved before need an upgrade for, like
+// a new field without a usable zero value or a renamed category.
+const SchemaVersion = 1
+
+// storedGame is the stored form of a game, with the fields kept out of its
+// JSON form.
+type storedGame struct {
+	yahtzee.Game
+	Seed int64
+
+	// Version is the SchemaVersion of the game
+	Version int
+}
+
+// Marshal returns the stored form of the game, stamped with the
+// SchemaVersion. The stores save the games in this form.
+func Marshal(g yahtzee.Game) ([]byte, error) {
+	return json.Marshal(storedGame{Game: g, Seed: g.Seed, Version: SchemaVersion})
+}
+
+// Unmarshal returns the game of its stored form in `raw`, upgraded to the
+// SchemaVersion first.
+func Unmarshal(raw []byte) (yahtzee.Game, error) {
+	raw, err := Upgrade(raw)
+	if err != nil {
+		return yahtzee.Game{}, err
+	}
+
+	var res storedGame
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return yahtzee.Game{}, err
+	}
+	res.Game.Seed = res.Seed
+	return res.Game, nil
+}
+
+// upgrades has the upgrades of the stored games, the one at the index `v`
+// turns a game of version `v` into the next version. The games are in their
+// JSON form, with the numbers kept as json.Number.
+var upgrades = []func(g map[string]interface{}) error{
+	upgradeSides,
+}
+
+// Upgrade returns the stored form of a game in `raw` upgraded to the
+// SchemaVersion, the stores call it on Load before decoding the game. The
+// games without a version were saved before the versions. The games of the
+// current version, and the ones of a newer version saved by a newer server,
+// are returned as they are.
+func Upgrade(raw []byte) ([]byte, error) {
+	var stamp struct {
+		Version int
+	}
+	if err := json.Unmarshal(raw, &stamp); err != nil {
+		return nil, err
+	}
+	if stamp.Version >= SchemaVersion {
+		return raw, nil
+	}
+
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	var g map[string]interface{}
+	if err := d.Decode(&g); err != nil {
+		return nil, err
+	}
+
+	for v := stamp.Version; v < SchemaVersion; v++ {
+		if err := upgrades[v](g); err != nil {
+			return nil, fmt.Errorf("upgrade from version %d: %w", v, err)
+		}
+	}
+	g["Version"] = SchemaVersion
+
+	return json.Marshal(g)
+}
+
+// upgradeSides sets the sides of the dices of the games saved before the
+// dices could have other than six sides.
+func upgradeSides(g map[string]interface{}) error {
+	if sides, ok := g["Sides"].(json.Number); ok && sides.String() != "0" {
+		return nil
+	}
+	g["Sides"] = yahtzee.NumberOfSides
+	return nil
+}
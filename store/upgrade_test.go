@@ -0,0 +1,69 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/store"
+)
+
+func TestUpgrade(t *testing.T) {
+	t.Run("before the versions", func(t *testing.T) {
+		got, err := store.Upgrade([]byte(`{"Players":[],"Dices":[{"Value":3,"Locked":false}],"Round":2,"Seed":12345678901234567}`))
+		require.NoError(t, err)
+		assert.JSONEq(t,
+			`{"Players":[],"Dices":[{"Value":3,"Locked":false}],"Round":2,"Seed":12345678901234567,"Sides":6,"Version":1}`,
+			string(got))
+	})
+
+	t.Run("sides kept", func(t *testing.T) {
+		got, err := store.Upgrade([]byte(`{"Sides":8}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"Sides":8,"Version":1}`, string(got))
+	})
+
+	t.Run("current", func(t *testing.T) {
+		raw := []byte(`{"Sides":0,"Version":1}`)
+		got, err := store.Upgrade(raw)
+		require.NoError(t, err)
+		assert.Exactly(t, raw, got)
+	})
+
+	t.Run("newer", func(t *testing.T) {
+		raw := []byte(`{"Version":1000}`)
+		got, err := store.Upgrade(raw)
+		require.NoError(t, err)
+		assert.Exactly(t, raw, got)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := store.Upgrade([]byte(`[`))
+		assert.Error(t, err)
+	})
+}
+
+func TestMarshal(t *testing.T) {
+	g := yahtzee.NewGame(yahtzee.Yatzy)
+	g.Seed = 12345678901234567
+
+	raw, err := store.Marshal(*g)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"Seed":12345678901234567`)
+	assert.Contains(t, string(raw), `"Version":1`)
+
+	got, err := store.Unmarshal(raw)
+	require.NoError(t, err)
+	assert.Exactly(t, *g, got)
+
+	// upgraded before it's decoded
+	got, err = store.Unmarshal([]byte(`{"Players":[],"Round":2,"Seed":12345678901234567}`))
+	require.NoError(t, err)
+	assert.Exactly(t, yahtzee.NumberOfSides, got.Sides)
+	assert.Exactly(t, int64(12345678901234567), got.Seed)
+
+	_, err = store.Unmarshal([]byte(`[`))
+	assert.Error(t, err)
+}
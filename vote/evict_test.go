@@ -0,0 +1,32 @@
+package vote
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvict(t *testing.T) {
+	now := time.Unix(1000, 0)
+	p := NewPoll(time.Minute)
+	p.now = func() time.Time { return now }
+
+	require.NoError(t, p.Vote("alice", "a"))
+	now = now.Add(30 * time.Second)
+	require.NoError(t, p.Vote("bob", "b"))
+	assert.Exactly(t, ErrTooFrequent, p.Vote("alice", "b"))
+
+	// alice can vote again, bob still can't
+	now = now.Add(30 * time.Second)
+	_, err := p.Close()
+	require.NoError(t, err)
+	assert.Len(t, p.lastVote, 1)
+	assert.Contains(t, p.lastVote, "bob")
+
+	now = now.Add(30 * time.Second)
+	_, err = p.Close()
+	assert.Exactly(t, ErrNoVotes, err)
+	assert.Empty(t, p.lastVote)
+}
@@ -0,0 +1,111 @@
+// Package vote aggregates the choices of many voters into one decision.
+package vote
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTooFrequent is returned when a voter votes again too soon.
+	ErrTooFrequent = errors.New("too frequent votes")
+
+	// ErrNoVotes is returned when a poll is closed without votes.
+	ErrNoVotes = errors.New("no votes")
+)
+
+// Poll collects votes for options. Every voter has one vote, voting again
+// replaces the previous choice.
+type Poll struct {
+	mu sync.Mutex
+
+	votes    map[string]string
+	lastVote map[string]time.Time
+	interval time.Duration
+
+	now func() time.Time
+}
+
+// NewPoll creates an empty poll where voters can change their vote once in
+// every `interval`.
+func NewPoll(interval time.Duration) *Poll {
+	return &Poll{
+		votes:    map[string]string{},
+		lastVote: map[string]time.Time{},
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Vote records `option` as the choice of `voter`.
+func (p *Poll) Vote(voter, option string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if last, ok := p.lastVote[voter]; ok && now.Sub(last) < p.interval {
+		return ErrTooFrequent
+	}
+
+	p.lastVote[voter] = now
+	p.votes[voter] = option
+	return nil
+}
+
+// evict forgets the last votes older than the interval, they don't limit the
+// voters anymore.
+func (p *Poll) evict(now time.Time) {
+	for voter, last := range p.lastVote {
+		if now.Sub(last) >= p.interval {
+			delete(p.lastVote, voter)
+		}
+	}
+}
+
+// Result is the outcome of a poll.
+type Result struct {
+	// Winner is the option with the most votes
+	Winner string
+
+	// Votes shows how many votes the winner got
+	Votes int
+
+	// Total shows how many votes were cast
+	Total int
+}
+
+// Close returns the result of the poll and resets it for the next round.
+// Ties are broken by choosing the option first in alphabetical order.
+func (p *Poll) Close() (Result, error) {
+	p.mu.Lock()
+	votes := p.votes
+	p.votes = map[string]string{}
+	p.evict(p.now())
+	p.mu.Unlock()
+
+	if len(votes) == 0 {
+		return Result{}, ErrNoVotes
+	}
+
+	tally := map[string]int{}
+	for _, option := range votes {
+		tally[option]++
+	}
+
+	options := make([]string, 0, len(tally))
+	for option := range tally {
+		options = append(options, option)
+	}
+	sort.Strings(options)
+
+	res := Result{Total: len(votes)}
+	for _, option := range options {
+		if tally[option] > res.Votes {
+			res.Winner = option
+			res.Votes = tally[option]
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,53 @@
+package vote_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee/vote"
+)
+
+func TestPoll(t *testing.T) {
+	p := vote.NewPoll(0)
+
+	_, err := p.Close()
+	assert.Exactly(t, vote.ErrNoVotes, err)
+
+	require.NoError(t, p.Vote("alice", "keep 1 2"))
+	require.NoError(t, p.Vote("bob", "score chance"))
+	require.NoError(t, p.Vote("carol", "score chance"))
+	require.NoError(t, p.Vote("dave", "keep 1 2"))
+	require.NoError(t, p.Vote("dave", "score chance"))
+
+	res, err := p.Close()
+	require.NoError(t, err)
+	assert.Exactly(t, vote.Result{Winner: "score chance", Votes: 3, Total: 4}, res)
+
+	_, err = p.Close()
+	assert.Exactly(t, vote.ErrNoVotes, err)
+}
+
+func TestPollTie(t *testing.T) {
+	p := vote.NewPoll(0)
+	require.NoError(t, p.Vote("alice", "b"))
+	require.NoError(t, p.Vote("bob", "a"))
+
+	res, err := p.Close()
+	require.NoError(t, err)
+	assert.Exactly(t, "a", res.Winner)
+}
+
+func TestPollRateLimit(t *testing.T) {
+	p := vote.NewPoll(time.Hour)
+
+	require.NoError(t, p.Vote("alice", "a"))
+	assert.Exactly(t, vote.ErrTooFrequent, p.Vote("alice", "b"))
+	require.NoError(t, p.Vote("bob", "b"))
+
+	res, err := p.Close()
+	require.NoError(t, err)
+	assert.Exactly(t, 2, res.Total)
+}
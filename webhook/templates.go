@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/akarasz/yahtzee/event"
+)
+
+// JSONPayload is the payload of the json template.
+type JSONPayload struct {
+	Game  string
+	Event *event.Event
+}
+
+// JSON sends the event as it is, with the ID of its game.
+func JSON(gameID string, e *event.Event) ([]byte, error) {
+	return json.Marshal(&JSONPayload{Game: gameID, Event: e})
+}
+
+// Slack sends a summary of the event as a message with blocks for the
+// incoming webhooks of Slack.
+func Slack(gameID string, e *event.Event) ([]byte, error) {
+	text := summary(gameID, e)
+
+	type textObject struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	type block struct {
+		Type string     `json:"type"`
+		Text textObject `json:"text"`
+	}
+
+	return json.Marshal(&struct {
+		Text   string  `json:"text"`
+		Blocks []block `json:"blocks"`
+	}{
+		Text: text,
+		Blocks: []block{{
+			Type: "section",
+			Text: textObject{Type: "mrkdwn", Text: text},
+		}},
+	})
+}
+
+// Discord sends a summary of the event as a message for the webhooks of
+// Discord.
+func Discord(gameID string, e *event.Event) ([]byte, error) {
+	return json.Marshal(&struct {
+		Content string `json:"content"`
+	}{
+		Content: summary(gameID, e),
+	})
+}
+
+// summary returns the one line description of the event, like
+// "Alice: score in game aBcD".
+func summary(gameID string, e *event.Event) string {
+	if e.User == nil {
+		return fmt.Sprintf("%s in game %s", e.Action, gameID)
+	}
+	return fmt.Sprintf("%s: %s in game %s", *e.User, e.Action, gameID)
+}
@@ -0,0 +1,182 @@
+// Package webhook sends the events of the games to HTTP endpoints. The
+// payload of every registration is made by its template, so chat services
+// like Slack or Discord can take the events without a translation service in
+// between.
+package webhook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/akarasz/yahtzee/event"
+)
+
+// ErrUnknownTemplate is returned for a registration with a template not
+// registered.
+var ErrUnknownTemplate = errors.New("unknown template")
+
+// DefaultTimeout is the time a delivery can take when no client is given.
+const DefaultTimeout = 5 * time.Second
+
+// queueSize is the number of payloads waiting for the delivery to an
+// endpoint, the payloads over it are dropped.
+const queueSize = 100
+
+// Template makes the payload of the event of the game.
+type Template func(gameID string, e *event.Event) ([]byte, error)
+
+var registry = struct {
+	sync.RWMutex
+	templates map[string]Template
+}{
+	templates: map[string]Template{
+		"json":    JSON,
+		"slack":   Slack,
+		"discord": Discord,
+	},
+}
+
+// RegisterTemplate adds a template the registrations can choose by its name.
+// It panics when the name is already taken.
+func RegisterTemplate(name string, t Template) {
+	if t == nil {
+		panic("webhook: register template with nil template")
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.templates[name]; ok {
+		panic(fmt.Sprintf("webhook: template %q registered twice", name))
+	}
+	registry.templates[name] = t
+}
+
+func lookupTemplate(name string) (Template, bool) {
+	if name == "" {
+		name = "json"
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	t, ok := registry.templates[name]
+	return t, ok
+}
+
+// Registration is an endpoint the events are sent to.
+type Registration struct {
+	// URL is where the payloads are posted
+	URL string
+
+	// Template is the name of the template of the payloads, "json" when it's
+	// empty
+	Template string
+
+	// Events has the types of the events sent, every event is sent when
+	// it's empty
+	Events []event.Type
+}
+
+// wants tells if the event of the type is sent to the registration.
+func (r *Registration) wants(t event.Type) bool {
+	if len(r.Events) == 0 {
+		return true
+	}
+	for _, e := range r.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+type hook struct {
+	Registration
+	template Template
+	queue    chan []byte
+}
+
+// run delivers the payloads of the hook one after the other, so the
+// endpoint gets the events in the order they happened.
+func (h *hook) run(client *http.Client) {
+	for payload := range h.queue {
+		deliver(client, h.URL, payload)
+	}
+}
+
+type webhookEmitter struct {
+	next  event.Emitter
+	hooks []*hook
+}
+
+// Emitter returns `e` also posting its events to the registrations, or `e`
+// itself without registrations. The events are delivered in the background
+// with `client`, a client with the DefaultTimeout is used when it's nil. The
+// events of a slow endpoint are dropped instead of holding up the games.
+func Emitter(e event.Emitter, client *http.Client, registrations ...Registration) (event.Emitter, error) {
+	if len(registrations) == 0 {
+		return e, nil
+	}
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+
+	res := &webhookEmitter{
+		next: e,
+	}
+	for _, r := range registrations {
+		t, ok := lookupTemplate(r.Template)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownTemplate, r.Template)
+		}
+		h := &hook{
+			Registration: r,
+			template:     t,
+			queue:        make(chan []byte, queueSize),
+		}
+		go h.run(client)
+		res.hooks = append(res.hooks, h)
+	}
+	return res, nil
+}
+
+func (e *webhookEmitter) Emit(gameID string, ev *event.Event) {
+	e.next.Emit(gameID, ev)
+
+	for _, h := range e.hooks {
+		if !h.wants(ev.Action) {
+			continue
+		}
+
+		payload, err := h.template(gameID, ev)
+		if err != nil {
+			log.Printf("webhook %s: %v", h.URL, err)
+			continue
+		}
+
+		select {
+		case h.queue <- payload:
+		default:
+			log.Printf("webhook %s: queue is full, %s event dropped", h.URL, ev.Action)
+		}
+	}
+}
+
+func deliver(client *http.Client, url string, payload []byte) {
+	res, err := client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("webhook %s: %v", url, err)
+		return
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		log.Printf("webhook %s: %s", url, res.Status)
+	}
+}
@@ -0,0 +1,123 @@
+package webhook_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akarasz/yahtzee"
+	"github.com/akarasz/yahtzee/event"
+	"github.com/akarasz/yahtzee/webhook"
+)
+
+type recorder struct {
+	events []*event.Event
+}
+
+func (r *recorder) Emit(gameID string, e *event.Event) {
+	r.events = append(r.events, e)
+}
+
+// endpoint returns a server sending the bodies of the posts to the channel.
+func endpoint(t *testing.T) (*httptest.Server, chan string) {
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Exactly(t, "POST", r.Method)
+		assert.Exactly(t, "application/json", r.Header.Get("Content-Type"))
+
+		raw, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		bodies <- string(raw)
+	}))
+	t.Cleanup(server.Close)
+	return server, bodies
+}
+
+func receive(t *testing.T, bodies chan string) string {
+	select {
+	case got := <-bodies:
+		return got
+	case <-time.After(3 * time.Second):
+		t.Fatal("no payload")
+		return ""
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	r := &recorder{}
+	got, err := webhook.Emitter(r, nil)
+	require.NoError(t, err)
+	assert.Same(t, r, got)
+}
+
+func TestUnknownTemplate(t *testing.T) {
+	_, err := webhook.Emitter(&recorder{}, nil, webhook.Registration{URL: "http://localhost", Template: "teams"})
+	assert.True(t, errors.Is(err, webhook.ErrUnknownTemplate))
+}
+
+func TestTemplates(t *testing.T) {
+	generic, genericBodies := endpoint(t)
+	slack, slackBodies := endpoint(t)
+	discord, discordBodies := endpoint(t)
+
+	r := &recorder{}
+	e, err := webhook.Emitter(r, nil,
+		webhook.Registration{URL: generic.URL},
+		webhook.Registration{URL: slack.URL, Template: "slack", Events: []event.Type{event.GameOver}},
+		webhook.Registration{URL: discord.URL, Template: "discord", Events: []event.Type{event.Score}})
+	require.NoError(t, err)
+
+	alice := yahtzee.User("Alice")
+	e.Emit("aBcD", &event.Event{User: &alice, Action: event.Score, Hash: "hash"})
+	e.Emit("aBcD", &event.Event{Action: event.GameOver})
+
+	// the events are still emitted
+	assert.Len(t, r.events, 2)
+
+	assert.JSONEq(t, `{
+		"Game": "aBcD",
+		"Event": {"User": "Alice", "Action": "score", "Data": null, "Hash": "hash", "AppliedActionID": ""}
+	}`, receive(t, genericBodies))
+	assert.JSONEq(t, `{
+		"Game": "aBcD",
+		"Event": {"User": null, "Action": "game-over", "Data": null, "Hash": "", "AppliedActionID": ""}
+	}`, receive(t, genericBodies))
+
+	// only the chosen events are sent
+	assert.JSONEq(t, `{"content": "Alice: score in game aBcD"}`, receive(t, discordBodies))
+	assert.JSONEq(t, `{
+		"text": "game-over in game aBcD",
+		"blocks": [{"type": "section", "text": {"type": "mrkdwn", "text": "game-over in game aBcD"}}]
+	}`, receive(t, slackBodies))
+	assert.Empty(t, discordBodies)
+	assert.Empty(t, slackBodies)
+}
+
+// registerPlain registers the template once, even when the tests are run
+// many times.
+var registerPlain sync.Once
+
+func TestRegisterTemplate(t *testing.T) {
+	server, bodies := endpoint(t)
+
+	registerPlain.Do(func() {
+		webhook.RegisterTemplate("plain", func(gameID string, e *event.Event) ([]byte, error) {
+			return []byte(`"` + gameID + `"`), nil
+		})
+	})
+	assert.Panics(t, func() {
+		webhook.RegisterTemplate("slack", webhook.JSON)
+	})
+
+	e, err := webhook.Emitter(&recorder{}, nil, webhook.Registration{URL: server.URL, Template: "plain"})
+	require.NoError(t, err)
+	e.Emit("aBcD", &event.Event{Action: event.Roll})
+	assert.Exactly(t, `"aBcD"`, receive(t, bodies))
+}